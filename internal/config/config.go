@@ -1,7 +1,11 @@
 package config
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -15,17 +19,47 @@ type Config struct {
 	Database  DatabaseConfig  `mapstructure:"database"`
 	Redis     RedisConfig     `mapstructure:"redis"`
 	JWT       JWTConfig       `mapstructure:"jwt"`
+	Auth      AuthConfig      `mapstructure:"auth"`
+	User      UserConfig      `mapstructure:"user"`
 	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
 	Log       LogConfig       `mapstructure:"log"`
+	Todo      TodoConfig      `mapstructure:"todo"`
+	Metrics   MetricsConfig   `mapstructure:"metrics"`
+	Scheduler SchedulerConfig `mapstructure:"scheduler"`
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	Host         string        `mapstructure:"host"`
-	Port         int           `mapstructure:"port"`
-	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
-	WriteTimeout time.Duration `mapstructure:"write_timeout"`
-	Environment  string        `mapstructure:"environment"`
+	Host               string        `mapstructure:"host"`
+	Port               int           `mapstructure:"port"`
+	ReadTimeout        time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout       time.Duration `mapstructure:"write_timeout"`
+	Environment        string        `mapstructure:"environment"`
+	BasePath           string        `mapstructure:"base_path"`
+	PublicURL          string        `mapstructure:"public_url"`
+	TrustedProxies     []string      `mapstructure:"trusted_proxies"`
+	JSONOptionalFields string        `mapstructure:"json_optional_fields"`
+	CacheMaxAge        time.Duration `mapstructure:"cache_max_age"`
+	LocalizeTimestamps bool          `mapstructure:"localize_timestamps"`
+
+	// Compression compresses responses for clients that accept it, at CompressionLevel
+	// (default, speed or best)
+	Compression      bool   `mapstructure:"compression"`
+	CompressionLevel string `mapstructure:"compression_level"`
+
+	// BodyLimit is the largest request body accepted, in bytes
+	BodyLimit int `mapstructure:"body_limit"`
+
+	// RequestTimeout is the deadline on the context handlers pass to repositories (0 disables it)
+	RequestTimeout time.Duration `mapstructure:"request_timeout"`
+
+	// DefaultTimezone is the IANA timezone date-based features use for requests without an
+	// X-Timezone header
+	DefaultTimezone string `mapstructure:"default_timezone"`
+
+	// ExposeErrorDetails sends raw error strings to clients as details. When not configured
+	// it is on everywhere but production.
+	ExposeErrorDetails bool `mapstructure:"expose_error_details"`
 }
 
 // DatabaseConfig holds database configuration
@@ -35,6 +69,37 @@ type DatabaseConfig struct {
 	MongoURL     string `mapstructure:"mongo_url"`
 	MaxOpenConns int    `mapstructure:"max_open_conns"`
 	MaxIdleConns int    `mapstructure:"max_idle_conns"`
+
+	// UserDriver and TodoDriver store users and todos with a driver other than Driver. Left
+	// empty they are set to Driver, and only the databases they use need a URL.
+	UserDriver string `mapstructure:"user_driver"`
+	TodoDriver string `mapstructure:"todo_driver"`
+
+	// AcquireTimeout bounds how long a query waits for a pooled PostgreSQL connection (0 waits indefinitely)
+	AcquireTimeout time.Duration `mapstructure:"acquire_timeout"`
+
+	// MaxConcurrentSearches caps in-flight full-text searches; further searches get 429 (0 disables the cap)
+	MaxConcurrentSearches int `mapstructure:"max_concurrent_searches"`
+
+	// MongoReadPreference is the MongoDB read preference for reads (primary, primaryPreferred
+	// or secondaryPreferred). Writes always go to the primary.
+	MongoReadPreference string `mapstructure:"mongo_read_preference"`
+
+	// PoolSaturationThreshold is the share of acquired PostgreSQL connections (0-1) at which
+	// a warning is logged (0 disables the check)
+	PoolSaturationThreshold float64 `mapstructure:"pool_saturation_threshold"`
+
+	// PoolCheckInterval is how often the pool saturation check runs
+	PoolCheckInterval time.Duration `mapstructure:"pool_check_interval"`
+
+	// DriverDefaulted reports that Driver fell back to postgres because both URLs were set
+	// without a driver
+	DriverDefaulted bool `mapstructure:"-"`
+}
+
+// Uses reports whether users or todos are stored with the driver
+func (c DatabaseConfig) Uses(driver string) bool {
+	return c.UserDriver == driver || c.TodoDriver == driver
 }
 
 // RedisConfig holds Redis configuration
@@ -50,6 +115,88 @@ type JWTConfig struct {
 	AccessExpiry  time.Duration `mapstructure:"access_expiry"`
 	RefreshExpiry time.Duration `mapstructure:"refresh_expiry"`
 	Issuer        string        `mapstructure:"issuer"`
+
+	// Algorithm is HS256, signing with Secret, or RS256, signing with PrivateKey and verifying
+	// with PublicKey. Each key is PEM encoded, inline or in the file named by its *File field.
+	Algorithm      string `mapstructure:"algorithm"`
+	PrivateKey     string `mapstructure:"private_key"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKey      string `mapstructure:"public_key"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+
+	// GenerateSecret lets environments other than production start without a secret by
+	// generating a random one; tokens signed with it stop working on restart
+	GenerateSecret bool `mapstructure:"generate_secret"`
+
+	// SecretGenerated reports that Secret was generated at startup rather than configured
+	SecretGenerated bool `mapstructure:"-"`
+}
+
+// AuthConfig holds account security configuration
+type AuthConfig struct {
+	// PasswordMinScore is the lowest password strength score, from 0 to 4, accepted on
+	// registration and password change; 0 disables the check
+	PasswordMinScore int `mapstructure:"password_min_score"`
+
+	// PasswordResetTTL is how long a password reset token can be used
+	PasswordResetTTL time.Duration `mapstructure:"password_reset_ttl"`
+
+	// LogResetTokens writes issued password reset tokens to the debug log so they can be used
+	// without a delivery channel during development. Not allowed in production.
+	LogResetTokens bool `mapstructure:"log_reset_tokens"`
+
+	// TOTPEncryptionKey is the hex-encoded 32-byte key TOTP secrets are encrypted with. Without
+	// it users cannot set up two-factor authentication.
+	TOTPEncryptionKey string `mapstructure:"totp_encryption_key"`
+
+	// TOTPIssuer names the app in authenticator apps
+	TOTPIssuer string `mapstructure:"totp_issuer"`
+
+	// LockoutThreshold is how many failed logins within LockoutWindow lock an account for
+	// LockoutDuration; 0 disables the lockout
+	LockoutThreshold int           `mapstructure:"lockout_threshold"`
+	LockoutWindow    time.Duration `mapstructure:"lockout_window"`
+	LockoutDuration  time.Duration `mapstructure:"lockout_duration"`
+
+	// AdminUsernames lists users given the admin role at startup. Every name must belong to
+	// an existing user. Roles are never taken away here, so removing a name does not demote
+	// the user.
+	AdminUsernames []string `mapstructure:"admin_usernames"`
+}
+
+// UserConfig holds user account configuration
+type UserConfig struct {
+	// DeletePolicy decides what happens to a user's todos when they delete their account:
+	// cascade soft deletes them, block refuses the deletion while any are not done, and
+	// anonymize strips the account's personal data but keeps the todos. With users and todos
+	// in different databases only anonymize is allowed.
+	DeletePolicy string `mapstructure:"delete_policy"`
+}
+
+// TOTPKey decodes TOTPEncryptionKey, returning nil when it is not set
+func (c AuthConfig) TOTPKey() ([]byte, error) {
+	if c.TOTPEncryptionKey == "" {
+		return nil, nil
+	}
+
+	key, err := hex.DecodeString(c.TOTPEncryptionKey)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("auth totp encryption key must be 64 hex characters")
+	}
+	return key, nil
+}
+
+// DefaultLocation loads DefaultTimezone, returning UTC when it is not set
+func (c ServerConfig) DefaultLocation() (*time.Location, error) {
+	if c.DefaultTimezone == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(c.DefaultTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server default timezone: %s", c.DefaultTimezone)
+	}
+	return loc, nil
 }
 
 // RateLimitConfig holds rate limiting configuration
@@ -62,6 +209,67 @@ type RateLimitConfig struct {
 type LogConfig struct {
 	Level  string `mapstructure:"level"`
 	Format string `mapstructure:"format"`
+
+	// Bodies logs redacted request and response bodies, capped at BodyLimit bytes each.
+	// It is a debugging aid and is rejected in production.
+	Bodies    bool `mapstructure:"bodies"`
+	BodyLimit int  `mapstructure:"body_limit"`
+}
+
+// maxImportChunkSize bounds TODO_IMPORT_CHUNK_SIZE. A chunk is inserted with one statement,
+// and PostgreSQL allows at most 65535 parameters per statement.
+const maxImportChunkSize = 5000
+
+// TodoConfig holds todo domain configuration
+type TodoConfig struct {
+	Priorities      []string `mapstructure:"priorities"`
+	DefaultPriority string   `mapstructure:"default_priority"`
+	Statuses        []string `mapstructure:"statuses"`
+	DefaultStatus   string   `mapstructure:"default_status"`
+	DoneStatus      string   `mapstructure:"done_status"`
+	DescriptionMax  int      `mapstructure:"description_max"`
+
+	// MergeDueDateStrategy picks the merged due date: earliest, latest or target
+	MergeDueDateStrategy string `mapstructure:"merge_due_date_strategy"`
+
+	// AutoStartStatus, when set, is assigned to a todo still in the default status the first
+	// time its other fields are edited, unless the edit sets a status itself
+	AutoStartStatus string `mapstructure:"auto_start_status"`
+
+	// EndOfDayDueDates treats due dates at exactly midnight as date-only and moves them to the
+	// end of that day in the client's timezone
+	EndOfDayDueDates bool `mapstructure:"end_of_day_due_dates"`
+
+	// ImportMaxItems caps the todos read from one import request, and ImportChunkSize is the
+	// number of valid todos inserted together as the request is read
+	ImportMaxItems  int `mapstructure:"import_max_items"`
+	ImportChunkSize int `mapstructure:"import_chunk_size"`
+
+	// ImportBodyLimit is the largest import request body accepted, in bytes. It is checked on
+	// top of the server's body limit, so it only matters when smaller.
+	ImportBodyLimit int `mapstructure:"import_body_limit"`
+
+	// TagCaseInsensitive makes tag filters match tags regardless of case
+	TagCaseInsensitive bool `mapstructure:"tag_case_insensitive"`
+
+	// InsightsCacheTTL is how long a user's insights are reused before they are recomputed;
+	// 0 disables the cache
+	InsightsCacheTTL time.Duration `mapstructure:"insights_cache_ttl"`
+
+	// TrashRetentionDays is how long deleted todos stay in the trash before a background job
+	// removes them for good; 0, the default, keeps them forever
+	TrashRetentionDays int `mapstructure:"trash_retention_days"`
+}
+
+// MetricsConfig holds metrics endpoint configuration
+type MetricsConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	CacheTTL time.Duration `mapstructure:"cache_ttl"`
+}
+
+// SchedulerConfig holds background job scheduler configuration
+type SchedulerConfig struct {
+	Workers int `mapstructure:"workers"`
 }
 
 // Load loads configuration from environment variables and .env file
@@ -87,6 +295,15 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	// Error details default to the environment, so production hides them unless asked
+	if !viper.IsSet("server.expose_error_details") {
+		config.Server.ExposeErrorDetails = !config.IsProduction()
+	}
+
+	if err := generateJWTSecret(&config); err != nil {
+		return nil, err
+	}
+
 	// Validate configuration
 	if err := validate(&config); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
@@ -103,13 +320,32 @@ func bindEnvVars() {
 	viper.BindEnv("server.read_timeout", "SERVER_READ_TIMEOUT")
 	viper.BindEnv("server.write_timeout", "SERVER_WRITE_TIMEOUT")
 	viper.BindEnv("server.environment", "SERVER_ENVIRONMENT")
+	viper.BindEnv("server.base_path", "SERVER_BASE_PATH")
+	viper.BindEnv("server.public_url", "SERVER_PUBLIC_URL")
+	viper.BindEnv("server.trusted_proxies", "SERVER_TRUSTED_PROXIES")
+	viper.BindEnv("server.json_optional_fields", "SERVER_JSON_OPTIONAL_FIELDS")
+	viper.BindEnv("server.cache_max_age", "SERVER_CACHE_MAX_AGE")
+	viper.BindEnv("server.localize_timestamps", "SERVER_LOCALIZE_TIMESTAMPS")
+	viper.BindEnv("server.request_timeout", "SERVER_REQUEST_TIMEOUT")
+	viper.BindEnv("server.body_limit", "SERVER_BODY_LIMIT")
+	viper.BindEnv("server.compression", "SERVER_COMPRESSION")
+	viper.BindEnv("server.compression_level", "SERVER_COMPRESSION_LEVEL")
+	viper.BindEnv("server.default_timezone", "SERVER_DEFAULT_TIMEZONE")
+	viper.BindEnv("server.expose_error_details", "API_EXPOSE_ERROR_DETAILS")
 
 	// Database configuration
 	viper.BindEnv("database.driver", "DATABASE_DRIVER")
+	viper.BindEnv("database.user_driver", "DATABASE_USER_DRIVER")
+	viper.BindEnv("database.todo_driver", "DATABASE_TODO_DRIVER")
 	viper.BindEnv("database.postgres_url", "DATABASE_POSTGRES_URL")
 	viper.BindEnv("database.mongo_url", "DATABASE_MONGO_URL")
 	viper.BindEnv("database.max_open_conns", "DATABASE_MAX_OPEN_CONNS")
 	viper.BindEnv("database.max_idle_conns", "DATABASE_MAX_IDLE_CONNS")
+	viper.BindEnv("database.acquire_timeout", "DATABASE_ACQUIRE_TIMEOUT")
+	viper.BindEnv("database.max_concurrent_searches", "DATABASE_MAX_CONCURRENT_SEARCHES")
+	viper.BindEnv("database.mongo_read_preference", "DATABASE_MONGO_READ_PREFERENCE")
+	viper.BindEnv("database.pool_saturation_threshold", "DATABASE_POOL_SATURATION_THRESHOLD")
+	viper.BindEnv("database.pool_check_interval", "DATABASE_POOL_CHECK_INTERVAL")
 
 	// Redis configuration
 	viper.BindEnv("redis.url", "REDIS_URL")
@@ -121,6 +357,26 @@ func bindEnvVars() {
 	viper.BindEnv("jwt.access_expiry", "JWT_ACCESS_EXPIRY")
 	viper.BindEnv("jwt.refresh_expiry", "JWT_REFRESH_EXPIRY")
 	viper.BindEnv("jwt.issuer", "JWT_ISSUER")
+	viper.BindEnv("jwt.generate_secret", "JWT_GENERATE_SECRET")
+	viper.BindEnv("jwt.algorithm", "JWT_ALGORITHM")
+	viper.BindEnv("jwt.private_key", "JWT_PRIVATE_KEY")
+	viper.BindEnv("jwt.private_key_file", "JWT_PRIVATE_KEY_FILE")
+	viper.BindEnv("jwt.public_key", "JWT_PUBLIC_KEY")
+	viper.BindEnv("jwt.public_key_file", "JWT_PUBLIC_KEY_FILE")
+
+	// Auth configuration
+	viper.BindEnv("auth.password_min_score", "AUTH_PASSWORD_MIN_SCORE")
+	viper.BindEnv("auth.password_reset_ttl", "AUTH_PASSWORD_RESET_TTL")
+	viper.BindEnv("auth.log_reset_tokens", "AUTH_LOG_RESET_TOKENS")
+	viper.BindEnv("auth.totp_encryption_key", "AUTH_TOTP_ENCRYPTION_KEY")
+	viper.BindEnv("auth.totp_issuer", "AUTH_TOTP_ISSUER")
+	viper.BindEnv("auth.lockout_threshold", "AUTH_LOCKOUT_THRESHOLD")
+	viper.BindEnv("auth.lockout_window", "AUTH_LOCKOUT_WINDOW")
+	viper.BindEnv("auth.lockout_duration", "AUTH_LOCKOUT_DURATION")
+	viper.BindEnv("auth.admin_usernames", "AUTH_ADMIN_USERNAMES")
+
+	// User configuration
+	viper.BindEnv("user.delete_policy", "USER_DELETE_POLICY")
 
 	// Rate limit configuration
 	viper.BindEnv("rate_limit.requests", "RATE_LIMIT_REQUESTS")
@@ -129,6 +385,32 @@ func bindEnvVars() {
 	// Log configuration
 	viper.BindEnv("log.level", "LOG_LEVEL")
 	viper.BindEnv("log.format", "LOG_FORMAT")
+	viper.BindEnv("log.bodies", "DEBUG_LOG_BODIES")
+	viper.BindEnv("log.body_limit", "DEBUG_LOG_BODY_LIMIT")
+
+	// Todo configuration
+	viper.BindEnv("todo.priorities", "TODO_PRIORITIES")
+	viper.BindEnv("todo.default_priority", "TODO_DEFAULT_PRIORITY")
+	viper.BindEnv("todo.statuses", "TODO_STATUSES")
+	viper.BindEnv("todo.default_status", "TODO_DEFAULT_STATUS")
+	viper.BindEnv("todo.done_status", "TODO_DONE_STATUS")
+	viper.BindEnv("todo.description_max", "TODO_DESCRIPTION_MAX")
+	viper.BindEnv("todo.merge_due_date_strategy", "TODO_MERGE_DUE_DATE_STRATEGY")
+	viper.BindEnv("todo.auto_start_status", "TODO_AUTO_START_STATUS")
+	viper.BindEnv("todo.end_of_day_due_dates", "TODO_END_OF_DAY_DUE_DATES")
+	viper.BindEnv("todo.import_max_items", "TODO_IMPORT_MAX_ITEMS")
+	viper.BindEnv("todo.import_chunk_size", "TODO_IMPORT_CHUNK_SIZE")
+	viper.BindEnv("todo.import_body_limit", "TODO_IMPORT_BODY_LIMIT")
+	viper.BindEnv("todo.tag_case_insensitive", "TODO_TAG_CASE_INSENSITIVE")
+	viper.BindEnv("todo.insights_cache_ttl", "TODO_INSIGHTS_CACHE_TTL")
+	viper.BindEnv("todo.trash_retention_days", "TODO_TRASH_RETENTION_DAYS")
+
+	// Metrics configuration
+	viper.BindEnv("metrics.enabled", "METRICS_ENABLED")
+	viper.BindEnv("metrics.cache_ttl", "METRICS_CACHE_TTL")
+
+	// Scheduler configuration
+	viper.BindEnv("scheduler.workers", "SCHEDULER_WORKERS")
 }
 
 // setDefaults sets default values for configuration
@@ -139,11 +421,24 @@ func setDefaults() {
 	viper.SetDefault("server.read_timeout", "10s")
 	viper.SetDefault("server.write_timeout", "10s")
 	viper.SetDefault("server.environment", "development")
+	viper.SetDefault("server.base_path", "/api/v1")
+	viper.SetDefault("server.json_optional_fields", "omit")
+	viper.SetDefault("server.cache_max_age", "1h")
+	viper.SetDefault("server.localize_timestamps", false)
+	viper.SetDefault("server.request_timeout", "30s")
+	viper.SetDefault("server.body_limit", 4*1024*1024)
+	viper.SetDefault("server.compression", true)
+	viper.SetDefault("server.compression_level", "default")
+	viper.SetDefault("server.default_timezone", "UTC")
 
 	// Database defaults
-	viper.SetDefault("database.driver", "postgres")
 	viper.SetDefault("database.max_open_conns", 25)
 	viper.SetDefault("database.max_idle_conns", 5)
+	viper.SetDefault("database.acquire_timeout", "5s")
+	viper.SetDefault("database.max_concurrent_searches", 8)
+	viper.SetDefault("database.mongo_read_preference", "primary")
+	viper.SetDefault("database.pool_saturation_threshold", 0.8)
+	viper.SetDefault("database.pool_check_interval", "30s")
 
 	// Redis defaults
 	viper.SetDefault("redis.url", "redis://localhost:6379/0")
@@ -153,6 +448,20 @@ func setDefaults() {
 	viper.SetDefault("jwt.access_expiry", "15m")
 	viper.SetDefault("jwt.refresh_expiry", "168h")
 	viper.SetDefault("jwt.issuer", "go-fiber")
+	viper.SetDefault("jwt.generate_secret", true)
+	viper.SetDefault("jwt.algorithm", JWTAlgorithmHS256)
+
+	// Auth defaults
+	viper.SetDefault("auth.password_min_score", 0)
+	viper.SetDefault("auth.password_reset_ttl", "15m")
+	viper.SetDefault("auth.log_reset_tokens", false)
+	viper.SetDefault("auth.totp_issuer", "Go Fiber Todo")
+	viper.SetDefault("auth.lockout_threshold", 5)
+	viper.SetDefault("auth.lockout_window", "15m")
+	viper.SetDefault("auth.lockout_duration", "15m")
+
+	// User defaults
+	viper.SetDefault("user.delete_policy", "cascade")
 
 	// Rate limit defaults
 	viper.SetDefault("rate_limit.requests", 100)
@@ -161,6 +470,32 @@ func setDefaults() {
 	// Log defaults
 	viper.SetDefault("log.level", "info")
 	viper.SetDefault("log.format", "json")
+	viper.SetDefault("log.bodies", false)
+	viper.SetDefault("log.body_limit", 2048)
+
+	// Todo defaults
+	viper.SetDefault("todo.priorities", []string{"low", "medium", "high"})
+	viper.SetDefault("todo.default_priority", "medium")
+	viper.SetDefault("todo.statuses", []string{"pending", "in_progress", "completed"})
+	viper.SetDefault("todo.default_status", "pending")
+	viper.SetDefault("todo.done_status", "completed")
+	viper.SetDefault("todo.description_max", 5000)
+	viper.SetDefault("todo.merge_due_date_strategy", "earliest")
+	viper.SetDefault("todo.auto_start_status", "")
+	viper.SetDefault("todo.end_of_day_due_dates", false)
+	viper.SetDefault("todo.import_max_items", 500)
+	viper.SetDefault("todo.import_chunk_size", 100)
+	viper.SetDefault("todo.import_body_limit", 1024*1024)
+	viper.SetDefault("todo.tag_case_insensitive", false)
+	viper.SetDefault("todo.insights_cache_ttl", "5m")
+	viper.SetDefault("todo.trash_retention_days", 0)
+
+	// Metrics defaults
+	viper.SetDefault("metrics.enabled", false)
+	viper.SetDefault("metrics.cache_ttl", "15s")
+
+	// Scheduler defaults
+	viper.SetDefault("scheduler.workers", 4)
 }
 
 // validate validates the configuration
@@ -170,26 +505,162 @@ func validate(config *Config) error {
 		return fmt.Errorf("invalid server port: %d", config.Server.Port)
 	}
 
+	if !strings.HasPrefix(config.Server.BasePath, "/") {
+		return fmt.Errorf("server base path must start with '/': %s", config.Server.BasePath)
+	}
+	config.Server.BasePath = strings.TrimRight(config.Server.BasePath, "/")
+
+	if config.Server.PublicURL != "" {
+		publicURL, err := url.Parse(config.Server.PublicURL)
+		if err != nil || publicURL.Scheme == "" || publicURL.Host == "" {
+			return fmt.Errorf("invalid server public url: %s", config.Server.PublicURL)
+		}
+		config.Server.PublicURL = strings.TrimRight(config.Server.PublicURL, "/")
+	}
+
+	if config.Server.JSONOptionalFields != "omit" && config.Server.JSONOptionalFields != "null" {
+		return fmt.Errorf("invalid server json optional fields policy: %s", config.Server.JSONOptionalFields)
+	}
+
+	if _, err := config.Server.DefaultLocation(); err != nil {
+		return err
+	}
+
+	if config.Server.CacheMaxAge < 0 {
+		return fmt.Errorf("server cache max age must not be negative: %s", config.Server.CacheMaxAge)
+	}
+
+	switch config.Server.CompressionLevel {
+	case "default", "speed", "best":
+	default:
+		return fmt.Errorf("invalid server compression level: %s", config.Server.CompressionLevel)
+	}
+
+	if config.Server.BodyLimit <= 0 {
+		return fmt.Errorf("server body limit must be positive: %d", config.Server.BodyLimit)
+	}
+
+	if config.Server.RequestTimeout < 0 {
+		return fmt.Errorf("server request timeout must not be negative: %s", config.Server.RequestTimeout)
+	}
+
 	// Validate database configuration
+	if err := resolveDatabaseDriver(&config.Database); err != nil {
+		return err
+	}
+
 	if config.Database.Driver != "postgres" && config.Database.Driver != "mongodb" {
 		return fmt.Errorf("unsupported database driver: %s", config.Database.Driver)
 	}
 
-	if config.Database.Driver == "postgres" && config.Database.PostgresURL == "" {
+	if config.Database.UserDriver == "" {
+		config.Database.UserDriver = config.Database.Driver
+	}
+	if config.Database.TodoDriver == "" {
+		config.Database.TodoDriver = config.Database.Driver
+	}
+	for _, driver := range []string{config.Database.UserDriver, config.Database.TodoDriver} {
+		if driver != "postgres" && driver != "mongodb" {
+			return fmt.Errorf("unsupported database driver: %s", driver)
+		}
+	}
+
+	// PostgreSQL todos reference their user with a foreign key, so the user must be there too
+	if config.Database.TodoDriver == "postgres" && config.Database.UserDriver != "postgres" {
+		return fmt.Errorf("todos can only use the postgres driver when users do too")
+	}
+
+	if config.Database.Uses("postgres") && config.Database.PostgresURL == "" {
 		return fmt.Errorf("postgres_url is required when using postgres driver")
 	}
 
-	if config.Database.Driver == "mongodb" && config.Database.MongoURL == "" {
+	if config.Database.Uses("mongodb") && config.Database.MongoURL == "" {
 		return fmt.Errorf("mongo_url is required when using mongodb driver")
 	}
 
+	if err := checkDatabaseURLSchemes(&config.Database); err != nil {
+		return err
+	}
+
+	if config.Database.AcquireTimeout < 0 {
+		return fmt.Errorf("database acquire timeout must not be negative: %s", config.Database.AcquireTimeout)
+	}
+
+	if config.Database.MaxConcurrentSearches < 0 {
+		return fmt.Errorf("database max concurrent searches must not be negative: %d", config.Database.MaxConcurrentSearches)
+	}
+
+	switch config.Database.MongoReadPreference {
+	case "primary", "primaryPreferred", "secondaryPreferred":
+	default:
+		return fmt.Errorf("unsupported mongo read preference: %q", config.Database.MongoReadPreference)
+	}
+
+	if config.Database.PoolSaturationThreshold < 0 || config.Database.PoolSaturationThreshold > 1 {
+		return fmt.Errorf("database pool saturation threshold must be between 0 and 1: %g", config.Database.PoolSaturationThreshold)
+	}
+
+	if config.Database.PoolSaturationThreshold > 0 && config.Database.PoolCheckInterval <= 0 {
+		return fmt.Errorf("database pool check interval must be positive: %s", config.Database.PoolCheckInterval)
+	}
+
 	// Validate JWT configuration
-	if config.JWT.Secret == "" {
-		return fmt.Errorf("jwt secret is required")
+	switch config.JWT.Algorithm {
+	case JWTAlgorithmHS256:
+		if config.JWT.Secret == "" {
+			return fmt.Errorf("jwt secret is required")
+		}
+
+		if len(config.JWT.Secret) < 32 {
+			return fmt.Errorf("jwt secret must be at least 32 characters long")
+		}
+	case JWTAlgorithmRS256:
+		keys, err := config.JWT.Keys()
+		if err != nil {
+			return err
+		}
+
+		// The server issues tokens, so verifying alone is not enough
+		if keys.SignKey == nil {
+			return fmt.Errorf("jwt private key is required for RS256")
+		}
+	default:
+		return fmt.Errorf("unsupported jwt algorithm: %q", config.JWT.Algorithm)
+	}
+
+	if config.Auth.PasswordMinScore < 0 || config.Auth.PasswordMinScore > 4 {
+		return fmt.Errorf("auth password min score must be between 0 and 4: %d", config.Auth.PasswordMinScore)
+	}
+
+	if config.Auth.PasswordResetTTL <= 0 {
+		return fmt.Errorf("auth password reset TTL must be positive: %s", config.Auth.PasswordResetTTL)
+	}
+
+	if config.Auth.LogResetTokens && config.Server.Environment == "production" {
+		return fmt.Errorf("password reset token logging must not be enabled in production")
 	}
 
-	if len(config.JWT.Secret) < 32 {
-		return fmt.Errorf("jwt secret must be at least 32 characters long")
+	if _, err := config.Auth.TOTPKey(); err != nil {
+		return err
+	}
+
+	if config.Auth.LockoutThreshold < 0 {
+		return fmt.Errorf("auth lockout threshold must not be negative: %d", config.Auth.LockoutThreshold)
+	}
+
+	if config.Auth.LockoutThreshold > 0 && (config.Auth.LockoutWindow <= 0 || config.Auth.LockoutDuration <= 0) {
+		return fmt.Errorf("auth lockout window and duration must be positive: %s, %s", config.Auth.LockoutWindow, config.Auth.LockoutDuration)
+	}
+
+	switch config.User.DeletePolicy {
+	case "cascade", "block", "anonymize":
+	default:
+		return fmt.Errorf("invalid user delete policy: %s", config.User.DeletePolicy)
+	}
+
+	// Cascade and block act on the user's todos in the same database as the user
+	if config.Database.UserDriver != config.Database.TodoDriver && config.User.DeletePolicy != "anonymize" {
+		return fmt.Errorf("user delete policy %s needs users and todos in the same database; use anonymize", config.User.DeletePolicy)
 	}
 
 	// Validate Redis configuration
@@ -197,6 +668,169 @@ func validate(config *Config) error {
 		return fmt.Errorf("redis url is required")
 	}
 
+	// Validate log configuration
+	if config.Log.Bodies && config.Server.Environment == "production" {
+		return fmt.Errorf("debug body logging must not be enabled in production")
+	}
+
+	if config.Log.BodyLimit <= 0 {
+		return fmt.Errorf("debug log body limit must be positive: %d", config.Log.BodyLimit)
+	}
+
+	// Validate todo configuration
+	if len(config.Todo.Priorities) == 0 {
+		return fmt.Errorf("at least one todo priority is required")
+	}
+
+	if !slices.Contains(config.Todo.Priorities, config.Todo.DefaultPriority) {
+		return fmt.Errorf("default todo priority %q is not in the configured priorities", config.Todo.DefaultPriority)
+	}
+
+	if len(config.Todo.Statuses) == 0 {
+		return fmt.Errorf("at least one todo status is required")
+	}
+
+	if !slices.Contains(config.Todo.Statuses, config.Todo.DefaultStatus) {
+		return fmt.Errorf("default todo status %q is not in the configured statuses", config.Todo.DefaultStatus)
+	}
+
+	if !slices.Contains(config.Todo.Statuses, config.Todo.DoneStatus) {
+		return fmt.Errorf("done todo status %q is not in the configured statuses", config.Todo.DoneStatus)
+	}
+
+	if config.Todo.DescriptionMax <= 0 {
+		return fmt.Errorf("todo description max must be positive: %d", config.Todo.DescriptionMax)
+	}
+
+	if config.Todo.ImportMaxItems <= 0 {
+		return fmt.Errorf("todo import max items must be positive: %d", config.Todo.ImportMaxItems)
+	}
+	if config.Todo.ImportChunkSize <= 0 || config.Todo.ImportChunkSize > min(config.Todo.ImportMaxItems, maxImportChunkSize) {
+		return fmt.Errorf("todo import chunk size must be between 1 and the import max items, at most %d: %d", maxImportChunkSize, config.Todo.ImportChunkSize)
+	}
+	if config.Todo.ImportBodyLimit <= 0 || config.Todo.ImportBodyLimit > config.Server.BodyLimit {
+		return fmt.Errorf("todo import body limit must be between 1 and the server body limit: %d", config.Todo.ImportBodyLimit)
+	}
+
+	if config.Todo.InsightsCacheTTL < 0 {
+		return fmt.Errorf("todo insights cache ttl must not be negative: %s", config.Todo.InsightsCacheTTL)
+	}
+
+	if config.Todo.TrashRetentionDays < 0 {
+		return fmt.Errorf("todo trash retention days must not be negative: %d", config.Todo.TrashRetentionDays)
+	}
+
+	switch config.Todo.MergeDueDateStrategy {
+	case "earliest", "latest", "target":
+	default:
+		return fmt.Errorf("invalid todo merge due date strategy: %s", config.Todo.MergeDueDateStrategy)
+	}
+
+	if config.Todo.AutoStartStatus != "" {
+		if !slices.Contains(config.Todo.Statuses, config.Todo.AutoStartStatus) {
+			return fmt.Errorf("auto start todo status %q is not in the configured statuses", config.Todo.AutoStartStatus)
+		}
+		if config.Todo.AutoStartStatus == config.Todo.DefaultStatus {
+			return fmt.Errorf("auto start todo status must differ from the default status: %s", config.Todo.AutoStartStatus)
+		}
+	}
+
+	// Validate metrics configuration
+	if config.Metrics.CacheTTL < 0 {
+		return fmt.Errorf("metrics cache ttl must not be negative: %s", config.Metrics.CacheTTL)
+	}
+
+	// Validate scheduler configuration
+	if config.Scheduler.Workers < 1 {
+		return fmt.Errorf("scheduler workers must be at least 1: %d", config.Scheduler.Workers)
+	}
+
+	return nil
+}
+
+// driverForURL infers the database driver from a connection URL's scheme
+func driverForURL(rawURL string) string {
+	scheme, _, found := strings.Cut(rawURL, "://")
+	if !found {
+		return ""
+	}
+
+	switch strings.ToLower(scheme) {
+	case "postgres", "postgresql":
+		return "postgres"
+	case "mongodb", "mongodb+srv":
+		return "mongodb"
+	default:
+		return ""
+	}
+}
+
+// resolveDatabaseDriver infers the driver from the configured URL when it is not set explicitly.
+// With both URLs set it falls back to postgres, the driver used before detection existed.
+// When both the user and todo drivers are set, Driver is only a default and takes the todo driver.
+func resolveDatabaseDriver(db *DatabaseConfig) error {
+	if db.Driver != "" {
+		return nil
+	}
+	if db.UserDriver != "" && db.TodoDriver != "" {
+		db.Driver = db.TodoDriver
+		return nil
+	}
+
+	switch {
+	case db.PostgresURL != "" && db.MongoURL != "":
+		db.Driver = "postgres"
+		db.DriverDefaulted = true
+	case db.PostgresURL != "":
+		db.Driver = driverForURL(db.PostgresURL)
+	case db.MongoURL != "":
+		db.Driver = driverForURL(db.MongoURL)
+	default:
+		return fmt.Errorf("database driver is not set and no database url is configured")
+	}
+
+	if db.Driver == "" {
+		return fmt.Errorf("database driver could not be detected from the url scheme")
+	}
+	return nil
+}
+
+// checkDatabaseURLSchemes ensures the URL of each driver in use has a matching scheme
+func checkDatabaseURLSchemes(db *DatabaseConfig) error {
+	for _, driver := range []string{"postgres", "mongodb"} {
+		if !db.Uses(driver) {
+			continue
+		}
+
+		name, rawURL := "postgres_url", db.PostgresURL
+		if driver == "mongodb" {
+			name, rawURL = "mongo_url", db.MongoURL
+		}
+
+		if detected := driverForURL(rawURL); detected != driver {
+			if detected == "" {
+				return fmt.Errorf("%s has an unrecognised scheme for the %s driver", name, driver)
+			}
+			return fmt.Errorf("database driver %s does not match %s, which is a %s url", driver, name, detected)
+		}
+	}
+	return nil
+}
+
+// generateJWTSecret fills in a random JWT secret when none is configured, unless in
+// production, turned off with jwt.generate_secret or not signing with a secret
+func generateJWTSecret(config *Config) error {
+	if config.JWT.Algorithm != JWTAlgorithmHS256 || config.JWT.Secret != "" || !config.JWT.GenerateSecret || config.IsProduction() {
+		return nil
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("failed to generate jwt secret: %w", err)
+	}
+
+	config.JWT.Secret = hex.EncodeToString(secret)
+	config.JWT.SecretGenerated = true
 	return nil
 }
 
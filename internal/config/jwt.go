@@ -0,0 +1,94 @@
+package config
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported JWT signing algorithms. HS256 signs and verifies with the shared secret; RS256
+// signs with a private key and verifies with its public key, so other services can verify
+// tokens without being able to issue them.
+const (
+	JWTAlgorithmHS256 = "HS256"
+	JWTAlgorithmRS256 = "RS256"
+)
+
+// JWTKeys holds the signing method of the configured algorithm and its keys
+type JWTKeys struct {
+	Method jwt.SigningMethod
+
+	// SignKey is nil when only an RS256 public key is configured, so tokens can be verified
+	// but not issued
+	SignKey   interface{}
+	VerifyKey interface{}
+}
+
+// Keys loads the keys of the configured algorithm, treating an empty algorithm as HS256.
+// RS256 keys are PEM encoded and given inline or as files, with inline keys taking
+// precedence; the public key is derived from the private key when only that is set.
+func (c JWTConfig) Keys() (*JWTKeys, error) {
+	switch c.Algorithm {
+	case "", JWTAlgorithmHS256:
+		return &JWTKeys{
+			Method:    jwt.SigningMethodHS256,
+			SignKey:   []byte(c.Secret),
+			VerifyKey: []byte(c.Secret),
+		}, nil
+	case JWTAlgorithmRS256:
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm: %q", c.Algorithm)
+	}
+
+	privatePEM, err := readPEM(c.PrivateKey, c.PrivateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwt private key: %w", err)
+	}
+	publicPEM, err := readPEM(c.PublicKey, c.PublicKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwt public key: %w", err)
+	}
+	if privatePEM == nil && publicPEM == nil {
+		return nil, fmt.Errorf("jwt private or public key is required for RS256")
+	}
+
+	keys := &JWTKeys{Method: jwt.SigningMethodRS256}
+
+	var privateKey *rsa.PrivateKey
+	if privatePEM != nil {
+		privateKey, err = jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwt private key: %w", err)
+		}
+		keys.SignKey = privateKey
+		keys.VerifyKey = &privateKey.PublicKey
+	}
+
+	if publicPEM != nil {
+		publicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwt public key: %w", err)
+		}
+		if privateKey != nil && !privateKey.PublicKey.Equal(publicKey) {
+			return nil, fmt.Errorf("jwt public key does not match the private key")
+		}
+		keys.VerifyKey = publicKey
+	}
+
+	return keys, nil
+}
+
+// readPEM returns the inline PEM value, in which escaped newlines are restored so keys fit in
+// a single environment variable, or else the contents of file. Neither being set yields nil.
+func readPEM(inline, file string) ([]byte, error) {
+	if inline != "" {
+		return []byte(strings.ReplaceAll(inline, `\n`, "\n")), nil
+	}
+	if file == "" {
+		return nil, nil
+	}
+	return os.ReadFile(file)
+}
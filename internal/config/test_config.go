@@ -1,6 +1,10 @@
 package config
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -10,11 +14,20 @@ import (
 func NewTestConfig() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Host:         "localhost",
-			Port:         9000,
-			ReadTimeout:  10 * time.Second,
-			WriteTimeout: 10 * time.Second,
-			Environment:  "test",
+			Host:               "localhost",
+			Port:               9000,
+			ReadTimeout:        10 * time.Second,
+			WriteTimeout:       10 * time.Second,
+			Environment:        "test",
+			BasePath:           "/api/v1",
+			JSONOptionalFields: "omit",
+			CacheMaxAge:        time.Hour,
+			RequestTimeout:     30 * time.Second,
+			BodyLimit:          4 * 1024 * 1024,
+			Compression:        true,
+			CompressionLevel:   "default",
+			DefaultTimezone:    "UTC",
+			ExposeErrorDetails: true,
 		},
 		Database: DatabaseConfig{
 			Driver:       "postgres",
@@ -22,6 +35,13 @@ func NewTestConfig() *Config {
 			MongoURL:     "mongodb://localhost:27017/test_db",
 			MaxOpenConns: 10,
 			MaxIdleConns: 5,
+
+			AcquireTimeout:        5 * time.Second,
+			MaxConcurrentSearches: 8,
+			MongoReadPreference:   "primary",
+
+			PoolSaturationThreshold: 0.8,
+			PoolCheckInterval:       30 * time.Second,
 		},
 		Redis: RedisConfig{
 			URL:      "redis://localhost:6379/1", // Use DB 1 for tests
@@ -33,15 +53,50 @@ func NewTestConfig() *Config {
 			AccessExpiry:  15 * time.Minute,
 			RefreshExpiry: 24 * time.Hour,
 			Issuer:        "go-fiber-test",
+			Algorithm:     JWTAlgorithmHS256,
+		},
+		Auth: AuthConfig{
+			PasswordResetTTL: 15 * time.Minute,
+			TOTPIssuer:       "Go Fiber Todo",
+			LockoutThreshold: 5,
+			LockoutWindow:    15 * time.Minute,
+			LockoutDuration:  15 * time.Minute,
+		},
+		User: UserConfig{
+			DeletePolicy: "cascade",
 		},
 		Log: LogConfig{
-			Level:  "debug",
-			Format: "json",
+			Level:     "debug",
+			Format:    "json",
+			BodyLimit: 2048,
 		},
 		RateLimit: RateLimitConfig{
 			Requests: 1000, // High limit for tests
 			Window:   time.Minute,
 		},
+		Todo: TodoConfig{
+			Priorities:      []string{"low", "medium", "high"},
+			DefaultPriority: "medium",
+			Statuses:        []string{"pending", "in_progress", "completed"},
+			DefaultStatus:   "pending",
+			DoneStatus:      "completed",
+			DescriptionMax:  5000,
+			ImportMaxItems:  500,
+			ImportChunkSize: 100,
+			ImportBodyLimit: 1024 * 1024,
+
+			InsightsCacheTTL:   5 * time.Minute,
+			TrashRetentionDays: 0,
+
+			MergeDueDateStrategy: "earliest",
+		},
+		Metrics: MetricsConfig{
+			Enabled:  false,
+			CacheTTL: 15 * time.Second,
+		},
+		Scheduler: SchedulerConfig{
+			Workers: 4,
+		},
 	}
 }
 
@@ -49,3 +104,20 @@ func NewTestConfig() *Config {
 func NewTestLogger() zerolog.Logger {
 	return zerolog.Nop() // No-op logger for tests
 }
+
+// NewTestRSAKeyPEM generates an RSA key pair for RS256 tests and returns the private and
+// public keys PEM encoded
+func NewTestRSAKeyPEM() (string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		panic(err)
+	}
+
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	return string(privatePEM), string(publicPEM)
+}
@@ -16,6 +16,9 @@ type Config struct {
 	URI      string
 	Database string
 	Timeout  time.Duration
+
+	// ReadPreference is the read preference mode for reads (primary when empty)
+	ReadPreference string
 }
 
 // Connection wraps MongoDB client and database
@@ -31,7 +34,11 @@ func NewConnection(config Config, logger zerolog.Logger) (*Connection, error) {
 	defer cancel()
 
 	// Set client options
-	clientOptions := options.Client().ApplyURI(config.URI)
+	clientOptions, err := newClientOptions(config)
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid MongoDB configuration.")
+		return nil, err
+	}
 
 	// Create client
 	client, err := mongo.Connect(ctx, clientOptions)
@@ -50,6 +57,7 @@ func NewConnection(config Config, logger zerolog.Logger) (*Connection, error) {
 
 	logger.Info().
 		Str("database", config.Database).
+		Str("read_preference", clientOptions.ReadPreference.Mode().String()).
 		Msg("Successfully connected to MongoDB.")
 
 	return &Connection{
@@ -59,6 +67,28 @@ func NewConnection(config Config, logger zerolog.Logger) (*Connection, error) {
 	}, nil
 }
 
+// newClientOptions builds the client options for config. The read preference applies to
+// plain reads only: the server always runs writes on the primary, and the repositories run
+// their transactions with a primary read preference. With primaryPreferred or
+// secondaryPreferred, reads keep working on a secondary while a new primary is elected.
+func newClientOptions(config Config) (*options.ClientOptions, error) {
+	mode := readpref.PrimaryMode
+	if config.ReadPreference != "" {
+		var err error
+		mode, err = readpref.ModeFromString(config.ReadPreference)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MongoDB read preference %q: %w", config.ReadPreference, err)
+		}
+	}
+
+	readPreference, err := readpref.New(mode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MongoDB read preference %q: %w", config.ReadPreference, err)
+	}
+
+	return options.Client().ApplyURI(config.URI).SetReadPreference(readPreference), nil
+}
+
 // Close closes the MongoDB connection
 func (c *Connection) Close(ctx context.Context) error {
 	if err := c.Client.Disconnect(ctx); err != nil {
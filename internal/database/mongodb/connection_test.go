@@ -0,0 +1,45 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func TestNewClientOptions_ReadPreference(t *testing.T) {
+	tests := []struct {
+		readPreference string
+		want           readpref.Mode
+	}{
+		{"", readpref.PrimaryMode},
+		{"primary", readpref.PrimaryMode},
+		{"primaryPreferred", readpref.PrimaryPreferredMode},
+		{"secondaryPreferred", readpref.SecondaryPreferredMode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.readPreference, func(t *testing.T) {
+			// Act
+			opts, err := newClientOptions(Config{URI: "mongodb://localhost:27017", ReadPreference: tt.readPreference})
+			require.NoError(t, err)
+
+			// Connect does not dial, so the client can be inspected without a server
+			client, err := mongo.Connect(context.Background(), opts)
+			require.NoError(t, err)
+			defer client.Disconnect(context.Background())
+
+			// Assert - finds inherit the database's read preference
+			assert.Equal(t, tt.want, opts.ReadPreference.Mode())
+			assert.Equal(t, tt.want, client.Database("todoapp").ReadPreference().Mode())
+		})
+	}
+
+	t.Run("unknown mode", func(t *testing.T) {
+		_, err := newClientOptions(Config{URI: "mongodb://localhost:27017", ReadPreference: "fastest"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// PoolStatsProvider reports how many pooled connections are in use and how many
+// the pool may hold
+type PoolStatsProvider interface {
+	PoolUsage() (acquired, total int32)
+}
+
+// PoolUsage returns the acquired connections and the pool's maximum size
+func (db *DB) PoolUsage() (acquired, total int32) {
+	stats := db.Pool.Stat()
+	return stats.AcquiredConns(), stats.MaxConns()
+}
+
+// SaturationMonitor periodically samples pool usage and warns when the share of
+// acquired connections reaches a threshold, before acquires start timing out
+type SaturationMonitor struct {
+	stats     PoolStatsProvider
+	threshold float64
+	logger    zerolog.Logger
+
+	// saturation holds the latest acquired/total ratio as float64 bits
+	saturation atomic.Uint64
+}
+
+// NewSaturationMonitor creates a monitor that warns once acquired/total reaches threshold
+func NewSaturationMonitor(stats PoolStatsProvider, threshold float64, logger zerolog.Logger) *SaturationMonitor {
+	return &SaturationMonitor{
+		stats:     stats,
+		threshold: threshold,
+		logger:    logger,
+	}
+}
+
+// Check samples the pool once; it matches scheduler.JobFunc so it can run as a periodic job
+func (m *SaturationMonitor) Check(ctx context.Context) error {
+	acquired, total := m.stats.PoolUsage()
+	if total <= 0 {
+		return nil
+	}
+
+	ratio := float64(acquired) / float64(total)
+	m.saturation.Store(math.Float64bits(ratio))
+
+	if ratio >= m.threshold {
+		m.logger.Warn().
+			Int32("acquired_conns", acquired).
+			Int32("total_conns", total).
+			Float64("saturation", ratio).
+			Float64("threshold", m.threshold).
+			Msg("Database connection pool is nearing capacity.")
+	}
+
+	return nil
+}
+
+// Saturation returns the acquired/total ratio observed by the latest check
+func (m *SaturationMonitor) Saturation() float64 {
+	return math.Float64frombits(m.saturation.Load())
+}
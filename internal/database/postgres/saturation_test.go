@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePoolStats reports fixed pool usage
+type fakePoolStats struct {
+	acquired int32
+	total    int32
+}
+
+func (f fakePoolStats) PoolUsage() (int32, int32) {
+	return f.acquired, f.total
+}
+
+func TestSaturationMonitor_Check(t *testing.T) {
+	tests := []struct {
+		name     string
+		stats    fakePoolStats
+		wantWarn bool
+		wantSat  float64
+	}{
+		{name: "below threshold", stats: fakePoolStats{acquired: 7, total: 10}, wantWarn: false, wantSat: 0.7},
+		{name: "at threshold", stats: fakePoolStats{acquired: 8, total: 10}, wantWarn: true, wantSat: 0.8},
+		{name: "past threshold", stats: fakePoolStats{acquired: 10, total: 10}, wantWarn: true, wantSat: 1},
+		{name: "empty pool", stats: fakePoolStats{acquired: 0, total: 0}, wantWarn: false, wantSat: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var buf bytes.Buffer
+			monitor := NewSaturationMonitor(tt.stats, 0.8, zerolog.New(&buf))
+
+			// Act
+			err := monitor.Check(context.Background())
+
+			// Assert
+			require.NoError(t, err)
+			assert.InDelta(t, tt.wantSat, monitor.Saturation(), 1e-9)
+			if tt.wantWarn {
+				assert.Contains(t, buf.String(), `"level":"warn"`)
+				assert.Contains(t, buf.String(), `"acquired_conns":`)
+			} else {
+				assert.Empty(t, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"go-fiber/internal/middleware"
+	"go-fiber/internal/models"
+	"go-fiber/internal/repository/interfaces"
+	"go-fiber/internal/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// AdminHandler handles HTTP requests reserved for admins
+type AdminHandler struct {
+	userRepo  interfaces.UserRepository
+	validator *validator.Validate
+	logger    zerolog.Logger
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(userRepo interfaces.UserRepository, validator *validator.Validate, logger zerolog.Logger) *AdminHandler {
+	return &AdminHandler{
+		userRepo:  userRepo,
+		validator: validator,
+		logger:    logger,
+	}
+}
+
+// RegisterRoutes registers admin routes behind the auth middleware and the admin role
+func (h *AdminHandler) RegisterRoutes(router fiber.Router, authMiddleware fiber.Handler) {
+	admin := router.Group("/admin", authMiddleware, middleware.RequireRole(models.UserRoleAdmin))
+
+	admin.Get("/users", h.ListUsers)
+}
+
+// ListUsers handles listing all users
+// @Summary List users
+// @Description List every user account with pagination. Admins only.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Number of users to return" default(10)
+// @Param offset query int false "Number of users to skip" default(0)
+// @Success 200 {object} utils.PaginatedResponse[[]models.UserResponse]
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/users [get]
+func (h *AdminHandler) ListUsers(c *fiber.Ctx) error {
+	// Parse and validate query parameters
+	var queryParams models.PaginationQueryParams
+
+	if err := c.QueryParser(&queryParams); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse query parameters.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid query parameters format",
+		})
+	}
+
+	// Set defaults for unprovided parameters
+	queryParams.SetDefaults()
+
+	if err := h.validator.Struct(&queryParams); err != nil {
+		h.logger.Error().Err(err).Msg("List users query parameters validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid query parameters",
+		}, err))
+	}
+
+	// Get users
+	users, total, err := h.userRepo.List(c.UserContext(), queryParams.Limit, queryParams.Offset)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", middleware.GetUserID(c)).Msg("Failed to list users.")
+		return repositoryError(c, err, "Failed to list users")
+	}
+
+	responses := make([]*models.UserResponse, len(users))
+	for i, user := range users {
+		responses[i] = user.ToResponse()
+	}
+
+	return utils.SendPaginated(c, responses, total, queryParams.Limit, queryParams.Offset)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"go-fiber/internal/config"
+	"go-fiber/internal/mocks"
+	"go-fiber/internal/models"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupAdminApp mounts the admin routes with a fake auth middleware that signs in as role
+func setupAdminApp(role string) (*fiber.App, *mocks.MockUserRepository) {
+	mockUserRepo := new(mocks.MockUserRepository)
+	handler := NewAdminHandler(mockUserRepo, validator.New(), config.NewTestLogger())
+
+	app := fiber.New()
+	authMiddleware := func(c *fiber.Ctx) error {
+		c.Locals("userID", "test-user-id")
+		c.Locals("username", "testuser")
+		c.Locals("role", role)
+		return c.Next()
+	}
+	handler.RegisterRoutes(app.Group("/api/v1"), authMiddleware)
+
+	return app, mockUserRepo
+}
+
+func TestAdminHandler_ListUsers(t *testing.T) {
+	t.Run("admin lists users", func(t *testing.T) {
+		// Arrange
+		app, mockRepo := setupAdminApp(models.UserRoleAdmin)
+		mockRepo.On("List", mock.Anything, 10, 0).Return([]*models.User{
+			{ID: "user-1", Username: "alice", Password: "hash", Role: models.UserRoleAdmin},
+			{ID: "user-2", Username: "bob", Password: "hash", Role: models.UserRoleUser},
+		}, int64(2), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/admin/users", nil))
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body struct {
+			Data  []map[string]any `json:"data"`
+			Total int64            `json:"total"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, int64(2), body.Total)
+		require.Len(t, body.Data, 2)
+		assert.Equal(t, "admin", body.Data[0]["role"])
+		assert.NotContains(t, body.Data[0], "password")
+	})
+
+	t.Run("user is forbidden", func(t *testing.T) {
+		// Arrange
+		app, mockRepo := setupAdminApp(models.UserRoleUser)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/admin/users", nil))
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+
+	"go-fiber/internal/middleware"
+	"go-fiber/internal/models"
+	"go-fiber/internal/repository/interfaces"
+	"go-fiber/internal/services"
+	"go-fiber/internal/utils"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// APIKeyHandler handles API key management HTTP requests
+type APIKeyHandler struct {
+	apiKeyService *services.APIKeyService
+	validator     *validator.Validate
+	logger        zerolog.Logger
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(apiKeyService *services.APIKeyService, validator *validator.Validate, logger zerolog.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		validator:     validator,
+		logger:        logger,
+	}
+}
+
+// RegisterRoutes registers API key routes
+func (h *APIKeyHandler) RegisterRoutes(router fiber.Router, authMiddleware fiber.Handler) {
+	apiKeys := router.Group("/auth/api-keys", authMiddleware)
+
+	apiKeys.Post("/", h.CreateAPIKey)
+	apiKeys.Get("/", h.ListAPIKeys)
+	apiKeys.Delete("/:id", h.RevokeAPIKey)
+}
+
+// CreateAPIKey handles API key creation
+// @Summary Create an API key
+// @Description Create a named API key for machine clients. The plaintext key is returned only in this response; send it in the X-API-Key header to call the todo endpoints.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateAPIKeyRequest true "API key request"
+// @Success 201 {object} models.CreateAPIKeyResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	// Get user ID from context (set by auth middleware)
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	var req models.CreateAPIKeyRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse API key request.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		h.logger.Error().Err(err).Msg("API key request validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid input data",
+		}, err))
+	}
+
+	// Create key
+	key, apiKey, err := h.apiKeyService.Create(c.UserContext(), userID, &req)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to create API key.")
+		return repositoryError(c, err, "Failed to create API key")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.CreateAPIKeyResponse{
+		Message: "API key created successfully",
+		Key:     key,
+		APIKey:  apiKey,
+	})
+}
+
+// ListAPIKeys handles listing the user's API keys
+// @Summary List API keys
+// @Description List the authenticated user's API keys. Only metadata is returned, never the keys themselves.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.APIKeyListResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *fiber.Ctx) error {
+	// Get user ID from context (set by auth middleware)
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	apiKeys, err := h.apiKeyService.List(c.UserContext(), userID)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to list API keys.")
+		return repositoryError(c, err, "Failed to list API keys")
+	}
+
+	if apiKeys == nil {
+		apiKeys = []*models.APIKey{}
+	}
+	return c.JSON(models.APIKeyListResponse{APIKeys: apiKeys})
+}
+
+// RevokeAPIKey handles API key revocation
+// @Summary Revoke an API key
+// @Description Delete one of the authenticated user's API keys. Requests using it are rejected from then on.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "API key ID"
+// @Success 200 {object} models.MessageResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	// Get user ID from context (set by auth middleware)
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	if err := h.apiKeyService.Revoke(c.UserContext(), userID, c.Params("id")); err != nil {
+		if errors.Is(err, interfaces.ErrAPIKeyNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":   "Not Found",
+				"message": "API key not found",
+			})
+		}
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to revoke API key.")
+		return repositoryError(c, err, "Failed to revoke API key")
+	}
+
+	return c.JSON(models.MessageResponse{Message: "API key revoked successfully"})
+}
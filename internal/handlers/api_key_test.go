@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-fiber/internal/config"
+	"go-fiber/internal/mocks"
+	"go-fiber/internal/models"
+	"go-fiber/internal/repository/interfaces"
+	"go-fiber/internal/services"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAPIKeyApp() (*fiber.App, *mocks.MockAPIKeyRepository) {
+	mockAPIKeyRepo := new(mocks.MockAPIKeyRepository)
+	logger := config.NewTestLogger()
+	apiKeyService := services.NewAPIKeyService(mockAPIKeyRepo, new(mocks.MockUserRepository), logger)
+	handler := NewAPIKeyHandler(apiKeyService, validator.New(), logger)
+
+	app := fiber.New()
+	authMiddleware := func(c *fiber.Ctx) error {
+		c.Locals("userID", "test-user-id")
+		c.Locals("username", "testuser")
+		return c.Next()
+	}
+	handler.RegisterRoutes(app.Group("/api/v1"), authMiddleware)
+
+	return app, mockAPIKeyRepo
+}
+
+func TestAPIKeyHandler_CreateAPIKey(t *testing.T) {
+	t.Run("returns the plaintext key once", func(t *testing.T) {
+		// Arrange
+		app, mockRepo := setupAPIKeyApp()
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(key *models.APIKey) bool {
+			return key.UserID == "test-user-id" && key.Name == "ci" && key.KeyHash != ""
+		})).Return(&models.APIKey{ID: "key-1", Name: "ci", Scopes: []string{models.APIKeyScopeTodosRead}}, nil)
+
+		body, _ := json.Marshal(models.CreateAPIKeyRequest{Name: "ci", Scopes: []string{models.APIKeyScopeTodosRead}})
+		req := httptest.NewRequest("POST", "/api/v1/auth/api-keys", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+		var response models.CreateAPIKeyResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		assert.True(t, strings.HasPrefix(response.Key, models.APIKeyPrefix))
+		assert.Equal(t, "key-1", response.APIKey.ID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects unknown scopes", func(t *testing.T) {
+		// Arrange
+		app, mockRepo := setupAPIKeyApp()
+		body := `{"name":"ci","scopes":["admin"]}`
+		req := httptest.NewRequest("POST", "/api/v1/auth/api-keys", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAPIKeyHandler_ListAPIKeys(t *testing.T) {
+	// Arrange
+	app, mockRepo := setupAPIKeyApp()
+	mockRepo.On("ListByUserID", mock.Anything, "test-user-id").Return([]*models.APIKey{
+		{ID: "key-1", Name: "ci", KeyHash: "secret-hash"},
+	}, nil)
+
+	// Act
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/auth/api-keys", nil))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var raw bytes.Buffer
+	_, _ = raw.ReadFrom(resp.Body)
+	assert.Contains(t, raw.String(), `"key-1"`)
+	assert.NotContains(t, raw.String(), "secret-hash")
+}
+
+func TestAPIKeyHandler_RevokeAPIKey(t *testing.T) {
+	t.Run("revokes an owned key", func(t *testing.T) {
+		// Arrange
+		app, mockRepo := setupAPIKeyApp()
+		mockRepo.On("Delete", mock.Anything, "key-1", "test-user-id").Return(nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/api/v1/auth/api-keys/key-1", nil))
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown key is not found", func(t *testing.T) {
+		// Arrange
+		app, mockRepo := setupAPIKeyApp()
+		mockRepo.On("Delete", mock.Anything, "other", "test-user-id").Return(interfaces.ErrAPIKeyNotFound)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/api/v1/auth/api-keys/other", nil))
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+}
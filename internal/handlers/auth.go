@@ -1,9 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"math"
+	"strconv"
+
 	"go-fiber/internal/middleware"
 	"go-fiber/internal/models"
+	"go-fiber/internal/repository/interfaces"
 	"go-fiber/internal/services"
+	"go-fiber/internal/utils"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -36,9 +42,20 @@ func (h *AuthHandler) RegisterRoutes(router fiber.Router, authMiddleware fiber.H
 	auth.Post("/login/email", h.LoginByEmail)
 	auth.Post("/refresh", h.RefreshToken)
 	auth.Post("/logout", h.Logout)
+	auth.Post("/logout/all", authMiddleware, h.LogoutAll)
+	auth.Post("/password/reset-request", h.RequestPasswordReset)
+	auth.Post("/password/reset", h.ResetPassword)
+	auth.Post("/2fa/challenge", h.TwoFactorChallenge)
 
 	// Protected routes
 	auth.Get("/me", authMiddleware, h.Me)
+	auth.Delete("/me", authMiddleware, h.DeleteAccount)
+	auth.Get("/security", authMiddleware, h.Security)
+	auth.Get("/sessions", authMiddleware, h.ListSessions)
+	auth.Delete("/sessions/:id", authMiddleware, h.RevokeSession)
+	auth.Patch("/password", authMiddleware, h.ChangePassword)
+	auth.Post("/2fa/enable", authMiddleware, h.EnableTwoFactor)
+	auth.Post("/2fa/verify", authMiddleware, h.VerifyTwoFactor)
 }
 
 // Register handles user registration
@@ -68,17 +85,20 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	// Validate request
 	if err := h.validator.Struct(&req); err != nil {
 		h.logger.Error().Err(err).Msg("Registration request validation failed.")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
 			"error":   "Validation Error",
 			"message": "Invalid input data",
-			"details": err.Error(),
-		})
+		}, err))
 	}
 
 	// Register user
-	response, err := h.authService.Register(c.Context(), &req)
+	response, err := h.authService.Register(c.UserContext(), &req)
 	if err != nil {
-		if err.Error() == "username already exists" || err.Error() == "email already exists" {
+		var weak *services.WeakPasswordError
+		if errors.As(err, &weak) {
+			return weakPasswordResponse(c, weak)
+		}
+		if errors.Is(err, interfaces.ErrUsernameTaken) || errors.Is(err, interfaces.ErrEmailTaken) {
 			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 				"error":   "Conflict",
 				"message": err.Error(),
@@ -97,7 +117,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 
 // Login handles user login
 // @Summary Login user
-// @Description Authenticate user and return JWT tokens
+// @Description Authenticate user and return JWT tokens. Send either a username, or an identifier that is treated as an email when it contains "@" and as a username otherwise. Users with two-factor authentication get a TwoFactorChallengeResponse instead, to answer at /auth/2fa/challenge.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -105,6 +125,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 // @Success 200 {object} models.LoginResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse "Account locked after repeated failed logins"
 // @Failure 500 {object} models.ErrorResponse
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
@@ -122,17 +143,31 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	// Validate request
 	if err := h.validator.Struct(&req); err != nil {
 		h.logger.Error().Err(err).Msg("Login request validation failed.")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
 			"error":   "Validation Error",
 			"message": "Invalid input data",
-			"details": err.Error(),
-		})
+		}, err))
 	}
 
-	// Login user
-	response, err := h.authService.Login(c.Context(), &req)
+	// Record the client on the session
+	req.UserAgent, req.IP = c.Get(fiber.HeaderUserAgent), c.IP()
+
+	// Login user, resolving the identifier to an email or username when one is given
+	login := h.authService.Login
+	if req.Identifier != "" {
+		login = h.authService.LoginByIdentifier
+	}
+	response, err := login(c.UserContext(), &req)
 	if err != nil {
-		if err.Error() == "invalid credentials" {
+		var challenge *services.TwoFactorRequiredError
+		if errors.As(err, &challenge) {
+			return twoFactorChallengeResponse(c, challenge)
+		}
+		var locked *services.AccountLockedError
+		if errors.As(err, &locked) {
+			return accountLockedResponse(c, locked)
+		}
+		if errors.Is(err, services.ErrInvalidCredentials) {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error":   "Unauthorized",
 				"message": "Invalid credentials",
@@ -145,13 +180,13 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		})
 	}
 
-	h.logger.Info().Str("username", req.Username).Msg("User logged in successfully.")
+	h.logger.Info().Str("username", response.User.Username).Msg("User logged in successfully.")
 	return c.JSON(response)
 }
 
 // LoginByEmail handles user login by email
 // @Summary Login user by email
-// @Description Authenticate user by email and return JWT tokens
+// @Description Authenticate user by email and return JWT tokens. Users with two-factor authentication get a TwoFactorChallengeResponse instead, to answer at /auth/2fa/challenge.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -159,6 +194,7 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 // @Success 200 {object} models.LoginResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse "Account locked after repeated failed logins"
 // @Failure 500 {object} models.ErrorResponse
 // @Router /auth/login/email [post]
 func (h *AuthHandler) LoginByEmail(c *fiber.Ctx) error {
@@ -176,17 +212,27 @@ func (h *AuthHandler) LoginByEmail(c *fiber.Ctx) error {
 	// Validate request
 	if err := h.validator.Struct(&req); err != nil {
 		h.logger.Error().Err(err).Msg("Login by email request validation failed.")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
 			"error":   "Validation Error",
 			"message": "Invalid input data",
-			"details": err.Error(),
-		})
+		}, err))
 	}
 
+	// Record the client on the session
+	req.UserAgent, req.IP = c.Get(fiber.HeaderUserAgent), c.IP()
+
 	// Login user by email
-	response, err := h.authService.LoginByEmail(c.Context(), &req)
+	response, err := h.authService.LoginByEmail(c.UserContext(), &req)
 	if err != nil {
-		if err.Error() == "invalid credentials" {
+		var challenge *services.TwoFactorRequiredError
+		if errors.As(err, &challenge) {
+			return twoFactorChallengeResponse(c, challenge)
+		}
+		var locked *services.AccountLockedError
+		if errors.As(err, &locked) {
+			return accountLockedResponse(c, locked)
+		}
+		if errors.Is(err, services.ErrInvalidCredentials) {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error":   "Unauthorized",
 				"message": "Invalid credentials",
@@ -230,17 +276,16 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 	// Validate request
 	if err := h.validator.Struct(&req); err != nil {
 		h.logger.Error().Err(err).Msg("Refresh token request validation failed.")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
 			"error":   "Validation Error",
 			"message": "Invalid input data",
-			"details": err.Error(),
-		})
+		}, err))
 	}
 
 	// Refresh token
-	response, err := h.authService.RefreshToken(c.Context(), &req)
+	response, err := h.authService.RefreshToken(c.UserContext(), &req)
 	if err != nil {
-		if err.Error() == "invalid refresh token" || err.Error() == "invalid session" || err.Error() == "session expired" {
+		if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrInvalidSession) || errors.Is(err, services.ErrSessionExpired) {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error":   "Unauthorized",
 				"message": err.Error(),
@@ -278,7 +323,7 @@ func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 	}
 
 	// Logout user
-	response, err := h.authService.Logout(c.Context(), &req)
+	response, err := h.authService.Logout(c.UserContext(), &req)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("Failed to logout user.")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -291,6 +336,39 @@ func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 	return c.JSON(response)
 }
 
+// LogoutAll handles ending every session of the authenticated user
+// @Summary Logout all sessions
+// @Description End every session of the authenticated user, e.g. after a password change or suspected compromise. Refresh tokens of the ended sessions stop working.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.LogoutAllResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/logout/all [post]
+func (h *AuthHandler) LogoutAll(c *fiber.Ctx) error {
+	// Get user ID from context (set by auth middleware)
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	// Logout all sessions
+	response, err := h.authService.LogoutAll(c.UserContext(), userID)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to logout all sessions.")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Internal Server Error",
+			"message": "Failed to logout all sessions",
+		})
+	}
+
+	return c.JSON(response)
+}
+
 // Me handles getting current user information
 // @Summary Get current user
 // @Description Get authenticated user information
@@ -312,7 +390,7 @@ func (h *AuthHandler) Me(c *fiber.Ctx) error {
 	}
 
 	// Get user information
-	response, err := h.authService.GetAuthenticatedUser(c.Context(), userID)
+	response, err := h.authService.GetAuthenticatedUser(c.UserContext(), userID)
 	if err != nil {
 		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get authenticated user.")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -323,3 +401,562 @@ func (h *AuthHandler) Me(c *fiber.Ctx) error {
 
 	return c.JSON(response)
 }
+
+// Security handles getting the account security summary
+// @Summary Get account security summary
+// @Description Get the current session, the number of active sessions and the time, IP address and user agent of the most recent login of the authenticated user
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.SecuritySummaryResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/security [get]
+func (h *AuthHandler) Security(c *fiber.Ctx) error {
+	// Get user ID from context (set by auth middleware)
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	// Get security summary
+	response, err := h.authService.GetSecuritySummary(c.UserContext(), userID, middleware.GetSessionID(c))
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get security summary.")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Internal Server Error",
+			"message": "Failed to get security summary",
+		})
+	}
+
+	return c.JSON(response)
+}
+
+// ListSessions handles listing the authenticated user's sessions
+// @Summary List sessions
+// @Description Get the active sessions of the authenticated user, newest first, with the user agent and IP of each login
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.SessionListResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/sessions [get]
+func (h *AuthHandler) ListSessions(c *fiber.Ctx) error {
+	// Get user ID from context (set by auth middleware)
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	// List sessions
+	response, err := h.authService.ListSessions(c.UserContext(), userID, middleware.GetSessionID(c))
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to list sessions.")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Internal Server Error",
+			"message": "Failed to list sessions",
+		})
+	}
+
+	return c.JSON(response)
+}
+
+// RevokeSession handles ending one of the authenticated user's sessions
+// @Summary Revoke session
+// @Description End a session of the authenticated user; its refresh token stops working. Sessions of other users are reported as not found.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} models.MessageResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(c *fiber.Ctx) error {
+	// Get user ID from context (set by auth middleware)
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	// Revoke session
+	if err := h.authService.RevokeSession(c.UserContext(), userID, c.Params("id")); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":   "Not Found",
+				"message": "Session not found",
+			})
+		}
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to revoke session.")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Internal Server Error",
+			"message": "Failed to revoke session",
+		})
+	}
+
+	return c.JSON(models.MessageResponse{Message: "Session revoked successfully"})
+}
+
+// ChangePassword handles changing the authenticated user's password
+// @Summary Change password
+// @Description Replace the authenticated user's password after checking the current one. Set logoutOtherSessions to end every other session of the user.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.UpdatePasswordRequest true "Change password request"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/password [patch]
+func (h *AuthHandler) ChangePassword(c *fiber.Ctx) error {
+	// Get user ID from context (set by auth middleware)
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	var req models.UpdatePasswordRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse change password request.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Change password request validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid input data",
+		}, err))
+	}
+
+	// Change password
+	if err := h.authService.ChangePassword(c.UserContext(), userID, middleware.GetSessionID(c), &req); err != nil {
+		var weak *services.WeakPasswordError
+		if errors.As(err, &weak) {
+			return weakPasswordResponse(c, weak)
+		}
+		if errors.Is(err, services.ErrInvalidCurrentPassword) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":   "Unauthorized",
+				"message": "Current password is incorrect",
+			})
+		}
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to change password.")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Internal Server Error",
+			"message": "Failed to change password",
+		})
+	}
+
+	h.logger.Info().Str("user_id", userID).Msg("Password changed successfully.")
+	return c.JSON(models.MessageResponse{Message: "Password changed successfully"})
+}
+
+// DeleteAccount handles deleting the authenticated user's account
+// @Summary Delete account
+// @Description Delete the authenticated user's account after checking their password and end all of their sessions. USER_DELETE_POLICY decides what happens to their todos: cascade deletes them, block refuses with 409 while any are not done, and anonymize keeps them but strips the account's personal data.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.DeleteAccountRequest true "Delete account request"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/me [delete]
+func (h *AuthHandler) DeleteAccount(c *fiber.Ctx) error {
+	// Get user ID from context (set by auth middleware)
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	var req models.DeleteAccountRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse delete account request.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Delete account request validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid input data",
+		}, err))
+	}
+
+	if err := h.authService.DeleteAccount(c.UserContext(), userID, req.Password); err != nil {
+		switch {
+		case errors.Is(err, services.ErrIncorrectPassword):
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":   "Unauthorized",
+				"message": "Password is incorrect",
+			})
+		case errors.Is(err, interfaces.ErrUserHasActiveTodos):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   "Conflict",
+				"message": "Complete or delete your todos before deleting your account",
+			})
+		}
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to delete account.")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Internal Server Error",
+			"message": "Failed to delete account",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RequestPasswordReset handles requests for a password reset token
+// @Summary Request a password reset
+// @Description Issue a single-use password reset token for the account with the email. The response is the same whether or not the email has an account.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.PasswordResetRequest true "Password reset request"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/password/reset-request [post]
+func (h *AuthHandler) RequestPasswordReset(c *fiber.Ctx) error {
+	var req models.PasswordResetRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse password reset request.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Password reset request validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid input data",
+		}, err))
+	}
+
+	// Issue a reset token
+	if err := h.authService.RequestPasswordReset(c.UserContext(), req.Email); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to request password reset.")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Internal Server Error",
+			"message": "Failed to request password reset",
+		})
+	}
+
+	return c.JSON(models.MessageResponse{Message: "If an account exists for that email, a password reset token has been sent"})
+}
+
+// ResetPassword handles setting a new password with a reset token
+// @Summary Reset password
+// @Description Set a new password with a token from /auth/password/reset-request. The token can only be used once, and all of the user's sessions are ended.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.ResetPasswordRequest true "Reset password request"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	var req models.ResetPasswordRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse reset password request.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Reset password request validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid input data",
+		}, err))
+	}
+
+	// Reset password
+	if err := h.authService.ResetPassword(c.UserContext(), &req); err != nil {
+		var weak *services.WeakPasswordError
+		if errors.As(err, &weak) {
+			return weakPasswordResponse(c, weak)
+		}
+		if errors.Is(err, services.ErrInvalidResetToken) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Bad Request",
+				"message": "Reset token is invalid or has expired",
+			})
+		}
+		h.logger.Error().Err(err).Msg("Failed to reset password.")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Internal Server Error",
+			"message": "Failed to reset password",
+		})
+	}
+
+	return c.JSON(models.MessageResponse{Message: "Password reset successfully"})
+}
+
+// EnableTwoFactor handles starting two-factor authentication setup
+// @Summary Set up two-factor authentication
+// @Description Generate a TOTP secret for the authenticated user. Add it to an authenticator app by typing in the secret or scanning the otpauth URL as a QR code, then confirm with /auth/2fa/verify. Calling this again before verifying replaces the secret.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.TOTPSetupResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /auth/2fa/enable [post]
+func (h *AuthHandler) EnableTwoFactor(c *fiber.Ctx) error {
+	// Get user ID from context (set by auth middleware)
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	// Generate secret
+	response, err := h.authService.EnableTwoFactor(c.UserContext(), userID)
+	if err != nil {
+		if status, body, ok := twoFactorError(err); ok {
+			return c.Status(status).JSON(body)
+		}
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to set up two-factor authentication.")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Internal Server Error",
+			"message": "Failed to set up two-factor authentication",
+		})
+	}
+
+	return c.JSON(response)
+}
+
+// VerifyTwoFactor handles confirming two-factor authentication setup
+// @Summary Verify two-factor authentication
+// @Description Turn on two-factor authentication with a code from the authenticator app set up with /auth/2fa/enable. Later logins then need a code.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.TOTPCodeRequest true "Two-factor code"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /auth/2fa/verify [post]
+func (h *AuthHandler) VerifyTwoFactor(c *fiber.Ctx) error {
+	// Get user ID from context (set by auth middleware)
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	var req models.TOTPCodeRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse two-factor verify request.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Two-factor verify request validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid input data",
+		}, err))
+	}
+
+	// Enable two-factor authentication
+	if err := h.authService.VerifyTwoFactor(c.UserContext(), userID, req.Code); err != nil {
+		if errors.Is(err, services.ErrInvalidTOTPCode) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Bad Request",
+				"message": "Invalid two-factor code",
+			})
+		}
+		if status, body, ok := twoFactorError(err); ok {
+			return c.Status(status).JSON(body)
+		}
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to verify two-factor authentication.")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Internal Server Error",
+			"message": "Failed to verify two-factor authentication",
+		})
+	}
+
+	return c.JSON(models.MessageResponse{Message: "Two-factor authentication enabled successfully"})
+}
+
+// TwoFactorChallenge handles finishing a login with a two-factor code
+// @Summary Complete two-factor login
+// @Description Exchange the challenge token from a login of a user with two-factor authentication and a code from their authenticator app for JWT tokens. Each code and each challenge works once, a challenge is spent after too many wrong codes, and repeated wrong codes lock the account.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.TOTPChallengeRequest true "Two-factor challenge"
+// @Success 200 {object} models.LoginResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /auth/2fa/challenge [post]
+func (h *AuthHandler) TwoFactorChallenge(c *fiber.Ctx) error {
+	var req models.TOTPChallengeRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse two-factor challenge request.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Two-factor challenge request validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid input data",
+		}, err))
+	}
+
+	// Record the client on the session
+	req.UserAgent, req.IP = c.Get(fiber.HeaderUserAgent), c.IP()
+
+	// Complete login
+	response, err := h.authService.CompleteTwoFactorLogin(c.UserContext(), &req)
+	if err != nil {
+		var locked *services.AccountLockedError
+		if errors.As(err, &locked) {
+			return accountLockedResponse(c, locked)
+		}
+		if errors.Is(err, services.ErrInvalidTOTPCode) || errors.Is(err, services.ErrInvalidTwoFactorChallenge) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":   "Unauthorized",
+				"message": "Invalid two-factor code or challenge",
+			})
+		}
+		if status, body, ok := twoFactorError(err); ok {
+			return c.Status(status).JSON(body)
+		}
+		h.logger.Error().Err(err).Msg("Failed to complete two-factor login.")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Internal Server Error",
+			"message": "Failed to login user",
+		})
+	}
+
+	return c.JSON(response)
+}
+
+// twoFactorChallengeResponse answers a login that needs a two-factor code
+func twoFactorChallengeResponse(c *fiber.Ctx, challenge *services.TwoFactorRequiredError) error {
+	return c.JSON(models.TwoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    challenge.ChallengeToken,
+		ExpiresAt:         challenge.ExpiresAt,
+	})
+}
+
+// accountLockedResponse answers a login to an account locked after repeated failed logins,
+// telling the client when to try again
+func accountLockedResponse(c *fiber.Ctx, locked *services.AccountLockedError) error {
+	retryAfter := int(math.Ceil(locked.RetryAfter.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error":       "Too Many Requests",
+		"message":     "Too many failed login attempts. Please try again later.",
+		"retry_after": retryAfter,
+	})
+}
+
+// twoFactorError maps the two-factor setup errors shared by the 2FA endpoints to a
+// response, reporting false for any other error
+func twoFactorError(err error) (int, fiber.Map, bool) {
+	switch {
+	case errors.Is(err, services.ErrTwoFactorNotConfigured):
+		return fiber.StatusServiceUnavailable, fiber.Map{
+			"error":   "Service Unavailable",
+			"message": "Two-factor authentication is not configured",
+		}, true
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+		return fiber.StatusConflict, fiber.Map{
+			"error":   "Conflict",
+			"message": "Two-factor authentication is already enabled",
+		}, true
+	case errors.Is(err, services.ErrTwoFactorNotEnrolled):
+		return fiber.StatusBadRequest, fiber.Map{
+			"error":   "Bad Request",
+			"message": "Set up two-factor authentication with /auth/2fa/enable first",
+		}, true
+	}
+	return 0, nil, false
+}
+
+// weakPasswordResponse rejects a password that is too easy to guess, with suggestions for
+// a stronger one
+func weakPasswordResponse(c *fiber.Ctx, weak *services.WeakPasswordError) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error":       "Weak Password",
+		"message":     "Password is too easy to guess",
+		"suggestions": weak.Suggestions,
+	})
+}
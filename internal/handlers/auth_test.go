@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-fiber/internal/config"
+	"go-fiber/internal/mocks"
+	"go-fiber/internal/models"
+	"go-fiber/internal/repository/interfaces"
+	"go-fiber/internal/services"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// setupAuthApp creates a fresh auth handler and app with an authenticated test user
+func setupAuthApp() (*fiber.App, *mocks.MockUserRepository, *mocks.MockSessionStore) {
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockSessionStore := new(mocks.MockSessionStore)
+	cfg := config.NewTestConfig()
+	logger := config.NewTestLogger()
+
+	authService := services.NewAuthService(mockUserRepo, mockSessionStore, &cfg.JWT, logger)
+	authService.SetBcryptCost(bcrypt.MinCost)
+	handler := NewAuthHandler(authService, validator.New(), logger)
+
+	authMiddleware := func(c *fiber.Ctx) error {
+		c.Locals("userID", "test-user-id")
+		c.Locals("sessionID", "test-session-id")
+		return c.Next()
+	}
+
+	app := fiber.New()
+	handler.RegisterRoutes(app.Group("/api/v1"), authMiddleware)
+
+	return app, mockUserRepo, mockSessionStore
+}
+
+func TestAuthHandler_DeleteAccount(t *testing.T) {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	user := &models.User{ID: "test-user-id", Username: "testuser", Password: string(hashedPassword)}
+
+	deleteAccount := func(app *fiber.App, req models.DeleteAccountRequest) int {
+		body, _ := json.Marshal(req)
+		httpReq := httptest.NewRequest("DELETE", "/api/v1/auth/me", bytes.NewReader(body))
+		httpReq.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(httpReq)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	t.Run("successful deletion", func(t *testing.T) {
+		// Arrange
+		app, mockUserRepo, mockSessionStore := setupAuthApp()
+		mockUserRepo.On("GetByID", mock.Anything, "test-user-id").Return(user, nil)
+		mockUserRepo.On("DeleteAccount", mock.Anything, "test-user-id", models.UserDeletePolicyCascade).Return(nil)
+		mockSessionStore.On("DeleteUserSessions", mock.Anything, "test-user-id").Return(int64(1), nil)
+
+		// Act
+		status := deleteAccount(app, models.DeleteAccountRequest{Password: "password123"})
+
+		// Assert
+		assert.Equal(t, 204, status)
+		mockUserRepo.AssertExpectations(t)
+		mockSessionStore.AssertExpectations(t)
+	})
+
+	t.Run("active todos block the deletion", func(t *testing.T) {
+		// Arrange
+		app, mockUserRepo, _ := setupAuthApp()
+		mockUserRepo.On("GetByID", mock.Anything, "test-user-id").Return(user, nil)
+		mockUserRepo.On("DeleteAccount", mock.Anything, "test-user-id", mock.Anything).Return(interfaces.ErrUserHasActiveTodos)
+
+		// Act
+		status := deleteAccount(app, models.DeleteAccountRequest{Password: "password123"})
+
+		// Assert
+		assert.Equal(t, 409, status)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		// Arrange
+		app, mockUserRepo, _ := setupAuthApp()
+		mockUserRepo.On("GetByID", mock.Anything, "test-user-id").Return(user, nil)
+
+		// Act
+		status := deleteAccount(app, models.DeleteAccountRequest{Password: "wrongpassword"})
+
+		// Assert
+		assert.Equal(t, 401, status)
+		mockUserRepo.AssertNotCalled(t, "DeleteAccount", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("missing password", func(t *testing.T) {
+		// Arrange
+		app, _, _ := setupAuthApp()
+
+		// Act
+		status := deleteAccount(app, models.DeleteAccountRequest{})
+
+		// Assert
+		assert.Equal(t, 400, status)
+	})
+}
+
+func TestAuthHandler_ChangePassword(t *testing.T) {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	user := &models.User{ID: "test-user-id", Username: "testuser", Password: string(hashedPassword)}
+
+	changePassword := func(app *fiber.App, req models.UpdatePasswordRequest) int {
+		body, _ := json.Marshal(req)
+		httpReq := httptest.NewRequest("PATCH", "/api/v1/auth/password", bytes.NewReader(body))
+		httpReq.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(httpReq)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	t.Run("successful change", func(t *testing.T) {
+		// Arrange
+		app, mockUserRepo, _ := setupAuthApp()
+		mockUserRepo.On("GetByID", mock.Anything, "test-user-id").Return(user, nil)
+		mockUserRepo.On("UpdatePassword", mock.Anything, "test-user-id", mock.AnythingOfType("string")).Return(nil)
+
+		// Act
+		status := changePassword(app, models.UpdatePasswordRequest{CurrentPassword: "password123", NewPassword: "newpassword456"})
+
+		// Assert
+		assert.Equal(t, 200, status)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("wrong current password", func(t *testing.T) {
+		// Arrange
+		app, mockUserRepo, _ := setupAuthApp()
+		mockUserRepo.On("GetByID", mock.Anything, "test-user-id").Return(user, nil)
+
+		// Act
+		status := changePassword(app, models.UpdatePasswordRequest{CurrentPassword: "wrongpassword", NewPassword: "newpassword456"})
+
+		// Assert
+		assert.Equal(t, 401, status)
+		mockUserRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("weak new password", func(t *testing.T) {
+		// Arrange
+		app, mockUserRepo, _ := setupAuthApp()
+
+		// Act
+		status := changePassword(app, models.UpdatePasswordRequest{CurrentPassword: "password123", NewPassword: "short"})
+
+		// Assert
+		assert.Equal(t, 400, status)
+		mockUserRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("new password same as current", func(t *testing.T) {
+		// Arrange
+		app, mockUserRepo, _ := setupAuthApp()
+
+		// Act
+		status := changePassword(app, models.UpdatePasswordRequest{CurrentPassword: "password123", NewPassword: "password123"})
+
+		// Assert
+		assert.Equal(t, 400, status)
+		mockUserRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthHandler_LogoutAll(t *testing.T) {
+	t.Run("returns the number of sessions deleted", func(t *testing.T) {
+		// Arrange
+		app, _, mockSessionStore := setupAuthApp()
+		mockSessionStore.On("DeleteUserSessions", mock.Anything, "test-user-id").Return(int64(2), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("POST", "/api/v1/auth/logout/all", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.LogoutAllResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		assert.Equal(t, int64(2), response.SessionsDeleted)
+		mockSessionStore.AssertExpectations(t)
+	})
+
+	t.Run("session store failure", func(t *testing.T) {
+		// Arrange
+		app, _, mockSessionStore := setupAuthApp()
+		mockSessionStore.On("DeleteUserSessions", mock.Anything, "test-user-id").Return(int64(0), errors.New("redis down"))
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("POST", "/api/v1/auth/logout/all", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 500, resp.StatusCode)
+	})
+}
+
+func TestAuthHandler_Security(t *testing.T) {
+	// Arrange
+	app, _, mockSessionStore := setupAuthApp()
+	now := time.Now()
+	mockSessionStore.On("GetUserSessions", mock.Anything, "test-user-id").Return([]*models.Session{
+		{ID: "test-session-id", UserID: "test-user-id", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour), IsActive: true},
+		{ID: "other-session-id", UserID: "test-user-id", CreatedAt: now, ExpiresAt: now.Add(time.Hour), IsActive: true},
+	}, nil)
+
+	// Act
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/auth/security", nil))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var response models.SecuritySummaryResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Equal(t, 2, response.ActiveSessions)
+	if assert.NotNil(t, response.CurrentSession) {
+		assert.Equal(t, "test-session-id", response.CurrentSession.ID)
+	}
+	if assert.NotNil(t, response.LastLoginAt) {
+		assert.True(t, now.Equal(*response.LastLoginAt))
+	}
+	mockSessionStore.AssertExpectations(t)
+}
+
+func TestAuthHandler_Sessions(t *testing.T) {
+	t.Run("lists the sessions with the current one marked", func(t *testing.T) {
+		// Arrange
+		app, _, mockSessionStore := setupAuthApp()
+		now := time.Now()
+		mockSessionStore.On("GetUserSessions", mock.Anything, "test-user-id").Return([]*models.Session{
+			{ID: "test-session-id", UserID: "test-user-id", CreatedAt: now, ExpiresAt: now.Add(time.Hour), IsActive: true, UserAgent: "curl/8.0", IP: "203.0.113.7"},
+		}, nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/auth/sessions", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.SessionListResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		assert.Equal(t, "test-session-id", response.CurrentSessionID)
+		if assert.Len(t, response.Sessions, 1) {
+			assert.Equal(t, "curl/8.0", response.Sessions[0].UserAgent)
+			assert.Equal(t, "203.0.113.7", response.Sessions[0].IP)
+		}
+	})
+
+	t.Run("revokes an own session", func(t *testing.T) {
+		// Arrange
+		app, _, mockSessionStore := setupAuthApp()
+		mockSessionStore.On("Get", mock.Anything, "session-1").Return(&models.Session{ID: "session-1", UserID: "test-user-id"}, nil)
+		mockSessionStore.On("Delete", mock.Anything, "session-1").Return(nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/api/v1/auth/sessions/session-1", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		mockSessionStore.AssertExpectations(t)
+	})
+
+	t.Run("another user's session is not found", func(t *testing.T) {
+		// Arrange
+		app, _, mockSessionStore := setupAuthApp()
+		mockSessionStore.On("Get", mock.Anything, "session-2").Return(&models.Session{ID: "session-2", UserID: "other-user-id"}, nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/api/v1/auth/sessions/session-2", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+		mockSessionStore.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthHandler_LoginRecordsDevice(t *testing.T) {
+	// Arrange
+	app, mockUserRepo, mockSessionStore := setupAuthApp()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	mockUserRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(&models.User{ID: "test-user-id", Username: "testuser", Email: "test@example.com", Password: string(hashedPassword)}, nil)
+	mockSessionStore.On("Set", mock.Anything, mock.AnythingOfType("string"), mock.MatchedBy(func(session *models.Session) bool {
+		return session.UserAgent == "TodoApp/2.1 (iOS)" && session.IP != ""
+	}), mock.AnythingOfType("time.Duration")).Return(nil)
+
+	httpReq := httptest.NewRequest("POST", "/api/v1/auth/login/email", bytes.NewBufferString(`{"email": "test@example.com", "password": "password123"}`))
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "TodoApp/2.1 (iOS)")
+
+	// Act
+	resp, err := app.Test(httpReq)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	mockSessionStore.AssertExpectations(t)
+}
+
+func TestAuthHandler_LoginLockout(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockAttempts := new(mocks.MockLoginAttemptStore)
+	cfg := config.NewTestConfig()
+	logger := config.NewTestLogger()
+	authService := services.NewAuthService(mockUserRepo, new(mocks.MockSessionStore), &cfg.JWT, logger)
+	authService.SetLockout(mockAttempts, cfg.Auth.LockoutThreshold, cfg.Auth.LockoutWindow, cfg.Auth.LockoutDuration)
+	app := fiber.New()
+	NewAuthHandler(authService, validator.New(), logger).RegisterRoutes(app.Group("/api/v1"), func(c *fiber.Ctx) error { return c.Next() })
+
+	mockUserRepo.On("GetByUsername", mock.Anything, "testuser").Return(&models.User{ID: "test-user-id", Username: "testuser"}, nil)
+	mockAttempts.On("LockedFor", mock.Anything, "user:test-user-id").Return(90*time.Second+time.Millisecond, nil)
+
+	httpReq := httptest.NewRequest("POST", "/api/v1/auth/login", bytes.NewBufferString(`{"username": "testuser", "password": "password123"}`))
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	// Act
+	resp, err := app.Test(httpReq)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 429, resp.StatusCode)
+	assert.Equal(t, "91", resp.Header.Get("Retry-After"))
+	var body map[string]any
+	json.NewDecoder(resp.Body).Decode(&body)
+	assert.Equal(t, float64(91), body["retry_after"])
+	mockAttempts.AssertNotCalled(t, "RecordFailure", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"errors"
+
+	"go-fiber/internal/repository/interfaces"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// repositoryError responds to a failed repository call, using 503 when the store is
+// temporarily unavailable so clients can retry, and 500 otherwise
+func repositoryError(c *fiber.Ctx, err error, message string) error {
+	if errors.Is(err, interfaces.ErrUnavailable) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":   "Service Unavailable",
+			"message": message,
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error":   "Internal Server Error",
+		"message": message,
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"time"
 
+	"go-fiber/internal/utils"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
@@ -70,7 +72,7 @@ func (h *HealthHandler) HealthCheck(c *fiber.Ctx) error {
 	// Check PostgreSQL
 	if h.pgDB != nil {
 		start := time.Now()
-		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 		defer cancel()
 
 		err := h.pgDB.Ping(ctx)
@@ -80,7 +82,7 @@ func (h *HealthHandler) HealthCheck(c *fiber.Ctx) error {
 			response.Services["postgresql"] = ServiceInfo{
 				Status:       "unhealthy",
 				ResponseTime: responseTime.String(),
-				Error:        err.Error(),
+				Error:        utils.ErrorDetail(err),
 			}
 			response.Status = "degraded"
 			h.logger.Error().Err(err).Msg("PostgreSQL health check failed.")
@@ -95,7 +97,7 @@ func (h *HealthHandler) HealthCheck(c *fiber.Ctx) error {
 	// Check MongoDB
 	if h.mongoDB != nil {
 		start := time.Now()
-		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 		defer cancel()
 
 		err := h.mongoDB.Client().Ping(ctx, readpref.Primary())
@@ -105,7 +107,7 @@ func (h *HealthHandler) HealthCheck(c *fiber.Ctx) error {
 			response.Services["mongodb"] = ServiceInfo{
 				Status:       "unhealthy",
 				ResponseTime: responseTime.String(),
-				Error:        err.Error(),
+				Error:        utils.ErrorDetail(err),
 			}
 			response.Status = "degraded"
 			h.logger.Error().Err(err).Msg("MongoDB health check failed.")
@@ -120,7 +122,7 @@ func (h *HealthHandler) HealthCheck(c *fiber.Ctx) error {
 	// Check Redis
 	if h.redis != nil {
 		start := time.Now()
-		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 		defer cancel()
 
 		err := h.redis.Ping(ctx).Err()
@@ -130,7 +132,7 @@ func (h *HealthHandler) HealthCheck(c *fiber.Ctx) error {
 			response.Services["redis"] = ServiceInfo{
 				Status:       "unhealthy",
 				ResponseTime: responseTime.String(),
-				Error:        err.Error(),
+				Error:        utils.ErrorDetail(err),
 			}
 			response.Status = "degraded"
 			h.logger.Error().Err(err).Msg("Redis health check failed.")
@@ -171,7 +173,7 @@ func (h *HealthHandler) ReadinessCheck(c *fiber.Ctx) error {
 	// Check all critical services for readiness
 	if h.pgDB != nil {
 		start := time.Now()
-		ctx, cancel := context.WithTimeout(c.Context(), 3*time.Second)
+		ctx, cancel := context.WithTimeout(c.UserContext(), 3*time.Second)
 		defer cancel()
 
 		err := h.pgDB.Ping(ctx)
@@ -181,7 +183,7 @@ func (h *HealthHandler) ReadinessCheck(c *fiber.Ctx) error {
 			response.Services["postgresql"] = ServiceInfo{
 				Status:       "not_ready",
 				ResponseTime: responseTime.String(),
-				Error:        err.Error(),
+				Error:        utils.ErrorDetail(err),
 			}
 			allHealthy = false
 		} else {
@@ -194,7 +196,7 @@ func (h *HealthHandler) ReadinessCheck(c *fiber.Ctx) error {
 
 	if h.mongoDB != nil {
 		start := time.Now()
-		ctx, cancel := context.WithTimeout(c.Context(), 3*time.Second)
+		ctx, cancel := context.WithTimeout(c.UserContext(), 3*time.Second)
 		defer cancel()
 
 		err := h.mongoDB.Client().Ping(ctx, readpref.Primary())
@@ -204,7 +206,7 @@ func (h *HealthHandler) ReadinessCheck(c *fiber.Ctx) error {
 			response.Services["mongodb"] = ServiceInfo{
 				Status:       "not_ready",
 				ResponseTime: responseTime.String(),
-				Error:        err.Error(),
+				Error:        utils.ErrorDetail(err),
 			}
 			allHealthy = false
 		} else {
@@ -217,7 +219,7 @@ func (h *HealthHandler) ReadinessCheck(c *fiber.Ctx) error {
 
 	if h.redis != nil {
 		start := time.Now()
-		ctx, cancel := context.WithTimeout(c.Context(), 3*time.Second)
+		ctx, cancel := context.WithTimeout(c.UserContext(), 3*time.Second)
 		defer cancel()
 
 		err := h.redis.Ping(ctx).Err()
@@ -227,7 +229,7 @@ func (h *HealthHandler) ReadinessCheck(c *fiber.Ctx) error {
 			response.Services["redis"] = ServiceInfo{
 				Status:       "not_ready",
 				ResponseTime: responseTime.String(),
-				Error:        err.Error(),
+				Error:        utils.ErrorDetail(err),
 			}
 			allHealthy = false
 		} else {
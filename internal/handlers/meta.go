@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"go-fiber/internal/models"
+	"go-fiber/internal/repository/interfaces"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// unknownSchemaVersion is reported when the database schema is not managed by migrations
+const unknownSchemaVersion = "unknown"
+
+// MetaHandler serves information about the running deployment
+type MetaHandler struct {
+	schemaRepo interfaces.SchemaRepository
+	driver     string
+	logger     zerolog.Logger
+}
+
+// NewMetaHandler creates a new meta handler for the given database driver
+func NewMetaHandler(schemaRepo interfaces.SchemaRepository, driver string, logger zerolog.Logger) *MetaHandler {
+	return &MetaHandler{
+		schemaRepo: schemaRepo,
+		driver:     driver,
+		logger:     logger,
+	}
+}
+
+// RegisterRoutes registers meta routes
+func (h *MetaHandler) RegisterRoutes(router fiber.Router) {
+	router.Get("/meta/schema-version", h.GetSchemaVersion)
+	router.Get("/meta/enums", h.GetEnums)
+}
+
+// GetSchemaVersion handles reporting the applied database schema version
+// @Summary Get schema version
+// @Description Get the latest applied database migration, or "unknown" when the schema is not managed by migrations (always the case for MongoDB)
+// @Tags meta
+// @Produce json
+// @Success 200 {object} models.SchemaVersionResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /meta/schema-version [get]
+func (h *MetaHandler) GetSchemaVersion(c *fiber.Ctx) error {
+	version, err := h.schemaRepo.GetSchemaVersion(c.UserContext())
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get schema version.")
+		return repositoryError(c, err, "Failed to get schema version")
+	}
+
+	if version == "" {
+		version = unknownSchemaVersion
+	}
+
+	return c.JSON(models.SchemaVersionResponse{
+		Driver:  h.driver,
+		Version: version,
+	})
+}
+
+// GetEnums handles reporting the configured todo statuses and priorities
+// @Summary Get todo enums
+// @Description Get the statuses and priority levels this deployment accepts, with their defaults and the status that marks a todo as done, so clients need not hardcode them. Priorities are ordered from lowest to highest.
+// @Tags meta
+// @Produce json
+// @Success 200 {object} models.EnumsResponse
+// @Router /meta/enums [get]
+func (h *MetaHandler) GetEnums(c *fiber.Ctx) error {
+	return c.JSON(models.EnumsResponse{
+		Statuses:        models.Statuses(),
+		DefaultStatus:   models.DefaultStatus(),
+		DoneStatus:      models.DoneStatus(),
+		Priorities:      models.Priorities(),
+		DefaultPriority: models.DefaultPriority(),
+	})
+}
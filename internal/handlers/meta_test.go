@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"go-fiber/internal/config"
+	"go-fiber/internal/mocks"
+	"go-fiber/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupMetaApp creates a fresh meta handler and app for each test
+func setupMetaApp(driver string) (*fiber.App, *mocks.MockSchemaRepository) {
+	mockRepo := new(mocks.MockSchemaRepository)
+	handler := NewMetaHandler(mockRepo, driver, config.NewTestLogger())
+
+	app := fiber.New()
+	handler.RegisterRoutes(app)
+
+	return app, mockRepo
+}
+
+// latestMigrationVersion returns the version prefix of the newest goose migration on disk
+func latestMigrationVersion(t *testing.T) string {
+	entries, err := os.ReadDir("../../migrations/postgres")
+	require.NoError(t, err)
+
+	var versions []string
+	for _, entry := range entries {
+		if version, _, ok := strings.Cut(entry.Name(), "_"); ok && strings.HasSuffix(entry.Name(), ".sql") {
+			versions = append(versions, version)
+		}
+	}
+	require.NotEmpty(t, versions)
+
+	sort.Strings(versions)
+	return versions[len(versions)-1]
+}
+
+func TestMetaHandler_GetSchemaVersion(t *testing.T) {
+	t.Run("reports the latest applied migration", func(t *testing.T) {
+		// Arrange
+		app, mockRepo := setupMetaApp("postgres")
+		latest := latestMigrationVersion(t)
+		mockRepo.On("GetSchemaVersion", mock.Anything).Return(latest, nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/meta/schema-version", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var body models.SchemaVersionResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "postgres", body.Driver)
+		assert.Equal(t, latest, body.Version)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("reports unknown when migrations are not managed", func(t *testing.T) {
+		// Arrange
+		app, mockRepo := setupMetaApp("mongodb")
+		mockRepo.On("GetSchemaVersion", mock.Anything).Return("", nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/meta/schema-version", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var body models.SchemaVersionResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "mongodb", body.Driver)
+		assert.Equal(t, "unknown", body.Version)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		// Arrange
+		app, mockRepo := setupMetaApp("postgres")
+		mockRepo.On("GetSchemaVersion", mock.Anything).Return("", errors.New("database error"))
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/meta/schema-version", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 500, resp.StatusCode)
+	})
+}
+
+func TestMetaHandler_GetEnums(t *testing.T) {
+	t.Cleanup(func() {
+		models.SetStatuses([]string{models.TodoStatusPending, models.TodoStatusInProgress, models.TodoStatusCompleted}, models.TodoStatusPending, models.TodoStatusCompleted)
+		models.SetPriorities([]string{models.TodoPriorityLow, models.TodoPriorityMedium, models.TodoPriorityHigh}, models.TodoPriorityMedium)
+	})
+
+	t.Run("reports the configured values", func(t *testing.T) {
+		// Arrange
+		models.SetStatuses([]string{"todo", "blocked", "done"}, "todo", "done")
+		models.SetPriorities([]string{"low", "high", "urgent"}, "high")
+		app, _ := setupMetaApp("postgres")
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/meta/enums", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var body models.EnumsResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, models.EnumsResponse{
+			Statuses:        []string{"todo", "blocked", "done"},
+			DefaultStatus:   "todo",
+			DoneStatus:      "done",
+			Priorities:      []string{"low", "high", "urgent"},
+			DefaultPriority: "high",
+		}, body)
+	})
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go-fiber/internal/metrics"
+	"go-fiber/internal/models"
+	"go-fiber/internal/repository/interfaces"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// prometheusContentType is the content type of the Prometheus text exposition format
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// MetricsHandler exposes aggregate todo metrics in the Prometheus text format
+type MetricsHandler struct {
+	todoRepo interfaces.TodoRepository
+	cacheTTL time.Duration
+	logger   zerolog.Logger
+
+	// poolSaturation reports the database pool's acquired/total ratio, when available
+	poolSaturation func() float64
+
+	// registry holds the request metrics and runtime gauges served on /metrics, when set
+	registry *metrics.Registry
+
+	mu        sync.Mutex
+	cached    string
+	expiresAt time.Time
+}
+
+// NewMetricsHandler creates a new metrics handler
+func NewMetricsHandler(todoRepo interfaces.TodoRepository, cacheTTL time.Duration, logger zerolog.Logger) *MetricsHandler {
+	return &MetricsHandler{
+		todoRepo: todoRepo,
+		cacheTTL: cacheTTL,
+		logger:   logger,
+	}
+}
+
+// SetPoolSaturation exports the database pool's acquired/total ratio as a gauge
+func (h *MetricsHandler) SetPoolSaturation(saturation func() float64) {
+	h.poolSaturation = saturation
+}
+
+// SetRegistry serves the request metrics and runtime gauges of registry on /metrics
+func (h *MetricsHandler) SetRegistry(registry *metrics.Registry) {
+	h.registry = registry
+}
+
+// RegisterRoutes registers metrics routes
+func (h *MetricsHandler) RegisterRoutes(router fiber.Router) {
+	if h.registry != nil {
+		router.Get("/metrics", h.Metrics)
+	}
+	router.Get("/metrics/todos", h.TodoMetrics)
+}
+
+// Metrics handles exposing HTTP request, connection pool and session metrics
+// @Summary Service metrics
+// @Description HTTP request counts and latency by route and status, database and Redis pool connections, and active sessions in the Prometheus text format
+// @Tags metrics
+// @Produce plain
+// @Success 200 {string} string "Prometheus metrics"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /metrics [get]
+func (h *MetricsHandler) Metrics(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, prometheusContentType)
+	if err := h.registry.Write(c.UserContext(), c.Response().BodyWriter()); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to write metrics.")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Internal Server Error",
+			"message": "Failed to write metrics",
+		})
+	}
+	return nil
+}
+
+// TodoMetrics handles exposing todo counts per status and the overdue count
+// @Summary Todo metrics
+// @Description Aggregate todo counts across all users in the Prometheus text format
+// @Tags metrics
+// @Produce plain
+// @Success 200 {string} string "Prometheus metrics"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /metrics/todos [get]
+func (h *MetricsHandler) TodoMetrics(c *fiber.Ctx) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cached == "" || !time.Now().Before(h.expiresAt) {
+		body, err := h.collect(c)
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to collect todo metrics.")
+			return repositoryError(c, err, "Failed to collect todo metrics")
+		}
+
+		h.cached = body
+		h.expiresAt = time.Now().Add(h.cacheTTL)
+	}
+
+	c.Set(fiber.HeaderContentType, prometheusContentType)
+	return c.SendString(h.cached)
+}
+
+// collect runs the aggregation queries and renders them in the exposition format
+func (h *MetricsHandler) collect(c *fiber.Ctx) (string, error) {
+	counts, err := h.todoRepo.CountAllByStatus(c.UserContext())
+	if err != nil {
+		return "", err
+	}
+
+	overdue, err := h.todoRepo.CountAllOverdue(c.UserContext())
+	if err != nil {
+		return "", err
+	}
+
+	// Report every configured status, plus any legacy status still stored
+	statuses := models.Statuses()
+	for status := range counts {
+		if !slices.Contains(statuses, status) {
+			statuses = append(statuses, status)
+		}
+	}
+	slices.Sort(statuses)
+
+	var b strings.Builder
+	b.WriteString("# HELP todo_api_todos Number of todos by status.\n")
+	b.WriteString("# TYPE todo_api_todos gauge\n")
+	for _, status := range statuses {
+		fmt.Fprintf(&b, "todo_api_todos{status=\"%s\"} %d\n", escapeLabelValue(status), counts[status])
+	}
+	b.WriteString("# HELP todo_api_todos_overdue Number of overdue todos that are not done.\n")
+	b.WriteString("# TYPE todo_api_todos_overdue gauge\n")
+	fmt.Fprintf(&b, "todo_api_todos_overdue %d\n", overdue)
+	if h.poolSaturation != nil {
+		b.WriteString("# HELP todo_api_db_pool_saturation Share of database pool connections in use at the latest check.\n")
+		b.WriteString("# TYPE todo_api_db_pool_saturation gauge\n")
+		fmt.Fprintf(&b, "todo_api_db_pool_saturation %g\n", h.poolSaturation())
+	}
+
+	return b.String(), nil
+}
+
+// escapeLabelValue escapes a Prometheus label value
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-fiber/internal/config"
+	"go-fiber/internal/metrics"
+	"go-fiber/internal/mocks"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// setupMetricsApp creates a fresh metrics handler and app for each test
+func setupMetricsApp(cacheTTL time.Duration) (*fiber.App, *mocks.MockTodoRepository) {
+	mockRepo := new(mocks.MockTodoRepository)
+	handler := NewMetricsHandler(mockRepo, cacheTTL, config.NewTestLogger())
+
+	app := fiber.New()
+	handler.RegisterRoutes(app)
+
+	return app, mockRepo
+}
+
+func TestMetricsHandler_TodoMetrics(t *testing.T) {
+	t.Run("exposes per-status and overdue gauges", func(t *testing.T) {
+		// Arrange
+		app, mockRepo := setupMetricsApp(time.Minute)
+		mockRepo.On("CountAllByStatus", mock.Anything).Return(map[string]int64{
+			"pending":   4,
+			"completed": 2,
+		}, nil)
+		mockRepo.On("CountAllOverdue", mock.Anything).Return(int64(1), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/metrics/todos", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", resp.Header.Get("Content-Type"))
+
+		body, _ := io.ReadAll(resp.Body)
+		expected := "# HELP todo_api_todos Number of todos by status.\n" +
+			"# TYPE todo_api_todos gauge\n" +
+			"todo_api_todos{status=\"completed\"} 2\n" +
+			"todo_api_todos{status=\"in_progress\"} 0\n" +
+			"todo_api_todos{status=\"pending\"} 4\n" +
+			"# HELP todo_api_todos_overdue Number of overdue todos that are not done.\n" +
+			"# TYPE todo_api_todos_overdue gauge\n" +
+			"todo_api_todos_overdue 1\n"
+		assert.Equal(t, expected, string(body))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("exposes pool saturation when set", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		handler := NewMetricsHandler(mockRepo, time.Minute, config.NewTestLogger())
+		handler.SetPoolSaturation(func() float64 { return 0.75 })
+		app := fiber.New()
+		handler.RegisterRoutes(app)
+		mockRepo.On("CountAllByStatus", mock.Anything).Return(map[string]int64{}, nil)
+		mockRepo.On("CountAllOverdue", mock.Anything).Return(int64(0), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/metrics/todos", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body), "# TYPE todo_api_db_pool_saturation gauge\ntodo_api_db_pool_saturation 0.75\n")
+	})
+
+	t.Run("serves request metrics when a registry is set", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		registry := metrics.NewRegistry(config.NewTestLogger())
+		registry.ObserveRequest("GET", "/api/v1/todos", 200, 10*time.Millisecond)
+		handler := NewMetricsHandler(mockRepo, time.Minute, config.NewTestLogger())
+		handler.SetRegistry(registry)
+		app := fiber.New()
+		handler.RegisterRoutes(app)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", resp.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body), "todo_api_http_requests_total{method=\"GET\",route=\"/api/v1/todos\",status=\"200\"} 1\n")
+	})
+
+	t.Run("no request metrics without a registry", func(t *testing.T) {
+		// Arrange
+		app, _ := setupMetricsApp(time.Minute)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+
+	t.Run("serves cached metrics within the ttl", func(t *testing.T) {
+		// Arrange
+		app, mockRepo := setupMetricsApp(time.Minute)
+		mockRepo.On("CountAllByStatus", mock.Anything).Return(map[string]int64{}, nil).Once()
+		mockRepo.On("CountAllOverdue", mock.Anything).Return(int64(0), nil).Once()
+
+		// Act
+		first, err1 := app.Test(httptest.NewRequest("GET", "/metrics/todos", nil))
+		second, err2 := app.Test(httptest.NewRequest("GET", "/metrics/todos", nil))
+
+		// Assert
+		assert.NoError(t, err1)
+		assert.NoError(t, err2)
+		assert.Equal(t, 200, first.StatusCode)
+		assert.Equal(t, 200, second.StatusCode)
+		mockRepo.AssertNumberOfCalls(t, "CountAllByStatus", 1)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		// Arrange
+		app, mockRepo := setupMetricsApp(time.Minute)
+		mockRepo.On("CountAllByStatus", mock.Anything).Return(nil, errors.New("database error"))
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/metrics/todos", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 500, resp.StatusCode)
+	})
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"go-fiber/internal/models"
+	"go-fiber/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// noCache makes responses revalidate by default; handlers for rarely changing
+// resources override it with utils.CacheUntilModified
+func noCache(c *fiber.Ctx) error {
+	utils.NoCache(c)
+	return c.Next()
+}
+
+// respondWithTodo writes a todo, or only its ID when the client sent Prefer: return=minimal
+func respondWithTodo(c *fiber.Ctx, status int, todo *models.Todo) error {
+	c.Vary("Prefer")
+
+	if utils.PreferReturn(c) == "minimal" {
+		c.Set("Preference-Applied", "return=minimal")
+		return c.Status(status).JSON(models.IDResponse{ID: todo.ID})
+	}
+
+	return c.Status(status).JSON(todo)
+}
@@ -1,9 +1,23 @@
 package handlers
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
 	"go-fiber/internal/middleware"
 	"go-fiber/internal/models"
 	"go-fiber/internal/repository/interfaces"
+	"go-fiber/internal/services"
+	"go-fiber/internal/utils"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -12,23 +26,100 @@ import (
 
 // TodoHandler handles todo-related HTTP requests
 type TodoHandler struct {
-	todoRepo  interfaces.TodoRepository
-	validator *validator.Validate
-	logger    zerolog.Logger
+	todoRepo    interfaces.TodoRepository
+	todoService *services.TodoService
+	cacheMaxAge time.Duration
+	validator   *validator.Validate
+	logger      zerolog.Logger
+
+	// searchSlots bounds concurrent search queries; nil leaves them unbounded
+	searchSlots chan struct{}
+
+	// endOfDayDueDates treats midnight due dates as all-day
+	endOfDayDueDates bool
+
+	// importMaxItems caps the todos read from one import, inserted importChunkSize at a time
+	importMaxItems  int
+	importChunkSize int
+	importBodyLimit int
+
+	// tagCaseInsensitive makes tag filters ignore case
+	tagCaseInsensitive bool
+
+	// defaultTimezone resolves dates for requests without an X-Timezone header
+	defaultTimezone *time.Location
 }
 
-// NewTodoHandler creates a new todo handler
-func NewTodoHandler(todoRepo interfaces.TodoRepository, validator *validator.Validate, logger zerolog.Logger) *TodoHandler {
+// NewTodoHandler creates a new todo handler. Done todos are served with client caching
+// headers for cacheMaxAge; zero disables caching.
+func NewTodoHandler(todoRepo interfaces.TodoRepository, todoService *services.TodoService, cacheMaxAge time.Duration, validator *validator.Validate, logger zerolog.Logger) *TodoHandler {
 	return &TodoHandler{
-		todoRepo:  todoRepo,
-		validator: validator,
-		logger:    logger,
+		todoRepo:    todoRepo,
+		todoService: todoService,
+		cacheMaxAge: cacheMaxAge,
+		validator:   validator,
+		logger:      logger,
+
+		importMaxItems:  models.DefaultImportMaxItems,
+		importChunkSize: models.DefaultImportChunkSize,
+
+		defaultTimezone: time.UTC,
+	}
+}
+
+// SetSearchConcurrency caps the number of searches that may run at once. Searches
+// beyond the cap are rejected with 429 instead of queueing; zero removes the cap.
+func (h *TodoHandler) SetSearchConcurrency(max int) {
+	if max <= 0 {
+		h.searchSlots = nil
+		return
+	}
+	h.searchSlots = make(chan struct{}, max)
+}
+
+// SetEndOfDayDueDates makes due dates sent at exactly midnight all-day, as if they were
+// sent with allDay, so they are not overdue until the end of that day
+func (h *TodoHandler) SetEndOfDayDueDates(enabled bool) {
+	h.endOfDayDueDates = enabled
+}
+
+// SetImportLimits caps the number of todos read from one import request and sets how many
+// valid todos are inserted together as the request is read
+func (h *TodoHandler) SetImportLimits(maxItems, chunkSize int) {
+	h.importMaxItems = maxItems
+	h.importChunkSize = chunkSize
+}
+
+// SetImportBodyLimit caps the size of an import request body in bytes (0 leaves only the
+// server's limit)
+func (h *TodoHandler) SetImportBodyLimit(limit int) {
+	h.importBodyLimit = limit
+}
+
+// SetTagCaseInsensitive makes tag filters match tags regardless of case instead of exactly
+func (h *TodoHandler) SetTagCaseInsensitive(enabled bool) {
+	h.tagCaseInsensitive = enabled
+}
+
+// SetDefaultTimezone sets the timezone dates are resolved in for requests without an
+// X-Timezone header
+func (h *TodoHandler) SetDefaultTimezone(loc *time.Location) {
+	h.defaultTimezone = loc
+}
+
+// timezone returns the timezone named by the X-Timezone header, or the default one when
+// the header is not sent
+func (h *TodoHandler) timezone(c *fiber.Ctx) (*time.Location, error) {
+	name := c.Get("X-Timezone")
+	if name == "" {
+		return h.defaultTimezone, nil
 	}
+	return utils.LoadTimezone(name)
 }
 
 // RegisterRoutes registers todo routes
 func (h *TodoHandler) RegisterRoutes(router fiber.Router, authMiddleware fiber.Handler) {
-	todos := router.Group("/todos", authMiddleware)
+	todos := router.Group("/todos", authMiddleware, noCache)
 
 	// CRUD operations
 	todos.Post("/", h.CreateTodo)
@@ -36,16 +127,42 @@ func (h *TodoHandler) RegisterRoutes(router fiber.Router, authMiddleware fiber.H
 
 	// Special operations (must be registered before parameterized routes)
 	todos.Get("/overdue", h.GetOverdueTodos)
+	todos.Get("/overdue/worst", h.GetMostOverdueTodo)
+	todos.Get("/upcoming", h.GetUpcomingTodos)
+	todos.Get("/undated", h.GetUndatedTodos)
+	todos.Get("/agenda", h.GetAgenda)
+	todos.Get("/export", h.ExportTodos)
+	todos.Get("/export.csv", h.ExportTodosCSV)
+	todos.Get("/recent", h.GetRecentTodos)
+	todos.Get("/due-distribution", h.GetDueDistribution)
+	todos.Get("/completion-rate", h.GetCompletionRate)
 	todos.Get("/search", h.SearchTodos)
 	todos.Get("/stats", h.GetTodoStats)
+	todos.Get("/stats/summary", h.GetTodoStatsSummary)
+	todos.Get("/trash", h.GetTrashedTodos)
+	todos.Delete("/trash/:id", h.PurgeTodo)
+	todos.Post("/merge", h.MergeTodos)
+	todos.Post("/bulk-reschedule", h.BulkRescheduleTodos)
+	todos.Post("/bulk-priority", h.BulkUpdatePriority)
+	todos.Patch("/bulk", h.BulkPatchTodos)
+	todos.Patch("/bulk/status", h.BulkUpdateStatus)
+	todos.Post("/validate", h.ValidateTodos)
+	todos.Post("/import", middleware.BodyLimit(h.importBodyLimit), h.ImportTodos)
+	todos.Delete("/completed", h.DeleteCompletedTodos)
 
 	// Parameterized routes (must be registered after specific routes)
 	todos.Get("/:id", h.GetTodo)
 	todos.Put("/:id", h.UpdateTodo)
+	todos.Patch("/:id", h.PatchTodo)
 	todos.Delete("/:id", h.DeleteTodo)
+	todos.Post("/:id/restore", h.RestoreTodo)
+	todos.Post("/:id/subtasks", h.AddSubtask)
+	todos.Patch("/:id/subtasks/:index", h.UpdateSubtask)
+	todos.Delete("/:id/subtasks/:index", h.DeleteSubtask)
 
 	// Status operations
 	todos.Patch("/:id/status", h.UpdateTodoStatus)
+	todos.Post("/:id/complete", h.CompleteTodo)
 }
 
 // CreateTodo handles todo creation
@@ -56,7 +173,9 @@ func (h *TodoHandler) RegisterRoutes(router fiber.Router, authMiddleware fiber.H
 // @Produce json
 // @Security BearerAuth
 // @Param request body models.CreateTodoRequest true "Create todo request"
-// @Success 201 {object} models.Todo
+// @Param X-Timezone header string false "IANA timezone used to resolve dueDateText (default SERVER_DEFAULT_TIMEZONE)"
+// @Param Prefer header string false "return=minimal to receive only the new todo's ID"
+// @Success 201 {object} models.Todo "Full todo, or models.IDResponse with Prefer: return=minimal"
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
@@ -85,11 +204,31 @@ func (h *TodoHandler) CreateTodo(c *fiber.Ctx) error {
 	// Validate request
 	if err := h.validator.Struct(&req); err != nil {
 		h.logger.Error().Err(err).Msg("Create todo request validation failed.")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
 			"error":   "Validation Error",
 			"message": "Invalid input data",
-			"details": err.Error(),
-		})
+		}, err))
+	}
+
+	// Resolve a natural-language due date; an explicit dueDate takes precedence
+	dueDate, allDay := h.requestDueDate(&req)
+	if dueDate == nil && req.DueDateText != "" {
+		loc, err := h.timezone(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Bad Request",
+				"message": "Invalid X-Timezone header",
+			})
+		}
+
+		parsed, err := utils.ParseNaturalDate(req.DueDateText, time.Now().In(loc))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Bad Request",
+				"message": "Could not understand dueDateText",
+			})
+		}
+		dueDate = &parsed
 	}
 
 	// Create todo
@@ -98,32 +237,38 @@ func (h *TodoHandler) CreateTodo(c *fiber.Ctx) error {
 		Title:       req.Title,
 		Description: req.Description,
 		Priority:    req.Priority,
-		DueDate:     req.DueDate,
+		DueDate:     dueDate,
+		AllDay:      allDay,
+		Tags:        models.NormalizeTags(req.Tags),
 	}
 
-	createdTodo, err := h.todoRepo.Create(c.Context(), todo)
+	createdTodo, err := h.todoRepo.Create(c.UserContext(), todo)
 	if err != nil {
 		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to create todo.")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Internal Server Error",
-			"message": "Failed to create todo",
-		})
+		return repositoryError(c, err, "Failed to create todo")
 	}
 
 	h.logger.Info().Str("todo_id", createdTodo.ID).Str("user_id", userID).Msg("Todo created successfully.")
-	return c.Status(fiber.StatusCreated).JSON(createdTodo)
+	return respondWithTodo(c, fiber.StatusCreated, createdTodo)
 }
 
 // GetTodos handles getting user's todos with pagination
 // @Summary Get user's todos
-// @Description Get todos for the authenticated user with pagination and filtering
+// @Description Get todos for the authenticated user with pagination and filtering. Filters combine, so every given filter must match.
 // @Tags todos
 // @Produce json
 // @Security BearerAuth
 // @Param limit query int false "Number of todos to return" default(10)
 // @Param offset query int false "Number of todos to skip" default(0)
-// @Param status query string false "Filter by status" Enums(pending, in_progress, completed)
-// @Param priority query string false "Filter by priority" Enums(low, medium, high)
+// @Param status query []string false "Filter by status; repeat it or separate statuses with commas to match any of them" collectionFormat(multi)
+// @Param priority query string false "Filter by priority (configured priority levels)"
+// @Param tag query string false "Filter by tag, trimmed and lowercased like stored tags"
+// @Param dueFrom query string false "Only todos due at or after this RFC 3339 time"
+// @Param dueBefore query string false "Only todos due before this RFC 3339 time"
+// @Param sortBy query string false "Sort field: createdAt (default), dueDate, priority (by configured level) or title"
+// @Param order query string false "Sort order: asc or desc (default desc for createdAt and priority, asc otherwise)"
+// @Param cursor query string false "Page with nextCursor from the previous response instead of offset; send it empty for the first page"
+// @Param snapshot query string false "RFC 3339 time to take cursor pages as of; todos created after it are left out"
 // @Success 200 {object} models.TodoListResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
@@ -153,46 +298,243 @@ func (h *TodoHandler) GetTodos(c *fiber.Ctx) error {
 
 	// Set defaults for unprovided parameters
 	queryParams.SetDefaults()
+	queryParams.Normalize()
 
 	// Validate query parameters
 	if err := h.validator.Struct(&queryParams); err != nil {
 		h.logger.Error().Err(err).Msg("Get todos query parameters validation failed.")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
 			"error":   "Validation Error",
 			"message": "Invalid query parameters",
-			"details": err.Error(),
-		})
+		}, err))
+	}
+
+	// Page by cursor when one is given, even an empty one asking for the first page.
+	// A snapshot implies cursor pagination.
+	if c.Request().URI().QueryArgs().Has("cursor") || queryParams.Snapshot != "" {
+		if queryParams.Offset != 0 || len(queryParams.Status) > 0 || queryParams.Priority != "" || queryParams.Tag != "" ||
+			queryParams.DueFrom != "" || queryParams.DueBefore != "" || queryParams.SortBy != "" || queryParams.Order != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Bad Request",
+				"message": "Cursor pagination cannot be combined with offset, filters or sorting",
+			})
+		}
+
+		// Every page taken as of the same snapshot leaves out todos created while paging
+		var snapshot *time.Time
+		if queryParams.Snapshot != "" {
+			parsed, _ := time.Parse(time.RFC3339, queryParams.Snapshot)
+			snapshot = &parsed
+		}
+
+		todos, nextCursor, err := h.todoRepo.GetByUserIDCursor(c.UserContext(), userID, queryParams.Cursor, snapshot, queryParams.Limit)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidCursor) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   "Bad Request",
+					"message": "Invalid cursor",
+				})
+			}
+			h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get todos by cursor.")
+			return repositoryError(c, err, "Failed to get todos")
+		}
+
+		return c.JSON(models.NewTodoCursorResponse(todos, queryParams.Limit, nextCursor, snapshot))
+	}
+
+	// Every filter given narrows the list, and the total counts the same matches
+	filter := models.TodoFilter{
+		Priority: queryParams.Priority,
+		Tag:      models.NormalizeTag(queryParams.Tag),
+
+		TagCaseInsensitive: h.tagCaseInsensitive,
+	}
+	switch len(queryParams.Status) {
+	case 0:
+	case 1:
+		filter.Status = queryParams.Status[0]
+	default:
+		filter.Statuses = queryParams.Status
+	}
+	if queryParams.DueFrom != "" {
+		dueFrom, _ := time.Parse(time.RFC3339, queryParams.DueFrom)
+		filter.DueFrom = &dueFrom
+	}
+	if queryParams.DueBefore != "" {
+		dueBefore, _ := time.Parse(time.RFC3339, queryParams.DueBefore)
+		filter.DueBefore = &dueBefore
 	}
 
+	sort := models.NewTodoSort(queryParams.SortBy, queryParams.Order)
+
+	// Several statuses alone, in the default order, are what GetByStatuses answers
 	var todos []*models.Todo
 	var total int64
 	var err error
-
-	// Filter by status or priority if provided
-	if queryParams.Status != "" {
-		todos, total, err = h.todoRepo.GetByStatus(c.Context(), userID, queryParams.Status, queryParams.Limit, queryParams.Offset)
-	} else if queryParams.Priority != "" {
-		todos, total, err = h.todoRepo.GetByPriority(c.Context(), userID, queryParams.Priority, queryParams.Limit, queryParams.Offset)
+	if len(filter.Statuses) > 0 && filter.Priority == "" && filter.Tag == "" && filter.DueFrom == nil && filter.DueBefore == nil &&
+		queryParams.SortBy == "" && queryParams.Order == "" {
+		todos, total, err = h.todoRepo.GetByStatuses(c.UserContext(), userID, filter.Statuses, queryParams.Limit, queryParams.Offset)
 	} else {
-		todos, total, err = h.todoRepo.GetByUserID(c.Context(), userID, queryParams.Limit, queryParams.Offset)
+		todos, total, err = h.todoRepo.GetFiltered(c.UserContext(), userID, filter, sort, queryParams.Limit, queryParams.Offset)
 	}
-
 	if err != nil {
 		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get todos.")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Internal Server Error",
-			"message": "Failed to get todos",
+		return repositoryError(c, err, "Failed to get todos")
+	}
+
+	response := models.NewTodoListResponse(todos, total, queryParams.Limit, queryParams.Offset)
+
+	return c.JSON(response)
+}
+
+// exportFlushInterval is the number of exported todos written between flushes
+const exportFlushInterval = 100
+
+// ExportTodos handles exporting all of the user's todos
+// @Summary Export todos
+// @Description Download every todo of the authenticated user as a JSON array attachment. The array is streamed as it is read, without pagination.
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Todo
+// @Failure 401 {object} models.ErrorResponse
+// @Router /todos/export [get]
+func (h *TodoHandler) ExportTodos(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
 		})
 	}
 
-	response := &models.TodoListResponse{
-		Todos:  todos,
-		Total:  total,
-		Limit:  queryParams.Limit,
-		Offset: queryParams.Offset,
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="todos.json"`)
+
+	// The stream writer runs after the handler returns, when the request context is no
+	// longer valid, so the export gets its own context
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		h.writeTodoExport(context.Background(), w, userID)
+	})
+
+	return nil
+}
+
+// writeTodoExport writes the user's todos to w as a JSON array, flushing as it goes. The
+// status has already been sent by then, so a failure leaves the array unterminated.
+func (h *TodoHandler) writeTodoExport(ctx context.Context, w *bufio.Writer, userID string) {
+	exported := 0
+	w.WriteByte('[')
+
+	err := h.todoRepo.StreamByUserID(ctx, userID, func(todo *models.Todo) error {
+		data, err := json.Marshal(todo)
+		if err != nil {
+			return err
+		}
+		if exported > 0 {
+			w.WriteByte(',')
+		}
+		w.Write(data)
+
+		exported++
+		if exported%exportFlushInterval == 0 {
+			return w.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Int("exported", exported).Msg("Failed to export todos.")
+		return
+	}
+
+	w.WriteByte(']')
+	if err := w.Flush(); err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to flush todo export.")
 	}
+}
 
-	return c.JSON(response)
+// todoCSVHeader lists the columns of the CSV export
+var todoCSVHeader = []string{"id", "title", "description", "status", "priority", "dueDate", "createdAt"}
+
+// ExportTodosCSV handles exporting all of the user's todos as CSV
+// @Summary Export todos as CSV
+// @Description Download every todo of the authenticated user as a CSV attachment with a header row. Timestamps are RFC 3339 in UTC and an unset due date is empty. The file is streamed as it is read, without pagination.
+// @Tags todos
+// @Produce text/csv
+// @Security BearerAuth
+// @Success 200 {string} string "CSV file"
+// @Failure 401 {object} models.ErrorResponse
+// @Router /todos/export.csv [get]
+func (h *TodoHandler) ExportTodosCSV(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="todos.csv"`)
+
+	// See ExportTodos for why the export gets its own context
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		h.writeTodoCSVExport(context.Background(), w, userID)
+	})
+
+	return nil
+}
+
+// writeTodoCSVExport writes the user's todos to w as CSV, flushing as it goes. The status
+// has already been sent by then, so a failure leaves the file cut short.
+func (h *TodoHandler) writeTodoCSVExport(ctx context.Context, w *bufio.Writer, userID string) {
+	exported := 0
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Write(todoCSVHeader)
+
+	err := h.todoRepo.StreamByUserID(ctx, userID, func(todo *models.Todo) error {
+		dueDate := ""
+		if todo.DueDate != nil {
+			dueDate = todo.DueDate.UTC().Format(time.RFC3339)
+		}
+
+		if err := csvWriter.Write([]string{
+			todo.ID,
+			todo.Title,
+			todo.Description,
+			todo.Status,
+			todo.Priority,
+			dueDate,
+			todo.CreatedAt.UTC().Format(time.RFC3339),
+		}); err != nil {
+			return err
+		}
+
+		exported++
+		if exported%exportFlushInterval == 0 {
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				return err
+			}
+			return w.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Int("exported", exported).Msg("Failed to export todos as CSV.")
+		return
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to flush CSV todo export.")
+		return
+	}
+	if err := w.Flush(); err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to flush CSV todo export.")
+	}
 }
 
 // GetTodo handles getting a specific todo
@@ -202,7 +544,9 @@ func (h *TodoHandler) GetTodos(c *fiber.Ctx) error {
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Todo ID"
+// @Param withTotal query bool false "Report the user's total todo count in the X-Total-Count header"
 // @Success 200 {object} models.Todo
+// @Header 200 {integer} X-Total-Count "Total number of the user's todos, when withTotal is set"
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
@@ -227,28 +571,34 @@ func (h *TodoHandler) GetTodo(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get todo
-	todo, err := h.todoRepo.GetByID(c.Context(), todoID)
+	// Get todo, scoped to the authenticated user
+	todo, err := h.todoRepo.GetByIDForUser(c.UserContext(), todoID, userID)
 	if err != nil {
-		if err.Error() == "todo not found" {
+		if errors.Is(err, interfaces.ErrTodoNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"error":   "Not Found",
 				"message": "Todo not found",
 			})
 		}
 		h.logger.Error().Err(err).Str("todo_id", todoID).Msg("Failed to get todo.")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Internal Server Error",
-			"message": "Failed to get todo",
-		})
+		return repositoryError(c, err, "Failed to get todo")
 	}
 
-	// Check if todo belongs to the authenticated user
-	if todo.UserID != userID {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error":   "Not Found",
-			"message": "Todo not found",
-		})
+	// Counting costs an extra query, so the total is only reported on request
+	if c.QueryBool("withTotal") {
+		total, err := h.todoRepo.CountByUserID(c.UserContext(), userID)
+		if err != nil {
+			h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count todos.")
+			return repositoryError(c, err, "Failed to count todos")
+		}
+		c.Set("X-Total-Count", strconv.FormatInt(total, 10))
+	}
+
+	// Done todos rarely change, so clients may reuse them until they are updated
+	if todo.Status == models.DoneStatus() && h.cacheMaxAge > 0 {
+		if utils.CacheUntilModified(c, todo.UpdatedAt, h.cacheMaxAge) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
 	}
 
 	return c.JSON(todo)
@@ -263,7 +613,8 @@ func (h *TodoHandler) GetTodo(c *fiber.Ctx) error {
 // @Security BearerAuth
 // @Param id path string true "Todo ID"
 // @Param request body models.UpdateTodoRequest true "Update todo request"
-// @Success 200 {object} models.Todo
+// @Param Prefer header string false "return=minimal to receive only the todo's ID"
+// @Success 200 {object} models.Todo "Full todo, or models.IDResponse with Prefer: return=minimal"
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
@@ -302,66 +653,111 @@ func (h *TodoHandler) UpdateTodo(c *fiber.Ctx) error {
 	// Validate request
 	if err := h.validator.Struct(&req); err != nil {
 		h.logger.Error().Err(err).Msg("Update todo request validation failed.")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
 			"error":   "Validation Error",
 			"message": "Invalid input data",
-			"details": err.Error(),
-		})
+		}, err))
+	}
+
+	// A new midnight due date makes the todo all-day unless the request says otherwise
+	if req.DueDate != nil && req.AllDay == nil && h.isAllDay(*req.DueDate, false) {
+		allDay := true
+		req.AllDay = &allDay
 	}
 
-	// Get existing todo to verify ownership
-	existingTodo, err := h.todoRepo.GetByID(c.Context(), todoID)
+	// Update todo
+	updatedTodo, err := h.todoService.Update(c.UserContext(), userID, todoID, &req)
 	if err != nil {
-		if err.Error() == "todo not found" {
+		if errors.Is(err, interfaces.ErrTodoNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"error":   "Not Found",
 				"message": "Todo not found",
 			})
 		}
-		h.logger.Error().Err(err).Str("todo_id", todoID).Msg("Failed to get todo for update.")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Internal Server Error",
-			"message": "Failed to get todo",
-		})
+		h.logger.Error().Err(err).Str("todo_id", todoID).Msg("Failed to update todo.")
+		return repositoryError(c, err, "Failed to update todo")
 	}
 
-	// Check if todo belongs to the authenticated user
-	if existingTodo.UserID != userID {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error":   "Not Found",
-			"message": "Todo not found",
+	h.logger.Info().Str("todo_id", todoID).Str("user_id", userID).Msg("Todo updated successfully.")
+	return respondWithTodo(c, fiber.StatusOK, updatedTodo)
+}
+
+// PatchTodo handles partial todo updates
+// @Summary Partially update a todo
+// @Description Change only the fields present in the body. Unlike PUT, an empty description or a null dueDate clears the field.
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Todo ID"
+// @Param request body models.PatchTodoRequest true "Patch todo request"
+// @Param Prefer header string false "return=minimal to receive only the todo's ID"
+// @Success 200 {object} models.Todo "Full todo, or models.IDResponse with Prefer: return=minimal"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/{id} [patch]
+func (h *TodoHandler) PatchTodo(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
 		})
 	}
 
-	// Update todo fields
-	if req.Title != "" {
-		existingTodo.Title = req.Title
-	}
-	if req.Description != "" {
-		existingTodo.Description = req.Description
+	// Get todo ID from params
+	todoID := c.Params("id")
+	if todoID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Todo ID is required",
+		})
 	}
-	if req.Status != "" {
-		existingTodo.Status = req.Status
+
+	var req models.PatchTodoRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse patch todo request.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid request body",
+		})
 	}
-	if req.Priority != "" {
-		existingTodo.Priority = req.Priority
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Patch todo request validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid input data",
+		}, err))
 	}
-	if req.DueDate != nil {
-		existingTodo.DueDate = req.DueDate
+
+	// A new midnight due date makes the todo all-day unless the request says otherwise
+	if req.DueDate.Value != nil && req.AllDay == nil && h.isAllDay(*req.DueDate.Value, false) {
+		allDay := true
+		req.AllDay = &allDay
 	}
 
-	// Update todo
-	updatedTodo, err := h.todoRepo.Update(c.Context(), existingTodo)
+	// Patch todo
+	updatedTodo, err := h.todoService.Patch(c.UserContext(), userID, todoID, &req)
 	if err != nil {
-		h.logger.Error().Err(err).Str("todo_id", todoID).Msg("Failed to update todo.")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Internal Server Error",
-			"message": "Failed to update todo",
-		})
+		if errors.Is(err, interfaces.ErrTodoNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":   "Not Found",
+				"message": "Todo not found",
+			})
+		}
+		h.logger.Error().Err(err).Str("todo_id", todoID).Msg("Failed to patch todo.")
+		return repositoryError(c, err, "Failed to update todo")
 	}
 
-	h.logger.Info().Str("todo_id", todoID).Str("user_id", userID).Msg("Todo updated successfully.")
-	return c.JSON(updatedTodo)
+	h.logger.Info().Str("todo_id", todoID).Str("user_id", userID).Msg("Todo patched successfully.")
+	return respondWithTodo(c, fiber.StatusOK, updatedTodo)
 }
 
 // DeleteTodo handles todo deletion
@@ -395,59 +791,36 @@ func (h *TodoHandler) DeleteTodo(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get existing todo to verify ownership
-	existingTodo, err := h.todoRepo.GetByID(c.Context(), todoID)
-	if err != nil {
-		if err.Error() == "todo not found" {
+	// Delete todo; todos of other users are not found
+	if err := h.todoRepo.Delete(c.UserContext(), userID, todoID); err != nil {
+		if errors.Is(err, interfaces.ErrTodoNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"error":   "Not Found",
 				"message": "Todo not found",
 			})
 		}
-		h.logger.Error().Err(err).Str("todo_id", todoID).Msg("Failed to get todo for deletion.")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Internal Server Error",
-			"message": "Failed to get todo",
-		})
-	}
-
-	// Check if todo belongs to the authenticated user
-	if existingTodo.UserID != userID {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error":   "Not Found",
-			"message": "Todo not found",
-		})
-	}
-
-	// Delete todo
-	if err := h.todoRepo.Delete(c.Context(), todoID); err != nil {
 		h.logger.Error().Err(err).Str("todo_id", todoID).Msg("Failed to delete todo.")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Internal Server Error",
-			"message": "Failed to delete todo",
-		})
+		return repositoryError(c, err, "Failed to delete todo")
 	}
 
 	h.logger.Info().Str("todo_id", todoID).Str("user_id", userID).Msg("Todo deleted successfully.")
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
-// UpdateTodoStatus handles todo status updates
-// @Summary Update todo status
-// @Description Update the status of a specific todo
+// RestoreTodo handles restoring a soft-deleted todo
+// @Summary Restore a deleted todo
+// @Description Undo the deletion of a todo owned by the authenticated user so it shows up in listings again
 // @Tags todos
-// @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Todo ID"
-// @Param request body models.UpdateTodoStatusRequest true "Update status request"
-// @Success 200 {object} models.MessageResponse
+// @Success 200 {object} models.Todo
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
-// @Router /todos/{id}/status [patch]
-func (h *TodoHandler) UpdateTodoStatus(c *fiber.Ctx) error {
+// @Router /todos/{id}/restore [post]
+func (h *TodoHandler) RestoreTodo(c *fiber.Ctx) error {
 	// Get user ID from context
 	userID := middleware.GetUserID(c)
 	if userID == "" {
@@ -466,81 +839,41 @@ func (h *TodoHandler) UpdateTodoStatus(c *fiber.Ctx) error {
 		})
 	}
 
-	var req models.UpdateTodoStatusRequest
-
-	// Parse request body
-	if err := c.BodyParser(&req); err != nil {
-		h.logger.Error().Err(err).Msg("Failed to parse update status request.")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   "Bad Request",
-			"message": "Invalid request body",
-		})
-	}
-
-	// Validate request
-	if err := h.validator.Struct(&req); err != nil {
-		h.logger.Error().Err(err).Msg("Update status request validation failed.")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   "Validation Error",
-			"message": "Invalid input data",
-			"details": err.Error(),
-		})
-	}
-
-	// Get existing todo to verify ownership
-	existingTodo, err := h.todoRepo.GetByID(c.Context(), todoID)
-	if err != nil {
-		if err.Error() == "todo not found" {
+	// Restore todo; todos outside the user's trash are not found
+	if err := h.todoRepo.Restore(c.UserContext(), userID, todoID); err != nil {
+		if errors.Is(err, interfaces.ErrTodoNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"error":   "Not Found",
-				"message": "Todo not found",
+				"message": "Deleted todo not found",
 			})
 		}
-		h.logger.Error().Err(err).Str("todo_id", todoID).Msg("Failed to get todo for status update.")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Internal Server Error",
-			"message": "Failed to get todo",
-		})
-	}
-
-	// Check if todo belongs to the authenticated user
-	if existingTodo.UserID != userID {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error":   "Not Found",
-			"message": "Todo not found",
-		})
+		h.logger.Error().Err(err).Str("todo_id", todoID).Msg("Failed to restore todo.")
+		return repositoryError(c, err, "Failed to restore todo")
 	}
 
-	// Update status
-	if err := h.todoRepo.UpdateStatus(c.Context(), todoID, req.Status); err != nil {
-		h.logger.Error().Err(err).Str("todo_id", todoID).Msg("Failed to update todo status.")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Internal Server Error",
-			"message": "Failed to update todo status",
-		})
+	restoredTodo, err := h.todoRepo.GetByIDForUser(c.UserContext(), todoID, userID)
+	if err != nil {
+		h.logger.Error().Err(err).Str("todo_id", todoID).Msg("Failed to get restored todo.")
+		return repositoryError(c, err, "Failed to get todo")
 	}
 
-	h.logger.Info().Str("todo_id", todoID).Str("status", req.Status).Str("user_id", userID).Msg("Todo status updated successfully.")
-	return c.JSON(fiber.Map{
-		"message": "Todo status updated successfully",
-		"status":  req.Status,
-	})
+	h.logger.Info().Str("todo_id", todoID).Str("user_id", userID).Msg("Todo restored successfully.")
+	return c.JSON(restoredTodo)
 }
 
-// GetOverdueTodos handles getting overdue todos
-// @Summary Get overdue todos
-// @Description Get overdue todos for the authenticated user
+// PurgeTodo handles permanently deleting a todo from the trash
+// @Summary Permanently delete a trashed todo
+// @Description Permanently remove a soft-deleted todo owned by the authenticated user. It can no longer be restored.
 // @Tags todos
-// @Produce json
 // @Security BearerAuth
-// @Param limit query int false "Number of todos to return" default(10)
-// @Param offset query int false "Number of todos to skip" default(0)
-// @Success 200 {object} models.TodoListResponse
+// @Param id path string true "Todo ID"
+// @Success 204 "No Content"
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
-// @Router /todos/overdue [get]
-func (h *TodoHandler) GetOverdueTodos(c *fiber.Ctx) error {
+// @Router /todos/trash/{id} [delete]
+func (h *TodoHandler) PurgeTodo(c *fiber.Ctx) error {
 	// Get user ID from context
 	userID := middleware.GetUserID(c)
 	if userID == "" {
@@ -550,13 +883,1236 @@ func (h *TodoHandler) GetOverdueTodos(c *fiber.Ctx) error {
 		})
 	}
 
-	// Parse and validate query parameters
-	var queryParams models.PaginationQueryParams
-
-	// Parse query parameters using Fiber's QueryParser
-	if err := c.QueryParser(&queryParams); err != nil {
-		h.logger.Error().Err(err).Msg("Failed to parse query parameters.")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+	// Get todo ID from params
+	todoID := c.Params("id")
+	if todoID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Todo ID is required",
+		})
+	}
+
+	// The delete is scoped to the owner's trash, so other users' todos are not found
+	if err := h.todoRepo.HardDelete(c.UserContext(), userID, todoID); err != nil {
+		if errors.Is(err, interfaces.ErrTodoNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":   "Not Found",
+				"message": "Deleted todo not found",
+			})
+		}
+		h.logger.Error().Err(err).Str("todo_id", todoID).Msg("Failed to permanently delete todo.")
+		return repositoryError(c, err, "Failed to delete todo")
+	}
+
+	h.logger.Info().Str("todo_id", todoID).Str("user_id", userID).Msg("Todo permanently deleted.")
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// AddSubtask handles appending a subtask to a todo
+// @Summary Add a subtask
+// @Description Append a checklist item to a todo owned by the authenticated user
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Todo ID"
+// @Param request body models.CreateSubtaskRequest true "Create subtask request"
+// @Success 201 {object} models.Todo
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/{id}/subtasks [post]
+func (h *TodoHandler) AddSubtask(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	var req models.CreateSubtaskRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse create subtask request.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid input data",
+		}, err))
+	}
+
+	todo, err := h.todoService.AddSubtask(c.UserContext(), userID, c.Params("id"), req.Title)
+	if err != nil {
+		return h.subtaskError(c, err, "Failed to add subtask")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(todo)
+}
+
+// UpdateSubtask handles updating a subtask
+// @Summary Update a subtask
+// @Description Rename a checklist item or mark it done or not done. Subtasks are addressed by their zero-based position.
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Todo ID"
+// @Param index path int true "Subtask index"
+// @Param request body models.UpdateSubtaskRequest true "Update subtask request"
+// @Success 200 {object} models.Todo
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/{id}/subtasks/{index} [patch]
+func (h *TodoHandler) UpdateSubtask(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	index, err := strconv.Atoi(c.Params("index"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Subtask index must be a number",
+		})
+	}
+
+	var req models.UpdateSubtaskRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse update subtask request.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid input data",
+		}, err))
+	}
+
+	todo, err := h.todoService.UpdateSubtask(c.UserContext(), userID, c.Params("id"), index, &req)
+	if err != nil {
+		return h.subtaskError(c, err, "Failed to update subtask")
+	}
+
+	return c.JSON(todo)
+}
+
+// DeleteSubtask handles removing a subtask
+// @Summary Delete a subtask
+// @Description Remove a checklist item from a todo; the subtasks after it move up one position
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Todo ID"
+// @Param index path int true "Subtask index"
+// @Success 200 {object} models.Todo
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/{id}/subtasks/{index} [delete]
+func (h *TodoHandler) DeleteSubtask(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	index, err := strconv.Atoi(c.Params("index"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Subtask index must be a number",
+		})
+	}
+
+	todo, err := h.todoService.DeleteSubtask(c.UserContext(), userID, c.Params("id"), index)
+	if err != nil {
+		return h.subtaskError(c, err, "Failed to delete subtask")
+	}
+
+	return c.JSON(todo)
+}
+
+// subtaskError maps a subtask operation failure to a response
+func (h *TodoHandler) subtaskError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, interfaces.ErrTodoNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   "Not Found",
+			"message": "Todo not found",
+		})
+	case errors.Is(err, services.ErrSubtaskNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   "Not Found",
+			"message": "Subtask not found",
+		})
+	case errors.Is(err, services.ErrTooManySubtasks):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": err.Error(),
+		})
+	}
+
+	h.logger.Error().Err(err).Str("todo_id", c.Params("id")).Msg(message + ".")
+	return repositoryError(c, err, message)
+}
+
+// UpdateTodoStatus handles todo status updates
+// @Summary Update todo status
+// @Description Update the status of a specific todo, given in the request body or, for quick actions without a body, in the "to" query parameter
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Todo ID"
+// @Param to query string false "New status, used when the request has no body"
+// @Param request body models.UpdateTodoStatusRequest false "Update status request"
+// @Success 200 {object} models.TodoStatusResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/{id}/status [patch]
+func (h *TodoHandler) UpdateTodoStatus(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	// Get todo ID from params
+	todoID := c.Params("id")
+	if todoID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Todo ID is required",
+		})
+	}
+
+	var req models.UpdateTodoStatusRequest
+
+	// Quick actions may send the status as ?to= without a body
+	if len(c.Body()) == 0 {
+		req.Status = c.Query("to")
+		if req.Status == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Bad Request",
+				"message": "Status is required in the request body or the to query parameter",
+			})
+		}
+	} else if err := c.BodyParser(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse update status request.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Update status request validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid input data",
+		}, err))
+	}
+
+	// Update status; todos of other users are not found
+	if err := h.todoRepo.UpdateStatus(c.UserContext(), userID, todoID, req.Status); err != nil {
+		if errors.Is(err, interfaces.ErrTodoNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":   "Not Found",
+				"message": "Todo not found",
+			})
+		}
+		h.logger.Error().Err(err).Str("todo_id", todoID).Msg("Failed to update todo status.")
+		return repositoryError(c, err, "Failed to update todo status")
+	}
+
+	h.logger.Info().Str("todo_id", todoID).Str("status", req.Status).Str("user_id", userID).Msg("Todo status updated successfully.")
+	return c.JSON(models.TodoStatusResponse{
+		Message: "Todo status updated successfully",
+		Status:  req.Status,
+	})
+}
+
+// CompleteTodo handles marking a todo as done
+// @Summary Complete a todo
+// @Description Mark a specific todo as done
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Todo ID"
+// @Success 200 {object} models.TodoStatusResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/{id}/complete [post]
+func (h *TodoHandler) CompleteTodo(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	// Get todo ID from params
+	todoID := c.Params("id")
+	if todoID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Todo ID is required",
+		})
+	}
+
+	// Complete todo; todos of other users are not found
+	if err := h.todoRepo.MarkCompleted(c.UserContext(), userID, todoID); err != nil {
+		if errors.Is(err, interfaces.ErrTodoNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":   "Not Found",
+				"message": "Todo not found",
+			})
+		}
+		h.logger.Error().Err(err).Str("todo_id", todoID).Msg("Failed to complete todo.")
+		return repositoryError(c, err, "Failed to complete todo")
+	}
+
+	h.logger.Info().Str("todo_id", todoID).Str("user_id", userID).Msg("Todo completed successfully.")
+	return c.JSON(models.TodoStatusResponse{
+		Message: "Todo completed successfully",
+		Status:  models.DoneStatus(),
+	})
+}
+
+// DeleteCompletedTodos handles moving all of the user's completed todos to the trash
+// @Summary Delete completed todos
+// @Description Move every completed todo of the authenticated user to the trash and report how many were deleted
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.DeleteCompletedResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/completed [delete]
+func (h *TodoHandler) DeleteCompletedTodos(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	deleted, err := h.todoRepo.DeleteCompleted(c.UserContext(), userID)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to delete completed todos.")
+		return repositoryError(c, err, "Failed to delete completed todos")
+	}
+
+	h.logger.Info().Str("user_id", userID).Int64("deleted", deleted).Msg("Completed todos deleted successfully.")
+	return c.JSON(models.DeleteCompletedResponse{
+		Message: "Completed todos deleted successfully",
+		Deleted: deleted,
+	})
+}
+
+// MergeTodos handles merging a duplicate todo into another
+// @Summary Merge todos
+// @Description Append the source todo's description to the target, keep the due date chosen by the configured strategy, and move the source to the trash
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.MergeTodosRequest true "Merge todos request"
+// @Success 200 {object} models.Todo
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/merge [post]
+func (h *TodoHandler) MergeTodos(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	var req models.MergeTodosRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse merge todos request.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Merge todos request validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid input data",
+		}, err))
+	}
+
+	// Merge todos
+	merged, err := h.todoService.Merge(c.UserContext(), userID, req.SourceID, req.TargetID)
+	if err != nil {
+		switch {
+		case errors.Is(err, interfaces.ErrTodoNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":   "Not Found",
+				"message": "Todo not found",
+			})
+		case errors.Is(err, services.ErrMergeIntoItself):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Bad Request",
+				"message": "Source and target must be different todos",
+			})
+		}
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to merge todos.")
+		return repositoryError(c, err, "Failed to merge todos")
+	}
+
+	return c.JSON(merged)
+}
+
+// BulkRescheduleTodos handles moving the due dates of several todos at once
+// @Summary Bulk reschedule todos
+// @Description Shift the due dates of up to 100 owned todos by a duration such as "48h" or "-30m" (todos without a due date are shifted from now), or set them all to an absolute due date. Todos that are not owned by the user are skipped.
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.BulkRescheduleRequest true "Bulk reschedule request"
+// @Success 200 {object} models.BulkUpdateResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/bulk-reschedule [post]
+func (h *TodoHandler) BulkRescheduleTodos(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	var req models.BulkRescheduleRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse bulk reschedule request.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Bulk reschedule request validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid input data",
+		}, err))
+	}
+
+	// Parse the shift when no absolute due date was given
+	var shift time.Duration
+	if req.DueDate == nil {
+		var err error
+		shift, err = time.ParseDuration(req.Shift)
+		if err != nil || shift == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Validation Error",
+				"message": "Shift must be a non-zero duration such as 48h or -30m",
+			})
+		}
+	}
+
+	// Reschedule todos
+	updated, err := h.todoRepo.BulkReschedule(c.UserContext(), userID, req.IDs, req.DueDate, shift)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to bulk reschedule todos.")
+		return repositoryError(c, err, "Failed to reschedule todos")
+	}
+
+	return c.JSON(models.BulkUpdateResponse{
+		Message: "Todos rescheduled successfully",
+		Updated: updated,
+	})
+}
+
+// BulkUpdateStatus handles setting the status of several todos at once
+// @Summary Bulk update todo status
+// @Description Set the status of up to 100 owned todos. Todos that are not owned by the user are skipped, and the response reports how many were actually updated.
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.BulkStatusRequest true "Bulk status request"
+// @Success 200 {object} models.BulkUpdateResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/bulk/status [patch]
+func (h *TodoHandler) BulkUpdateStatus(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	var req models.BulkStatusRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse bulk status request.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Bulk status request validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid input data",
+		}, err))
+	}
+
+	// Update only the todos owned by the user
+	updated, err := h.todoRepo.BulkUpdateStatusForUser(c.UserContext(), userID, req.IDs, req.Status)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to bulk update todo status.")
+		return repositoryError(c, err, "Failed to update todo status")
+	}
+
+	return c.JSON(models.BulkUpdateResponse{
+		Message: "Todo statuses updated successfully",
+		Updated: updated,
+	})
+}
+
+// BulkUpdatePriority handles setting the priority of every todo matching a filter
+// @Summary Bulk update todo priority
+// @Description Set the priority of all owned todos matching a status, tag and due date range filter. With dryRun nothing is changed and only the matching todos are counted.
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.BulkPriorityRequest true "Bulk priority request"
+// @Success 200 {object} models.BulkPriorityResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/bulk-priority [post]
+func (h *TodoHandler) BulkUpdatePriority(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	var req models.BulkPriorityRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse bulk priority request.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Bulk priority request validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid input data",
+		}, err))
+	}
+
+	if req.Filter.DueFrom != nil && req.Filter.DueBefore != nil && !req.Filter.DueFrom.Before(*req.Filter.DueBefore) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation Error",
+			"message": "dueFrom must be before dueBefore",
+		})
+	}
+	req.Filter.Tag = models.NormalizeTag(req.Filter.Tag)
+	req.Filter.TagCaseInsensitive = h.tagCaseInsensitive
+
+	// Update or, on a dry run, count matching todos
+	count, err := h.todoRepo.BulkUpdatePriority(c.UserContext(), userID, req.Filter, req.Priority, req.DryRun)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to bulk update todo priority.")
+		return repositoryError(c, err, "Failed to update todo priorities")
+	}
+
+	if req.DryRun {
+		return c.JSON(models.BulkPriorityResponse{
+			Message: "Dry run, no todos were changed",
+			Matched: count,
+			DryRun:  true,
+		})
+	}
+
+	h.logger.Info().Str("user_id", userID).Str("priority", req.Priority).Int64("updated_count", count).Msg("Todo priorities updated in bulk.")
+	return c.JSON(models.BulkPriorityResponse{
+		Message: "Todo priorities updated successfully",
+		Matched: count,
+		Updated: count,
+	})
+}
+
+// BulkPatchTodos handles applying the same field changes to several todos at once
+// @Summary Bulk patch todos
+// @Description Apply the same status, priority, tags and due date changes to up to 100 owned todos. Only the fields present in the patch are changed; a null due date clears it and an empty tags array removes all tags. Todos that are not owned by the user are skipped. With dryRun nothing is changed and only the matching todos are counted.
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.BulkPatchRequest true "Bulk patch request"
+// @Success 200 {object} models.BulkPatchResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/bulk [patch]
+func (h *TodoHandler) BulkPatchTodos(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	var req models.BulkPatchRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse bulk patch request.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate request
+	if err := h.validator.Struct(&req); err != nil {
+		h.logger.Error().Err(err).Msg("Bulk patch request validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid input data",
+		}, err))
+	}
+
+	if req.Patch.Empty() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Patch must change at least one of status, priority, tags or dueDate",
+		})
+	}
+	if req.Patch.Tags != nil {
+		req.Patch.Tags = models.NormalizeTags(req.Patch.Tags)
+	}
+
+	// Update or, on a dry run, count matching todos
+	count, err := h.todoRepo.BulkPatch(c.UserContext(), userID, req.IDs, req.Patch.ToPatchRequest(), req.DryRun)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to bulk patch todos.")
+		return repositoryError(c, err, "Failed to update todos")
+	}
+
+	if req.DryRun {
+		return c.JSON(models.BulkPatchResponse{
+			Message: "Dry run, no todos were changed",
+			Matched: count,
+			DryRun:  true,
+		})
+	}
+
+	h.logger.Info().Str("user_id", userID).Int64("updated_count", count).Msg("Todos patched in bulk.")
+	return c.JSON(models.BulkPatchResponse{
+		Message: "Todos updated successfully",
+		Matched: count,
+		Updated: count,
+	})
+}
+
+// ValidateTodos handles checking a batch of todos without creating them
+// @Summary Validate todos
+// @Description Validate up to 100 create todo requests and report per-item field errors, without persisting anything
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body []models.CreateTodoRequest true "Create todo requests"
+// @Success 200 {object} models.ValidateTodosResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /todos/validate [post]
+func (h *TodoHandler) ValidateTodos(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	var reqs []models.CreateTodoRequest
+
+	// Parse request body
+	if err := c.BodyParser(&reqs); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse validate todos request.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Request body must be an array of todos",
+		})
+	}
+
+	if len(reqs) == 0 || len(reqs) > models.MaxValidateTodos {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Between 1 and 100 todos can be validated at once",
+		})
+	}
+
+	// Validate each todo as CreateTodo would
+	response := models.ValidateTodosResponse{
+		Results: make([]models.TodoValidationResult, len(reqs)),
+	}
+	for i := range reqs {
+		fieldErrors := h.createRequestErrors(&reqs[i])
+
+		response.Results[i] = models.TodoValidationResult{
+			Index:  i,
+			Valid:  len(fieldErrors) == 0,
+			Errors: fieldErrors,
+		}
+		if response.Results[i].Valid {
+			response.Valid++
+		} else {
+			response.Invalid++
+		}
+	}
+
+	return c.JSON(response)
+}
+
+// ImportTodos handles creating a batch of todos
+// @Summary Import todos
+// @Description Create todos from an array of create todo requests, read one item at a time so large arrays are never held in memory. Each item is validated as on create and invalid ones are reported with their field errors. Valid todos are inserted in chunks of TODO_IMPORT_CHUNK_SIZE, each all or none; todos of chunks already inserted stay created if a later chunk or the rest of the body fails. Items past TODO_IMPORT_MAX_ITEMS are not read.
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body []models.CreateTodoRequest true "Create todo requests"
+// @Param X-Timezone header string false "IANA timezone used to resolve dueDateText (default SERVER_DEFAULT_TIMEZONE)"
+// @Success 201 {object} models.ImportTodosResponse "Some todos were created"
+// @Success 200 {object} models.ImportTodosResponse "No item was valid, so nothing was created"
+// @Failure 400 {object} models.ErrorResponse "Invalid body; an array past the cap instead gets an ImportTodosResponse with truncated set, and the items within the cap were imported"
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/import [post]
+func (h *TodoHandler) ImportTodos(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	loc, err := h.timezone(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid X-Timezone header",
+		})
+	}
+	now := time.Now().In(loc)
+
+	invalidBody := func(err error) error {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to parse import todos request.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Request body must be an array of todos",
+		})
+	}
+
+	// Decode the array one item at a time, straight from the connection when the server
+	// streams request bodies
+	var body io.Reader = c.Context().RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+	decoder := json.NewDecoder(body)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return invalidBody(err)
+	}
+
+	response := models.ImportTodosResponse{
+		Results: []models.TodoImportResult{},
+	}
+	chunk := make([]*models.Todo, 0, h.importChunkSize)
+	indexes := make([]int, 0, h.importChunkSize)
+
+	// insertChunk creates the valid todos read since the last chunk together
+	insertChunk := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+
+		created, err := h.todoRepo.CreateBatch(c.UserContext(), chunk)
+		if err != nil {
+			h.logger.Error().Err(err).Str("user_id", userID).Int("count", len(chunk)).Int("created", response.Created).Msg("Failed to import todos.")
+			return err
+		}
+
+		for i, todo := range created {
+			response.Results[indexes[i]].Created = true
+			response.Results[indexes[i]].ID = todo.ID
+		}
+		response.Created += len(created)
+
+		chunk = make([]*models.Todo, 0, h.importChunkSize)
+		indexes = indexes[:0]
+		return nil
+	}
+
+	for decoder.More() {
+		if len(response.Results) == h.importMaxItems {
+			response.Truncated = true
+			break
+		}
+
+		var req models.CreateTodoRequest
+		if err := decoder.Decode(&req); err != nil {
+			return invalidBody(err)
+		}
+
+		// Validate each todo as CreateTodo would
+		index := len(response.Results)
+		response.Results = append(response.Results, models.TodoImportResult{Index: index})
+		todo, fieldErrors := h.importedTodo(&req, userID, now)
+		if len(fieldErrors) > 0 {
+			response.Results[index].Errors = fieldErrors
+			response.Invalid++
+			continue
+		}
+
+		chunk = append(chunk, todo)
+		indexes = append(indexes, index)
+		if len(chunk) == h.importChunkSize {
+			if err := insertChunk(); err != nil {
+				return repositoryError(c, err, "Failed to import todos")
+			}
+		}
+	}
+
+	if !response.Truncated {
+		if _, err := decoder.Token(); err != nil {
+			return invalidBody(err)
+		}
+		if len(response.Results) == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Bad Request",
+				"message": "At least one todo must be imported",
+			})
+		}
+	}
+
+	if err := insertChunk(); err != nil {
+		return repositoryError(c, err, "Failed to import todos")
+	}
+
+	h.logger.Info().Str("user_id", userID).Int("created", response.Created).Int("invalid", response.Invalid).Bool("truncated", response.Truncated).Msg("Todos imported.")
+
+	switch {
+	case response.Truncated:
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	case response.Created > 0:
+		return c.Status(fiber.StatusCreated).JSON(response)
+	default:
+		return c.JSON(response)
+	}
+}
+
+// importedTodo builds the todo for one imported create request, or returns its field errors
+func (h *TodoHandler) importedTodo(req *models.CreateTodoRequest, userID string, now time.Time) (*models.Todo, []models.FieldError) {
+	if fieldErrors := h.createRequestErrors(req); len(fieldErrors) > 0 {
+		return nil, fieldErrors
+	}
+
+	dueDate, allDay := h.requestDueDate(req)
+	if dueDate == nil && req.DueDateText != "" {
+		// Already checked by createRequestErrors, so this only resolves it in now's zone
+		parsed, _ := utils.ParseNaturalDate(req.DueDateText, now)
+		dueDate = &parsed
+	}
+
+	return &models.Todo{
+		UserID:      userID,
+		Title:       req.Title,
+		Description: req.Description,
+		Priority:    req.Priority,
+		DueDate:     dueDate,
+		AllDay:      allDay,
+		Tags:        models.NormalizeTags(req.Tags),
+	}, nil
+}
+
+// GetOverdueTodos handles getting overdue todos
+// @Summary Get overdue todos
+// @Description Get overdue todos for the authenticated user. All-day todos are overdue once their day has ended in the X-Timezone timezone.
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Number of todos to return" default(10)
+// @Param offset query int false "Number of todos to skip" default(0)
+// @Param X-Timezone header string false "IANA timezone used to resolve day boundaries (default SERVER_DEFAULT_TIMEZONE)"
+// @Success 200 {object} models.TodoListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/overdue [get]
+func (h *TodoHandler) GetOverdueTodos(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	// Parse and validate query parameters
+	var queryParams models.PaginationQueryParams
+
+	// Parse query parameters using Fiber's QueryParser
+	if err := c.QueryParser(&queryParams); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse query parameters.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid query parameters format",
+		})
+	}
+
+	// Set defaults for unprovided parameters
+	queryParams.SetDefaults()
+
+	// Validate query parameters
+	if err := h.validator.Struct(&queryParams); err != nil {
+		h.logger.Error().Err(err).Msg("Get overdue todos query parameters validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid query parameters",
+		}, err))
+	}
+
+	loc, err := h.timezone(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid X-Timezone header",
+		})
+	}
+
+	// Get overdue todos
+	cutoff := models.NewOverdueCutoff(time.Now().In(loc))
+	todos, total, err := h.todoRepo.GetOverdue(c.UserContext(), userID, cutoff, queryParams.Limit, queryParams.Offset)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get overdue todos.")
+		return repositoryError(c, err, "Failed to get overdue todos")
+	}
+
+	response := models.NewTodoListResponse(todos, total, queryParams.Limit, queryParams.Offset)
+
+	return c.JSON(response)
+}
+
+// GetUpcomingTodos handles getting todos due soon
+// @Summary Get upcoming todos
+// @Description Get the authenticated user's not-done todos due between now and the given number of days ahead, soonest first
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "Number of days ahead to look, from 1 to 365" default(7)
+// @Param limit query int false "Number of todos to return" default(10)
+// @Param offset query int false "Number of todos to skip" default(0)
+// @Success 200 {object} models.TodoListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/upcoming [get]
+func (h *TodoHandler) GetUpcomingTodos(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	// Parse and validate query parameters
+	var queryParams models.UpcomingTodosQueryParams
+
+	// Parse query parameters using Fiber's QueryParser
+	if err := c.QueryParser(&queryParams); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse query parameters.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid query parameters format",
+		})
+	}
+
+	// Set defaults for unprovided parameters
+	queryParams.SetDefaults()
+
+	// Validate query parameters
+	if err := h.validator.Struct(&queryParams); err != nil {
+		h.logger.Error().Err(err).Msg("Get upcoming todos query parameters validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid query parameters",
+		}, err))
+	}
+
+	// Get upcoming todos
+	todos, total, err := h.todoRepo.GetUpcoming(c.UserContext(), userID, queryParams.Days, queryParams.Limit, queryParams.Offset)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get upcoming todos.")
+		return repositoryError(c, err, "Failed to get upcoming todos")
+	}
+
+	response := models.NewTodoListResponse(todos, total, queryParams.Limit, queryParams.Offset)
+
+	return c.JSON(response)
+}
+
+// GetMostOverdueTodo handles getting the single most overdue todo
+// @Summary Get the most overdue todo
+// @Description Get the authenticated user's not-done todo with the oldest past due date
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Param X-Timezone header string false "IANA timezone used to resolve day boundaries (default SERVER_DEFAULT_TIMEZONE)"
+// @Success 200 {object} models.Todo
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/overdue/worst [get]
+func (h *TodoHandler) GetMostOverdueTodo(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	loc, err := h.timezone(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid X-Timezone header",
+		})
+	}
+
+	// Get most overdue todo
+	todo, err := h.todoRepo.GetMostOverdue(c.UserContext(), userID, models.NewOverdueCutoff(time.Now().In(loc)))
+	if err != nil {
+		if errors.Is(err, interfaces.ErrTodoNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":   "Not Found",
+				"message": "No overdue todos",
+			})
+		}
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get most overdue todo.")
+		return repositoryError(c, err, "Failed to get most overdue todo")
+	}
+
+	return c.JSON(todo)
+}
+
+// GetAgenda handles getting the daily agenda
+// @Summary Get the daily agenda
+// @Description Get the authenticated user's not-done todos due on a day, plus up to 100 that were overdue when it started, each ordered by priority then due time. Send Accept: text/plain for a printable version.
+// @Tags todos
+// @Produce json,plain
+// @Security BearerAuth
+// @Param date query string false "Day as YYYY-MM-DD (default today)"
+// @Param X-Timezone header string false "IANA timezone used to resolve the day (default SERVER_DEFAULT_TIMEZONE)"
+// @Success 200 {object} models.Agenda
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/agenda [get]
+func (h *TodoHandler) GetAgenda(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	loc, err := h.timezone(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid X-Timezone header",
+		})
+	}
+
+	day := time.Now().In(loc)
+	if date := c.Query("date"); date != "" {
+		day, err = time.ParseInLocation(time.DateOnly, date, loc)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Bad Request",
+				"message": "date must be formatted as YYYY-MM-DD",
+			})
+		}
+	}
+
+	agenda, err := h.todoService.Agenda(c.UserContext(), userID, day)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get agenda.")
+		return repositoryError(c, err, "Failed to get agenda")
+	}
+
+	if c.Accepts(fiber.MIMEApplicationJSON, fiber.MIMETextPlain) == fiber.MIMETextPlain {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+		return c.SendString(formatAgenda(agenda, day))
+	}
+
+	return c.JSON(agenda)
+}
+
+// formatAgenda renders an agenda as plain text for printing, with times in day's location
+func formatAgenda(agenda *models.Agenda, day time.Time) string {
+	loc := day.Location()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Agenda for %s\n", day.Format("Monday, January 2, 2006"))
+
+	fmt.Fprintf(&b, "\nOverdue (%d)\n", agenda.OverdueTotal)
+	for _, todo := range agenda.Overdue {
+		due := todo.DueDate.UTC().Format("Mon Jan 2")
+		if !todo.AllDay {
+			due = todo.DueDate.In(loc).Format("Mon Jan 2 15:04")
+		}
+		fmt.Fprintf(&b, "  [ ] %s [%s] (due %s)\n", todo.Title, todo.Priority, due)
+	}
+	if more := agenda.OverdueTotal - int64(len(agenda.Overdue)); more > 0 {
+		fmt.Fprintf(&b, "  ...and %d more\n", more)
+	}
+
+	fmt.Fprintf(&b, "\nDue (%d)\n", len(agenda.Due))
+	for _, todo := range agenda.Due {
+		at := "all day"
+		if !todo.AllDay {
+			at = todo.DueDate.In(loc).Format("15:04")
+		}
+		fmt.Fprintf(&b, "  [ ] %-7s %s [%s]\n", at, todo.Title, todo.Priority)
+	}
+
+	return b.String()
+}
+
+// GetUndatedTodos handles getting todos without a due date
+// @Summary Get undated todos
+// @Description Get the authenticated user's not-done todos that have no due date
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Number of todos to return" default(10)
+// @Param offset query int false "Number of todos to skip" default(0)
+// @Success 200 {object} models.TodoListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/undated [get]
+func (h *TodoHandler) GetUndatedTodos(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	// Parse and validate query parameters
+	var queryParams models.PaginationQueryParams
+
+	// Parse query parameters using Fiber's QueryParser
+	if err := c.QueryParser(&queryParams); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse query parameters.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error":   "Bad Request",
 			"message": "Invalid query parameters format",
 		})
@@ -567,32 +2123,205 @@ func (h *TodoHandler) GetOverdueTodos(c *fiber.Ctx) error {
 
 	// Validate query parameters
 	if err := h.validator.Struct(&queryParams); err != nil {
-		h.logger.Error().Err(err).Msg("Get overdue todos query parameters validation failed.")
+		h.logger.Error().Err(err).Msg("Get undated todos query parameters validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid query parameters",
+		}, err))
+	}
+
+	// Get undated todos
+	todos, total, err := h.todoRepo.GetUndated(c.UserContext(), userID, queryParams.Limit, queryParams.Offset)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get undated todos.")
+		return repositoryError(c, err, "Failed to get undated todos")
+	}
+
+	response := models.NewTodoListResponse(todos, total, queryParams.Limit, queryParams.Offset)
+
+	return c.JSON(response)
+}
+
+// GetRecentTodos handles getting recently updated todos
+// @Summary Get recently updated todos
+// @Description Get the authenticated user's todos of any status, most recently updated first
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Number of todos to return" default(10)
+// @Param offset query int false "Number of todos to skip" default(0)
+// @Success 200 {object} models.TodoListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/recent [get]
+func (h *TodoHandler) GetRecentTodos(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	// Parse and validate query parameters
+	var queryParams models.PaginationQueryParams
+
+	// Parse query parameters using Fiber's QueryParser
+	if err := c.QueryParser(&queryParams); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse query parameters.")
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid query parameters format",
+		})
+	}
+
+	// Set defaults for unprovided parameters
+	queryParams.SetDefaults()
+
+	// Validate query parameters
+	if err := h.validator.Struct(&queryParams); err != nil {
+		h.logger.Error().Err(err).Msg("Get recent todos query parameters validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
 			"error":   "Validation Error",
 			"message": "Invalid query parameters",
-			"details": err.Error(),
+		}, err))
+	}
+
+	// Get recently updated todos
+	todos, total, err := h.todoRepo.GetRecentlyUpdated(c.UserContext(), userID, queryParams.Limit, queryParams.Offset)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get recently updated todos.")
+		return repositoryError(c, err, "Failed to get recently updated todos")
+	}
+
+	response := models.NewTodoListResponse(todos, total, queryParams.Limit, queryParams.Offset)
+
+	return c.JSON(response)
+}
+
+// GetDueDistribution handles counting todos by how soon they are due
+// @Summary Get due date distribution
+// @Description Count the authenticated user's not-done todos that are overdue, due today, due in the next six days, due later, or undated. Days are calendar days in the X-Timezone timezone.
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Param X-Timezone header string false "IANA timezone used to resolve day boundaries (default SERVER_DEFAULT_TIMEZONE)"
+// @Success 200 {object} models.DueDistribution
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/due-distribution [get]
+func (h *TodoHandler) GetDueDistribution(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
 		})
 	}
 
-	// Get overdue todos
-	todos, total, err := h.todoRepo.GetOverdue(c.Context(), userID, queryParams.Limit, queryParams.Offset)
+	loc, err := h.timezone(c)
 	if err != nil {
-		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get overdue todos.")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Internal Server Error",
-			"message": "Failed to get overdue todos",
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid X-Timezone header",
 		})
 	}
 
-	response := &models.TodoListResponse{
-		Todos:  todos,
-		Total:  total,
-		Limit:  queryParams.Limit,
-		Offset: queryParams.Offset,
+	// Get due distribution
+	bounds := models.NewDueDistributionBounds(time.Now().In(loc))
+	distribution, err := h.todoRepo.GetDueDistribution(c.UserContext(), userID, bounds)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get due distribution.")
+		return repositoryError(c, err, "Failed to get due distribution")
 	}
 
-	return c.JSON(response)
+	return c.JSON(distribution)
+}
+
+// defaultCompletionDays is the length of the completion rate window when from is not given
+const defaultCompletionDays = 30
+
+// GetCompletionRate handles getting the share of todos completed in a period
+// @Summary Get completion rate
+// @Description Get the ratio of the authenticated user's done todos to all their todos created, or due, between two calendar days in the X-Timezone timezone. The rate is 0 when there are no todos in the period.
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "First day as YYYY-MM-DD (default 29 days before to)"
+// @Param to query string false "Last day as YYYY-MM-DD (default today)"
+// @Param by query string false "Count todos by created (default) or due date"
+// @Param X-Timezone header string false "IANA timezone used to resolve the days (default SERVER_DEFAULT_TIMEZONE)"
+// @Success 200 {object} models.CompletionRate
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/completion-rate [get]
+func (h *TodoHandler) GetCompletionRate(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	loc, err := h.timezone(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid X-Timezone header",
+		})
+	}
+
+	by := c.Query("by", models.CompletionByCreated)
+	if by != models.CompletionByCreated && by != models.CompletionByDue {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "by must be created or due",
+		})
+	}
+
+	to := time.Now().In(loc)
+	if date := c.Query("to"); date != "" {
+		to, err = time.ParseInLocation(time.DateOnly, date, loc)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Bad Request",
+				"message": "to must be formatted as YYYY-MM-DD",
+			})
+		}
+	}
+	from := to.AddDate(0, 0, -(defaultCompletionDays - 1))
+	if date := c.Query("from"); date != "" {
+		from, err = time.ParseInLocation(time.DateOnly, date, loc)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Bad Request",
+				"message": "from must be formatted as YYYY-MM-DD",
+			})
+		}
+	}
+
+	window := models.NewCompletionWindow(from, to, by)
+	if !window.Start.Before(window.End) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "from must not be after to",
+		})
+	}
+
+	completed, total, err := h.todoRepo.CountCompletion(c.UserContext(), userID, window)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get completion rate.")
+		return repositoryError(c, err, "Failed to get completion rate")
+	}
+
+	return c.JSON(models.NewCompletionRate(window, completed, total))
 }
 
 // SearchTodos handles todo search
@@ -602,11 +2331,13 @@ func (h *TodoHandler) GetOverdueTodos(c *fiber.Ctx) error {
 // @Produce json
 // @Security BearerAuth
 // @Param q query string true "Search query"
+// @Param mode query string false "words (every word), prefix (words starting with each term) or phrase (words in order)" default(words)
 // @Param limit query int false "Number of todos to return" default(10)
 // @Param offset query int false "Number of todos to skip" default(0)
 // @Success 200 {object} models.TodoListResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /todos/search [get]
 func (h *TodoHandler) SearchTodos(c *fiber.Ctx) error {
@@ -637,29 +2368,42 @@ func (h *TodoHandler) SearchTodos(c *fiber.Ctx) error {
 	// Validate query parameters
 	if err := h.validator.Struct(&queryParams); err != nil {
 		h.logger.Error().Err(err).Msg("Search todos query parameters validation failed.")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
 			"error":   "Validation Error",
 			"message": "Invalid query parameters",
-			"details": err.Error(),
+		}, err))
+	}
+
+	// A query of only spaces or punctuation has nothing to search for
+	if len(models.SearchTerms(queryParams.Query)) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Search query must contain at least one word",
 		})
 	}
 
+	// Reject rather than queue searches beyond the concurrency cap
+	if h.searchSlots != nil {
+		select {
+		case h.searchSlots <- struct{}{}:
+			defer func() { <-h.searchSlots }()
+		default:
+			h.logger.Warn().Str("user_id", userID).Msg("Search concurrency limit reached.")
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error":   "Too Many Requests",
+				"message": "Too many searches in progress. Please try again shortly.",
+			})
+		}
+	}
+
 	// Search todos
-	todos, total, err := h.todoRepo.Search(c.Context(), userID, queryParams.Query, queryParams.Limit, queryParams.Offset)
+	todos, total, err := h.todoRepo.Search(c.UserContext(), userID, queryParams.Query, queryParams.Mode, queryParams.Limit, queryParams.Offset)
 	if err != nil {
 		h.logger.Error().Err(err).Str("user_id", userID).Str("query", queryParams.Query).Msg("Failed to search todos.")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Internal Server Error",
-			"message": "Failed to search todos",
-		})
+		return repositoryError(c, err, "Failed to search todos")
 	}
 
-	response := &models.TodoListResponse{
-		Todos:  todos,
-		Total:  total,
-		Limit:  queryParams.Limit,
-		Offset: queryParams.Offset,
-	}
+	response := models.NewTodoListResponse(todos, total, queryParams.Limit, queryParams.Offset)
 
 	return c.JSON(response)
 }
@@ -685,16 +2429,202 @@ func (h *TodoHandler) GetTodoStats(c *fiber.Ctx) error {
 	}
 
 	// Get todo statistics
-	stats, err := h.todoRepo.CountByStatus(c.Context(), userID)
+	stats, err := h.todoRepo.CountByStatus(c.UserContext(), userID)
 	if err != nil {
 		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get todo statistics.")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Internal Server Error",
-			"message": "Failed to get todo statistics",
-		})
+		return repositoryError(c, err, "Failed to get todo statistics")
 	}
 
 	return c.JSON(fiber.Map{
 		"stats": stats,
 	})
 }
+
+// GetTodoStatsSummary handles getting a summary of the user's todos
+// @Summary Get todo statistics summary
+// @Description Get the total, per-status and overdue counts of the authenticated user's todos with the percentage completed. The percentage is 0 when there are no todos.
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Param X-Timezone header string false "IANA timezone used to resolve day boundaries (default SERVER_DEFAULT_TIMEZONE)"
+// @Success 200 {object} models.TodoStatsResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/stats/summary [get]
+func (h *TodoHandler) GetTodoStatsSummary(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	loc, err := h.timezone(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid X-Timezone header",
+		})
+	}
+
+	counts, err := h.todoRepo.CountByStatus(c.UserContext(), userID)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get todo statistics.")
+		return repositoryError(c, err, "Failed to get todo statistics")
+	}
+
+	overdue, err := h.todoRepo.CountOverdue(c.UserContext(), userID, models.NewOverdueCutoff(time.Now().In(loc)))
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count overdue todos.")
+		return repositoryError(c, err, "Failed to get todo statistics")
+	}
+
+	return c.JSON(models.NewTodoStatsResponse(counts, overdue))
+}
+
+// GetInsights handles getting the user's year in review
+// @Summary Get personal insights
+// @Description Get the authenticated user's year in review over the todos they created that year: how many were created and completed, the completion rate, the busiest day, the most-used tags and the average hours from creating a done todo to its last update. Results are cached for TODO_INSIGHTS_CACHE_TTL.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param year query int false "Year to review (default the current year)"
+// @Param X-Timezone header string false "IANA timezone used to resolve the year and days (default SERVER_DEFAULT_TIMEZONE)"
+// @Success 200 {object} models.UserInsights
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /auth/me/insights [get]
+func (h *TodoHandler) GetInsights(c *fiber.Ctx) error {
+	// Mounted with the auth routes rather than under /todos, so it sets its own cache policy
+	utils.NoCache(c)
+
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	loc, err := h.timezone(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid X-Timezone header",
+		})
+	}
+
+	year := time.Now().In(loc).Year()
+	if value := c.Query("year"); value != "" {
+		year, err = strconv.Atoi(value)
+		if err != nil || year < 1 || year > 9999 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Bad Request",
+				"message": "year must be a number between 1 and 9999",
+			})
+		}
+	}
+
+	insights, err := h.todoService.Insights(c.UserContext(), userID, year, loc)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get insights.")
+		return repositoryError(c, err, "Failed to get insights")
+	}
+
+	return c.JSON(insights)
+}
+
+// GetTrashedTodos handles getting soft-deleted todos
+// @Summary Get deleted todos
+// @Description Get soft-deleted todos for the authenticated user, most recently deleted first
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Number of todos to return" default(10)
+// @Param offset query int false "Number of todos to skip" default(0)
+// @Success 200 {object} models.TodoListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /todos/trash [get]
+func (h *TodoHandler) GetTrashedTodos(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+	}
+
+	// Parse and validate query parameters
+	var queryParams models.PaginationQueryParams
+
+	// Parse query parameters using Fiber's QueryParser
+	if err := c.QueryParser(&queryParams); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to parse query parameters.")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid query parameters format",
+		})
+	}
+
+	// Set defaults for unprovided parameters
+	queryParams.SetDefaults()
+
+	// Validate query parameters
+	if err := h.validator.Struct(&queryParams); err != nil {
+		h.logger.Error().Err(err).Msg("Get trashed todos query parameters validation failed.")
+		return c.Status(fiber.StatusBadRequest).JSON(utils.WithErrorDetails(fiber.Map{
+			"error":   "Validation Error",
+			"message": "Invalid query parameters",
+		}, err))
+	}
+
+	// Get deleted todos
+	todos, total, err := h.todoRepo.GetDeleted(c.UserContext(), userID, queryParams.Limit, queryParams.Offset)
+	if err != nil {
+		h.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get deleted todos.")
+		return repositoryError(c, err, "Failed to get deleted todos")
+	}
+
+	response := models.NewTodoListResponse(todos, total, queryParams.Limit, queryParams.Offset)
+
+	return c.JSON(response)
+}
+
+// requestDueDate returns the explicit due date of a create request and whether it is
+// all-day, keeping only the day of an all-day due date
+func (h *TodoHandler) requestDueDate(req *models.CreateTodoRequest) (*time.Time, bool) {
+	if req.DueDate == nil {
+		return nil, false
+	}
+	if !h.isAllDay(*req.DueDate, req.AllDay) {
+		return req.DueDate, false
+	}
+	day := models.AllDayDate(*req.DueDate)
+	return &day, true
+}
+
+// createRequestErrors validates a create request as CreateTodo would, including whether
+// its dueDateText can be understood
+func (h *TodoHandler) createRequestErrors(req *models.CreateTodoRequest) []models.FieldError {
+	fieldErrors := models.FieldErrors(h.validator.Struct(req))
+	if len(fieldErrors) == 0 && req.DueDate == nil && req.DueDateText != "" {
+		if _, err := utils.ParseNaturalDate(req.DueDateText, time.Now()); err != nil {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: "dueDateText", Rule: "natural_date"})
+		}
+	}
+	return fieldErrors
+}
+
+// isAllDay reports whether a due date is all-day: when the client says so or, with
+// end-of-day due dates enabled, when it falls at exactly midnight
+func (h *TodoHandler) isAllDay(dueDate time.Time, allDay bool) bool {
+	return allDay || h.endOfDayDueDates && utils.IsDateOnly(dueDate)
+}
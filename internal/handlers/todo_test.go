@@ -1,27 +1,45 @@
 package handlers
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"go-fiber/internal/config"
+	"go-fiber/internal/middleware"
 	"go-fiber/internal/mocks"
 	"go-fiber/internal/models"
+	"go-fiber/internal/repository/interfaces"
+	"go-fiber/internal/services"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func setupTodoHandler() (*TodoHandler, *mocks.MockTodoRepository) {
 	mockRepo := new(mocks.MockTodoRepository)
 	logger := config.NewTestLogger()
 	validator := validator.New()
-	handler := NewTodoHandler(mockRepo, validator, logger)
+	models.RegisterValidations(validator)
+	todoService := services.NewTodoService(mockRepo, &config.NewTestConfig().Todo, logger)
+	handler := NewTodoHandler(mockRepo, todoService, config.NewTestConfig().Server.CacheMaxAge, validator, logger)
 	return handler, mockRepo
 }
 
@@ -39,6 +57,9 @@ func setupFiberApp(handler *TodoHandler) *fiber.App {
 	api := app.Group("/api/v1")
 	handler.RegisterRoutes(api, authMiddleware)
 
+	// The server mounts insights with the auth routes
+	api.Get("/auth/me/insights", authMiddleware, handler.GetInsights)
+
 	return app
 }
 
@@ -149,7 +170,7 @@ func TestTodoHandler_GetTodos(t *testing.T) {
 			},
 		}
 
-		mockRepo.On("GetByUserID", mock.Anything, "test-user-id", 10, 0).Return(expectedTodos, int64(2), nil)
+		mockRepo.On("GetFiltered", mock.Anything, "test-user-id", models.TodoFilter{}, models.TodoSort{Field: "createdAt", Order: "desc"}, 10, 0).Return(expectedTodos, int64(2), nil)
 
 		req := httptest.NewRequest("GET", "/api/v1/todos", nil)
 
@@ -186,7 +207,7 @@ func TestTodoHandler_GetTodos(t *testing.T) {
 			},
 		}
 
-		mockRepo.On("GetByUserID", mock.Anything, "test-user-id", 5, 5).Return(expectedTodos, int64(6), nil)
+		mockRepo.On("GetFiltered", mock.Anything, "test-user-id", models.TodoFilter{}, mock.Anything, 5, 5).Return(expectedTodos, int64(6), nil)
 
 		req := httptest.NewRequest("GET", "/api/v1/todos?limit=5&offset=5", nil)
 
@@ -209,6 +230,319 @@ func TestTodoHandler_GetTodos(t *testing.T) {
 	})
 }
 
+func TestTodoHandler_GetTodos_Filters(t *testing.T) {
+	t.Run("status and priority combine", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		todos := []*models.Todo{
+			{ID: "todo-1", UserID: "test-user-id", Title: "Urgent", Status: models.TodoStatusPending, Priority: models.TodoPriorityHigh},
+		}
+		filter := models.TodoFilter{Status: models.TodoStatusPending, Priority: models.TodoPriorityHigh}
+		mockRepo.On("GetFiltered", mock.Anything, "test-user-id", filter, mock.Anything, 10, 0).Return(todos, int64(1), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?status=pending&priority=high", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.TodoListResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Len(t, response.Todos, 1)
+		assert.Equal(t, int64(1), response.Total)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("several statuses match any of them", func(t *testing.T) {
+		for _, query := range []string{"status=pending,in_progress", "status=pending&status=in_progress", "status=pending&status=in_progress,pending"} {
+			// Arrange
+			handler, mockRepo := setupTodoHandler()
+			app := setupFiberApp(handler)
+			statuses := []string{models.TodoStatusPending, models.TodoStatusInProgress}
+			mockRepo.On("GetByStatuses", mock.Anything, "test-user-id", statuses, 10, 0).Return([]*models.Todo{}, int64(0), nil)
+
+			// Act
+			resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?"+query, nil))
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, 200, resp.StatusCode, query)
+			mockRepo.AssertExpectations(t)
+		}
+	})
+
+	t.Run("several statuses combine with other filters", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		filter := models.TodoFilter{Statuses: []string{models.TodoStatusPending, models.TodoStatusInProgress}, Priority: models.TodoPriorityHigh}
+		mockRepo.On("GetFiltered", mock.Anything, "test-user-id", filter, mock.Anything, 10, 0).Return([]*models.Todo{}, int64(0), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?status=pending,in_progress&priority=high", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "GetByStatuses", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("one invalid status fails validation", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?status=pending,archived", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "GetFiltered", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("due range is parsed", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		from := time.Date(2025, 10, 13, 0, 0, 0, 0, time.UTC)
+		before := time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC)
+		matchesRange := mock.MatchedBy(func(filter models.TodoFilter) bool {
+			return filter.Tag == "work" &&
+				filter.DueFrom != nil && filter.DueFrom.Equal(from) &&
+				filter.DueBefore != nil && filter.DueBefore.Equal(before)
+		})
+		mockRepo.On("GetFiltered", mock.Anything, "test-user-id", matchesRange, mock.Anything, 10, 0).Return([]*models.Todo{}, int64(0), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?tag=work&dueFrom=2025-10-13T00:00:00Z&dueBefore=2025-10-20T00:00:00Z", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid due range", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?dueFrom=tomorrow", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "GetFiltered", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("sort is passed to the repository", func(t *testing.T) {
+		tests := []struct {
+			query    string
+			expected models.TodoSort
+		}{
+			{"sortBy=priority", models.TodoSort{Field: "priority", Order: "desc"}},
+			{"sortBy=dueDate", models.TodoSort{Field: "dueDate", Order: "asc"}},
+			{"sortBy=title&order=desc", models.TodoSort{Field: "title", Order: "desc"}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.query, func(t *testing.T) {
+				// Arrange
+				handler, mockRepo := setupTodoHandler()
+				app := setupFiberApp(handler)
+				mockRepo.On("GetFiltered", mock.Anything, "test-user-id", models.TodoFilter{}, tt.expected, 10, 0).Return([]*models.Todo{}, int64(0), nil)
+
+				// Act
+				resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?"+tt.query, nil))
+
+				// Assert
+				assert.NoError(t, err)
+				assert.Equal(t, 200, resp.StatusCode)
+				mockRepo.AssertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("unknown sort field or order", func(t *testing.T) {
+		for _, query := range []string{"sortBy=status", "sortBy=created_at", "order=up"} {
+			// Arrange
+			handler, mockRepo := setupTodoHandler()
+			app := setupFiberApp(handler)
+
+			// Act
+			resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?"+query, nil))
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, 400, resp.StatusCode, query)
+			mockRepo.AssertNotCalled(t, "GetFiltered", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("filters cannot be combined with a cursor", func(t *testing.T) {
+		// Arrange
+		handler, _ := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?cursor=&dueBefore=2025-10-20T00:00:00Z", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+}
+
+func TestTodoHandler_GetTodos_Cursor(t *testing.T) {
+	next := models.EncodeTodoCursor("01K7KZ6T7Q0S4E9M2W8H3XJ5VD")
+
+	t.Run("empty cursor returns the first page", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		todos := []*models.Todo{{ID: "01K7KZ6T7Q0S4E9M2W8H3XJ5VD", UserID: "test-user-id", Title: "Newest"}}
+		mockRepo.On("GetByUserIDCursor", mock.Anything, "test-user-id", "", (*time.Time)(nil), 1).Return(todos, next, nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?cursor=&limit=1", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.TodoListResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Len(t, response.Todos, 1)
+		assert.Equal(t, next, response.NextCursor)
+		assert.True(t, response.HasMore)
+	})
+
+	t.Run("last page has no next cursor", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("GetByUserIDCursor", mock.Anything, "test-user-id", next, (*time.Time)(nil), 10).Return([]*models.Todo{}, "", nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?cursor="+next, nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response map[string]any
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, false, response["hasMore"])
+		assert.NotContains(t, response, "nextCursor")
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("GetByUserIDCursor", mock.Anything, "test-user-id", "bogus", (*time.Time)(nil), 10).Return(nil, "", models.ErrInvalidCursor)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?cursor=bogus", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("todos created after the snapshot stay out of later pages", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		snapshot := time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC)
+		older := &models.Todo{ID: "01K7KZ6T7Q0S4E9M2W8H3XJ5VD", UserID: "test-user-id", Title: "Older", CreatedAt: snapshot.Add(-time.Hour)}
+		oldest := &models.Todo{ID: "01K7KZ6T7Q0S4E9M2W8H3XJ5VC", UserID: "test-user-id", Title: "Oldest", CreatedAt: snapshot.Add(-2 * time.Hour)}
+		inserted := &models.Todo{ID: "01K7M00000000000000000000A", UserID: "test-user-id", Title: "Inserted", CreatedAt: snapshot.Add(time.Minute)}
+		store := []*models.Todo{older, oldest}
+
+		// asOf returns the todos the repository would match for snapshot, newest first
+		asOf := func(snapshot time.Time, afterID string) []*models.Todo {
+			var page []*models.Todo
+			for _, todo := range store {
+				if !todo.CreatedAt.After(snapshot) && (afterID == "" || todo.ID < afterID) {
+					page = append(page, todo)
+				}
+			}
+			return page
+		}
+		sameSnapshot := mock.MatchedBy(func(s *time.Time) bool { return s != nil && s.Equal(snapshot) })
+
+		mockRepo.On("GetByUserIDCursor", mock.Anything, "test-user-id", "", sameSnapshot, 1).Return(asOf(snapshot, "")[:1], next, nil)
+
+		// Act: first page, then a todo is created before the second page is fetched
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?limit=1&snapshot=2025-10-15T12:00:00Z", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var first models.TodoListResponse
+		json.NewDecoder(resp.Body).Decode(&first)
+
+		store = append([]*models.Todo{inserted}, store...)
+		mockRepo.On("GetByUserIDCursor", mock.Anything, "test-user-id", next, sameSnapshot, 1).Return(asOf(snapshot, older.ID), "", nil)
+
+		resp, err = app.Test(httptest.NewRequest("GET", "/api/v1/todos?limit=1&snapshot=2025-10-15T12:00:00Z&cursor="+first.NextCursor, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var second models.TodoListResponse
+		json.NewDecoder(resp.Body).Decode(&second)
+
+		// Assert
+		require.Len(t, first.Todos, 1)
+		require.Len(t, second.Todos, 1)
+		assert.Equal(t, "Older", first.Todos[0].Title)
+		assert.Equal(t, "Oldest", second.Todos[0].Title)
+		assert.False(t, second.HasMore)
+		require.NotNil(t, second.Snapshot)
+		assert.True(t, second.Snapshot.Equal(snapshot))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid snapshot", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?cursor=&snapshot=yesterday", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "GetByUserIDCursor", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("cursor combined with a filter", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?cursor=&status=pending", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "GetByUserIDCursor", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
 func TestTodoHandler_GetTodo(t *testing.T) {
 	handler, mockRepo := setupTodoHandler()
 	app := setupFiberApp(handler)
@@ -226,7 +560,7 @@ func TestTodoHandler_GetTodo(t *testing.T) {
 			UpdatedAt:   time.Now(),
 		}
 
-		mockRepo.On("GetByID", mock.Anything, "todo-1").Return(expectedTodo, nil)
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(expectedTodo, nil)
 
 		req := httptest.NewRequest("GET", "/api/v1/todos/todo-1", nil)
 
@@ -248,7 +582,7 @@ func TestTodoHandler_GetTodo(t *testing.T) {
 
 	t.Run("todo not found", func(t *testing.T) {
 		// Arrange
-		mockRepo.On("GetByID", mock.Anything, "nonexistent").Return(nil, assert.AnError)
+		mockRepo.On("GetByIDForUser", mock.Anything, "nonexistent", "test-user-id").Return(nil, assert.AnError)
 
 		req := httptest.NewRequest("GET", "/api/v1/todos/nonexistent", nil)
 
@@ -263,6 +597,24 @@ func TestTodoHandler_GetTodo(t *testing.T) {
 	})
 }
 
+func TestTodoHandler_GetTodo_NotFound(t *testing.T) {
+	t.Run("wrapped sentinel maps to 404", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("GetByIDForUser", mock.Anything, "missing-id", "test-user-id").Return(nil, fmt.Errorf("lookup: %w", interfaces.ErrTodoNotFound))
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/missing-id", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
 func TestTodoHandler_UpdateTodo(t *testing.T) {
 	handler, mockRepo := setupTodoHandler()
 	app := setupFiberApp(handler)
@@ -297,7 +649,7 @@ func TestTodoHandler_UpdateTodo(t *testing.T) {
 			UpdatedAt:   time.Now(),
 		}
 
-		mockRepo.On("GetByID", mock.Anything, "todo-1").Return(existingTodo, nil)
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(existingTodo, nil)
 		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(updatedTodo, nil)
 
 		body, _ := json.Marshal(reqBody)
@@ -327,7 +679,7 @@ func TestTodoHandler_UpdateTodo(t *testing.T) {
 			Title: "Updated Todo",
 		}
 
-		mockRepo.On("GetByID", mock.Anything, "nonexistent").Return(nil, assert.AnError)
+		mockRepo.On("GetByIDForUser", mock.Anything, "nonexistent", "test-user-id").Return(nil, assert.AnError)
 
 		body, _ := json.Marshal(reqBody)
 		req := httptest.NewRequest("PUT", "/api/v1/todos/nonexistent", bytes.NewReader(body))
@@ -344,25 +696,132 @@ func TestTodoHandler_UpdateTodo(t *testing.T) {
 	})
 }
 
+func TestTodoHandler_PatchTodo(t *testing.T) {
+	dueDate := time.Date(2025, 10, 20, 15, 0, 0, 0, time.UTC)
+	existingTodo := func() *models.Todo {
+		return &models.Todo{
+			ID:          "todo-1",
+			UserID:      "test-user-id",
+			Title:       "Original Todo",
+			Description: "Original Description",
+			Status:      models.TodoStatusCompleted,
+			Priority:    models.TodoPriorityMedium,
+			DueDate:     &dueDate,
+		}
+	}
+
+	patch := func(app *fiber.App, body string) *http.Response {
+		req := httptest.NewRequest("PATCH", "/api/v1/todos/todo-1", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	t.Run("empty description clears it", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		cleared := existingTodo()
+		cleared.Description = ""
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(existingTodo(), nil)
+		mockRepo.On("PartialUpdate", mock.Anything, "test-user-id", "todo-1", mock.MatchedBy(func(p *models.PatchTodoRequest) bool {
+			return p.Description != nil && *p.Description == "" && p.Title == nil && !p.DueDate.Set
+		})).Return(cleared, nil)
+
+		// Act
+		resp := patch(app, `{"description": ""}`)
+
+		// Assert
+		assert.Equal(t, 200, resp.StatusCode)
+		var response models.Todo
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Empty(t, response.Description)
+		assert.Equal(t, "Original Todo", response.Title)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("null due date clears it", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		cleared := existingTodo()
+		cleared.DueDate = nil
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(existingTodo(), nil)
+		mockRepo.On("PartialUpdate", mock.Anything, "test-user-id", "todo-1", mock.MatchedBy(func(p *models.PatchTodoRequest) bool {
+			return p.DueDate.Set && p.DueDate.Value == nil && p.Description == nil
+		})).Return(cleared, nil)
+
+		// Act
+		resp := patch(app, `{"dueDate": null}`)
+
+		// Assert
+		assert.Equal(t, 200, resp.StatusCode)
+		var response models.Todo
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Nil(t, response.DueDate)
+		assert.Equal(t, "Original Description", response.Description)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("omitted fields are left alone", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		renamed := existingTodo()
+		renamed.Title = "Renamed"
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(existingTodo(), nil)
+		mockRepo.On("PartialUpdate", mock.Anything, "test-user-id", "todo-1", mock.MatchedBy(func(p *models.PatchTodoRequest) bool {
+			return p.Title != nil && *p.Title == "Renamed" && p.Description == nil && !p.DueDate.Set && p.Tags == nil && p.Status == nil
+		})).Return(renamed, nil)
+
+		// Act
+		resp := patch(app, `{"title": "Renamed"}`)
+
+		// Assert
+		assert.Equal(t, 200, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("empty title is rejected", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		// Act
+		resp := patch(app, `{"title": ""}`)
+
+		// Assert
+		assert.Equal(t, 400, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "PartialUpdate", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("todo of another user is not found", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(nil, interfaces.ErrTodoNotFound)
+
+		// Act
+		resp := patch(app, `{"title": "Renamed"}`)
+
+		// Assert
+		assert.Equal(t, 404, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
 func TestTodoHandler_DeleteTodo(t *testing.T) {
 	handler, mockRepo := setupTodoHandler()
 	app := setupFiberApp(handler)
 
 	t.Run("successful todo deletion", func(t *testing.T) {
 		// Arrange
-		existingTodo := &models.Todo{
-			ID:          "todo-1",
-			UserID:      "test-user-id",
-			Title:       "Todo to Delete",
-			Description: "Description",
-			Status:      models.TodoStatusPending,
-			Priority:    models.TodoPriorityMedium,
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-		}
-
-		mockRepo.On("GetByID", mock.Anything, "todo-1").Return(existingTodo, nil)
-		mockRepo.On("Delete", mock.Anything, "todo-1").Return(nil)
+		mockRepo.On("Delete", mock.Anything, "test-user-id", "todo-1").Return(nil)
 
 		req := httptest.NewRequest("DELETE", "/api/v1/todos/todo-1", nil)
 
@@ -377,10 +836,27 @@ func TestTodoHandler_DeleteTodo(t *testing.T) {
 	})
 
 	t.Run("todo not found", func(t *testing.T) {
+		// Arrange - the repository only deletes the caller's own todos
+		mockRepo.On("Delete", mock.Anything, "test-user-id", "other-users-todo").Return(interfaces.ErrTodoNotFound)
+
+		req := httptest.NewRequest("DELETE", "/api/v1/todos/other-users-todo", nil)
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "GetByIDForUser", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
 		// Arrange
-		mockRepo.On("GetByID", mock.Anything, "nonexistent").Return(nil, assert.AnError)
+		mockRepo.On("Delete", mock.Anything, "test-user-id", "broken").Return(assert.AnError)
 
-		req := httptest.NewRequest("DELETE", "/api/v1/todos/nonexistent", nil)
+		req := httptest.NewRequest("DELETE", "/api/v1/todos/broken", nil)
 
 		// Act
 		resp, err := app.Test(req)
@@ -388,7 +864,2826 @@ func TestTodoHandler_DeleteTodo(t *testing.T) {
 		// Assert
 		assert.NoError(t, err)
 		assert.Equal(t, 500, resp.StatusCode) // Handler returns 500 for generic errors
+	})
+}
 
-		mockRepo.AssertExpectations(t)
+func TestTodoHandler_GetTrashedTodos(t *testing.T) {
+	t.Run("only deleted todos are returned", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		deletedAt := time.Now()
+		deletedTodos := []*models.Todo{
+			{
+				ID:        "todo-1",
+				UserID:    "test-user-id",
+				Title:     "Deleted Todo",
+				Status:    models.TodoStatusPending,
+				Priority:  models.TodoPriorityMedium,
+				DeletedAt: &deletedAt,
+			},
+		}
+
+		mockRepo.On("GetDeleted", mock.Anything, "test-user-id", 10, 0).Return(deletedTodos, int64(1), nil)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos/trash", nil)
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.TodoListResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+
+		assert.Len(t, response.Todos, 1)
+		assert.Equal(t, "todo-1", response.Todos[0].ID)
+		assert.NotNil(t, response.Todos[0].DeletedAt)
+		assert.Equal(t, int64(1), response.Total)
+
+		mockRepo.AssertNotCalled(t, "GetByUserID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid pagination", func(t *testing.T) {
+		// Arrange
+		handler, _ := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos/trash?limit=500", nil)
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("database unavailable", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		unavailable := fmt.Errorf("failed to count deleted todos: %w", interfaces.ErrUnavailable)
+		mockRepo.On("GetDeleted", mock.Anything, "test-user-id", 10, 0).Return(nil, int64(0), unavailable)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos/trash", nil)
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 503, resp.StatusCode)
+	})
+}
+
+func TestTodoHandler_RestoreTodo(t *testing.T) {
+	t.Run("restores an owned deleted todo", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("Restore", mock.Anything, "test-user-id", "todo-1").Return(nil)
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(&models.Todo{ID: "todo-1", UserID: "test-user-id", Title: "Back again"}, nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("POST", "/api/v1/todos/todo-1/restore", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.Todo
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, "todo-1", response.ID)
+		assert.Nil(t, response.DeletedAt)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("todo that was never deleted", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("Restore", mock.Anything, "test-user-id", "todo-1").Return(interfaces.ErrTodoNotFound)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("POST", "/api/v1/todos/todo-1/restore", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "GetByIDForUser", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("todo owned by another user", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		// The repository only restores from the caller's own trash
+		mockRepo.On("Restore", mock.Anything, "test-user-id", "other-users-todo").Return(interfaces.ErrTodoNotFound)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("POST", "/api/v1/todos/other-users-todo/restore", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestTodoHandler_PurgeTodo(t *testing.T) {
+	t.Run("permanently deletes an owned trashed todo", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		mockRepo.On("HardDelete", mock.Anything, "test-user-id", "todo-1").Return(nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/api/v1/todos/trash/todo-1", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 204, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("todo owned by another user", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		// The repository only deletes within the caller's own trash
+		mockRepo.On("HardDelete", mock.Anything, "test-user-id", "other-users-todo").Return(interfaces.ErrTodoNotFound)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/api/v1/todos/trash/other-users-todo", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("todo that is not in the trash", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		mockRepo.On("HardDelete", mock.Anything, "test-user-id", "todo-1").Return(interfaces.ErrTodoNotFound)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/api/v1/todos/trash/todo-1", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		mockRepo.On("HardDelete", mock.Anything, "test-user-id", "todo-1").Return(errors.New("database error"))
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/api/v1/todos/trash/todo-1", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 500, resp.StatusCode)
+	})
+}
+
+func TestTodoHandler_CreateTodo_DueDateText(t *testing.T) {
+	t.Run("natural-language due date is resolved", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(todo *models.Todo) bool {
+			return todo.DueDate != nil && todo.DueDate.After(time.Now())
+		})).Return(&models.Todo{ID: "todo-1", UserID: "test-user-id", Title: "Test Todo"}, nil)
+
+		body, _ := json.Marshal(models.CreateTodoRequest{Title: "Test Todo", DueDateText: "tomorrow 5pm"})
+		req := httptest.NewRequest("POST", "/api/v1/todos", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Timezone", "Europe/London")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 201, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("explicit due date wins", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		explicit := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(todo *models.Todo) bool {
+			return todo.DueDate != nil && todo.DueDate.Equal(explicit)
+		})).Return(&models.Todo{ID: "todo-1", UserID: "test-user-id", Title: "Test Todo"}, nil)
+
+		body, _ := json.Marshal(models.CreateTodoRequest{Title: "Test Todo", DueDate: &explicit, DueDateText: "tomorrow"})
+		req := httptest.NewRequest("POST", "/api/v1/todos", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 201, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("unparseable due date text", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		body, _ := json.Marshal(models.CreateTodoRequest{Title: "Test Todo", DueDateText: "whenever"})
+		req := httptest.NewRequest("POST", "/api/v1/todos", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("invalid timezone", func(t *testing.T) {
+		// Arrange
+		handler, _ := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		body, _ := json.Marshal(models.CreateTodoRequest{Title: "Test Todo", DueDateText: "tomorrow"})
+		req := httptest.NewRequest("POST", "/api/v1/todos", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Timezone", "Mars/Olympus_Mons")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+}
+
+func TestTodoHandler_MergeTodos(t *testing.T) {
+	t.Run("successful merge", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		source := &models.Todo{ID: "source-id", UserID: "test-user-id", Title: "Dup", Description: "second"}
+		target := &models.Todo{ID: "target-id", UserID: "test-user-id", Title: "Dup", Description: "first"}
+		merged := &models.Todo{ID: "target-id", UserID: "test-user-id", Title: "Dup", Description: "first\n\nsecond"}
+
+		mockRepo.On("GetByIDForUser", mock.Anything, "source-id", "test-user-id").Return(source, nil)
+		mockRepo.On("GetByIDForUser", mock.Anything, "target-id", "test-user-id").Return(target, nil)
+		mockRepo.On("Merge", mock.Anything, mock.AnythingOfType("*models.Todo"), "source-id").Return(merged, nil)
+
+		body, _ := json.Marshal(models.MergeTodosRequest{SourceID: "source-id", TargetID: "target-id"})
+		req := httptest.NewRequest("POST", "/api/v1/todos/merge", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.Todo
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, "first\n\nsecond", response.Description)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("target not found", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("GetByIDForUser", mock.Anything, "source-id", "test-user-id").Return(&models.Todo{ID: "source-id", UserID: "test-user-id"}, nil)
+		mockRepo.On("GetByIDForUser", mock.Anything, "missing-id", "test-user-id").Return(nil, interfaces.ErrTodoNotFound)
+
+		body, _ := json.Marshal(models.MergeTodosRequest{SourceID: "source-id", TargetID: "missing-id"})
+		req := httptest.NewRequest("POST", "/api/v1/todos/merge", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "Merge", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("missing ids", func(t *testing.T) {
+		// Arrange
+		handler, _ := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		req := httptest.NewRequest("POST", "/api/v1/todos/merge", bytes.NewReader([]byte(`{"sourceId":"source-id"}`)))
+		req.Header.Set("Content-Type", "application/json")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+}
+
+func TestTodoHandler_CreateTodo_Prefer(t *testing.T) {
+	createdTodo := &models.Todo{
+		ID:       "todo-1",
+		UserID:   "test-user-id",
+		Title:    "Test Todo",
+		Status:   models.TodoStatusPending,
+		Priority: models.TodoPriorityMedium,
+	}
+
+	t.Run("return=minimal responds with only the id", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(createdTodo, nil)
+
+		req := httptest.NewRequest("POST", "/api/v1/todos", bytes.NewReader([]byte(`{"title":"Test Todo"}`)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Prefer", "return=minimal")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 201, resp.StatusCode)
+		assert.Equal(t, "return=minimal", resp.Header.Get("Preference-Applied"))
+
+		var response map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, map[string]interface{}{"id": "todo-1"}, response)
+	})
+
+	t.Run("full representation by default", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(createdTodo, nil)
+
+		req := httptest.NewRequest("POST", "/api/v1/todos", bytes.NewReader([]byte(`{"title":"Test Todo"}`)))
+		req.Header.Set("Content-Type", "application/json")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 201, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Preference-Applied"))
+
+		var response models.Todo
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, "todo-1", response.ID)
+		assert.Equal(t, "Test Todo", response.Title)
+	})
+}
+
+func TestTodoHandler_UpdateTodo_Prefer(t *testing.T) {
+	t.Run("return=minimal responds with only the id", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		existing := &models.Todo{ID: "todo-1", UserID: "test-user-id", Title: "Old", Status: models.TodoStatusPending}
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(existing, nil)
+		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.Todo")).Return(&models.Todo{ID: "todo-1", UserID: "test-user-id", Title: "New"}, nil)
+
+		req := httptest.NewRequest("PUT", "/api/v1/todos/todo-1", bytes.NewReader([]byte(`{"title":"New"}`)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Prefer", "return=minimal")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, map[string]interface{}{"id": "todo-1"}, response)
+	})
+}
+
+func TestTodoHandler_GetMostOverdueTodo(t *testing.T) {
+	t.Run("returns the most overdue todo", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		dueDate := time.Now().AddDate(0, 0, -30)
+		mockRepo.On("GetMostOverdue", mock.Anything, "test-user-id", mock.AnythingOfType("models.OverdueCutoff")).Return(&models.Todo{
+			ID:      "todo-1",
+			UserID:  "test-user-id",
+			Title:   "Renew passport",
+			Status:  models.TodoStatusPending,
+			DueDate: &dueDate,
+		}, nil)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos/overdue/worst", nil)
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.Todo
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, "todo-1", response.ID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("nothing overdue", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("GetMostOverdue", mock.Anything, "test-user-id", mock.AnythingOfType("models.OverdueCutoff")).Return(nil, interfaces.ErrTodoNotFound)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos/overdue/worst", nil)
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+
+	t.Run("all-day cutoff follows the timezone", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		loc, _ := time.LoadLocation("Pacific/Kiritimati")
+		today := models.AllDayDate(time.Now().In(loc))
+		mockRepo.On("GetMostOverdue", mock.Anything, "test-user-id", mock.MatchedBy(func(cutoff models.OverdueCutoff) bool {
+			return cutoff.Today.Equal(today)
+		})).Return(nil, interfaces.ErrTodoNotFound)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos/overdue/worst", nil)
+		req.Header.Set("X-Timezone", "Pacific/Kiritimati")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("default timezone applies without a header", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		loc, _ := time.LoadLocation("Pacific/Kiritimati")
+		handler.SetDefaultTimezone(loc)
+		app := setupFiberApp(handler)
+
+		today := models.AllDayDate(time.Now().In(loc))
+		mockRepo.On("GetMostOverdue", mock.Anything, "test-user-id", mock.MatchedBy(func(cutoff models.OverdueCutoff) bool {
+			return cutoff.Now.Location() == loc && cutoff.Today.Equal(today)
+		})).Return(nil, interfaces.ErrTodoNotFound)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos/overdue/worst", nil)
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("header overrides the default timezone", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		defaultLoc, _ := time.LoadLocation("Pacific/Kiritimati")
+		handler.SetDefaultTimezone(defaultLoc)
+		app := setupFiberApp(handler)
+
+		loc, _ := time.LoadLocation("Pacific/Pago_Pago")
+		today := models.AllDayDate(time.Now().In(loc))
+		mockRepo.On("GetMostOverdue", mock.Anything, "test-user-id", mock.MatchedBy(func(cutoff models.OverdueCutoff) bool {
+			return cutoff.Today.Equal(today)
+		})).Return(nil, interfaces.ErrTodoNotFound)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos/overdue/worst", nil)
+		req.Header.Set("X-Timezone", "Pacific/Pago_Pago")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestTodoHandler_GetUndatedTodos(t *testing.T) {
+	t.Run("returns undated todos", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		undatedTodos := []*models.Todo{
+			{
+				ID:       "todo-1",
+				UserID:   "test-user-id",
+				Title:    "Someday",
+				Status:   models.TodoStatusPending,
+				Priority: models.TodoPriorityLow,
+			},
+		}
+
+		mockRepo.On("GetUndated", mock.Anything, "test-user-id", 5, 5).Return(undatedTodos, int64(6), nil)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos/undated?limit=5&offset=5", nil)
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.TodoListResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+
+		assert.Len(t, response.Todos, 1)
+		assert.Nil(t, response.Todos[0].DueDate)
+		assert.Equal(t, int64(6), response.Total)
+		assert.False(t, response.HasMore)
+
+		mockRepo.AssertNotCalled(t, "GetByUserID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid pagination", func(t *testing.T) {
+		// Arrange
+		handler, _ := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos/undated?limit=500", nil)
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+}
+
+func TestTodoHandler_GetUpcomingTodos(t *testing.T) {
+	t.Run("defaults to the next 7 days", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		dueDate := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+		upcomingTodos := []*models.Todo{
+			{ID: "todo-1", UserID: "test-user-id", Title: "Soon", Status: models.TodoStatusPending, DueDate: &dueDate},
+		}
+		mockRepo.On("GetUpcoming", mock.Anything, "test-user-id", 7, 10, 0).Return(upcomingTodos, int64(1), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/upcoming", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.TodoListResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Len(t, response.Todos, 1)
+		assert.Equal(t, "todo-1", response.Todos[0].ID)
+		assert.Equal(t, int64(1), response.Total)
+		assert.False(t, response.HasMore)
+
+		mockRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("days and pagination are passed through", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("GetUpcoming", mock.Anything, "test-user-id", 30, 5, 5).Return([]*models.Todo{}, int64(12), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/upcoming?days=30&limit=5&offset=5", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.TodoListResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, int64(12), response.Total)
+		assert.True(t, response.HasMore)
+		mockRepo.AssertExpectations(t)
+	})
+
+	for _, query := range []string{"days=366", "days=-1", "days=soon", "limit=500"} {
+		t.Run("rejects "+query, func(t *testing.T) {
+			// Arrange
+			handler, mockRepo := setupTodoHandler()
+			app := setupFiberApp(handler)
+
+			// Act
+			resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/upcoming?"+query, nil))
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, 400, resp.StatusCode)
+			mockRepo.AssertNotCalled(t, "GetUpcoming", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("repository error", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("GetUpcoming", mock.Anything, "test-user-id", 7, 10, 0).Return(nil, int64(0), errors.New("database error"))
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/upcoming", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 500, resp.StatusCode)
+	})
+}
+
+func TestTodoHandler_GetRecentTodos(t *testing.T) {
+	t.Run("returns todos in update order", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		now := time.Now().UTC().Truncate(time.Second)
+		recentTodos := []*models.Todo{
+			{ID: "todo-2", UserID: "test-user-id", Title: "Edited just now", Status: models.TodoStatusCompleted, CreatedAt: now.Add(-48 * time.Hour), UpdatedAt: now},
+			{ID: "todo-1", UserID: "test-user-id", Title: "Edited an hour ago", Status: models.TodoStatusPending, CreatedAt: now.Add(-time.Hour), UpdatedAt: now.Add(-time.Hour)},
+		}
+		mockRepo.On("GetRecentlyUpdated", mock.Anything, "test-user-id", 2, 0).Return(recentTodos, int64(3), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/recent?limit=2", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.TodoListResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+
+		assert.Len(t, response.Todos, 2)
+		assert.Equal(t, "todo-2", response.Todos[0].ID)
+		assert.Equal(t, "todo-1", response.Todos[1].ID)
+		assert.True(t, response.Todos[0].UpdatedAt.After(response.Todos[1].UpdatedAt))
+		assert.True(t, response.HasMore)
+
+		mockRepo.AssertNotCalled(t, "GetByUserID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid pagination", func(t *testing.T) {
+		// Arrange
+		handler, _ := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/recent?offset=-1", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+}
+
+func TestTodoHandler_SearchTodos(t *testing.T) {
+	for _, tt := range []struct {
+		query string
+		q     string
+		mode  string
+	}{
+		{"q=milk", "milk", models.SearchModeWords},
+		{"q=buy+milk", "buy milk", models.SearchModeWords},
+		{"q=gro&mode=prefix", "gro", models.SearchModePrefix},
+		{"q=buy+milk&mode=phrase", "buy milk", models.SearchModePhrase},
+	} {
+		t.Run(tt.query, func(t *testing.T) {
+			// Arrange
+			handler, mockRepo := setupTodoHandler()
+			app := setupFiberApp(handler)
+
+			mockRepo.On("Search", mock.Anything, "test-user-id", tt.q, tt.mode, 10, 0).Return([]*models.Todo{}, int64(0), nil)
+
+			// Act
+			resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/search?"+tt.query, nil))
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, 200, resp.StatusCode)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+
+	for _, query := range []string{"q=", "q=+++", "q=%3F%21", "q=milk&mode=fuzzy"} {
+		t.Run("rejects "+query, func(t *testing.T) {
+			// Arrange
+			handler, mockRepo := setupTodoHandler()
+			app := setupFiberApp(handler)
+
+			// Act
+			resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/search?"+query, nil))
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, 400, resp.StatusCode)
+			mockRepo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestTodoHandler_SearchTodos_ConcurrencyLimit(t *testing.T) {
+	// Arrange
+	handler, mockRepo := setupTodoHandler()
+	handler.SetSearchConcurrency(2)
+	app := setupFiberApp(handler)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mockRepo.On("Search", mock.Anything, "test-user-id", "milk", models.SearchModeWords, 10, 0).
+		Run(func(mock.Arguments) {
+			started <- struct{}{}
+			<-release
+		}).
+		Return([]*models.Todo{}, int64(0), nil)
+	mockRepo.On("GetRecentlyUpdated", mock.Anything, "test-user-id", 10, 0).Return([]*models.Todo{}, int64(0), nil)
+
+	// Fill every search slot with a query that blocks until released
+	statuses := make(chan int, 2)
+	for range 2 {
+		go func() {
+			resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/search?q=milk", nil), -1)
+			if err != nil {
+				statuses <- 0
+				return
+			}
+			statuses <- resp.StatusCode
+		}()
+		<-started
+	}
+
+	// Act
+	rejected, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/search?q=milk", nil), -1)
+	assert.NoError(t, err)
+	read, readErr := app.Test(httptest.NewRequest("GET", "/api/v1/todos/recent", nil), -1)
+	close(release)
+
+	// Assert
+	assert.Equal(t, 429, rejected.StatusCode)
+	assert.NoError(t, readErr)
+	assert.Equal(t, 200, read.StatusCode)
+	assert.Equal(t, 200, <-statuses)
+	assert.Equal(t, 200, <-statuses)
+	mockRepo.AssertNumberOfCalls(t, "Search", 2)
+}
+
+func TestTodoHandler_GetDueDistribution(t *testing.T) {
+	t.Run("returns bucket counts", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		distribution := &models.DueDistribution{Overdue: 2, Today: 1, ThisWeek: 3, Later: 4, Undated: 5}
+		mockRepo.On("GetDueDistribution", mock.Anything, "test-user-id", mock.AnythingOfType("models.DueDistributionBounds")).Return(distribution, nil)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos/due-distribution", nil)
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.DueDistribution
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, *distribution, response)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("day bounds follow the timezone header", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		tokyo, _ := time.LoadLocation("Asia/Tokyo")
+		mockRepo.On("GetDueDistribution", mock.Anything, "test-user-id", mock.MatchedBy(func(bounds models.DueDistributionBounds) bool {
+			local := bounds.EndOfToday.In(tokyo)
+			return local.Hour() == 0 && local.Minute() == 0 &&
+				bounds.EndOfToday.After(bounds.Now) &&
+				bounds.EndOfWeek.Equal(bounds.EndOfToday.AddDate(0, 0, 6))
+		})).Return(&models.DueDistribution{}, nil)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos/due-distribution", nil)
+		req.Header.Set("X-Timezone", "Asia/Tokyo")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid timezone", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos/due-distribution", nil)
+		req.Header.Set("X-Timezone", "Mars/Olympus_Mons")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "GetDueDistribution", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("GetDueDistribution", mock.Anything, "test-user-id", mock.Anything).Return(nil, errors.New("database error"))
+
+		req := httptest.NewRequest("GET", "/api/v1/todos/due-distribution", nil)
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 500, resp.StatusCode)
+	})
+}
+
+func TestTodoHandler_GetTodoStatsSummary(t *testing.T) {
+	t.Run("counts, overdue and completion percentage", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("CountByStatus", mock.Anything, "test-user-id").Return(map[string]int64{"pending": 2, "in_progress": 1, "completed": 5}, nil)
+		mockRepo.On("CountOverdue", mock.Anything, "test-user-id", mock.AnythingOfType("models.OverdueCutoff")).Return(int64(1), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/stats/summary", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.TodoStatsResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, models.TodoStatsResponse{
+			Total:                8,
+			Completed:            5,
+			Pending:              2,
+			InProgress:           1,
+			Overdue:              1,
+			CompletionPercentage: 62.5,
+			ByStatus:             map[string]int64{"pending": 2, "in_progress": 1, "completed": 5},
+		}, response)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("no todos", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("CountByStatus", mock.Anything, "test-user-id").Return(map[string]int64{}, nil)
+		mockRepo.On("CountOverdue", mock.Anything, "test-user-id", mock.AnythingOfType("models.OverdueCutoff")).Return(int64(0), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/stats/summary", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response map[string]any
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, float64(0), response["total"])
+		assert.Equal(t, float64(0), response["completionPercentage"])
+	})
+}
+
+func TestTodoHandler_GetInsights(t *testing.T) {
+	t.Run("composes the year in review in the requested timezone", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		// 2025 in Tokyo starts at 15:00 UTC on Dec 31, 2024
+		inYear := mock.MatchedBy(func(window models.CompletionWindow) bool {
+			return window.By == models.CompletionByCreated &&
+				window.Start.Equal(time.Date(2024, 12, 31, 15, 0, 0, 0, time.UTC)) &&
+				window.End.Equal(time.Date(2025, 12, 31, 15, 0, 0, 0, time.UTC))
+		})
+		mockRepo.On("CountCompletion", mock.Anything, "test-user-id", inYear).Return(int64(3), int64(4), nil)
+		mockRepo.On("GetBusiestDay", mock.Anything, "test-user-id", inYear).Return(&models.DayCount{Date: "2025-06-02", Count: 2}, nil)
+		mockRepo.On("CountTopTags", mock.Anything, "test-user-id", inYear, models.MaxInsightTags).Return([]models.TagCount{{Tag: "work", Count: 3}}, nil)
+		mockRepo.On("AverageCompletionTime", mock.Anything, "test-user-id", inYear).Return(90*time.Minute, nil)
+
+		req := httptest.NewRequest("GET", "/api/v1/auth/me/insights?year=2025", nil)
+		req.Header.Set("X-Timezone", "Asia/Tokyo")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.UserInsights
+		json.NewDecoder(resp.Body).Decode(&response)
+		hours := 1.5
+		assert.Equal(t, models.UserInsights{
+			Year:                   2025,
+			Timezone:               "Asia/Tokyo",
+			Created:                4,
+			Completed:              3,
+			CompletionRate:         0.75,
+			BusiestDay:             &models.DayCount{Date: "2025-06-02", Count: 2},
+			TopTags:                []models.TagCount{{Tag: "work", Count: 3}},
+			AverageCompletionHours: &hours,
+		}, response)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("no todos returns empty insights", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("CountCompletion", mock.Anything, "test-user-id", mock.Anything).Return(int64(0), int64(0), nil)
+		mockRepo.On("GetBusiestDay", mock.Anything, "test-user-id", mock.Anything).Return(nil, nil)
+		mockRepo.On("CountTopTags", mock.Anything, "test-user-id", mock.Anything, models.MaxInsightTags).Return([]models.TagCount{}, nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/auth/me/insights", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response map[string]any
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, float64(time.Now().UTC().Year()), response["year"])
+		assert.Equal(t, float64(0), response["completionRate"])
+		assert.Nil(t, response["busiestDay"])
+		assert.Equal(t, []any{}, response["topTags"])
+		assert.Nil(t, response["averageCompletionHours"])
+	})
+
+	t.Run("invalid year", func(t *testing.T) {
+		// Arrange
+		handler, _ := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/auth/me/insights?year=last", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("CountCompletion", mock.Anything, "test-user-id", mock.Anything).Return(int64(0), int64(0), errors.New("database error"))
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/auth/me/insights", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 500, resp.StatusCode)
+	})
+}
+
+func TestTodoHandler_GetCompletionRate(t *testing.T) {
+	t.Run("ratio of done todos in the window", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("CountCompletion", mock.Anything, "test-user-id", mock.MatchedBy(func(window models.CompletionWindow) bool {
+			return window.By == models.CompletionByCreated &&
+				window.Start.Equal(time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)) &&
+				window.End.Equal(time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC))
+		})).Return(int64(3), int64(4), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/completion-rate?from=2025-10-01&to=2025-10-31", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.CompletionRate
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, models.CompletionRate{From: "2025-10-01", To: "2025-10-31", By: "created", Completed: 3, Total: 4, Rate: 0.75}, response)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("no todos in the window gives a zero rate", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("CountCompletion", mock.Anything, "test-user-id", mock.AnythingOfType("models.CompletionWindow")).Return(int64(0), int64(0), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/completion-rate", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response map[string]any
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, float64(0), response["rate"])
+		assert.Equal(t, float64(0), response["total"])
+	})
+
+	t.Run("days follow the timezone header", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("CountCompletion", mock.Anything, "test-user-id", mock.MatchedBy(func(window models.CompletionWindow) bool {
+			return window.By == models.CompletionByDue &&
+				window.Start.Equal(time.Date(2025, 10, 14, 15, 0, 0, 0, time.UTC)) &&
+				window.End.Equal(time.Date(2025, 10, 15, 15, 0, 0, 0, time.UTC)) &&
+				window.StartDate.Equal(time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC)) &&
+				window.EndDate.Equal(time.Date(2025, 10, 16, 0, 0, 0, 0, time.UTC))
+		})).Return(int64(1), int64(2), nil)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos/completion-rate?from=2025-10-15&to=2025-10-15&by=due", nil)
+		req.Header.Set("X-Timezone", "Asia/Tokyo")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"from=2025-10-31&to=2025-10-01", "from=yesterday", "to=10/31/2025", "by=updated"} {
+			// Arrange
+			handler, mockRepo := setupTodoHandler()
+			app := setupFiberApp(handler)
+
+			// Act
+			resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/completion-rate?"+query, nil))
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, 400, resp.StatusCode, query)
+			mockRepo.AssertNotCalled(t, "CountCompletion", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestTodoHandler_GetTodo_TotalCount(t *testing.T) {
+	todo := &models.Todo{ID: "todo-1", UserID: "test-user-id", Title: "Test Todo", Status: models.TodoStatusPending}
+
+	t.Run("header is omitted by default", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(todo, nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/todo-1", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("X-Total-Count"))
+		mockRepo.AssertNotCalled(t, "CountByUserID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("header reports the user's total when requested", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(todo, nil)
+		mockRepo.On("CountByUserID", mock.Anything, "test-user-id").Return(int64(42), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/todo-1?withTotal=true", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "42", resp.Header.Get("X-Total-Count"))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("todo owned by another user is not counted", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(nil, interfaces.ErrTodoNotFound)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/todo-1?withTotal=true", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("X-Total-Count"))
+		mockRepo.AssertNotCalled(t, "CountByUserID", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTodoHandler_Tags(t *testing.T) {
+	t.Run("tags are normalized on create", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(todo *models.Todo) bool {
+			return assert.ObjectsAreEqual([]string{"work", "urgent"}, todo.Tags)
+		})).Return(&models.Todo{ID: "todo-1", UserID: "test-user-id", Title: "Test Todo", Tags: []string{"work", "urgent"}}, nil)
+
+		body := `{"title": "Test Todo", "tags": ["Work", " urgent ", "WORK"]}`
+		req := httptest.NewRequest("POST", "/api/v1/todos", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 201, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("filter by tag", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		taggedTodos := []*models.Todo{
+			{ID: "todo-1", UserID: "test-user-id", Title: "Report", Status: models.TodoStatusPending, Tags: []string{"work"}},
+		}
+		mockRepo.On("GetFiltered", mock.Anything, "test-user-id", models.TodoFilter{Tag: "work"}, mock.Anything, 10, 0).Return(taggedTodos, int64(1), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?tag=work", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.TodoListResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Len(t, response.Todos, 1)
+		assert.Equal(t, []string{"work"}, response.Todos[0].Tags)
+		mockRepo.AssertNotCalled(t, "GetByUserID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("tag is normalized like stored tags", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("GetFiltered", mock.Anything, "test-user-id", models.TodoFilter{Tag: "work"}, mock.Anything, 10, 0).Return([]*models.Todo{}, int64(0), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?tag=%20Work%20", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("case-insensitive tag filter when configured", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		handler.SetTagCaseInsensitive(true)
+		app := setupFiberApp(handler)
+
+		taggedTodos := []*models.Todo{
+			{ID: "todo-1", UserID: "test-user-id", Title: "Report", Status: models.TodoStatusPending, Tags: []string{"work"}},
+		}
+		mockRepo.On("GetFiltered", mock.Anything, "test-user-id", models.TodoFilter{Tag: "work", TagCaseInsensitive: true}, mock.Anything, 10, 0).Return(taggedTodos, int64(1), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?tag=Work", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.TodoListResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Len(t, response.Todos, 1)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown tag returns an empty list", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("GetFiltered", mock.Anything, "test-user-id", models.TodoFilter{Tag: "nothing"}, mock.Anything, 10, 0).Return([]*models.Todo{}, int64(0), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?tag=nothing", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response map[string]any
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, []any{}, response["todos"])
+		assert.Equal(t, float64(0), response["total"])
+	})
+
+	t.Run("too many tags", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		tags := make([]string, 21)
+		for i := range tags {
+			tags[i] = fmt.Sprintf("tag-%d", i)
+		}
+		body, _ := json.Marshal(models.CreateTodoRequest{Title: "Test Todo", Tags: tags})
+		req := httptest.NewRequest("POST", "/api/v1/todos", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTodoHandler_GetTodo_CacheHeaders(t *testing.T) {
+	updatedAt := time.Date(2025, 10, 1, 9, 30, 0, 0, time.UTC)
+
+	t.Run("done todo is cacheable", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(&models.Todo{
+			ID:        "todo-1",
+			UserID:    "test-user-id",
+			Title:     "Done",
+			Status:    models.TodoStatusCompleted,
+			UpdatedAt: updatedAt,
+		}, nil)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos/todo-1", nil)
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "private, max-age=3600", resp.Header.Get("Cache-Control"))
+		assert.Equal(t, "Wed, 01 Oct 2025 09:30:00 GMT", resp.Header.Get("Last-Modified"))
+	})
+
+	t.Run("unchanged done todo returns not modified", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(&models.Todo{
+			ID:        "todo-1",
+			UserID:    "test-user-id",
+			Title:     "Done",
+			Status:    models.TodoStatusCompleted,
+			UpdatedAt: updatedAt,
+		}, nil)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos/todo-1", nil)
+		req.Header.Set("If-Modified-Since", "Wed, 01 Oct 2025 09:30:00 GMT")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 304, resp.StatusCode)
+	})
+
+	t.Run("open todo is not cached", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(&models.Todo{
+			ID:        "todo-1",
+			UserID:    "test-user-id",
+			Title:     "Open",
+			Status:    models.TodoStatusPending,
+			UpdatedAt: updatedAt,
+		}, nil)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos/todo-1", nil)
+		req.Header.Set("If-Modified-Since", "Wed, 01 Oct 2025 09:30:00 GMT")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+		assert.Empty(t, resp.Header.Get("Last-Modified"))
+	})
+
+	t.Run("list endpoints are not cached", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("GetFiltered", mock.Anything, "test-user-id", models.TodoFilter{}, mock.Anything, 10, 0).Return([]*models.Todo{}, int64(0), nil)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos", nil)
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+	})
+}
+
+func TestTodoHandler_BulkRescheduleTodos(t *testing.T) {
+	t.Run("shift mode", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		ids := []string{"todo-1", "todo-2"}
+		mockRepo.On("BulkReschedule", mock.Anything, "test-user-id", ids, (*time.Time)(nil), 48*time.Hour).Return(int64(2), nil)
+
+		body, _ := json.Marshal(map[string]any{"ids": ids, "shift": "48h"})
+		req := httptest.NewRequest("POST", "/api/v1/todos/bulk-reschedule", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.BulkUpdateResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, int64(2), response.Updated)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("absolute mode", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		dueDate := time.Date(2025, 11, 1, 17, 0, 0, 0, time.UTC)
+		mockRepo.On("BulkReschedule", mock.Anything, "test-user-id", []string{"todo-1"}, mock.MatchedBy(func(d *time.Time) bool {
+			return d != nil && d.Equal(dueDate)
+		}), time.Duration(0)).Return(int64(1), nil)
+
+		body := `{"ids":["todo-1"],"dueDate":"2025-11-01T17:00:00Z"}`
+		req := httptest.NewRequest("POST", "/api/v1/todos/bulk-reschedule", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		tooMany := make([]string, 101)
+		for i := range tooMany {
+			tooMany[i] = fmt.Sprintf("todo-%d", i)
+		}
+		tooManyBody, _ := json.Marshal(map[string]any{"ids": tooMany, "shift": "1h"})
+
+		tests := []struct {
+			name string
+			body string
+		}{
+			{"no ids", `{"ids":[],"shift":"48h"}`},
+			{"neither shift nor due date", `{"ids":["todo-1"]}`},
+			{"both shift and due date", `{"ids":["todo-1"],"shift":"48h","dueDate":"2025-11-01T17:00:00Z"}`},
+			{"unparseable shift", `{"ids":["todo-1"],"shift":"two days"}`},
+			{"zero shift", `{"ids":["todo-1"],"shift":"0s"}`},
+			{"batch too large", string(tooManyBody)},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// Arrange
+				handler, mockRepo := setupTodoHandler()
+				app := setupFiberApp(handler)
+
+				req := httptest.NewRequest("POST", "/api/v1/todos/bulk-reschedule", strings.NewReader(tt.body))
+				req.Header.Set("Content-Type", "application/json")
+
+				// Act
+				resp, err := app.Test(req)
+
+				// Assert
+				assert.NoError(t, err)
+				assert.Equal(t, 400, resp.StatusCode)
+				mockRepo.AssertNotCalled(t, "BulkReschedule", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			})
+		}
+	})
+}
+
+func TestTodoHandler_BulkUpdatePriority(t *testing.T) {
+	dueFrom := time.Date(2025, 10, 13, 0, 0, 0, 0, time.UTC)
+	dueBefore := dueFrom.AddDate(0, 0, 7)
+
+	send := func(app *fiber.App, body string) (*http.Response, error) {
+		req := httptest.NewRequest("POST", "/api/v1/todos/bulk-priority", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return app.Test(req)
+	}
+
+	t.Run("dry run counts this week's pending todos without changing them", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		filter := models.TodoFilter{Status: models.TodoStatusPending, Tag: "work", DueFrom: &dueFrom, DueBefore: &dueBefore}
+		mockRepo.On("BulkUpdatePriority", mock.Anything, "test-user-id", filter, models.TodoPriorityHigh, true).Return(int64(4), nil)
+
+		// Act
+		resp, err := send(app, `{"filter": {"status": "pending", "tag": " work ", "dueFrom": "2025-10-13T00:00:00Z", "dueBefore": "2025-10-20T00:00:00Z"}, "priority": "high", "dryRun": true}`)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.BulkPriorityResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.True(t, response.DryRun)
+		assert.Equal(t, int64(4), response.Matched)
+		assert.Equal(t, int64(0), response.Updated)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("updates the matching todos", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		filter := models.TodoFilter{Status: models.TodoStatusPending}
+		mockRepo.On("BulkUpdatePriority", mock.Anything, "test-user-id", filter, models.TodoPriorityHigh, false).Return(int64(2), nil)
+
+		// Act
+		resp, err := send(app, `{"filter": {"status": "pending"}, "priority": "high"}`)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.BulkPriorityResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.False(t, response.DryRun)
+		assert.Equal(t, int64(2), response.Updated)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects invalid input", func(t *testing.T) {
+		bodies := map[string]string{
+			"unknown priority": `{"filter": {"status": "pending"}, "priority": "urgent"}`,
+			"missing priority": `{"filter": {"status": "pending"}, "dryRun": true}`,
+			"unknown status":   `{"filter": {"status": "someday"}, "priority": "high"}`,
+			"empty due range":  `{"filter": {"dueFrom": "2025-10-20T00:00:00Z", "dueBefore": "2025-10-13T00:00:00Z"}, "priority": "high", "dryRun": true}`,
+		}
+
+		for name, body := range bodies {
+			t.Run(name, func(t *testing.T) {
+				// Arrange
+				handler, mockRepo := setupTodoHandler()
+				app := setupFiberApp(handler)
+
+				// Act
+				resp, err := send(app, body)
+
+				// Assert
+				assert.NoError(t, err)
+				assert.Equal(t, 400, resp.StatusCode)
+				mockRepo.AssertNotCalled(t, "BulkUpdatePriority", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			})
+		}
+	})
+}
+
+func TestTodoHandler_BulkPatchTodos(t *testing.T) {
+	ids := []string{"todo-1", "todo-2"}
+
+	send := func(app *fiber.App, body string) (*http.Response, error) {
+		req := httptest.NewRequest("PATCH", "/api/v1/todos/bulk", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return app.Test(req)
+	}
+
+	t.Run("applies several fields at once", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		dueDate := time.Date(2025, 10, 20, 17, 0, 0, 0, time.UTC)
+		mockRepo.On("BulkPatch", mock.Anything, "test-user-id", ids, mock.MatchedBy(func(patch *models.PatchTodoRequest) bool {
+			return *patch.Status == models.TodoStatusInProgress &&
+				*patch.Priority == models.TodoPriorityHigh &&
+				assert.ObjectsAreEqual([]string{"work", "urgent"}, patch.Tags) &&
+				patch.DueDate.Set && patch.DueDate.Value.Equal(dueDate) &&
+				patch.Title == nil && patch.AllDay == nil
+		}), false).Return(int64(2), nil)
+
+		// Act
+		resp, err := send(app, `{"ids": ["todo-1", "todo-2"], "patch": {"status": "in_progress", "priority": "high", "tags": [" Work ", "urgent", "work"], "dueDate": "2025-10-20T17:00:00Z"}}`)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.BulkPatchResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, int64(2), response.Updated)
+		assert.False(t, response.DryRun)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("clears due dates and tags", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("BulkPatch", mock.Anything, "test-user-id", ids, mock.MatchedBy(func(patch *models.PatchTodoRequest) bool {
+			return patch.DueDate.Set && patch.DueDate.Value == nil &&
+				patch.Tags != nil && len(patch.Tags) == 0 &&
+				patch.Status == nil && patch.Priority == nil
+		}), false).Return(int64(2), nil)
+
+		// Act
+		resp, err := send(app, `{"ids": ["todo-1", "todo-2"], "patch": {"dueDate": null, "tags": []}}`)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("dry run counts the matching todos", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("BulkPatch", mock.Anything, "test-user-id", ids, mock.AnythingOfType("*models.PatchTodoRequest"), true).Return(int64(1), nil)
+
+		// Act
+		resp, err := send(app, `{"ids": ["todo-1", "todo-2"], "patch": {"status": "completed", "priority": "low"}, "dryRun": true}`)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.BulkPatchResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.True(t, response.DryRun)
+		assert.Equal(t, int64(1), response.Matched)
+		assert.Equal(t, int64(0), response.Updated)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects invalid input", func(t *testing.T) {
+		bodies := map[string]string{
+			"empty patch":      `{"ids": ["todo-1"], "patch": {}}`,
+			"missing ids":      `{"patch": {"status": "completed"}}`,
+			"unknown status":   `{"ids": ["todo-1"], "patch": {"status": "someday"}}`,
+			"unknown priority": `{"ids": ["todo-1"], "patch": {"status": "completed", "priority": "urgent"}}`,
+			"invalid due date": `{"ids": ["todo-1"], "patch": {"dueDate": "next week"}}`,
+		}
+
+		for name, body := range bodies {
+			t.Run(name, func(t *testing.T) {
+				// Arrange
+				handler, mockRepo := setupTodoHandler()
+				app := setupFiberApp(handler)
+
+				// Act
+				resp, err := send(app, body)
+
+				// Assert
+				assert.NoError(t, err)
+				assert.Equal(t, 400, resp.StatusCode)
+				mockRepo.AssertNotCalled(t, "BulkPatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			})
+		}
+	})
+}
+
+func TestTodoHandler_ValidateTodos(t *testing.T) {
+	t.Run("reports per-item results without creating todos", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		body := `[
+			{"title": "Valid todo", "priority": "high", "dueDateText": "tomorrow 5pm"},
+			{"title": "", "priority": "urgent"},
+			{"title": "Unparseable due date", "dueDateText": "whenever"}
+		]`
+		req := httptest.NewRequest("POST", "/api/v1/todos/validate", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.ValidateTodosResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+
+		assert.Equal(t, 1, response.Valid)
+		assert.Equal(t, 2, response.Invalid)
+		assert.Len(t, response.Results, 3)
+
+		assert.Equal(t, models.TodoValidationResult{Index: 0, Valid: true}, response.Results[0])
+
+		assert.Equal(t, 1, response.Results[1].Index)
+		assert.False(t, response.Results[1].Valid)
+		assert.ElementsMatch(t, []models.FieldError{
+			{Field: "title", Rule: "required"},
+			{Field: "priority", Rule: "todo_priority"},
+		}, response.Results[1].Errors)
+
+		assert.False(t, response.Results[2].Valid)
+		assert.Equal(t, []models.FieldError{{Field: "dueDateText", Rule: "natural_date"}}, response.Results[2].Errors)
+
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("reports rule parameters", func(t *testing.T) {
+		// Arrange
+		handler, _ := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		body, _ := json.Marshal([]models.CreateTodoRequest{{Title: strings.Repeat("a", 201)}})
+		req := httptest.NewRequest("POST", "/api/v1/todos/validate", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.ValidateTodosResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, []models.FieldError{{Field: "title", Rule: "max", Param: "200"}}, response.Results[0].Errors)
+	})
+
+	t.Run("rejects an empty batch", func(t *testing.T) {
+		// Arrange
+		handler, _ := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		req := httptest.NewRequest("POST", "/api/v1/todos/validate", strings.NewReader(`[]`))
+		req.Header.Set("Content-Type", "application/json")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("rejects a body that is not an array", func(t *testing.T) {
+		// Arrange
+		handler, _ := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		req := httptest.NewRequest("POST", "/api/v1/todos/validate", strings.NewReader(`{"title": "Single todo"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+}
+
+func TestTodoHandler_UpdateTodoStatus_Inputs(t *testing.T) {
+	t.Run("status in body", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("UpdateStatus", mock.Anything, "test-user-id", "todo-1", models.TodoStatusCompleted).Return(nil)
+
+		req := httptest.NewRequest("PATCH", "/api/v1/todos/todo-1/status", strings.NewReader(`{"status":"completed"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("status in query without body", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("UpdateStatus", mock.Anything, "test-user-id", "todo-1", models.TodoStatusCompleted).Return(nil)
+
+		req := httptest.NewRequest("PATCH", "/api/v1/todos/todo-1/status?to=completed", nil)
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid status in query", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		req := httptest.NewRequest("PATCH", "/api/v1/todos/todo-1/status?to=archived", nil)
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("todo owned by another user", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		// The repository only updates the caller's own todos
+		mockRepo.On("UpdateStatus", mock.Anything, "test-user-id", "other-users-todo", models.TodoStatusCompleted).Return(interfaces.ErrTodoNotFound)
+
+		req := httptest.NewRequest("PATCH", "/api/v1/todos/other-users-todo/status?to=completed", nil)
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("no body and no query", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		req := httptest.NewRequest("PATCH", "/api/v1/todos/todo-1/status", nil)
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+
+		var response map[string]any
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, "Status is required in the request body or the to query parameter", response["message"])
+		mockRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestTodoHandler_AllDayDueDates(t *testing.T) {
+	midnight := time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	createTodo := func(app *fiber.App, reqBody models.CreateTodoRequest) int {
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest("POST", "/api/v1/todos", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	t.Run("allDay keeps only the day of the due date", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		afternoon := time.Date(2030, 1, 2, 15, 0, 0, 0, time.FixedZone("UTC+5", 5*60*60))
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(todo *models.Todo) bool {
+			return todo.AllDay && todo.DueDate != nil && todo.DueDate.Equal(midnight)
+		})).Return(&models.Todo{ID: "todo-1", UserID: "test-user-id", Title: "Test Todo"}, nil)
+
+		// Act
+		status := createTodo(app, models.CreateTodoRequest{Title: "Test Todo", DueDate: &afternoon, AllDay: true})
+
+		// Assert
+		assert.Equal(t, 201, status)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("midnight due date is all-day when enabled", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		handler.SetEndOfDayDueDates(true)
+		app := setupFiberApp(handler)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(todo *models.Todo) bool {
+			return todo.AllDay && todo.DueDate != nil && todo.DueDate.Equal(midnight)
+		})).Return(&models.Todo{ID: "todo-1", UserID: "test-user-id", Title: "Test Todo"}, nil)
+
+		// Act
+		status := createTodo(app, models.CreateTodoRequest{Title: "Test Todo", DueDate: &midnight})
+
+		// Assert
+		assert.Equal(t, 201, status)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("midnight due date stays timed when disabled", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(todo *models.Todo) bool {
+			return !todo.AllDay && todo.DueDate != nil && todo.DueDate.Equal(midnight)
+		})).Return(&models.Todo{ID: "todo-1", UserID: "test-user-id", Title: "Test Todo"}, nil)
+
+		// Act
+		status := createTodo(app, models.CreateTodoRequest{Title: "Test Todo", DueDate: &midnight})
+
+		// Assert
+		assert.Equal(t, 201, status)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("allDay on update keeps only the day of the existing due date", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		afternoon := time.Date(2030, 1, 2, 15, 0, 0, 0, time.UTC)
+		allDay := true
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(&models.Todo{ID: "todo-1", UserID: "test-user-id", Title: "Test Todo", DueDate: &afternoon}, nil)
+		mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(todo *models.Todo) bool {
+			return todo.AllDay && todo.DueDate != nil && todo.DueDate.Equal(midnight)
+		})).Return(&models.Todo{ID: "todo-1", UserID: "test-user-id", Title: "Test Todo"}, nil)
+
+		body, _ := json.Marshal(models.UpdateTodoRequest{AllDay: &allDay})
+		req := httptest.NewRequest("PUT", "/api/v1/todos/todo-1", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestTodoHandler_Subtasks(t *testing.T) {
+	ownedTodo := func() *models.Todo {
+		return &models.Todo{
+			ID:       "todo-1",
+			UserID:   "test-user-id",
+			Title:    "Pack for trip",
+			Subtasks: []models.Subtask{{Title: "Passport"}, {Title: "Charger"}},
+		}
+	}
+
+	send := func(app *fiber.App, method, path string, body interface{}) *http.Response {
+		var reader *bytes.Reader
+		if body != nil {
+			payload, _ := json.Marshal(body)
+			reader = bytes.NewReader(payload)
+		} else {
+			reader = bytes.NewReader(nil)
+		}
+		req := httptest.NewRequest(method, path, reader)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	t.Run("add appends a subtask", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(ownedTodo(), nil)
+		mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(todo *models.Todo) bool {
+			return len(todo.Subtasks) == 3 && todo.Subtasks[2] == models.Subtask{Title: "Sunscreen"}
+		})).Return(ownedTodo(), nil)
+
+		// Act
+		resp := send(app, "POST", "/api/v1/todos/todo-1/subtasks", models.CreateSubtaskRequest{Title: "Sunscreen"})
+
+		// Assert
+		assert.Equal(t, 201, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("update marks a subtask done", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		done := true
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(ownedTodo(), nil)
+		mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(todo *models.Todo) bool {
+			return todo.Subtasks[1] == models.Subtask{Title: "Charger", Done: true} && !todo.Subtasks[0].Done
+		})).Return(ownedTodo(), nil)
+
+		// Act
+		resp := send(app, "PATCH", "/api/v1/todos/todo-1/subtasks/1", models.UpdateSubtaskRequest{Done: &done})
+
+		// Assert
+		assert.Equal(t, 200, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("delete removes a subtask", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(ownedTodo(), nil)
+		mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(todo *models.Todo) bool {
+			return len(todo.Subtasks) == 1 && todo.Subtasks[0].Title == "Charger"
+		})).Return(ownedTodo(), nil)
+
+		// Act
+		resp := send(app, "DELETE", "/api/v1/todos/todo-1/subtasks/0", nil)
+
+		// Assert
+		assert.Equal(t, 200, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("out-of-range index returns 404", func(t *testing.T) {
+		for _, path := range []string{"/api/v1/todos/todo-1/subtasks/2", "/api/v1/todos/todo-1/subtasks/-1"} {
+			// Arrange
+			handler, mockRepo := setupTodoHandler()
+			app := setupFiberApp(handler)
+			mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(ownedTodo(), nil)
+
+			// Act
+			resp := send(app, "DELETE", path, nil)
+
+			// Assert
+			assert.Equal(t, 404, resp.StatusCode, path)
+			mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("non-numeric index returns 400", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		// Act
+		resp := send(app, "DELETE", "/api/v1/todos/todo-1/subtasks/first", nil)
+
+		// Assert
+		assert.Equal(t, 400, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "GetByIDForUser", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("another user's todo returns 404", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(nil, interfaces.ErrTodoNotFound)
+
+		// Act
+		resp := send(app, "POST", "/api/v1/todos/todo-1/subtasks", models.CreateSubtaskRequest{Title: "Sunscreen"})
+
+		// Assert
+		assert.Equal(t, 404, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("full checklist is rejected", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		fullTodo := ownedTodo()
+		fullTodo.Subtasks = make([]models.Subtask, models.MaxSubtasks)
+		mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").Return(fullTodo, nil)
+
+		// Act
+		resp := send(app, "POST", "/api/v1/todos/todo-1/subtasks", models.CreateSubtaskRequest{Title: "Sunscreen"})
+
+		// Assert
+		assert.Equal(t, 400, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+// countingWriter records how many bytes have been written through it
+type countingWriter struct {
+	written int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.written += len(p)
+	return len(p), nil
+}
+
+func TestTodoHandler_ExportTodos(t *testing.T) {
+	const total = 10000
+
+	// streamTodos makes the mock call fn with total freshly built todos, one at a time
+	streamTodos := func(onEach func(i int)) func(args mock.Arguments) {
+		return func(args mock.Arguments) {
+			fn := args.Get(2).(func(*models.Todo) error)
+			for i := 0; i < total; i++ {
+				todo := &models.Todo{ID: fmt.Sprintf("todo-%d", i), UserID: "test-user-id", Title: fmt.Sprintf("Todo %d", i), Status: "pending"}
+				if err := fn(todo); err != nil {
+					return
+				}
+				if onEach != nil {
+					onEach(i)
+				}
+			}
+		}
+	}
+
+	t.Run("exports every todo as a JSON attachment", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		mockRepo.On("StreamByUserID", mock.Anything, "test-user-id", mock.Anything).Run(streamTodos(nil)).Return(nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/export", nil), -1)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, `attachment; filename="todos.json"`, resp.Header.Get("Content-Disposition"))
+
+		var todos []models.Todo
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&todos))
+		assert.Len(t, todos, total)
+		assert.Equal(t, "todo-0", todos[0].ID)
+		assert.Equal(t, fmt.Sprintf("todo-%d", total-1), todos[total-1].ID)
+		mockRepo.AssertNotCalled(t, "GetByUserID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("writes incrementally instead of buffering the export", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		out := &countingWriter{}
+		w := bufio.NewWriter(out)
+		var writtenMidway int
+		mockRepo.On("StreamByUserID", mock.Anything, "test-user-id", mock.Anything).Run(streamTodos(func(i int) {
+			if i == total/2 {
+				writtenMidway = out.written
+			}
+		})).Return(nil)
+
+		// Act
+		handler.writeTodoExport(context.Background(), w, "test-user-id")
+
+		// Assert - by the halfway point most of the first half has left the buffer
+		assert.Greater(t, writtenMidway, out.written/2-w.Size()-1)
+		assert.Less(t, writtenMidway, out.written)
+	})
+
+	t.Run("streams with the request logger mounted", func(t *testing.T) {
+		// Arrange - the export pauses after its first flush until the client has seen it
+		handler, mockRepo := setupTodoHandler()
+		app := fiber.New()
+		app.Use(middleware.RequestLogger(config.NewTestLogger()))
+		handler.RegisterRoutes(app.Group("/api/v1"), func(c *fiber.Ctx) error {
+			c.Locals("userID", "test-user-id")
+			return c.Next()
+		})
+		release := make(chan struct{})
+		var releaseOnce sync.Once
+		mockRepo.On("StreamByUserID", mock.Anything, "test-user-id", mock.Anything).Run(streamTodos(func(i int) {
+			if i == exportFlushInterval-1 {
+				<-release
+			}
+		})).Return(nil)
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		go app.Listener(ln)
+		defer app.Shutdown()
+		defer releaseOnce.Do(func() { close(release) })
+
+		// Act
+		started := make(chan *http.Response, 1)
+		go func() {
+			resp, err := http.Get("http://" + ln.Addr().String() + "/api/v1/todos/export")
+			if err == nil {
+				_, err = resp.Body.Read(make([]byte, 1))
+			}
+			assert.NoError(t, err)
+			started <- resp
+		}()
+
+		// Assert
+		var resp *http.Response
+		select {
+		case resp = <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("export was buffered before being sent")
+		}
+		require.NotNil(t, resp)
+		defer resp.Body.Close()
+		releaseOnce.Do(func() { close(release) })
+		rest, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasSuffix(rest, []byte("]")))
+	})
+
+	t.Run("empty export is an empty array", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		mockRepo.On("StreamByUserID", mock.Anything, "test-user-id", mock.Anything).Return(nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/export", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		body := new(bytes.Buffer)
+		body.ReadFrom(resp.Body)
+		assert.Equal(t, "[]", body.String())
+	})
+}
+
+func TestTodoHandler_ExportTodosCSV(t *testing.T) {
+	exportCSV := func(app *fiber.App) (*http.Response, [][]string) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/export.csv", nil))
+		assert.NoError(t, err)
+
+		records, err := csv.NewReader(resp.Body).ReadAll()
+		assert.NoError(t, err)
+		return resp, records
+	}
+
+	t.Run("quotes commas, quotes and newlines", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		dueDate := time.Date(2025, 10, 15, 17, 0, 0, 0, time.FixedZone("EDT", -4*60*60))
+		createdAt := time.Date(2025, 10, 1, 9, 30, 0, 0, time.UTC)
+		todos := []*models.Todo{
+			{ID: "todo-1", Title: "Buy milk, eggs", Description: "Line one\nSay \"please\"", Status: "pending", Priority: "high", DueDate: &dueDate, CreatedAt: createdAt},
+			{ID: "todo-2", Title: "Undated", Status: "done", Priority: "low", CreatedAt: createdAt},
+		}
+		mockRepo.On("StreamByUserID", mock.Anything, "test-user-id", mock.Anything).Run(func(args mock.Arguments) {
+			fn := args.Get(2).(func(*models.Todo) error)
+			for _, todo := range todos {
+				fn(todo)
+			}
+		}).Return(nil)
+
+		// Act
+		resp, records := exportCSV(app)
+
+		// Assert
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="todos.csv"`, resp.Header.Get("Content-Disposition"))
+		assert.Equal(t, [][]string{
+			{"id", "title", "description", "status", "priority", "dueDate", "createdAt"},
+			{"todo-1", "Buy milk, eggs", "Line one\nSay \"please\"", "pending", "high", "2025-10-15T21:00:00Z", "2025-10-01T09:30:00Z"},
+			{"todo-2", "Undated", "", "done", "low", "", "2025-10-01T09:30:00Z"},
+		}, records)
+	})
+
+	t.Run("no todos gives just the header row", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		mockRepo.On("StreamByUserID", mock.Anything, "test-user-id", mock.Anything).Return(nil)
+
+		// Act
+		resp, records := exportCSV(app)
+
+		// Assert
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, [][]string{{"id", "title", "description", "status", "priority", "dueDate", "createdAt"}}, records)
+	})
+}
+
+func TestTodoHandler_GetAgenda(t *testing.T) {
+	newYork, _ := time.LoadLocation("America/New_York")
+	overdueDue := time.Date(2025, 10, 13, 21, 0, 0, 0, time.UTC)
+	allDayDue := time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC)
+	morningDue := time.Date(2025, 10, 15, 13, 30, 0, 0, time.UTC)
+	lateDue := time.Date(2025, 10, 16, 3, 30, 0, 0, time.UTC) // 23:30 on Oct 15 in New York
+
+	// Returned out of order so the test sees the agenda sort them
+	overdue := []*models.Todo{{ID: "overdue-1", Title: "Pay rent", Priority: "high", DueDate: &overdueDue}}
+	due := []*models.Todo{
+		{ID: "late", Title: "Call home", Priority: "medium", DueDate: &lateDue},
+		{ID: "standup", Title: "Standup", Priority: "high", DueDate: &morningDue},
+		{ID: "groceries", Title: "Groceries", Priority: "medium", DueDate: &allDayDue, AllDay: true},
+	}
+
+	// setupAgenda expects the agenda for Oct 15 in New York, which starts at 04:00 UTC
+	setupAgenda := func() *fiber.App {
+		handler, mockRepo := setupTodoHandler()
+		mockRepo.On("GetOverdue", mock.Anything, "test-user-id", mock.MatchedBy(func(cutoff models.OverdueCutoff) bool {
+			return cutoff.Now.Equal(time.Date(2025, 10, 15, 4, 0, 0, 0, time.UTC)) && cutoff.Today.Equal(allDayDue)
+		}), models.MaxAgendaOverdue, 0).Return(overdue, int64(3), nil)
+		mockRepo.On("GetDueOn", mock.Anything, "test-user-id", mock.MatchedBy(func(day models.DayRange) bool {
+			return day.Start.Equal(time.Date(2025, 10, 15, 4, 0, 0, 0, time.UTC)) &&
+				day.End.Equal(time.Date(2025, 10, 16, 4, 0, 0, 0, time.UTC)) &&
+				day.Date.Equal(allDayDue)
+		})).Return(slices.Clone(due), nil)
+		return setupFiberApp(handler)
+	}
+
+	getAgenda := func(app *fiber.App, accept string) *http.Response {
+		req := httptest.NewRequest("GET", "/api/v1/todos/agenda?date=2025-10-15", nil)
+		req.Header.Set("X-Timezone", newYork.String())
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	t.Run("JSON orders by priority then time within the local day", func(t *testing.T) {
+		// Arrange
+		app := setupAgenda()
+
+		// Act
+		resp := getAgenda(app, "")
+
+		// Assert
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var agenda models.Agenda
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&agenda))
+		assert.Equal(t, "2025-10-15", agenda.Date)
+		assert.Equal(t, int64(3), agenda.OverdueTotal)
+		assert.Len(t, agenda.Overdue, 1)
+
+		ids := make([]string, len(agenda.Due))
+		for i, todo := range agenda.Due {
+			ids[i] = todo.ID
+		}
+		assert.Equal(t, []string{"standup", "groceries", "late"}, ids)
+	})
+
+	t.Run("plain text for printing", func(t *testing.T) {
+		// Arrange
+		app := setupAgenda()
+
+		// Act
+		resp := getAgenda(app, "text/plain")
+
+		// Assert
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+
+		body := new(bytes.Buffer)
+		body.ReadFrom(resp.Body)
+		assert.Equal(t, "Agenda for Wednesday, October 15, 2025\n"+
+			"\n"+
+			"Overdue (3)\n"+
+			"  [ ] Pay rent [high] (due Mon Oct 13 17:00)\n"+
+			"  ...and 2 more\n"+
+			"\n"+
+			"Due (3)\n"+
+			"  [ ] 09:30   Standup [high]\n"+
+			"  [ ] all day Groceries [medium]\n"+
+			"  [ ] 23:30   Call home [medium]\n", body.String())
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos/agenda?date=15-10-2025", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "GetDueOn", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestTodoHandler_ImportTodos(t *testing.T) {
+	importTodos := func(app *fiber.App, body interface{}) (*http.Response, models.ImportTodosResponse) {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", "/api/v1/todos/import", bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+
+		var response models.ImportTodosResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+		return resp, response
+	}
+
+	t.Run("creates the valid items and reports the invalid ones", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		mockRepo.On("CreateBatch", mock.Anything, mock.MatchedBy(func(todos []*models.Todo) bool {
+			return len(todos) == 2 && todos[0].Title == "First" && todos[1].Title == "Third" &&
+				todos[1].UserID == "test-user-id" && slices.Equal(todos[1].Tags, []string{"work"})
+		})).Return([]*models.Todo{{ID: "todo-1"}, {ID: "todo-3"}}, nil)
+
+		// Act
+		resp, response := importTodos(app, []models.CreateTodoRequest{
+			{Title: "First"},
+			{Title: ""},
+			{Title: "Third", Tags: []string{" Work "}},
+		})
+
+		// Assert
+		assert.Equal(t, 201, resp.StatusCode)
+		assert.Equal(t, 2, response.Created)
+		assert.Equal(t, 1, response.Invalid)
+		assert.Equal(t, models.TodoImportResult{Index: 0, Created: true, ID: "todo-1"}, response.Results[0])
+		assert.False(t, response.Results[1].Created)
+		assert.Equal(t, "title", response.Results[1].Errors[0].Field)
+		assert.Equal(t, models.TodoImportResult{Index: 2, Created: true, ID: "todo-3"}, response.Results[2])
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("nothing valid creates nothing", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		// Act
+		resp, response := importTodos(app, []models.CreateTodoRequest{{Title: ""}, {Title: "Soon", DueDateText: "whenever"}})
+
+		// Assert
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, 2, response.Invalid)
+		assert.Equal(t, "dueDateText", response.Results[1].Errors[0].Field)
+		mockRepo.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything)
+	})
+
+	// largeImport returns count valid create requests
+	largeImport := func(count int) []models.CreateTodoRequest {
+		reqs := make([]models.CreateTodoRequest, count)
+		for i := range reqs {
+			reqs[i].Title = fmt.Sprintf("Todo %d", i)
+		}
+		return reqs
+	}
+
+	// onChunk expects CreateBatch to be called with chunks of size todos, creating them
+	onChunk := func(mockRepo *mocks.MockTodoRepository, size int) *mock.Call {
+		created := make([]*models.Todo, size)
+		for i := range created {
+			created[i] = &models.Todo{ID: fmt.Sprintf("todo-%d", i)}
+		}
+		return mockRepo.On("CreateBatch", mock.Anything, mock.MatchedBy(func(todos []*models.Todo) bool {
+			return len(todos) == size
+		})).Return(created, nil)
+	}
+
+	t.Run("large arrays are inserted in chunks", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		handler.SetImportLimits(5000, 100)
+		app := setupFiberApp(handler)
+		onChunk(mockRepo, 100).Times(20)
+		onChunk(mockRepo, 50).Once()
+
+		// Act
+		resp, response := importTodos(app, largeImport(2050))
+
+		// Assert
+		assert.Equal(t, 201, resp.StatusCode)
+		assert.Equal(t, 2050, response.Created)
+		assert.False(t, response.Truncated)
+		assert.Equal(t, models.TodoImportResult{Index: 2049, Created: true, ID: "todo-49"}, response.Results[2049])
+		mockRepo.AssertNumberOfCalls(t, "CreateBatch", 21)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("items past the cap are not read", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		handler.SetImportLimits(1000, 100)
+		app := setupFiberApp(handler)
+		onChunk(mockRepo, 100).Times(10)
+
+		// Act
+		resp, response := importTodos(app, largeImport(1500))
+
+		// Assert
+		assert.Equal(t, 400, resp.StatusCode)
+		assert.True(t, response.Truncated)
+		assert.Len(t, response.Results, 1000)
+		assert.Equal(t, 1000, response.Created)
+		mockRepo.AssertNumberOfCalls(t, "CreateBatch", 10)
+	})
+
+	t.Run("body over the import body limit is rejected", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		handler.SetImportBodyLimit(1024)
+		app := setupFiberApp(handler)
+
+		// Act
+		resp, _ := importTodos(app, largeImport(100))
+
+		// Assert
+		assert.Equal(t, 413, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("non-array body is rejected", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		// Act
+		resp, _ := importTodos(app, map[string]string{"title": "Not an array"})
+
+		// Assert
+		assert.Equal(t, 400, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("empty array is rejected", func(t *testing.T) {
+		// Arrange
+		handler, _ := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		// Act
+		resp, _ := importTodos(app, []models.CreateTodoRequest{})
+
+		// Assert
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("repository failure creates nothing", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+		mockRepo.On("CreateBatch", mock.Anything, mock.Anything).Return(nil, errors.New("insert failed"))
+
+		// Act
+		resp, _ := importTodos(app, []models.CreateTodoRequest{{Title: "First"}})
+
+		// Assert
+		assert.Equal(t, 500, resp.StatusCode)
+	})
+}
+
+func TestTodoHandler_CompleteTodo(t *testing.T) {
+	t.Run("marks an owned todo as done", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		mockRepo.On("MarkCompleted", mock.Anything, "test-user-id", "todo-1").Return(nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("POST", "/api/v1/todos/todo-1/complete", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.TodoStatusResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, "Todo completed successfully", response.Message)
+		assert.Equal(t, models.DoneStatus(), response.Status)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("todo of another user is not found", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		// The repository only completes the caller's own todos
+		mockRepo.On("MarkCompleted", mock.Anything, "test-user-id", "todo-2").Return(interfaces.ErrTodoNotFound)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("POST", "/api/v1/todos/todo-2/complete", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "GetByIDForUser", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestTodoHandler_DeleteCompletedTodos(t *testing.T) {
+	for _, deleted := range []int64{3, 0} {
+		t.Run(fmt.Sprintf("reports %d deleted", deleted), func(t *testing.T) {
+			// Arrange
+			handler, mockRepo := setupTodoHandler()
+			app := setupFiberApp(handler)
+
+			mockRepo.On("DeleteCompleted", mock.Anything, "test-user-id").Return(deleted, nil)
+
+			// Act
+			resp, err := app.Test(httptest.NewRequest("DELETE", "/api/v1/todos/completed", nil))
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, 200, resp.StatusCode)
+
+			var response models.DeleteCompletedResponse
+			json.NewDecoder(resp.Body).Decode(&response)
+			assert.Equal(t, deleted, response.Deleted)
+			mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTodoHandler_BulkUpdateStatus(t *testing.T) {
+	t.Run("updates only owned todos and reports the count", func(t *testing.T) {
+		// Arrange
+		handler, mockRepo := setupTodoHandler()
+		app := setupFiberApp(handler)
+
+		ids := []string{"todo-1", "todo-2", "other-users-todo"}
+		mockRepo.On("BulkUpdateStatusForUser", mock.Anything, "test-user-id", ids, models.TodoStatusCompleted).Return(int64(2), nil)
+
+		body, _ := json.Marshal(map[string]any{"ids": ids, "status": models.TodoStatusCompleted})
+		req := httptest.NewRequest("PATCH", "/api/v1/todos/bulk/status", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		var response models.BulkUpdateResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(t, int64(2), response.Updated)
+		mockRepo.AssertExpectations(t)
+	})
+
+	for name, body := range map[string]string{
+		"unknown status": `{"ids":["todo-1"],"status":"archived"}`,
+		"missing ids":    `{"ids":[],"status":"completed"}`,
+		"missing status": `{"ids":["todo-1"]}`,
+	} {
+		t.Run("rejects "+name, func(t *testing.T) {
+			// Arrange
+			handler, mockRepo := setupTodoHandler()
+			app := setupFiberApp(handler)
+
+			req := httptest.NewRequest("PATCH", "/api/v1/todos/bulk/status", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			// Act
+			resp, err := app.Test(req)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, 400, resp.StatusCode)
+			mockRepo.AssertNotCalled(t, "BulkUpdateStatusForUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestTodoHandler_RequestTimeout(t *testing.T) {
+	// Arrange
+	handler, mockRepo := setupTodoHandler()
+	app := fiber.New()
+	app.Use(middleware.RequestTimeout(50 * time.Millisecond))
+	handler.RegisterRoutes(app.Group("/api/v1"), func(c *fiber.Ctx) error {
+		c.Locals("userID", "test-user-id")
+		return c.Next()
+	})
+
+	// The slow repository blocks until its context is canceled, like a stalled query
+	canceled := make(chan error, 1)
+	mockRepo.On("GetByIDForUser", mock.Anything, "todo-1", "test-user-id").
+		Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(context.Context)
+			select {
+			case <-ctx.Done():
+				canceled <- ctx.Err()
+			case <-time.After(5 * time.Second):
+				canceled <- nil
+			}
+		}).
+		Return(nil, context.DeadlineExceeded)
+
+	// Act
+	req := httptest.NewRequest("GET", "/api/v1/todos/todo-1", nil)
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, fiber.StatusGatewayTimeout, resp.StatusCode)
+	assert.ErrorIs(t, <-canceled, context.DeadlineExceeded)
+
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "Gateway Timeout", body["error"])
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTodoHandler_Compression(t *testing.T) {
+	// setupCompressedApp serves the todo routes behind the compression middleware
+	setupCompressedApp := func() (*fiber.App, *mocks.MockTodoRepository) {
+		handler, mockRepo := setupTodoHandler()
+		app := fiber.New()
+		app.Use(middleware.Compress("default"))
+		handler.RegisterRoutes(app.Group("/api/v1"), func(c *fiber.Ctx) error {
+			c.Locals("userID", "test-user-id")
+			return c.Next()
+		})
+		return app, mockRepo
+	}
+
+	t.Run("listing is gzipped when the client accepts it", func(t *testing.T) {
+		// Arrange
+		app, mockRepo := setupCompressedApp()
+		todos := make([]*models.Todo, 10)
+		for i := range todos {
+			todos[i] = &models.Todo{ID: fmt.Sprintf("todo-%d", i), UserID: "test-user-id", Title: fmt.Sprintf("Todo %d", i), Status: models.TodoStatusPending}
+		}
+		mockRepo.On("GetFiltered", mock.Anything, "test-user-id", models.TodoFilter{}, models.TodoSort{Field: "createdAt", Order: "desc"}, 10, 0).Return(todos, int64(10), nil)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+
+		// Act
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+
+		reader, err := gzip.NewReader(resp.Body)
+		require.NoError(t, err)
+		var response models.TodoListResponse
+		require.NoError(t, json.NewDecoder(reader).Decode(&response))
+		assert.Len(t, response.Todos, 10)
+	})
+
+	t.Run("listing is not compressed without Accept-Encoding", func(t *testing.T) {
+		// Arrange
+		app, mockRepo := setupCompressedApp()
+		mockRepo.On("GetFiltered", mock.Anything, "test-user-id", models.TodoFilter{}, models.TodoSort{Field: "createdAt", Order: "desc"}, 10, 0).Return([]*models.Todo{}, int64(0), nil)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos", nil))
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Content-Encoding"))
+	})
+
+	t.Run("export is compressed as a stream", func(t *testing.T) {
+		// Arrange
+		app, mockRepo := setupCompressedApp()
+		mockRepo.On("StreamByUserID", mock.Anything, "test-user-id", mock.Anything).Run(func(args mock.Arguments) {
+			fn := args.Get(2).(func(*models.Todo) error)
+			for i := 0; i < 1000; i++ {
+				fn(&models.Todo{ID: fmt.Sprintf("todo-%d", i), UserID: "test-user-id", Title: fmt.Sprintf("Todo %d", i), Status: "pending"})
+			}
+		}).Return(nil)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos/export", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+
+		// Act
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+		assert.Empty(t, resp.Header.Get("Content-Length"))
+
+		reader, err := gzip.NewReader(resp.Body)
+		require.NoError(t, err)
+		var exported []models.Todo
+		require.NoError(t, json.NewDecoder(reader).Decode(&exported))
+		assert.Len(t, exported, 1000)
 	})
 }
@@ -1,13 +1,18 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
+	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"go-fiber/internal/config"
 	"go-fiber/internal/mocks"
 	"go-fiber/internal/models"
+	"go-fiber/internal/services"
+	"go-fiber/internal/utils"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -20,7 +25,9 @@ func setupValidationTest() (*fiber.App, *mocks.MockTodoRepository) {
 	mockRepo := new(mocks.MockTodoRepository)
 	logger := config.NewTestLogger()
 	validator := validator.New()
-	handler := NewTodoHandler(mockRepo, validator, logger)
+	models.RegisterValidations(validator)
+	todoService := services.NewTodoService(mockRepo, &config.NewTestConfig().Todo, logger)
+	handler := NewTodoHandler(mockRepo, todoService, config.NewTestConfig().Server.CacheMaxAge, validator, logger)
 
 	app := fiber.New()
 	authMiddleware := func(c *fiber.Ctx) error {
@@ -40,7 +47,7 @@ func TestQueryParameterValidation(t *testing.T) {
 		app, mockRepo := setupValidationTest()
 
 		// Mock successful response
-		mockRepo.On("GetByUserID", mock.Anything, "test-user-id", 5, 10).Return([]*models.Todo{}, int64(0), nil)
+		mockRepo.On("GetFiltered", mock.Anything, "test-user-id", models.TodoFilter{}, mock.Anything, 5, 10).Return([]*models.Todo{}, int64(0), nil)
 
 		req := httptest.NewRequest("GET", "/api/v1/todos?limit=5&offset=10", nil)
 		resp, err := app.Test(req)
@@ -92,3 +99,189 @@ func TestQueryParameterValidation(t *testing.T) {
 		assert.Equal(t, "Validation Error", response["error"])
 	})
 }
+
+func TestConfiguredPriorityValidation(t *testing.T) {
+	t.Cleanup(func() {
+		models.SetPriorities([]string{models.TodoPriorityLow, models.TodoPriorityMedium, models.TodoPriorityHigh}, models.TodoPriorityMedium)
+	})
+
+	t.Run("urgent is rejected by the default set", func(t *testing.T) {
+		app, _ := setupValidationTest()
+
+		req := httptest.NewRequest("GET", "/api/v1/todos?priority=urgent", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("urgent is accepted when configured", func(t *testing.T) {
+		models.SetPriorities([]string{"low", "medium", "high", "urgent"}, "medium")
+		app, mockRepo := setupValidationTest()
+
+		mockRepo.On("GetFiltered", mock.Anything, "test-user-id", models.TodoFilter{Priority: "urgent"}, mock.Anything, 10, 0).Return([]*models.Todo{}, int64(0), nil)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos?priority=urgent", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("configured default is applied", func(t *testing.T) {
+		models.SetPriorities([]string{"low", "high", "urgent"}, "high")
+
+		todo := &models.Todo{Title: "Test"}
+		todo.SetDefaults()
+
+		assert.Equal(t, "high", todo.Priority)
+		assert.False(t, models.IsValidPriority(models.TodoPriorityMedium))
+	})
+}
+
+func TestConfiguredStatusValidation(t *testing.T) {
+	t.Cleanup(func() {
+		models.SetStatuses([]string{models.TodoStatusPending, models.TodoStatusInProgress, models.TodoStatusCompleted}, models.TodoStatusPending, models.TodoStatusCompleted)
+	})
+
+	t.Run("custom status is rejected by the default set", func(t *testing.T) {
+		app, _ := setupValidationTest()
+
+		req := httptest.NewRequest("GET", "/api/v1/todos?status=blocked", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("custom status is accepted when configured", func(t *testing.T) {
+		models.SetStatuses([]string{"todo", "blocked", "review", "done"}, "todo", "done")
+		app, mockRepo := setupValidationTest()
+
+		mockRepo.On("GetFiltered", mock.Anything, "test-user-id", models.TodoFilter{Status: "blocked"}, mock.Anything, 10, 0).Return([]*models.Todo{}, int64(0), nil)
+
+		req := httptest.NewRequest("GET", "/api/v1/todos?status=blocked", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("removed default status is rejected when not configured", func(t *testing.T) {
+		models.SetStatuses([]string{"todo", "blocked", "review", "done"}, "todo", "done")
+		app, _ := setupValidationTest()
+
+		req := httptest.NewRequest("GET", "/api/v1/todos?status=completed", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("configured default status is applied", func(t *testing.T) {
+		models.SetStatuses([]string{"todo", "blocked", "review", "done"}, "todo", "done")
+
+		todo := &models.Todo{Title: "Test"}
+		todo.SetDefaults()
+
+		assert.Equal(t, "todo", todo.Status)
+		assert.Equal(t, "done", models.DoneStatus())
+	})
+}
+
+func TestDescriptionMaxValidation(t *testing.T) {
+	t.Cleanup(func() {
+		models.SetDescriptionMax(models.DefaultDescriptionMax)
+	})
+
+	createRequest := func(description string) *http.Request {
+		body, _ := json.Marshal(models.CreateTodoRequest{Title: "Test", Description: description})
+		req := httptest.NewRequest("POST", "/api/v1/todos", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	t.Run("description at the default limit is accepted", func(t *testing.T) {
+		app, mockRepo := setupValidationTest()
+
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(&models.Todo{ID: "todo-1", UserID: "test-user-id", Title: "Test"}, nil)
+
+		resp, err := app.Test(createRequest(strings.Repeat("a", models.DefaultDescriptionMax)))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 201, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("description one over the default limit is rejected", func(t *testing.T) {
+		app, mockRepo := setupValidationTest()
+
+		resp, err := app.Test(createRequest(strings.Repeat("a", models.DefaultDescriptionMax+1)))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("limit counts characters rather than bytes", func(t *testing.T) {
+		models.SetDescriptionMax(3)
+		app, mockRepo := setupValidationTest()
+
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(&models.Todo{ID: "todo-1", UserID: "test-user-id", Title: "Test"}, nil)
+
+		resp, err := app.Test(createRequest("äöü"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 201, resp.StatusCode)
+	})
+
+	t.Run("configured limit applies to updates", func(t *testing.T) {
+		models.SetDescriptionMax(10)
+		app, mockRepo := setupValidationTest()
+
+		body, _ := json.Marshal(models.UpdateTodoRequest{Description: strings.Repeat("a", 11)})
+		req := httptest.NewRequest("PUT", "/api/v1/todos/todo-1", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestErrorDetailsSuppression(t *testing.T) {
+	t.Cleanup(func() { utils.SetExposeErrorDetails(true) })
+
+	// invalidLimit returns the body of a request failing query validation
+	invalidLimit := func() map[string]any {
+		app, _ := setupValidationTest()
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/todos?limit=1000", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode)
+
+		var body map[string]any
+		json.NewDecoder(resp.Body).Decode(&body)
+		return body
+	}
+
+	t.Run("details are included when exposed", func(t *testing.T) {
+		utils.SetExposeErrorDetails(true)
+
+		body := invalidLimit()
+
+		assert.Contains(t, body["details"], "Limit")
+	})
+
+	t.Run("details are omitted when hidden", func(t *testing.T) {
+		utils.SetExposeErrorDetails(false)
+
+		body := invalidLimit()
+
+		assert.NotContains(t, body, "details")
+		assert.Equal(t, "Validation Error", body["error"])
+		assert.Equal(t, "Invalid query parameters", body["message"])
+	})
+}
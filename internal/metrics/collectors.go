@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// SessionCounter counts active sessions
+type SessionCounter interface {
+	Count(ctx context.Context) (int64, error)
+}
+
+// PostgresPool reports the connections of a PostgreSQL pool by state
+func PostgresPool(pool *pgxpool.Pool) GaugeFunc {
+	return func(ctx context.Context) ([]Sample, error) {
+		stats := pool.Stat()
+		return []Sample{
+			{Labels: map[string]string{"state": "acquired"}, Value: float64(stats.AcquiredConns())},
+			{Labels: map[string]string{"state": "idle"}, Value: float64(stats.IdleConns())},
+			{Labels: map[string]string{"state": "total"}, Value: float64(stats.TotalConns())},
+			{Labels: map[string]string{"state": "max"}, Value: float64(stats.MaxConns())},
+		}, nil
+	}
+}
+
+// RedisPool reports the connections of a Redis client pool by state
+func RedisPool(client *redis.Client) GaugeFunc {
+	return func(ctx context.Context) ([]Sample, error) {
+		stats := client.PoolStats()
+		return []Sample{
+			{Labels: map[string]string{"state": "idle"}, Value: float64(stats.IdleConns)},
+			{Labels: map[string]string{"state": "total"}, Value: float64(stats.TotalConns)},
+			{Labels: map[string]string{"state": "stale"}, Value: float64(stats.StaleConns)},
+		}, nil
+	}
+}
+
+// ActiveSessions reports the number of active sessions
+func ActiveSessions(sessions SessionCounter) GaugeFunc {
+	return func(ctx context.Context) ([]Sample, error) {
+		count, err := sessions.Count(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []Sample{{Value: float64(count)}}, nil
+	}
+}
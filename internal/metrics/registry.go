@@ -0,0 +1,188 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// DefaultBuckets are the request latency histogram bounds in seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Sample is one value of a gauge with its labels
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// GaugeFunc reads the current samples of a gauge when metrics are scraped
+type GaugeFunc func(ctx context.Context) ([]Sample, error)
+
+// gauge is a registered gauge read at scrape time
+type gauge struct {
+	name string
+	help string
+	read GaugeFunc
+}
+
+// requestKey identifies the requests counted together
+type requestKey struct {
+	method string
+	route  string
+	status string
+}
+
+// requestStats is the latency histogram of one request key
+type requestStats struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+// Registry records HTTP request metrics and renders them, together with gauges read at
+// scrape time, in the Prometheus text exposition format
+type Registry struct {
+	buckets []float64
+	logger  zerolog.Logger
+
+	mu       sync.Mutex
+	requests map[requestKey]*requestStats
+	gauges   []gauge
+}
+
+// NewRegistry creates an empty registry using DefaultBuckets
+func NewRegistry(logger zerolog.Logger) *Registry {
+	return &Registry{
+		buckets:  DefaultBuckets,
+		logger:   logger,
+		requests: make(map[requestKey]*requestStats),
+	}
+}
+
+// RegisterGauge adds a gauge that is read on every scrape
+func (r *Registry) RegisterGauge(name, help string, read GaugeFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.gauges = append(r.gauges, gauge{name: name, help: help, read: read})
+}
+
+// ObserveRequest records a handled request. route should be the matched route pattern, not the
+// requested path, so IDs in paths don't create a series per todo.
+func (r *Registry) ObserveRequest(method, route string, status int, duration time.Duration) {
+	key := requestKey{method: method, route: route, status: strconv.Itoa(status)}
+	seconds := duration.Seconds()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats, ok := r.requests[key]
+	if !ok {
+		stats = &requestStats{buckets: make([]uint64, len(r.buckets))}
+		r.requests[key] = stats
+	}
+	for i, bound := range r.buckets {
+		if seconds <= bound {
+			stats.buckets[i]++
+		}
+	}
+	stats.count++
+	stats.sum += seconds
+}
+
+// Write renders every metric in the Prometheus text format. A gauge that fails to read is
+// logged and left out rather than failing the whole scrape.
+func (r *Registry) Write(ctx context.Context, w io.Writer) error {
+	r.mu.Lock()
+	keys := slices.SortedFunc(maps.Keys(r.requests), compareRequestKeys)
+	requests := make([]requestStats, len(keys))
+	for i, key := range keys {
+		stats := r.requests[key]
+		requests[i] = requestStats{buckets: slices.Clone(stats.buckets), count: stats.count, sum: stats.sum}
+	}
+	gauges := slices.Clone(r.gauges)
+	r.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("# HELP todo_api_http_requests_total Number of handled HTTP requests.\n")
+	b.WriteString("# TYPE todo_api_http_requests_total counter\n")
+	for i, key := range keys {
+		fmt.Fprintf(&b, "todo_api_http_requests_total{%s} %d\n", key.labels(), requests[i].count)
+	}
+
+	b.WriteString("# HELP todo_api_http_request_duration_seconds HTTP request latency.\n")
+	b.WriteString("# TYPE todo_api_http_request_duration_seconds histogram\n")
+	for i, key := range keys {
+		labels := key.labels()
+		for j, bound := range r.buckets {
+			fmt.Fprintf(&b, "todo_api_http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, formatFloat(bound), requests[i].buckets[j])
+		}
+		fmt.Fprintf(&b, "todo_api_http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, requests[i].count)
+		fmt.Fprintf(&b, "todo_api_http_request_duration_seconds_sum{%s} %s\n", labels, formatFloat(requests[i].sum))
+		fmt.Fprintf(&b, "todo_api_http_request_duration_seconds_count{%s} %d\n", labels, requests[i].count)
+	}
+
+	for _, g := range gauges {
+		samples, err := g.read(ctx)
+		if err != nil {
+			r.logger.Warn().Err(err).Str("metric", g.name).Msg("Failed to read gauge.")
+			continue
+		}
+
+		fmt.Fprintf(&b, "# HELP %s %s\n", g.name, g.help)
+		fmt.Fprintf(&b, "# TYPE %s gauge\n", g.name)
+		for _, sample := range samples {
+			fmt.Fprintf(&b, "%s%s %s\n", g.name, renderLabels(sample.Labels), formatFloat(sample.Value))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// labels renders the labels of a request key
+func (k requestKey) labels() string {
+	return fmt.Sprintf(`method="%s",route="%s",status="%s"`, escapeLabelValue(k.method), escapeLabelValue(k.route), k.status)
+}
+
+// compareRequestKeys orders request keys by route, then method, then status
+func compareRequestKeys(a, b requestKey) int {
+	if c := strings.Compare(a.route, b.route); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.method, b.method); c != 0 {
+		return c
+	}
+	return strings.Compare(a.status, b.status)
+}
+
+// renderLabels renders labels sorted by name, or nothing when there are none
+func renderLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(labels))
+	for _, name := range slices.Sorted(maps.Keys(labels)) {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, escapeLabelValue(labels[name])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatFloat formats a sample value as Prometheus expects
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// escapeLabelValue escapes a Prometheus label value
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSessions reports a fixed session count
+type fakeSessions struct {
+	count int64
+	err   error
+}
+
+func (f fakeSessions) Count(context.Context) (int64, error) {
+	return f.count, f.err
+}
+
+func TestRegistry_ObserveRequest(t *testing.T) {
+	// Arrange
+	registry := NewRegistry(zerolog.Nop())
+	registry.buckets = []float64{0.1, 1}
+
+	// Act
+	registry.ObserveRequest("GET", "/api/v1/todos/:id", 200, 50*time.Millisecond)
+	registry.ObserveRequest("GET", "/api/v1/todos/:id", 200, 500*time.Millisecond)
+	registry.ObserveRequest("GET", "/api/v1/todos/:id", 404, 2*time.Second)
+
+	var out bytes.Buffer
+	err := registry.Write(context.Background(), &out)
+
+	// Assert
+	require.NoError(t, err)
+	expected := "# HELP todo_api_http_requests_total Number of handled HTTP requests.\n" +
+		"# TYPE todo_api_http_requests_total counter\n" +
+		"todo_api_http_requests_total{method=\"GET\",route=\"/api/v1/todos/:id\",status=\"200\"} 2\n" +
+		"todo_api_http_requests_total{method=\"GET\",route=\"/api/v1/todos/:id\",status=\"404\"} 1\n" +
+		"# HELP todo_api_http_request_duration_seconds HTTP request latency.\n" +
+		"# TYPE todo_api_http_request_duration_seconds histogram\n" +
+		"todo_api_http_request_duration_seconds_bucket{method=\"GET\",route=\"/api/v1/todos/:id\",status=\"200\",le=\"0.1\"} 1\n" +
+		"todo_api_http_request_duration_seconds_bucket{method=\"GET\",route=\"/api/v1/todos/:id\",status=\"200\",le=\"1\"} 2\n" +
+		"todo_api_http_request_duration_seconds_bucket{method=\"GET\",route=\"/api/v1/todos/:id\",status=\"200\",le=\"+Inf\"} 2\n" +
+		"todo_api_http_request_duration_seconds_sum{method=\"GET\",route=\"/api/v1/todos/:id\",status=\"200\"} 0.55\n" +
+		"todo_api_http_request_duration_seconds_count{method=\"GET\",route=\"/api/v1/todos/:id\",status=\"200\"} 2\n" +
+		"todo_api_http_request_duration_seconds_bucket{method=\"GET\",route=\"/api/v1/todos/:id\",status=\"404\",le=\"0.1\"} 0\n" +
+		"todo_api_http_request_duration_seconds_bucket{method=\"GET\",route=\"/api/v1/todos/:id\",status=\"404\",le=\"1\"} 0\n" +
+		"todo_api_http_request_duration_seconds_bucket{method=\"GET\",route=\"/api/v1/todos/:id\",status=\"404\",le=\"+Inf\"} 1\n" +
+		"todo_api_http_request_duration_seconds_sum{method=\"GET\",route=\"/api/v1/todos/:id\",status=\"404\"} 2\n" +
+		"todo_api_http_request_duration_seconds_count{method=\"GET\",route=\"/api/v1/todos/:id\",status=\"404\"} 1\n"
+	assert.Equal(t, expected, out.String())
+}
+
+func TestRegistry_Gauges(t *testing.T) {
+	t.Run("renders samples with sorted labels", func(t *testing.T) {
+		// Arrange
+		registry := NewRegistry(zerolog.Nop())
+		registry.RegisterGauge("todo_api_pool", "Pool connections.", func(context.Context) ([]Sample, error) {
+			return []Sample{{Labels: map[string]string{"state": "idle", "pool": "main"}, Value: 3}}, nil
+		})
+		registry.RegisterGauge("todo_api_active_sessions", "Number of active sessions.", ActiveSessions(fakeSessions{count: 42}))
+
+		// Act
+		var out bytes.Buffer
+		err := registry.Write(context.Background(), &out)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Contains(t, out.String(), "# TYPE todo_api_pool gauge\ntodo_api_pool{pool=\"main\",state=\"idle\"} 3\n")
+		assert.Contains(t, out.String(), "# TYPE todo_api_active_sessions gauge\ntodo_api_active_sessions 42\n")
+	})
+
+	t.Run("failing gauge is left out", func(t *testing.T) {
+		// Arrange
+		registry := NewRegistry(zerolog.Nop())
+		registry.RegisterGauge("todo_api_active_sessions", "Number of active sessions.", ActiveSessions(fakeSessions{err: errors.New("redis down")}))
+		registry.ObserveRequest("GET", "/health", 200, time.Millisecond)
+
+		// Act
+		var out bytes.Buffer
+		err := registry.Write(context.Background(), &out)
+
+		// Assert
+		require.NoError(t, err)
+		assert.NotContains(t, out.String(), "todo_api_active_sessions")
+		assert.Contains(t, out.String(), "todo_api_http_requests_total")
+	})
+}
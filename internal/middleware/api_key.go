@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"errors"
+
+	"go-fiber/internal/models"
+	"go-fiber/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// APIKeyHeader is the request header that carries an API key
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyMiddleware creates API key authentication middleware. Requests with an X-API-Key
+// header are authenticated by the key and get the same user locals as AuthMiddleware; GET and
+// HEAD requests need the todos:read scope and other methods todos:write. Requests without the
+// header are passed to fallback, usually AuthMiddleware, or rejected when it is nil. Requests
+// that also send an Authorization header are rejected with 400, since it is unclear which
+// credential, and so which user, they mean.
+func APIKeyMiddleware(apiKeyService *services.APIKeyService, fallback fiber.Handler, logger zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(APIKeyHeader)
+		if key == "" {
+			if fallback != nil {
+				return fallback(c)
+			}
+			logger.Warn().Str("path", c.Path()).Msg("Missing API key.")
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":   "Unauthorized",
+				"message": "Missing API key",
+			})
+		}
+
+		if c.Get(fiber.HeaderAuthorization) != "" {
+			logger.Warn().Str("path", c.Path()).Msg("Both API key and authorization header sent.")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Bad Request",
+				"message": "Send either an API key or a bearer token, not both",
+			})
+		}
+
+		// Validate key
+		apiKey, user, err := apiKeyService.Authenticate(c.UserContext(), key)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidAPIKey) {
+				logger.Warn().Str("path", c.Path()).Msg("Invalid API key.")
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error":   "Unauthorized",
+					"message": "Invalid API key",
+				})
+			}
+			logger.Error().Err(err).Str("path", c.Path()).Msg("Failed to authenticate API key.")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   "Internal Server Error",
+				"message": "Failed to authenticate API key",
+			})
+		}
+
+		// Check scope
+		scope := models.APIKeyScopeTodosWrite
+		if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+			scope = models.APIKeyScopeTodosRead
+		}
+		if !apiKey.HasScope(scope) {
+			logger.Warn().Str("api_key_id", apiKey.ID).Str("scope", scope).Str("path", c.Path()).Msg("API key lacks scope.")
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   "Forbidden",
+				"message": "API key lacks the " + scope + " scope",
+			})
+		}
+
+		// Store user information in context
+		c.Locals("userID", user.ID)
+		c.Locals("username", user.Username)
+		c.Locals("role", user.RoleOrDefault())
+		c.Locals("apiKeyID", apiKey.ID)
+
+		logger.Debug().
+			Str("user_id", user.ID).
+			Str("api_key_id", apiKey.ID).
+			Str("path", c.Path()).
+			Msg("User authenticated with API key.")
+
+		return c.Next()
+	}
+}
+
+// GetAPIKeyID returns the ID of the API key that authenticated the request, if any
+func GetAPIKeyID(c *fiber.Ctx) string {
+	apiKeyID, _ := c.Locals("apiKeyID").(string)
+	return apiKeyID
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http/httptest"
+	"testing"
+
+	"go-fiber/internal/mocks"
+	"go-fiber/internal/models"
+	"go-fiber/internal/repository/interfaces"
+	"go-fiber/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	const readKey = models.APIKeyPrefix + "read-only"
+	const unknownKey = models.APIKeyPrefix + "unknown"
+
+	mockAPIKeyRepo := new(mocks.MockAPIKeyRepository)
+	mockUserRepo := new(mocks.MockUserRepository)
+	apiKeyService := services.NewAPIKeyService(mockAPIKeyRepo, mockUserRepo, zerolog.Nop())
+
+	readOnly := &models.APIKey{ID: "key-1", UserID: "user-1", Scopes: []string{models.APIKeyScopeTodosRead}}
+	readHash := sha256.Sum256([]byte(readKey))
+	mockAPIKeyRepo.On("GetByHash", mock.Anything, hex.EncodeToString(readHash[:])).Return(readOnly, nil)
+	mockAPIKeyRepo.On("GetByHash", mock.Anything, mock.Anything).Return(nil, interfaces.ErrAPIKeyNotFound)
+	mockAPIKeyRepo.On("UpdateLastUsed", mock.Anything, "key-1", mock.Anything).Return(nil)
+	mockUserRepo.On("GetByID", mock.Anything, "user-1").Return(&models.User{ID: "user-1", Username: "alice"}, nil)
+
+	fallback := func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusTeapot).SendString("fallback")
+	}
+
+	app := fiber.New()
+	app.Use(APIKeyMiddleware(apiKeyService, fallback, zerolog.Nop()))
+	app.All("/todos", func(c *fiber.Ctx) error {
+		return c.SendString(GetUserID(c) + " " + GetUsername(c) + " " + GetAPIKeyID(c))
+	})
+
+	// request sends method /todos with the given API key
+	request := func(method, key string) int {
+		req := httptest.NewRequest(method, "/todos", nil)
+		if key != "" {
+			req.Header.Set(APIKeyHeader, key)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	t.Run("valid key with the read scope can read", func(t *testing.T) {
+		assert.Equal(t, fiber.StatusOK, request("GET", readKey))
+	})
+
+	t.Run("valid key without the write scope cannot write", func(t *testing.T) {
+		assert.Equal(t, fiber.StatusForbidden, request("POST", readKey))
+	})
+
+	t.Run("unknown key is rejected", func(t *testing.T) {
+		assert.Equal(t, fiber.StatusUnauthorized, request("GET", unknownKey))
+	})
+
+	t.Run("request without a key goes to the fallback", func(t *testing.T) {
+		assert.Equal(t, fiber.StatusTeapot, request("GET", ""))
+	})
+
+	t.Run("key and bearer token together are rejected", func(t *testing.T) {
+		// Arrange
+		req := httptest.NewRequest("GET", "/todos", nil)
+		req.Header.Set(APIKeyHeader, readKey)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer some-token")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
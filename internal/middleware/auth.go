@@ -54,6 +54,7 @@ func AuthMiddleware(authService *services.AuthService, logger zerolog.Logger) fi
 		// Store user information in context
 		c.Locals("userID", claims.UserID)
 		c.Locals("username", claims.Username)
+		c.Locals("role", claims.Role)
 		c.Locals("sessionID", claims.SessionID)
 
 		logger.Debug().
@@ -101,6 +102,7 @@ func OptionalAuthMiddleware(authService *services.AuthService, logger zerolog.Lo
 		// Store user information in context
 		c.Locals("userID", claims.UserID)
 		c.Locals("username", claims.Username)
+		c.Locals("role", claims.Role)
 		c.Locals("sessionID", claims.SessionID)
 
 		logger.Debug().
@@ -131,6 +133,15 @@ func GetUsername(c *fiber.Ctx) string {
 	return username
 }
 
+// GetRole extracts the user's role from Fiber context
+func GetRole(c *fiber.Ctx) string {
+	role, ok := c.Locals("role").(string)
+	if !ok {
+		return ""
+	}
+	return role
+}
+
 // GetSessionID extracts session ID from Fiber context
 func GetSessionID(c *fiber.Ctx) string {
 	sessionID, ok := c.Locals("sessionID").(string)
@@ -152,6 +163,27 @@ func RequireAuth(c *fiber.Ctx) error {
 	return nil
 }
 
+// RequireRole creates middleware that lets only users with the given role through. It reads
+// the role that AuthMiddleware took from the token, so it must run after it and needs no
+// database lookup.
+func RequireRole(role string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if GetUserID(c) == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":   "Unauthorized",
+				"message": "Authentication required",
+			})
+		}
+		if GetRole(c) != role {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   "Forbidden",
+				"message": "This endpoint requires the " + role + " role",
+			})
+		}
+		return c.Next()
+	}
+}
+
 // IsAuthenticated checks if the user is authenticated
 func IsAuthenticated(c *fiber.Ctx) bool {
 	return GetUserID(c) != ""
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"go-fiber/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireRole(t *testing.T) {
+	app := fiber.New()
+
+	// Stand in for AuthMiddleware, taking the user from test headers
+	app.Use(func(c *fiber.Ctx) error {
+		if userID := c.Get("X-Test-User"); userID != "" {
+			c.Locals("userID", userID)
+			c.Locals("role", c.Get("X-Test-Role"))
+		}
+		return c.Next()
+	})
+	app.Get("/admin", RequireRole(models.UserRoleAdmin), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	// request calls /admin as the given user and role
+	request := func(userID, role string) int {
+		req := httptest.NewRequest("GET", "/admin", nil)
+		req.Header.Set("X-Test-User", userID)
+		req.Header.Set("X-Test-Role", role)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	t.Run("admin is let through", func(t *testing.T) {
+		assert.Equal(t, fiber.StatusNoContent, request("user-1", models.UserRoleAdmin))
+	})
+
+	t.Run("user is forbidden", func(t *testing.T) {
+		assert.Equal(t, fiber.StatusForbidden, request("user-1", models.UserRoleUser))
+	})
+
+	t.Run("anonymous request is unauthorized", func(t *testing.T) {
+		assert.Equal(t, fiber.StatusUnauthorized, request("", ""))
+	})
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimit rejects requests whose body is larger than limit bytes with a 413, answered by
+// the app's error handler like the server-wide limit. It is meant for routes that need a
+// stricter limit than the server's; a limit of 0 or less allows any size the server accepts.
+func BodyLimit(limit int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if limit <= 0 {
+			return c.Next()
+		}
+
+		if c.Request().Header.ContentLength() > limit || len(c.Body()) > limit {
+			return fiber.NewError(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("Request body must not be larger than %d bytes", limit))
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-fiber/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBodyLimit(t *testing.T) {
+	// The error handler answers like the server's, so the 413 matches the server-wide limit
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			var fiberErr *fiber.Error
+			if !errors.As(err, &fiberErr) {
+				return c.SendStatus(fiber.StatusInternalServerError)
+			}
+			return c.Status(fiberErr.Code).JSON(models.ErrorResponse{
+				Error:   "Request Entity Too Large",
+				Message: fiberErr.Message,
+				Code:    "REQUEST_ENTITY_TOO_LARGE",
+			})
+		},
+	})
+	app.Post("/limited", BodyLimit(16), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	app.Post("/unlimited", BodyLimit(0), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	t.Run("accepts a body within the limit", func(t *testing.T) {
+		// Act
+		resp, err := app.Test(httptest.NewRequest("POST", "/limited", strings.NewReader(`{"a":1}`)))
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+	})
+
+	t.Run("rejects a body over the limit", func(t *testing.T) {
+		// Act
+		resp, err := app.Test(httptest.NewRequest("POST", "/limited", strings.NewReader(strings.Repeat("x", 17))))
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, fiber.StatusRequestEntityTooLarge, resp.StatusCode)
+		var body models.ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "Request body must not be larger than 16 bytes", body.Message)
+		assert.Equal(t, "REQUEST_ENTITY_TOO_LARGE", body.Code)
+	})
+
+	t.Run("zero limit allows any size", func(t *testing.T) {
+		// Act
+		resp, err := app.Test(httptest.NewRequest("POST", "/unlimited", strings.NewReader(strings.Repeat("x", 1024))))
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+	})
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
+)
+
+// compressionLevels maps the configured compression level names to Fiber's levels
+var compressionLevels = map[string]compress.Level{
+	"default": compress.LevelDefault,
+	"speed":   compress.LevelBestSpeed,
+	"best":    compress.LevelBestCompression,
+}
+
+// Compress creates a middleware that compresses responses for clients that accept gzip,
+// deflate or brotli. Streamed bodies are compressed as they are written rather than buffered.
+func Compress(level string) fiber.Handler {
+	return compress.New(compress.Config{
+		Level: compressionLevels[level],
+	})
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// PublicURL creates a middleware that exposes the configured public base URL to handlers
+// and strips forwarded host/proto headers sent by untrusted clients, preventing
+// host-header injection into generated links
+func PublicURL(publicURL string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if publicURL != "" {
+			c.Locals("publicURL", publicURL)
+		}
+
+		// Only honour forwarded headers coming from trusted proxies
+		if !c.IsProxyTrusted() {
+			c.Request().Header.Del(fiber.HeaderXForwardedHost)
+			c.Request().Header.Del(fiber.HeaderXForwardedProto)
+			c.Request().Header.Del(fiber.HeaderXForwardedProtocol)
+			c.Request().Header.Del(fiber.HeaderXForwardedSsl)
+		}
+
+		return c.Next()
+	}
+}
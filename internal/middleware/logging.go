@@ -1,25 +1,26 @@
 package middleware
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/oklog/ulid/v2"
 	"github.com/rs/zerolog"
 )
 
-// RequestLogger creates a request logging middleware
+// RequestLogger creates a structured access log middleware. It logs the request ID set by
+// RequestID, so it must run after it, and the authenticated user's ID when there is one.
 func RequestLogger(logger zerolog.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		start := time.Now()
-
 		// Process request
-		err := c.Next()
-
-		// Calculate duration
-		duration := time.Since(start)
+		duration, err := timeNext(c)
 
 		// Get status code
-		status := c.Response().StatusCode()
+		status := responseStatus(c, err)
 
 		// Log the request
 		logEvent := logger.Info()
@@ -34,14 +35,148 @@ func RequestLogger(logger zerolog.Logger) fiber.Handler {
 			Str("user_agent", c.Get("User-Agent")).
 			Int("status", status).
 			Dur("duration", duration).
-			Int("size", len(c.Response().Body())).
-			Str("request_id", c.Get("X-Request-ID")).
-			Msg("HTTP Request.")
+			Str("request_id", GetRequestID(c))
+		if size, ok := responseSize(c); ok {
+			logEvent.Int("size", size)
+		}
+		if userID := GetUserID(c); userID != "" {
+			logEvent.Str("user_id", userID)
+		}
+		logEvent.Msg("HTTP Request.")
+
+		return err
+	}
+}
+
+// responseSize returns the size of the response body. A streamed body is only written after
+// the middleware returns and reading it here would buffer it, so its size is only known
+// when the handler set a Content-Length.
+func responseSize(c *fiber.Ctx) (int, bool) {
+	if c.Response().IsBodyStream() {
+		size := c.Response().Header.ContentLength()
+		return size, size >= 0
+	}
+	return len(c.Response().Body()), true
+}
+
+// RequestObserver records the outcome of handled requests
+type RequestObserver interface {
+	ObserveRequest(method, route string, status int, duration time.Duration)
+}
 
+// RequestMetrics records the method, matched route, status and latency of every request
+func RequestMetrics(observer RequestObserver) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		duration, err := timeNext(c)
+
+		observer.ObserveRequest(c.Method(), c.Route().Path, responseStatus(c, err), duration)
 		return err
 	}
 }
 
+// responseStatus returns the status the client receives. An error returned down the chain is
+// only turned into a response by the error handler after the middleware returns.
+func responseStatus(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+	return fiber.StatusInternalServerError
+}
+
+// timeNext runs the rest of the handler chain and reports how long it took
+func timeNext(c *fiber.Ctx) (time.Duration, error) {
+	start := time.Now()
+	err := c.Next()
+	return time.Since(start), err
+}
+
+// BodyLogger logs request and response bodies for debugging clients. JSON fields that look
+// like credentials are redacted, non-JSON and streamed bodies are omitted, and each logged
+// body is capped at limit bytes.
+func BodyLogger(logger zerolog.Logger, limit int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Process request
+		err := c.Next()
+
+		// Reading a streamed body here would buffer all of it
+		responseBody := "[streamed body omitted]"
+		if !c.Response().IsBodyStream() {
+			responseBody = loggableBody(c.Response().Body(), limit)
+		}
+
+		logger.Info().
+			Str("method", c.Method()).
+			Str("path", c.Path()).
+			Int("status", c.Response().StatusCode()).
+			Str("request_body", loggableBody(c.Body(), limit)).
+			Str("response_body", responseBody).
+			Str("request_id", GetRequestID(c)).
+			Msg("HTTP Bodies.")
+
+		return err
+	}
+}
+
+// loggableBody redacts sensitive fields from a JSON body and truncates it to limit bytes
+func loggableBody(body []byte, limit int) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return fmt.Sprintf("[%d byte non-JSON body omitted]", len(body))
+	}
+
+	redacted, err := json.Marshal(redactSensitive(value))
+	if err != nil {
+		return fmt.Sprintf("[%d byte body omitted]", len(body))
+	}
+
+	if len(redacted) <= limit {
+		return string(redacted)
+	}
+	// Cutting at a byte limit may split a multi-byte character
+	return strings.ToValidUTF8(string(redacted[:limit]), "") + fmt.Sprintf("...[truncated %d bytes]", len(redacted)-limit)
+}
+
+// redactSensitive replaces the values of credential-like keys at any depth
+func redactSensitive(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if isSensitiveKey(key) {
+				v[key] = "[REDACTED]"
+			} else {
+				v[key] = redactSensitive(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactSensitive(item)
+		}
+	}
+	return value
+}
+
+// isSensitiveKey reports whether a JSON key names a password, token, secret, API key or
+// one-time code
+func isSensitiveKey(key string) bool {
+	key = strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+	return strings.Contains(key, "password") ||
+		strings.Contains(key, "token") ||
+		strings.Contains(key, "secret") ||
+		strings.Contains(key, "apikey") ||
+		key == "key" ||
+		key == "code" ||
+		key == "authorization"
+}
+
 // RequestID middleware adds a unique request ID to each request
 func RequestID() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -59,17 +194,13 @@ func RequestID() fiber.Handler {
 	}
 }
 
-// generateRequestID generates a unique request ID
-func generateRequestID() string {
-	return time.Now().Format("20060102150405") + "-" + randomString(8)
+// GetRequestID returns the request ID set by RequestID
+func GetRequestID(c *fiber.Ctx) string {
+	requestID, _ := c.Locals("requestID").(string)
+	return requestID
 }
 
-// randomString generates a random string of given length
-func randomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
-	}
-	return string(b)
+// generateRequestID generates a unique, time-sortable request ID
+func generateRequestID() string {
+	return ulid.Make().String()
 }
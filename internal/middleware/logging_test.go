@@ -0,0 +1,236 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBodyLogger(t *testing.T) {
+	var output bytes.Buffer
+	logger := zerolog.New(&output)
+
+	app := fiber.New()
+	app.Use(BodyLogger(logger, 64))
+	app.Post("/login", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"message":      "Login successful.",
+			"accessToken":  "access-secret-value",
+			"refreshToken": "refresh-secret-value",
+		})
+	})
+
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(`{"username":"alice","password":"hunter22"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err := app.Test(req)
+	require.NoError(t, err)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(output.Bytes(), &entry))
+
+	logged := output.String()
+	assert.NotContains(t, logged, "hunter22")
+	assert.NotContains(t, logged, "access-secret-value")
+	assert.NotContains(t, logged, "refresh-secret-value")
+	assert.Equal(t, `{"password":"[REDACTED]","username":"alice"}`, entry["request_body"])
+}
+
+func TestRequestLogger(t *testing.T) {
+	newApp := func(output *bytes.Buffer) *fiber.App {
+		app := fiber.New()
+		app.Use(RequestID())
+		app.Use(RequestLogger(zerolog.New(output)))
+		app.Get("/public", func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusOK)
+		})
+		app.Get("/private", func(c *fiber.Ctx) error {
+			c.Locals("userID", "user-123")
+			return fiber.NewError(fiber.StatusNotFound, "not found")
+		})
+		app.Get("/stream", func(c *fiber.Ctx) error {
+			size, _ := strconv.Atoi(c.Query("size", "-1"))
+			c.Context().SetBodyStream(strings.NewReader("streamed"), size)
+			return nil
+		})
+		return app
+	}
+
+	t.Run("logs generated request ID", func(t *testing.T) {
+		// Arrange
+		var output bytes.Buffer
+		app := newApp(&output)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/public", nil))
+		require.NoError(t, err)
+
+		// Assert
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(output.Bytes(), &entry))
+		assert.Equal(t, resp.Header.Get("X-Request-ID"), entry["request_id"])
+		assert.Len(t, entry["request_id"], 26)
+		assert.Equal(t, float64(fiber.StatusOK), entry["status"])
+		assert.NotContains(t, entry, "user_id")
+	})
+
+	t.Run("logs user ID and error status", func(t *testing.T) {
+		// Arrange
+		var output bytes.Buffer
+		app := newApp(&output)
+		req := httptest.NewRequest("GET", "/private", nil)
+		req.Header.Set("X-Request-ID", "client-request-id")
+
+		// Act
+		_, err := app.Test(req)
+		require.NoError(t, err)
+
+		// Assert
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(output.Bytes(), &entry))
+		assert.Equal(t, "client-request-id", entry["request_id"])
+		assert.Equal(t, "user-123", entry["user_id"])
+		assert.Equal(t, float64(fiber.StatusNotFound), entry["status"])
+		assert.Equal(t, "error", entry["level"])
+	})
+
+	t.Run("logs the declared size of a streamed body", func(t *testing.T) {
+		// Arrange
+		var output bytes.Buffer
+		app := newApp(&output)
+
+		// Act
+		_, err := app.Test(httptest.NewRequest("GET", "/stream?size=8", nil))
+		require.NoError(t, err)
+
+		// Assert
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(output.Bytes(), &entry))
+		assert.Equal(t, float64(8), entry["size"])
+	})
+
+	t.Run("omits the size of a streamed body of unknown length", func(t *testing.T) {
+		// Arrange
+		var output bytes.Buffer
+		app := newApp(&output)
+
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/stream", nil))
+		require.NoError(t, err)
+
+		// Assert
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "streamed", string(body))
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(output.Bytes(), &entry))
+		assert.NotContains(t, entry, "size")
+	})
+}
+
+func TestLoggableBody(t *testing.T) {
+	t.Run("redacts nested credentials", func(t *testing.T) {
+		body := []byte(`{"user":{"newPassword":"p1","currentPassword":"p2"},"items":[{"token":"t"}],"title":"ok"}`)
+
+		result := loggableBody(body, 1024)
+
+		assert.Equal(t, `{"items":[{"token":"[REDACTED]"}],"title":"ok","user":{"currentPassword":"[REDACTED]","newPassword":"[REDACTED]"}}`, result)
+	})
+
+	t.Run("redacts api keys and one-time codes", func(t *testing.T) {
+		body := []byte(`{"key":"gft_abc","api_key":"gft_def","sessionId":"s1","code":"123456"}`)
+
+		result := loggableBody(body, 1024)
+
+		assert.Equal(t, `{"api_key":"[REDACTED]","code":"[REDACTED]","key":"[REDACTED]","sessionId":"s1"}`, result)
+	})
+
+	t.Run("truncates long bodies", func(t *testing.T) {
+		body := []byte(`{"description":"` + strings.Repeat("a", 100) + `"}`)
+
+		result := loggableBody(body, 20)
+
+		assert.True(t, strings.HasPrefix(result, `{"description":"aaaa`))
+		assert.Contains(t, result, "...[truncated 98 bytes]")
+	})
+
+	t.Run("truncation happens after redaction", func(t *testing.T) {
+		body := []byte(`{"password":"` + strings.Repeat("x", 100) + `"}`)
+
+		result := loggableBody(body, 1024)
+
+		assert.Equal(t, `{"password":"[REDACTED]"}`, result)
+	})
+
+	t.Run("omits non-JSON bodies", func(t *testing.T) {
+		result := loggableBody([]byte("username=alice&password=hunter22"), 1024)
+
+		assert.Equal(t, "[32 byte non-JSON body omitted]", result)
+	})
+
+	t.Run("empty body", func(t *testing.T) {
+		assert.Equal(t, "", loggableBody(nil, 1024))
+	})
+}
+
+func TestGenerateRequestID(t *testing.T) {
+	// Act
+	seen := make(map[string]struct{}, 1000)
+	for i := 0; i < 1000; i++ {
+		id := generateRequestID()
+
+		// Assert
+		require.Len(t, id, 26)
+		_, duplicate := seen[id]
+		require.False(t, duplicate, "duplicate request ID %s", id)
+		seen[id] = struct{}{}
+	}
+}
+
+// recordedRequest is a request seen by recordingObserver
+type recordedRequest struct {
+	method string
+	route  string
+	status int
+}
+
+// recordingObserver keeps the requests it observes
+type recordingObserver struct {
+	requests []recordedRequest
+}
+
+func (o *recordingObserver) ObserveRequest(method, route string, status int, duration time.Duration) {
+	o.requests = append(o.requests, recordedRequest{method: method, route: route, status: status})
+}
+
+func TestRequestMetrics(t *testing.T) {
+	observer := &recordingObserver{}
+	app := fiber.New()
+	app.Use(RequestMetrics(observer))
+	app.Get("/todos/:id", func(c *fiber.Ctx) error {
+		if c.Params("id") == "missing" {
+			return fiber.ErrNotFound
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for _, path := range []string{"/todos/1", "/todos/missing"} {
+		_, err := app.Test(httptest.NewRequest("GET", path, nil))
+		require.NoError(t, err)
+	}
+
+	// Requests are grouped by route pattern rather than path
+	assert.Equal(t, []recordedRequest{
+		{method: "GET", route: "/todos/:id", status: 200},
+		{method: "GET", route: "/todos/:id", status: 404},
+	}, observer.requests)
+}
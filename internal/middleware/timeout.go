@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestTimeout gives each request a deadline on its user context, so repository calls made
+// with c.UserContext() are canceled once it passes. The handler is not interrupted; when it
+// returns after the deadline, whatever it answered is replaced with a 504.
+func RequestTimeout(timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return err
+		}
+
+		return c.Status(fiber.StatusGatewayTimeout).JSON(fiber.Map{
+			"error":   "Gateway Timeout",
+			"message": "The request took too long to process.",
+		})
+	}
+}
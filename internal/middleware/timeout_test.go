@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	app := fiber.New()
+	app.Use(RequestTimeout(20 * time.Millisecond))
+	app.Get("/fast", func(c *fiber.Ctx) error {
+		_, hasDeadline := c.UserContext().Deadline()
+		assert.True(t, hasDeadline)
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		<-c.UserContext().Done()
+		return c.Status(fiber.StatusInternalServerError).SendString(context.Cause(c.UserContext()).Error())
+	})
+
+	t.Run("passes through requests that finish in time", func(t *testing.T) {
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/fast", nil), -1)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+	})
+
+	t.Run("answers 504 once the deadline passes", func(t *testing.T) {
+		// Act
+		resp, err := app.Test(httptest.NewRequest("GET", "/slow", nil), -1)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, fiber.StatusGatewayTimeout, resp.StatusCode)
+		assert.Equal(t, fiber.MIMEApplicationJSON, resp.Header.Get("Content-Type"))
+	})
+}
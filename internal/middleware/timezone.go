@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// localizedTimestampKeys are the JSON keys whose RFC 3339 values are shifted into the
+// client's timezone
+var localizedTimestampKeys = map[string]bool{
+	"createdAt": true,
+	"updatedAt": true,
+	"dueDate":   true,
+	"deletedAt": true,
+}
+
+// LocalizeTimestamps rewrites timestamps in JSON responses into the timezone named by the
+// X-Timezone header, keeping the same instant but with the zone's offset. A missing or
+// invalid header leaves them in UTC. Due dates of all-day todos stand for a calendar day
+// rather than an instant, so they are left as stored.
+func LocalizeTimestamps() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Process request
+		err := c.Next()
+
+		// The body depends on the header whether or not it is sent
+		c.Vary("X-Timezone")
+
+		name := c.Get("X-Timezone")
+		if name == "" || c.Response().IsBodyStream() {
+			return err
+		}
+
+		loc, locErr := time.LoadLocation(name)
+		if locErr != nil || loc == time.UTC {
+			return err
+		}
+		if !strings.HasPrefix(string(c.Response().Header.ContentType()), fiber.MIMEApplicationJSON) {
+			return err
+		}
+
+		if body, ok := localizeBody(c.Response().Body(), loc); ok {
+			c.Response().SetBodyRaw(body)
+		}
+
+		return err
+	}
+}
+
+// localizeBody re-encodes a JSON body with its timestamps in loc, reporting false when the
+// body is not valid JSON
+func localizeBody(body []byte, loc *time.Location) ([]byte, bool) {
+	if len(body) == 0 {
+		return nil, false
+	}
+
+	// Decode numbers as json.Number so large integers survive the round trip
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+
+	localized, err := json.Marshal(localizeTimestamps(value, loc))
+	if err != nil {
+		return nil, false
+	}
+	return localized, true
+}
+
+// localizeTimestamps shifts the values of timestamp keys into loc at any depth
+func localizeTimestamps(value any, loc *time.Location) any {
+	switch v := value.(type) {
+	case map[string]any:
+		allDay, _ := v["allDay"].(bool)
+		for key, field := range v {
+			if localizedTimestampKeys[key] && !(key == "dueDate" && allDay) {
+				v[key] = localizeTimestamp(field, loc)
+			} else {
+				v[key] = localizeTimestamps(field, loc)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = localizeTimestamps(item, loc)
+		}
+	}
+	return value
+}
+
+// localizeTimestamp formats an RFC 3339 string in loc, leaving any other value as is
+func localizeTimestamp(value any, loc *time.Location) any {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return value
+	}
+	return t.In(loc).Format(time.RFC3339Nano)
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalizeTimestamps(t *testing.T) {
+	app := fiber.New()
+	app.Use(LocalizeTimestamps())
+	app.Get("/todo", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"id":        "todo-1",
+			"count":     9007199254740993,
+			"createdAt": "2025-10-15T13:30:00Z",
+			"dueDate":   "2025-01-15T18:00:00Z",
+			"allDay":    false,
+			"subtask":   fiber.Map{"updatedAt": "2025-10-15T13:30:00.5Z"},
+		})
+	})
+	app.Get("/all-day", func(c *fiber.Ctx) error {
+		return c.JSON([]fiber.Map{{"dueDate": "2025-10-15T00:00:00Z", "allDay": true, "createdAt": "2025-10-15T00:00:00Z"}})
+	})
+
+	// get returns the response's Vary header, raw body and decoded body
+	get := func(path, timezone string) (string, string, map[string]any) {
+		req := httptest.NewRequest("GET", path, nil)
+		if timezone != "" {
+			req.Header.Set("X-Timezone", timezone)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		body, _ := io.ReadAll(resp.Body)
+		var parsed map[string]any
+		json.Unmarshal(body, &parsed)
+		return resp.Header.Get("Vary"), string(body), parsed
+	}
+
+	t.Run("shifts timestamps to the zone's offset", func(t *testing.T) {
+		tests := []struct {
+			timezone  string
+			createdAt string
+			dueDate   string
+			updatedAt string
+		}{
+			// Daylight saving time in October, standard time in January
+			{"America/New_York", "2025-10-15T09:30:00-04:00", "2025-01-15T13:00:00-05:00", "2025-10-15T09:30:00.5-04:00"},
+			{"Asia/Kolkata", "2025-10-15T19:00:00+05:30", "2025-01-15T23:30:00+05:30", "2025-10-15T19:00:00.5+05:30"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.timezone, func(t *testing.T) {
+				vary, raw, body := get("/todo", tt.timezone)
+
+				assert.Equal(t, tt.createdAt, body["createdAt"])
+				assert.Equal(t, tt.dueDate, body["dueDate"])
+				assert.Equal(t, tt.updatedAt, body["subtask"].(map[string]any)["updatedAt"])
+				assert.Contains(t, raw, `"count":9007199254740993`)
+				assert.Contains(t, vary, "X-Timezone")
+			})
+		}
+	})
+
+	t.Run("missing or invalid timezone keeps UTC", func(t *testing.T) {
+		for _, timezone := range []string{"", "Mars/Olympus_Mons"} {
+			_, _, body := get("/todo", timezone)
+
+			assert.Equal(t, "2025-10-15T13:30:00Z", body["createdAt"], timezone)
+			assert.Equal(t, "2025-01-15T18:00:00Z", body["dueDate"], timezone)
+		}
+	})
+
+	t.Run("all-day due dates keep their day", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/all-day", nil)
+		req.Header.Set("X-Timezone", "America/New_York")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		var todos []map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&todos))
+		assert.Equal(t, "2025-10-15T00:00:00Z", todos[0]["dueDate"])
+		assert.Equal(t, "2025-10-14T20:00:00-04:00", todos[0]["createdAt"])
+	})
+}
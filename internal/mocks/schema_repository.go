@@ -0,0 +1,18 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSchemaRepository is a mock implementation of the SchemaRepository interface
+type MockSchemaRepository struct {
+	mock.Mock
+}
+
+// GetSchemaVersion returns the applied migration version
+func (m *MockSchemaRepository) GetSchemaVersion(ctx context.Context) (string, error) {
+	args := m.Called(ctx)
+	return args.String(0), args.Error(1)
+}
@@ -35,8 +35,86 @@ func (m *MockSessionStore) Delete(ctx context.Context, sessionID string) error {
 	return args.Error(0)
 }
 
+// GetUserSessions mocks the GetUserSessions method
+func (m *MockSessionStore) GetUserSessions(ctx context.Context, userID string) ([]*models.Session, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Session), args.Error(1)
+}
+
 // DeleteUserSessions mocks the DeleteUserSessions method
-func (m *MockSessionStore) DeleteUserSessions(ctx context.Context, userID string) error {
+func (m *MockSessionStore) DeleteUserSessions(ctx context.Context, userID string) (int64, error) {
 	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// DeleteOtherUserSessions mocks the DeleteOtherUserSessions method
+func (m *MockSessionStore) DeleteOtherUserSessions(ctx context.Context, userID, keepSessionID string) (int64, error) {
+	args := m.Called(ctx, userID, keepSessionID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockResetTokenStore is a mock implementation of ResetTokenStore
+type MockResetTokenStore struct {
+	mock.Mock
+}
+
+// Create mocks the Create method
+func (m *MockResetTokenStore) Create(ctx context.Context, userID string, expiration time.Duration) (string, error) {
+	args := m.Called(ctx, userID, expiration)
+	return args.String(0), args.Error(1)
+}
+
+// Get mocks the Get method
+func (m *MockResetTokenStore) Get(ctx context.Context, token string) (string, error) {
+	args := m.Called(ctx, token)
+	return args.String(0), args.Error(1)
+}
+
+// Consume mocks the Consume method
+func (m *MockResetTokenStore) Consume(ctx context.Context, token string) (string, error) {
+	args := m.Called(ctx, token)
+	return args.String(0), args.Error(1)
+}
+
+// MockLoginAttemptStore is a mock implementation of LoginAttemptStore
+type MockLoginAttemptStore struct {
+	mock.Mock
+}
+
+// RecordFailure mocks the RecordFailure method
+func (m *MockLoginAttemptStore) RecordFailure(ctx context.Context, key string, window time.Duration) (int64, error) {
+	args := m.Called(ctx, key, window)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// Lock mocks the Lock method
+func (m *MockLoginAttemptStore) Lock(ctx context.Context, key string, duration time.Duration) error {
+	args := m.Called(ctx, key, duration)
+	return args.Error(0)
+}
+
+// LockedFor mocks the LockedFor method
+func (m *MockLoginAttemptStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+// Reset mocks the Reset method
+func (m *MockLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+// MockResetTokenSender is a mock implementation of ResetTokenSender
+type MockResetTokenSender struct {
+	mock.Mock
+}
+
+// SendResetToken mocks the SendResetToken method
+func (m *MockResetTokenSender) SendResetToken(ctx context.Context, user *models.User, token string, expiresAt time.Time) error {
+	args := m.Called(ctx, user, token, expiresAt)
 	return args.Error(0)
 }
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"go-fiber/internal/models"
 
@@ -22,6 +23,15 @@ func (m *MockTodoRepository) Create(ctx context.Context, todo *models.Todo) (*mo
 	return args.Get(0).(*models.Todo), args.Error(1)
 }
 
+// CreateBatch creates several todos at once
+func (m *MockTodoRepository) CreateBatch(ctx context.Context, todos []*models.Todo) ([]*models.Todo, error) {
+	args := m.Called(ctx, todos)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Todo), args.Error(1)
+}
+
 // GetByID retrieves a todo by ID
 func (m *MockTodoRepository) GetByID(ctx context.Context, id string) (*models.Todo, error) {
 	args := m.Called(ctx, id)
@@ -31,6 +41,15 @@ func (m *MockTodoRepository) GetByID(ctx context.Context, id string) (*models.To
 	return args.Get(0).(*models.Todo), args.Error(1)
 }
 
+// GetByIDForUser retrieves a todo by ID if the user owns it
+func (m *MockTodoRepository) GetByIDForUser(ctx context.Context, id, userID string) (*models.Todo, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Todo), args.Error(1)
+}
+
 // GetByUserID retrieves all todos for a specific user
 func (m *MockTodoRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error) {
 	args := m.Called(ctx, userID, limit, offset)
@@ -49,15 +68,48 @@ func (m *MockTodoRepository) Update(ctx context.Context, todo *models.Todo) (*mo
 	return args.Get(0).(*models.Todo), args.Error(1)
 }
 
-// Delete soft deletes a todo
-func (m *MockTodoRepository) Delete(ctx context.Context, id string) error {
-	args := m.Called(ctx, id)
+// PartialUpdate sets only the fields present in patch on a user's todo
+func (m *MockTodoRepository) PartialUpdate(ctx context.Context, userID, id string, patch *models.PatchTodoRequest) (*models.Todo, error) {
+	args := m.Called(ctx, userID, id, patch)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Todo), args.Error(1)
+}
+
+// Delete soft deletes a todo of the user
+func (m *MockTodoRepository) Delete(ctx context.Context, userID, id string) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+// GetByUserIDCursor retrieves a cursor page of todos by user ID, optionally as of a snapshot
+func (m *MockTodoRepository) GetByUserIDCursor(ctx context.Context, userID string, cursor string, snapshot *time.Time, limit int) ([]*models.Todo, string, error) {
+	args := m.Called(ctx, userID, cursor, snapshot, limit)
+	if args.Get(0) == nil {
+		return nil, args.String(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.Todo), args.String(1), args.Error(2)
+}
+
+// StreamByUserID calls fn for each of a user's todos
+func (m *MockTodoRepository) StreamByUserID(ctx context.Context, userID string, fn func(*models.Todo) error) error {
+	args := m.Called(ctx, userID, fn)
 	return args.Error(0)
 }
 
-// UpdateStatus updates the status of a todo
-func (m *MockTodoRepository) UpdateStatus(ctx context.Context, id, status string) error {
-	args := m.Called(ctx, id, status)
+// GetFiltered retrieves todos matching a filter in the given order
+func (m *MockTodoRepository) GetFiltered(ctx context.Context, userID string, filter models.TodoFilter, sort models.TodoSort, limit, offset int) ([]*models.Todo, int64, error) {
+	args := m.Called(ctx, userID, filter, sort, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]*models.Todo), args.Get(1).(int64), args.Error(2)
+}
+
+// UpdateStatus updates the status of a todo of the user
+func (m *MockTodoRepository) UpdateStatus(ctx context.Context, userID, id, status string) error {
+	args := m.Called(ctx, userID, id, status)
 	return args.Error(0)
 }
 
@@ -70,6 +122,15 @@ func (m *MockTodoRepository) GetByStatus(ctx context.Context, userID, status str
 	return args.Get(0).([]*models.Todo), args.Get(1).(int64), args.Error(2)
 }
 
+// GetByStatuses retrieves todos by user ID in any of several statuses
+func (m *MockTodoRepository) GetByStatuses(ctx context.Context, userID string, statuses []string, limit, offset int) ([]*models.Todo, int64, error) {
+	args := m.Called(ctx, userID, statuses, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]*models.Todo), args.Get(1).(int64), args.Error(2)
+}
+
 // GetByPriority retrieves todos by user ID and priority
 func (m *MockTodoRepository) GetByPriority(ctx context.Context, userID, priority string, limit, offset int) ([]*models.Todo, int64, error) {
 	args := m.Called(ctx, userID, priority, limit, offset)
@@ -79,15 +140,33 @@ func (m *MockTodoRepository) GetByPriority(ctx context.Context, userID, priority
 	return args.Get(0).([]*models.Todo), args.Get(1).(int64), args.Error(2)
 }
 
+// GetByTag retrieves todos carrying a tag with pagination
+func (m *MockTodoRepository) GetByTag(ctx context.Context, userID, tag string, limit, offset int) ([]*models.Todo, int64, error) {
+	args := m.Called(ctx, userID, tag, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]*models.Todo), args.Get(1).(int64), args.Error(2)
+}
+
 // GetOverdue retrieves overdue todos
-func (m *MockTodoRepository) GetOverdue(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error) {
-	args := m.Called(ctx, userID, limit, offset)
+func (m *MockTodoRepository) GetOverdue(ctx context.Context, userID string, cutoff models.OverdueCutoff, limit, offset int) ([]*models.Todo, int64, error) {
+	args := m.Called(ctx, userID, cutoff, limit, offset)
 	if args.Get(0) == nil {
 		return nil, args.Get(1).(int64), args.Error(2)
 	}
 	return args.Get(0).([]*models.Todo), args.Get(1).(int64), args.Error(2)
 }
 
+// GetMostOverdue retrieves the todo with the oldest past due date
+func (m *MockTodoRepository) GetMostOverdue(ctx context.Context, userID string, cutoff models.OverdueCutoff) (*models.Todo, error) {
+	args := m.Called(ctx, userID, cutoff)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Todo), args.Error(1)
+}
+
 // GetUpcoming retrieves upcoming todos
 func (m *MockTodoRepository) GetUpcoming(ctx context.Context, userID string, days int, limit, offset int) ([]*models.Todo, int64, error) {
 	args := m.Called(ctx, userID, days, limit, offset)
@@ -97,15 +176,87 @@ func (m *MockTodoRepository) GetUpcoming(ctx context.Context, userID string, day
 	return args.Get(0).([]*models.Todo), args.Get(1).(int64), args.Error(2)
 }
 
+// GetDueDistribution counts not-done todos by due date bucket
+func (m *MockTodoRepository) GetDueDistribution(ctx context.Context, userID string, bounds models.DueDistributionBounds) (*models.DueDistribution, error) {
+	args := m.Called(ctx, userID, bounds)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DueDistribution), args.Error(1)
+}
+
+// CountCompletion counts the done and all todos in a completion window
+func (m *MockTodoRepository) CountCompletion(ctx context.Context, userID string, window models.CompletionWindow) (int64, int64, error) {
+	args := m.Called(ctx, userID, window)
+	return args.Get(0).(int64), args.Get(1).(int64), args.Error(2)
+}
+
+// GetBusiestDay finds the day the most todos in a window were created
+func (m *MockTodoRepository) GetBusiestDay(ctx context.Context, userID string, window models.CompletionWindow) (*models.DayCount, error) {
+	args := m.Called(ctx, userID, window)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DayCount), args.Error(1)
+}
+
+// CountTopTags counts the most-used tags of the todos in a window
+func (m *MockTodoRepository) CountTopTags(ctx context.Context, userID string, window models.CompletionWindow, limit int) ([]models.TagCount, error) {
+	args := m.Called(ctx, userID, window, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TagCount), args.Error(1)
+}
+
+// AverageCompletionTime averages the time taken to complete the done todos in a window
+func (m *MockTodoRepository) AverageCompletionTime(ctx context.Context, userID string, window models.CompletionWindow) (time.Duration, error) {
+	args := m.Called(ctx, userID, window)
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+// GetRecentlyUpdated retrieves todos ordered by last update
+func (m *MockTodoRepository) GetRecentlyUpdated(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error) {
+	args := m.Called(ctx, userID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]*models.Todo), args.Get(1).(int64), args.Error(2)
+}
+
+// GetDueOn retrieves not-done todos due on a day
+func (m *MockTodoRepository) GetDueOn(ctx context.Context, userID string, day models.DayRange) ([]*models.Todo, error) {
+	args := m.Called(ctx, userID, day)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Todo), args.Error(1)
+}
+
+// GetUndated retrieves not-done todos without a due date
+func (m *MockTodoRepository) GetUndated(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error) {
+	args := m.Called(ctx, userID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]*models.Todo), args.Get(1).(int64), args.Error(2)
+}
+
 // Search searches todos by query
-func (m *MockTodoRepository) Search(ctx context.Context, userID, query string, limit, offset int) ([]*models.Todo, int64, error) {
-	args := m.Called(ctx, userID, query, limit, offset)
+func (m *MockTodoRepository) Search(ctx context.Context, userID, query, mode string, limit, offset int) ([]*models.Todo, int64, error) {
+	args := m.Called(ctx, userID, query, mode, limit, offset)
 	if args.Get(0) == nil {
 		return nil, args.Get(1).(int64), args.Error(2)
 	}
 	return args.Get(0).([]*models.Todo), args.Get(1).(int64), args.Error(2)
 }
 
+// CountByUserID counts a user's todos
+func (m *MockTodoRepository) CountByUserID(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 // CountByStatus counts todos by status
 func (m *MockTodoRepository) CountByStatus(ctx context.Context, userID string) (map[string]int64, error) {
 	args := m.Called(ctx, userID)
@@ -115,20 +266,95 @@ func (m *MockTodoRepository) CountByStatus(ctx context.Context, userID string) (
 	return args.Get(0).(map[string]int64), args.Error(1)
 }
 
-// MarkCompleted marks a todo as completed
-func (m *MockTodoRepository) MarkCompleted(ctx context.Context, id string) error {
-	args := m.Called(ctx, id)
-	return args.Error(0)
+// CountOverdue counts a user's overdue todos
+func (m *MockTodoRepository) CountOverdue(ctx context.Context, userID string, cutoff models.OverdueCutoff) (int64, error) {
+	args := m.Called(ctx, userID, cutoff)
+	return args.Get(0).(int64), args.Error(1)
 }
 
-// BulkUpdateStatus updates status for multiple todos
-func (m *MockTodoRepository) BulkUpdateStatus(ctx context.Context, ids []string, status string) error {
-	args := m.Called(ctx, ids, status)
+// MarkCompleted marks a todo of the user as completed
+func (m *MockTodoRepository) MarkCompleted(ctx context.Context, userID, id string) error {
+	args := m.Called(ctx, userID, id)
 	return args.Error(0)
 }
 
+// BulkUpdateStatusForUser updates status for multiple todos owned by a user
+func (m *MockTodoRepository) BulkUpdateStatusForUser(ctx context.Context, userID string, ids []string, status string) (int64, error) {
+	args := m.Called(ctx, userID, ids, status)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// BulkReschedule sets or shifts the due dates of several todos
+func (m *MockTodoRepository) BulkReschedule(ctx context.Context, userID string, ids []string, dueDate *time.Time, shift time.Duration) (int64, error) {
+	args := m.Called(ctx, userID, ids, dueDate, shift)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// BulkUpdatePriority sets the priority of a user's todos matching a filter
+func (m *MockTodoRepository) BulkUpdatePriority(ctx context.Context, userID string, filter models.TodoFilter, priority string, dryRun bool) (int64, error) {
+	args := m.Called(ctx, userID, filter, priority, dryRun)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// BulkPatch applies the same field changes to several of a user's todos
+func (m *MockTodoRepository) BulkPatch(ctx context.Context, userID string, ids []string, patch *models.PatchTodoRequest, dryRun bool) (int64, error) {
+	args := m.Called(ctx, userID, ids, patch, dryRun)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 // DeleteCompleted deletes all completed todos for a user
-func (m *MockTodoRepository) DeleteCompleted(ctx context.Context, userID string) error {
+func (m *MockTodoRepository) DeleteCompleted(ctx context.Context, userID string) (int64, error) {
 	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// GetDeleted retrieves soft-deleted todos for a user
+func (m *MockTodoRepository) GetDeleted(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error) {
+	args := m.Called(ctx, userID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]*models.Todo), args.Get(1).(int64), args.Error(2)
+}
+
+// Restore clears the deletion mark of a user's soft-deleted todo
+func (m *MockTodoRepository) Restore(ctx context.Context, userID, id string) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+// HardDelete permanently removes a user's soft-deleted todo
+func (m *MockTodoRepository) HardDelete(ctx context.Context, userID, id string) error {
+	args := m.Called(ctx, userID, id)
 	return args.Error(0)
 }
+
+// PurgeDeleted permanently removes todos soft deleted before olderThan
+func (m *MockTodoRepository) PurgeDeleted(ctx context.Context, olderThan time.Time) (int64, error) {
+	args := m.Called(ctx, olderThan)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// CountAllByStatus counts todos across all users by status
+func (m *MockTodoRepository) CountAllByStatus(ctx context.Context) (map[string]int64, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+// CountAllOverdue counts overdue todos across all users
+func (m *MockTodoRepository) CountAllOverdue(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// Merge saves the merged target and soft deletes the source
+func (m *MockTodoRepository) Merge(ctx context.Context, target *models.Todo, sourceID string) (*models.Todo, error) {
+	args := m.Called(ctx, target, sourceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Todo), args.Error(1)
+}
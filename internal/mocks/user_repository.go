@@ -64,6 +64,12 @@ func (m *MockUserRepository) Delete(ctx context.Context, id string) error {
 	return args.Error(0)
 }
 
+// DeleteAccount mocks the DeleteAccount method
+func (m *MockUserRepository) DeleteAccount(ctx context.Context, id, policy string) error {
+	args := m.Called(ctx, id, policy)
+	return args.Error(0)
+}
+
 // UpdateImage mocks the UpdateImage method
 func (m *MockUserRepository) UpdateImage(ctx context.Context, id, imageURL string) error {
 	args := m.Called(ctx, id, imageURL)
@@ -76,6 +82,12 @@ func (m *MockUserRepository) UpdatePassword(ctx context.Context, id, hashedPassw
 	return args.Error(0)
 }
 
+// UpdateRole mocks the UpdateRole method
+func (m *MockUserRepository) UpdateRole(ctx context.Context, id, role string) error {
+	args := m.Called(ctx, id, role)
+	return args.Error(0)
+}
+
 // List mocks the List method
 func (m *MockUserRepository) List(ctx context.Context, limit, offset int) ([]*models.User, int64, error) {
 	args := m.Called(ctx, limit, offset)
@@ -96,3 +108,24 @@ func (m *MockUserRepository) ExistsByUsername(ctx context.Context, username stri
 	args := m.Called(ctx, username)
 	return args.Bool(0), args.Error(1)
 }
+
+// GetTOTP mocks the GetTOTP method
+func (m *MockUserRepository) GetTOTP(ctx context.Context, id string) (*models.UserTOTP, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserTOTP), args.Error(1)
+}
+
+// UpdateTOTP mocks the UpdateTOTP method
+func (m *MockUserRepository) UpdateTOTP(ctx context.Context, id, encryptedSecret string, enabled bool) error {
+	args := m.Called(ctx, id, encryptedSecret, enabled)
+	return args.Error(0)
+}
+
+// UseTOTPStep mocks the UseTOTPStep method
+func (m *MockUserRepository) UseTOTPStep(ctx context.Context, id string, step int64) (bool, error) {
+	args := m.Called(ctx, id, step)
+	return args.Bool(0), args.Error(1)
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// API key scopes. Read covers GET requests to the todo endpoints, write covers every other method.
+const (
+	APIKeyScopeTodosRead  = "todos:read"
+	APIKeyScopeTodosWrite = "todos:write"
+)
+
+// APIKeyPrefix starts every API key, so keys are easy to recognize in configs and secret scanners
+const APIKeyPrefix = "tdk_"
+
+// APIKey represents an API key a machine client uses instead of logging in. Only a hash of
+// the key is stored; Prefix keeps its first characters so users can tell their keys apart.
+type APIKey struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// HasScope reports whether the key was granted scope
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// CreateAPIKeyRequest represents the request to create an API key
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,oneof=todos:read todos:write"`
+}
+
+// CreateAPIKeyResponse carries a new API key. Key is the only time the plaintext is returned.
+type CreateAPIKeyResponse struct {
+	Message string  `json:"message" example:"API key created successfully."`
+	Key     string  `json:"key" example:"tdk_3q2-7wEjB0XbKfL8yZ1mN4pR6sT9uV2w"`
+	APIKey  *APIKey `json:"apiKey"`
+}
+
+// APIKeyListResponse lists a user's API keys without their plaintext
+type APIKeyListResponse struct {
+	APIKeys []*APIKey `json:"apiKeys"`
+}
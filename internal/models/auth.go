@@ -4,16 +4,56 @@ import (
 	"time"
 )
 
-// LoginRequest represents the request to login
+// LoginRequest represents the request to login, either with a username or with an
+// identifier that may be a username or an email
 type LoginRequest struct {
-	Username string `json:"username" validate:"required"`
-	Password string `json:"password" validate:"required,min=6"`
+	Identifier string `json:"identifier,omitempty" validate:"required_without=Username"`
+	Username   string `json:"username,omitempty" validate:"required_without=Identifier"`
+	Password   string `json:"password" validate:"required,min=6"`
+
+	// UserAgent and IP describe the client logging in; they are set by the handler
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
 }
 
 // LoginByEmailRequest represents the request to login by email
 type LoginByEmailRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6"`
+
+	// UserAgent and IP describe the client logging in; they are set by the handler
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
+}
+
+// TOTPCodeRequest represents a request carrying a code from the user's authenticator app
+type TOTPCodeRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// TOTPChallengeRequest represents the request to finish a login that needs a two-factor code
+type TOTPChallengeRequest struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+	Code           string `json:"code" validate:"required,len=6,numeric"`
+
+	// UserAgent and IP describe the client logging in; they are set by the handler
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
+}
+
+// TOTPSetupResponse represents a new TOTP secret to add to an authenticator app, either
+// by typing in the secret or by scanning the otpauth URL as a QR code
+type TOTPSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauthUrl"`
+}
+
+// TwoFactorChallengeResponse represents a login that needs a two-factor code before tokens
+// are issued; the challenge token is exchanged at /auth/2fa/challenge
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool      `json:"twoFactorRequired"`
+	ChallengeToken    string    `json:"challengeToken"`
+	ExpiresAt         time.Time `json:"expiresAt"`
 }
 
 // LoginResponse represents the response after successful login
@@ -59,6 +99,29 @@ type LogoutResponse struct {
 	Message string `json:"message"`
 }
 
+// LogoutAllResponse represents the response after ending all of a user's sessions
+type LogoutAllResponse struct {
+	Message         string `json:"message"`
+	SessionsDeleted int64  `json:"sessionsDeleted"`
+}
+
+// SecuritySummaryResponse represents the session overview shown on the account security page
+type SecuritySummaryResponse struct {
+	CurrentSession *Session   `json:"currentSession,omitempty"`
+	ActiveSessions int        `json:"activeSessions"`
+	LastLoginAt    *time.Time `json:"lastLoginAt,omitempty"`
+
+	// LastLoginIP and LastLoginUserAgent are those of the newest session, when captured
+	LastLoginIP        string `json:"lastLoginIp,omitempty"`
+	LastLoginUserAgent string `json:"lastLoginUserAgent,omitempty"`
+}
+
+// SessionListResponse represents the active sessions of a user
+type SessionListResponse struct {
+	Sessions         []*Session `json:"sessions"`
+	CurrentSessionID string     `json:"currentSessionId,omitempty"`
+}
+
 // AuthUserResponse represents the authenticated user response
 type AuthUserResponse struct {
 	User *UserResponse `json:"user"`
@@ -68,6 +131,7 @@ type AuthUserResponse struct {
 type Claims struct {
 	UserID    string `json:"userId"`
 	Username  string `json:"username"`
+	Role      string `json:"role"`
 	SessionID string `json:"sessionId"`
 	Type      string `json:"type"` // "access" or "refresh"
 }
@@ -76,6 +140,9 @@ type Claims struct {
 const (
 	TokenTypeAccess  = "access"
 	TokenTypeRefresh = "refresh"
+
+	// TokenTypeTOTPChallenge marks a login waiting for its two-factor code
+	TokenTypeTOTPChallenge = "totp_challenge"
 )
 
 // Session represents a user session
@@ -85,4 +152,8 @@ type Session struct {
 	CreatedAt time.Time `json:"createdAt"`
 	ExpiresAt time.Time `json:"expiresAt"`
 	IsActive  bool      `json:"isActive"`
+
+	// UserAgent and IP are captured from the login request
+	UserAgent string `json:"userAgent,omitempty"`
+	IP        string `json:"ip,omitempty"`
 }
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Policies for serializing empty optional fields in responses
+const (
+	// OptionalFieldsOmit leaves empty optional fields out of the JSON entirely
+	OptionalFieldsOmit = "omit"
+	// OptionalFieldsNull always includes optional fields, as null when empty
+	OptionalFieldsNull = "null"
+)
+
+// optionalFields is the configured policy for empty optional response fields
+var optionalFields = OptionalFieldsOmit
+
+// SetOptionalFieldsPolicy configures how empty optional response fields are serialized
+func SetOptionalFieldsPolicy(policy string) {
+	optionalFields = policy
+}
+
+// optionalString maps an empty string to nil so it follows the optional fields policy
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// todoJSON is the omit-policy wire format of a Todo
+type todoJSON struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"userId"`
+	Title       string     `json:"title"`
+	Description *string    `json:"description,omitempty"`
+	Status      string     `json:"status"`
+	Priority    string     `json:"priority"`
+	DueDate     *time.Time `json:"dueDate,omitempty"`
+	AllDay      bool       `json:"allDay"`
+	Tags        []string   `json:"tags"`
+	Subtasks    []Subtask  `json:"subtasks"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"`
+}
+
+// todoJSONNull is the null-policy wire format of a Todo. Its fields shadow the optional
+// fields of the embedded todoJSON without omitempty.
+type todoJSONNull struct {
+	todoJSON
+	Description *string    `json:"description"`
+	DueDate     *time.Time `json:"dueDate"`
+	DeletedAt   *time.Time `json:"deletedAt"`
+}
+
+// MarshalJSON serializes the todo following the optional fields policy
+func (t Todo) MarshalJSON() ([]byte, error) {
+	v := todoJSON{
+		ID:          t.ID,
+		UserID:      t.UserID,
+		Title:       t.Title,
+		Description: optionalString(t.Description),
+		Status:      t.Status,
+		Priority:    t.Priority,
+		DueDate:     t.DueDate,
+		AllDay:      t.AllDay,
+		Tags:        t.Tags,
+		Subtasks:    t.Subtasks,
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+		DeletedAt:   t.DeletedAt,
+	}
+
+	// Tags and subtasks are lists rather than optional fields, so they are always arrays
+	if v.Tags == nil {
+		v.Tags = []string{}
+	}
+	if v.Subtasks == nil {
+		v.Subtasks = []Subtask{}
+	}
+
+	if optionalFields == OptionalFieldsNull {
+		return json.Marshal(todoJSONNull{todoJSON: v, Description: v.Description, DueDate: v.DueDate, DeletedAt: v.DeletedAt})
+	}
+	return json.Marshal(v)
+}
+
+// userResponseJSON is the omit-policy wire format of a UserResponse; userResponseJSONNull differs only in tags
+type userResponseJSON struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	Email     *string   `json:"email,omitempty"`
+	Image     *string   `json:"image,omitempty"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type userResponseJSONNull struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	Email     *string   `json:"email"`
+	Image     *string   `json:"image"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// MarshalJSON serializes the user following the optional fields policy
+func (u UserResponse) MarshalJSON() ([]byte, error) {
+	v := userResponseJSON{
+		ID:        u.ID,
+		Username:  u.Username,
+		Email:     optionalString(u.Email),
+		Image:     optionalString(u.Image),
+		Role:      u.Role,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+
+	if optionalFields == OptionalFieldsNull {
+		return json.Marshal(userResponseJSONNull(v))
+	}
+	return json.Marshal(v)
+}
+
+// MarshalJSON serializes the user like its UserResponse, following the optional fields policy
+func (u User) MarshalJSON() ([]byte, error) {
+	return u.ToResponse().MarshalJSON()
+}
+
+// NullableTime is a timestamp field of a partial update that tells an absent field apart
+// from an explicit null: Set is true whenever the field is present, with a nil Value for null
+type NullableTime struct {
+	Set   bool
+	Value *time.Time
+}
+
+// UnmarshalJSON records that the field was present and decodes its value, if any
+func (n *NullableTime) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	return json.Unmarshal(data, &n.Value)
+}
+
+// MarshalJSON serializes the value, or null when it is unset or cleared
+func (n NullableTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.Value)
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func marshalToMap(t *testing.T, v any) map[string]any {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	var result map[string]any
+	require.NoError(t, json.Unmarshal(data, &result))
+	return result
+}
+
+func TestTodo_MarshalJSON_OptionalFields(t *testing.T) {
+	defer SetOptionalFieldsPolicy(OptionalFieldsOmit)
+
+	todo := &Todo{ID: "todo-1", UserID: "user-1", Title: "Test", Status: "pending", Priority: "medium"}
+
+	t.Run("omit leaves empty optional fields out", func(t *testing.T) {
+		SetOptionalFieldsPolicy(OptionalFieldsOmit)
+
+		result := marshalToMap(t, todo)
+
+		assert.NotContains(t, result, "description")
+		assert.NotContains(t, result, "dueDate")
+		assert.NotContains(t, result, "deletedAt")
+		assert.Equal(t, "medium", result["priority"])
+	})
+
+	t.Run("null includes empty optional fields as null", func(t *testing.T) {
+		SetOptionalFieldsPolicy(OptionalFieldsNull)
+
+		result := marshalToMap(t, todo)
+
+		for _, field := range []string{"description", "dueDate", "deletedAt"} {
+			value, ok := result[field]
+			assert.True(t, ok, field)
+			assert.Nil(t, value, field)
+		}
+	})
+
+	t.Run("tags are always an array", func(t *testing.T) {
+		for _, policy := range []string{OptionalFieldsOmit, OptionalFieldsNull} {
+			SetOptionalFieldsPolicy(policy)
+
+			assert.Equal(t, []any{}, marshalToMap(t, todo)["tags"], policy)
+		}
+	})
+
+	t.Run("set fields are serialized under both policies", func(t *testing.T) {
+		dueDate := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+		withValues := *todo
+		withValues.Description = "Details"
+		withValues.DueDate = &dueDate
+
+		for _, policy := range []string{OptionalFieldsOmit, OptionalFieldsNull} {
+			SetOptionalFieldsPolicy(policy)
+
+			result := marshalToMap(t, withValues)
+
+			assert.Equal(t, "Details", result["description"], policy)
+			assert.Equal(t, "2025-01-02T03:04:05Z", result["dueDate"], policy)
+		}
+	})
+}
+
+func TestUser_MarshalJSON_OptionalFields(t *testing.T) {
+	defer SetOptionalFieldsPolicy(OptionalFieldsOmit)
+
+	user := &User{ID: "user-1", Username: "testuser", Password: "hashed"}
+
+	t.Run("omit", func(t *testing.T) {
+		SetOptionalFieldsPolicy(OptionalFieldsOmit)
+
+		result := marshalToMap(t, user)
+
+		assert.NotContains(t, result, "email")
+		assert.NotContains(t, result, "image")
+		assert.NotContains(t, result, "password")
+	})
+
+	t.Run("null", func(t *testing.T) {
+		SetOptionalFieldsPolicy(OptionalFieldsNull)
+
+		result := marshalToMap(t, user.ToResponse())
+
+		assert.Contains(t, result, "email")
+		assert.Nil(t, result["email"])
+		assert.Contains(t, result, "image")
+		assert.Nil(t, result["image"])
+		assert.NotContains(t, result, "password")
+	})
+}
+
+func TestNullableTime_UnmarshalJSON(t *testing.T) {
+	var req PatchTodoRequest
+	require.NoError(t, json.Unmarshal([]byte(`{}`), &req))
+	assert.False(t, req.DueDate.Set)
+
+	req = PatchTodoRequest{}
+	require.NoError(t, json.Unmarshal([]byte(`{"dueDate": null}`), &req))
+	assert.True(t, req.DueDate.Set)
+	assert.Nil(t, req.DueDate.Value)
+
+	req = PatchTodoRequest{}
+	require.NoError(t, json.Unmarshal([]byte(`{"dueDate": "2025-10-20T15:00:00Z"}`), &req))
+	assert.True(t, req.DueDate.Set)
+	require.NotNil(t, req.DueDate.Value)
+	assert.True(t, req.DueDate.Value.Equal(time.Date(2025, 10, 20, 15, 0, 0, 0, time.UTC)))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"dueDate": "tomorrow"}`), &req))
+}
@@ -7,6 +7,7 @@ type ErrorResponse struct {
 	Error   string `json:"error" example:"Bad Request"`
 	Message string `json:"message" example:"Invalid input data."`
 	Details string `json:"details,omitempty" example:"Validation failed."`
+	Code    string `json:"code,omitempty" example:"NOT_FOUND"`
 }
 
 // MessageResponse represents a simple message response
@@ -20,6 +21,45 @@ type TodoResponse struct {
 	Data    *Todo  `json:"data"`
 }
 
+// TodoStatusResponse represents the result of changing the status of a todo
+type TodoStatusResponse struct {
+	Message string `json:"message" example:"Todo status updated successfully."`
+	Status  string `json:"status" example:"completed"`
+}
+
+// IDResponse represents a minimal response carrying only the resource ID
+type IDResponse struct {
+	ID string `json:"id" example:"01HZX3J5Q8W9K2M4N6P7R8S9T0"`
+}
+
+// BulkUpdateResponse represents the result of an operation on several todos
+type BulkUpdateResponse struct {
+	Message string `json:"message" example:"Todos rescheduled successfully."`
+	Updated int64  `json:"updated" example:"3"`
+}
+
+// DeleteCompletedResponse represents the result of clearing a user's completed todos
+type DeleteCompletedResponse struct {
+	Message string `json:"message" example:"Completed todos deleted successfully."`
+	Deleted int64  `json:"deleted" example:"3"`
+}
+
+// BulkPriorityResponse represents the result of a bulk priority change or its dry run
+type BulkPriorityResponse struct {
+	Message string `json:"message" example:"Todo priorities updated successfully."`
+	Matched int64  `json:"matched" example:"3"`
+	Updated int64  `json:"updated" example:"3"`
+	DryRun  bool   `json:"dryRun" example:"false"`
+}
+
+// BulkPatchResponse represents the result of a bulk patch or its dry run
+type BulkPatchResponse struct {
+	Message string `json:"message" example:"Todos updated successfully."`
+	Matched int64  `json:"matched" example:"3"`
+	Updated int64  `json:"updated" example:"3"`
+	DryRun  bool   `json:"dryRun" example:"false"`
+}
+
 // AuthResponse represents an authentication response
 type AuthResponse struct {
 	Message      string `json:"message" example:"Login successful."`
@@ -42,9 +82,39 @@ type HealthResponse struct {
 	Services  map[string]ServiceInfo `json:"services"`
 }
 
+// SchemaVersionResponse represents the applied database schema version
+type SchemaVersionResponse struct {
+	Driver  string `json:"driver" example:"postgres"`
+	Version string `json:"version" example:"20251015110000"`
+}
+
+// EnumsResponse represents the configured values clients can offer for todo fields
+type EnumsResponse struct {
+	Statuses        []string `json:"statuses" example:"pending,in_progress,completed"`
+	DefaultStatus   string   `json:"defaultStatus" example:"pending"`
+	DoneStatus      string   `json:"doneStatus,omitempty" example:"completed"`
+	Priorities      []string `json:"priorities" example:"low,medium,high"`
+	DefaultPriority string   `json:"defaultPriority" example:"medium"`
+}
+
 // ServiceInfo represents the status of a service
 type ServiceInfo struct {
 	Status       string `json:"status" example:"healthy"`
 	ResponseTime string `json:"responseTime" example:"5ms"`
 	Error        string `json:"error,omitempty" example:"Connection failed."`
 }
+
+// PageCount returns how many pages of limit items hold total items, and the 1-based page
+// starting at offset. A limit of 0 or less puts every item on a single page.
+func PageCount(total int64, limit, offset int) (totalPages, page int) {
+	if limit <= 0 {
+		if total > 0 {
+			return 1, 1
+		}
+		return 0, 1
+	}
+
+	totalPages = int((total + int64(limit) - 1) / int64(limit))
+	page = offset/limit + 1
+	return totalPages, page
+}
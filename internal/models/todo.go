@@ -1,7 +1,16 @@
 package models
 
 import (
+	"encoding/base64"
+	"errors"
+	"math"
+	"slices"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/oklog/ulid/v2"
 )
 
 // Todo represents a todo item in the system
@@ -9,20 +18,61 @@ type Todo struct {
 	ID          string     `json:"id" db:"id"`
 	UserID      string     `json:"userId" db:"user_id"`
 	Title       string     `json:"title" db:"title" validate:"required,min=1,max=200"`
-	Description string     `json:"description" db:"description"`
-	Status      string     `json:"status" db:"status" validate:"required,oneof=pending in_progress completed"`
-	Priority    string     `json:"priority" db:"priority" validate:"oneof=low medium high"`
+	Description string     `json:"description" db:"description" validate:"todo_description"`
+	Status      string     `json:"status" db:"status" validate:"required,todo_status"`
+	Priority    string     `json:"priority" db:"priority" validate:"todo_priority"`
 	DueDate     *time.Time `json:"dueDate,omitempty" db:"due_date"`
+	AllDay      bool       `json:"allDay" db:"all_day"`
+	Tags        []string   `json:"tags" db:"tags"`
+	Subtasks    []Subtask  `json:"subtasks" db:"subtasks"`
 	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
 	UpdatedAt   time.Time  `json:"updatedAt" db:"updated_at"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
+}
+
+// Subtask is a checklist item within a todo, addressed by its position in the list
+type Subtask struct {
+	Title string `json:"title"`
+	Done  bool   `json:"done"`
+}
+
+// MaxSubtasks caps the checklist length of a single todo
+const MaxSubtasks = 100
+
+// SubtaskProgress returns the percentage of done subtasks, rounded down; a todo without
+// subtasks reports 0
+func (t *Todo) SubtaskProgress() int {
+	if len(t.Subtasks) == 0 {
+		return 0
+	}
+
+	done := 0
+	for _, subtask := range t.Subtasks {
+		if subtask.Done {
+			done++
+		}
+	}
+	return done * 100 / len(t.Subtasks)
 }
 
 // GetTodosQueryParams represents query parameters for getting todos
 type GetTodosQueryParams struct {
 	Limit    int    `query:"limit" validate:"omitempty,min=1,max=100"`
 	Offset   int    `query:"offset" validate:"omitempty,min=0"`
-	Status   string `query:"status" validate:"omitempty,oneof=pending in_progress completed"`
-	Priority string `query:"priority" validate:"omitempty,oneof=low medium high"`
+	Priority string `query:"priority" validate:"omitempty,todo_priority"`
+	Tag      string `query:"tag" validate:"omitempty,max=50"`
+	Cursor   string `query:"cursor" validate:"omitempty,max=64"`
+	// Status holds the requested statuses, given as repeated or comma-separated values
+	Status []string `query:"status" validate:"omitempty,dive,todo_status"`
+	// Snapshot is an RFC 3339 time that cursor pages are taken as of
+	Snapshot string `query:"snapshot" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+
+	// DueFrom and DueBefore are RFC 3339 timestamps bounding the due date
+	DueFrom   string `query:"dueFrom" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	DueBefore string `query:"dueBefore" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+
+	SortBy string `query:"sortBy" validate:"omitempty,oneof=createdAt dueDate priority title"`
+	Order  string `query:"order" validate:"omitempty,oneof=asc desc"`
 }
 
 // PaginationQueryParams represents basic pagination query parameters
@@ -31,9 +81,28 @@ type PaginationQueryParams struct {
 	Offset int `query:"offset" validate:"omitempty,min=0"`
 }
 
+// DefaultUpcomingDays is how far ahead upcoming todos are listed when days is not given
+const DefaultUpcomingDays = 7
+
+// UpcomingTodosQueryParams represents query parameters for listing upcoming todos
+type UpcomingTodosQueryParams struct {
+	Days   int `query:"days" validate:"omitempty,min=1,max=365"`
+	Limit  int `query:"limit" validate:"omitempty,min=1,max=100"`
+	Offset int `query:"offset" validate:"omitempty,min=0"`
+}
+
+// Search modes: words matches todos containing every word, prefix also matches words that
+// start with each term, and phrase matches the words next to each other in order
+const (
+	SearchModeWords  = "words"
+	SearchModePrefix = "prefix"
+	SearchModePhrase = "phrase"
+)
+
 // SearchTodosQueryParams represents query parameters for searching todos
 type SearchTodosQueryParams struct {
 	Query  string `query:"q" validate:"required,min=1"`
+	Mode   string `query:"mode" validate:"omitempty,oneof=words prefix phrase"`
 	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
 	Offset int    `query:"offset" validate:"omitempty,min=0"`
 }
@@ -45,6 +114,23 @@ func (q *GetTodosQueryParams) SetDefaults() {
 	}
 }
 
+// Normalize splits comma-separated statuses, dropping blanks and repeats
+func (q *GetTodosQueryParams) Normalize() {
+	var statuses []string
+	seen := map[string]bool{}
+	for _, value := range q.Status {
+		for _, status := range strings.Split(value, ",") {
+			status = strings.TrimSpace(status)
+			if status == "" || seen[status] {
+				continue
+			}
+			seen[status] = true
+			statuses = append(statuses, status)
+		}
+	}
+	q.Status = statuses
+}
+
 // SetDefaults sets default values for pagination parameters
 func (p *PaginationQueryParams) SetDefaults() {
 	if p.Limit == 0 {
@@ -52,41 +138,548 @@ func (p *PaginationQueryParams) SetDefaults() {
 	}
 }
 
+// SetDefaults sets default values for upcoming todos parameters
+func (u *UpcomingTodosQueryParams) SetDefaults() {
+	if u.Days == 0 {
+		u.Days = DefaultUpcomingDays
+	}
+	if u.Limit == 0 {
+		u.Limit = 10
+	}
+}
+
 // SetDefaults sets default values for search parameters
 func (s *SearchTodosQueryParams) SetDefaults() {
 	if s.Limit == 0 {
 		s.Limit = 10
 	}
+	if s.Mode == "" {
+		s.Mode = SearchModeWords
+	}
+}
+
+// SearchTerms splits a search query into its words, dropping punctuation. Both drivers
+// search by these terms, so a query without any is rejected rather than matching nothing.
+func SearchTerms(query string) []string {
+	return strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
 }
 
 // CreateTodoRequest represents the request to create a new todo
 type CreateTodoRequest struct {
 	Title       string     `json:"title" validate:"required,min=1,max=200"`
-	Description string     `json:"description,omitempty"`
-	Priority    string     `json:"priority,omitempty" validate:"omitempty,oneof=low medium high"`
+	Description string     `json:"description,omitempty" validate:"todo_description"`
+	Priority    string     `json:"priority,omitempty" validate:"omitempty,todo_priority"`
 	DueDate     *time.Time `json:"dueDate,omitempty"`
+	Tags        []string   `json:"tags,omitempty" validate:"omitempty,max=20,dive,max=50"`
+	// DueDateText is a natural-language due date (e.g. "tomorrow 5pm"), ignored when DueDate is set
+	DueDateText string `json:"dueDateText,omitempty" validate:"omitempty,max=100" example:"next friday 9am"`
+	// AllDay makes the todo due for the whole calendar day of DueDate
+	AllDay bool `json:"allDay,omitempty"`
 }
 
 // UpdateTodoRequest represents the request to update a todo
 type UpdateTodoRequest struct {
 	Title       string     `json:"title,omitempty" validate:"omitempty,min=1,max=200"`
-	Description string     `json:"description,omitempty"`
-	Status      string     `json:"status,omitempty" validate:"omitempty,oneof=pending in_progress completed"`
-	Priority    string     `json:"priority,omitempty" validate:"omitempty,oneof=low medium high"`
+	Description string     `json:"description,omitempty" validate:"todo_description"`
+	Status      string     `json:"status,omitempty" validate:"omitempty,todo_status"`
+	Priority    string     `json:"priority,omitempty" validate:"omitempty,todo_priority"`
 	DueDate     *time.Time `json:"dueDate,omitempty"`
+	// Tags replaces the todo's tags when present; an empty array removes them all
+	Tags []string `json:"tags,omitempty" validate:"omitempty,max=20,dive,max=50"`
+	// AllDay switches the todo between all-day and timed when present
+	AllDay *bool `json:"allDay,omitempty"`
+}
+
+// PatchTodoRequest represents a partial update of a todo. Only the fields present in the
+// body are changed; unlike UpdateTodoRequest, an empty description or a null due date clears it.
+type PatchTodoRequest struct {
+	Title       *string      `json:"title,omitempty" validate:"omitempty,min=1,max=200"`
+	Description *string      `json:"description,omitempty" validate:"omitempty,todo_description"`
+	Status      *string      `json:"status,omitempty" validate:"omitempty,todo_status"`
+	Priority    *string      `json:"priority,omitempty" validate:"omitempty,todo_priority"`
+	DueDate     NullableTime `json:"dueDate" swaggertype:"string" format:"date-time"`
+	// AllDay switches the todo between all-day and timed when present
+	AllDay *bool `json:"allDay,omitempty"`
+	// Tags replaces the todo's tags when present; an empty array removes them all
+	Tags []string `json:"tags,omitempty" validate:"omitempty,max=20,dive,max=50"`
+}
+
+// BulkTodoPatch holds the field changes applied to every todo of a bulk patch. As with
+// PatchTodoRequest, only the fields present are changed and a null due date clears it.
+type BulkTodoPatch struct {
+	Status   *string      `json:"status,omitempty" validate:"omitempty,todo_status"`
+	Priority *string      `json:"priority,omitempty" validate:"omitempty,todo_priority"`
+	DueDate  NullableTime `json:"dueDate" swaggertype:"string" format:"date-time"`
+	// Tags replaces the todos' tags when present; an empty array removes them all
+	Tags []string `json:"tags,omitempty" validate:"omitempty,max=20,dive,max=50"`
+}
+
+// Empty reports whether the patch changes no field
+func (p BulkTodoPatch) Empty() bool {
+	return p.Status == nil && p.Priority == nil && !p.DueDate.Set && p.Tags == nil
+}
+
+// ToPatchRequest returns the patch as a partial todo update
+func (p BulkTodoPatch) ToPatchRequest() *PatchTodoRequest {
+	return &PatchTodoRequest{
+		Status:   p.Status,
+		Priority: p.Priority,
+		DueDate:  p.DueDate,
+		Tags:     p.Tags,
+	}
+}
+
+// BulkPatchRequest represents the request to apply the same field changes to up to 100
+// todos. With DryRun set nothing is changed and only the matching todos are counted.
+type BulkPatchRequest struct {
+	IDs    []string      `json:"ids" validate:"required,min=1,max=100,dive,required"`
+	Patch  BulkTodoPatch `json:"patch"`
+	DryRun bool          `json:"dryRun"`
+}
+
+// CreateSubtaskRequest represents the request to append a subtask to a todo
+type CreateSubtaskRequest struct {
+	Title string `json:"title" validate:"required,min=1,max=200"`
+}
+
+// UpdateSubtaskRequest represents the request to update a subtask; absent fields are kept
+type UpdateSubtaskRequest struct {
+	Title string `json:"title,omitempty" validate:"omitempty,min=1,max=200"`
+	Done  *bool  `json:"done,omitempty"`
+}
+
+// MergeTodosRequest represents the request to merge a source todo into a target todo
+type MergeTodosRequest struct {
+	SourceID string `json:"sourceId" validate:"required"`
+	TargetID string `json:"targetId" validate:"required"`
+}
+
+// BulkRescheduleRequest represents the request to move the due dates of up to 100 todos,
+// either by a shift such as "48h" or to an absolute due date
+type BulkRescheduleRequest struct {
+	IDs     []string   `json:"ids" validate:"required,min=1,max=100,dive,required"`
+	Shift   string     `json:"shift,omitempty" validate:"required_without=DueDate,excluded_with=DueDate"`
+	DueDate *time.Time `json:"dueDate,omitempty" validate:"required_without=Shift"`
+}
+
+// BulkStatusRequest represents the request to set the status of several todos
+type BulkStatusRequest struct {
+	IDs    []string `json:"ids" validate:"required,min=1,max=100,dive,required"`
+	Status string   `json:"status" validate:"required,todo_status"`
+}
+
+// TodoFilter selects a user's todos for listing and bulk operations. Empty fields match every todo;
+// a due range only matches todos due at or after DueFrom and before DueBefore.
+type TodoFilter struct {
+	Status    string     `json:"status,omitempty" validate:"omitempty,todo_status"`
+	Priority  string     `json:"priority,omitempty" validate:"omitempty,todo_priority"`
+	Tag       string     `json:"tag,omitempty" validate:"omitempty,max=50"`
+	DueFrom   *time.Time `json:"dueFrom,omitempty"`
+	DueBefore *time.Time `json:"dueBefore,omitempty"`
+
+	// Statuses matches todos in any of several statuses; it comes from the list query
+	Statuses []string `json:"-"`
+
+	// TagCaseInsensitive matches Tag ignoring case instead of exactly; it comes from
+	// configuration, never from the request
+	TagCaseInsensitive bool `json:"-"`
+}
+
+// Fields todo listings can be sorted by
+const (
+	TodoSortCreatedAt = "createdAt"
+	TodoSortDueDate   = "dueDate"
+	TodoSortPriority  = "priority"
+	TodoSortTitle     = "title"
+)
+
+// Sort orders
+const (
+	SortAscending  = "asc"
+	SortDescending = "desc"
+)
+
+// TodoSort orders a todo listing by one field. Priorities sort by their configured level
+// rather than alphabetically, and todos without a due date come last either way.
+type TodoSort struct {
+	Field string
+	Order string
+}
+
+// NewTodoSort returns the sort for the given field and order. An empty field sorts by
+// creation date, and an empty order puts the newest, most important, soonest due or
+// alphabetically first todos first.
+func NewTodoSort(field, order string) TodoSort {
+	if field == "" {
+		field = TodoSortCreatedAt
+	}
+	if order == "" {
+		order = SortAscending
+		if field == TodoSortCreatedAt || field == TodoSortPriority {
+			order = SortDescending
+		}
+	}
+	return TodoSort{Field: field, Order: order}
+}
+
+// Descending reports whether the sort runs from the highest value to the lowest
+func (s TodoSort) Descending() bool {
+	return s.Order == SortDescending
+}
+
+// BulkPriorityRequest represents the request to set the priority of every todo matching a
+// filter. With DryRun set nothing is changed and only the matching todos are counted.
+type BulkPriorityRequest struct {
+	Filter   TodoFilter `json:"filter"`
+	Priority string     `json:"priority" validate:"required,todo_priority"`
+	DryRun   bool       `json:"dryRun"`
+}
+
+// NormalizeTags trims and lowercases tags, dropping blanks and duplicates while keeping
+// the order in which tags first appear
+func NormalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// NormalizeTag trims and lowercases a tag the way NormalizeTags stores it, so a tag filter
+// matches the stored form
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// MaxValidateTodos is the largest batch accepted by POST /todos/validate
+const MaxValidateTodos = 100
+
+// TodoValidationResult reports whether one item of a validated batch could be created
+type TodoValidationResult struct {
+	Index  int          `json:"index" example:"0"`
+	Valid  bool         `json:"valid" example:"false"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// ValidateTodosResponse represents the per-item results of validating a batch of todos
+type ValidateTodosResponse struct {
+	Valid   int                    `json:"valid" example:"2"`
+	Invalid int                    `json:"invalid" example:"1"`
+	Results []TodoValidationResult `json:"results"`
+}
+
+// Defaults for the number of todos read from one POST /todos/import request and the number
+// inserted together as it is read
+const (
+	DefaultImportMaxItems  = 500
+	DefaultImportChunkSize = 100
+)
+
+// TodoImportResult reports whether one item of an imported batch was created, with the new
+// todo's ID, or why it failed validation
+type TodoImportResult struct {
+	Index   int          `json:"index" example:"0"`
+	Created bool         `json:"created" example:"true"`
+	ID      string       `json:"id,omitempty" example:"01ARZ3NDEKTSV4RRFFQ69G5FAV"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+// ImportTodosResponse represents the per-item results of importing a batch of todos.
+// Truncated reports that items past the import cap were left unread.
+type ImportTodosResponse struct {
+	Created   int                `json:"created" example:"2"`
+	Invalid   int                `json:"invalid" example:"1"`
+	Truncated bool               `json:"truncated,omitempty" example:"false"`
+	Results   []TodoImportResult `json:"results"`
 }
 
 // UpdateTodoStatusRequest represents the request to update todo status
 type UpdateTodoStatusRequest struct {
-	Status string `json:"status" validate:"required,oneof=pending in_progress completed"`
+	Status string `json:"status" validate:"required,todo_status"`
 }
 
-// TodoListResponse represents the response for listing todos
+// TodoListResponse represents the response for listing todos. Cursor pages carry
+// NextCursor instead of a total, offset and page numbers, and the snapshot they were taken as of.
 type TodoListResponse struct {
-	Todos  []*Todo `json:"todos"`
-	Total  int64   `json:"total"`
-	Limit  int     `json:"limit"`
-	Offset int     `json:"offset"`
+	Todos      []*Todo    `json:"todos"`
+	Total      int64      `json:"total"`
+	Limit      int        `json:"limit"`
+	Offset     int        `json:"offset"`
+	TotalPages int        `json:"totalPages"`
+	Page       int        `json:"page"`
+	HasMore    bool       `json:"hasMore"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+	Snapshot   *time.Time `json:"snapshot,omitempty"`
+}
+
+// NewTodoListResponse builds a list response, flagging whether more todos follow this page
+func NewTodoListResponse(todos []*Todo, total int64, limit, offset int) *TodoListResponse {
+	totalPages, page := PageCount(total, limit, offset)
+	return &TodoListResponse{
+		Todos:      todos,
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
+		TotalPages: totalPages,
+		Page:       page,
+		HasMore:    int64(offset+len(todos)) < total,
+	}
+}
+
+// NewTodoCursorResponse builds a list response for a cursor page, taken as of snapshot when
+// it is not nil; an empty nextCursor marks the last page
+func NewTodoCursorResponse(todos []*Todo, limit int, nextCursor string, snapshot *time.Time) *TodoListResponse {
+	return &TodoListResponse{
+		Todos:      todos,
+		Limit:      limit,
+		HasMore:    nextCursor != "",
+		NextCursor: nextCursor,
+		Snapshot:   snapshot,
+	}
+}
+
+// ErrInvalidCursor is returned when a pagination cursor was not issued by this API
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeTodoCursor turns the ID of the last todo on a page into an opaque cursor
+func EncodeTodoCursor(id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(id))
+}
+
+// DecodeTodoCursor returns the todo ID a cursor points after. An empty cursor decodes to
+// an empty ID, meaning the first page.
+func DecodeTodoCursor(cursor string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", ErrInvalidCursor
+	}
+
+	id, err := ulid.ParseStrict(string(raw))
+	if err != nil {
+		return "", ErrInvalidCursor
+	}
+	return id.String(), nil
+}
+
+// CursorPage trims a result fetched with limit+1 rows to limit and returns the cursor of
+// the next page, or an empty cursor when there is none
+func CursorPage(todos []*Todo, limit int) ([]*Todo, string) {
+	if len(todos) <= limit {
+		return todos, ""
+	}
+
+	todos = todos[:limit]
+	return todos, EncodeTodoCursor(todos[len(todos)-1].ID)
+}
+
+// DueDistribution counts a user's not-done todos by how soon they are due
+type DueDistribution struct {
+	Overdue  int64 `json:"overdue"`
+	Today    int64 `json:"today"`
+	ThisWeek int64 `json:"thisWeek"`
+	Later    int64 `json:"later"`
+	Undated  int64 `json:"undated"`
+}
+
+// DueDistributionBounds separates the due distribution buckets: todos due before Now are
+// overdue, before EndOfToday due today, before EndOfWeek due this week and later otherwise.
+// All-day todos are bucketed by their day instead, against Today.
+type DueDistributionBounds struct {
+	Now        time.Time
+	EndOfToday time.Time
+	EndOfWeek  time.Time
+	Today      time.Time
+}
+
+// NewDueDistributionBounds computes the bucket bounds in now's location, so today ends at
+// the next local midnight and this week covers the six days after today
+func NewDueDistributionBounds(now time.Time) DueDistributionBounds {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return DueDistributionBounds{
+		Now:        now,
+		EndOfToday: today.AddDate(0, 0, 1),
+		EndOfWeek:  today.AddDate(0, 0, 7),
+		Today:      AllDayDate(now),
+	}
+}
+
+// OverdueCutoff separates overdue todos from the rest: timed todos are overdue once due
+// before Now, all-day todos once their day is before Today
+type OverdueCutoff struct {
+	Now   time.Time
+	Today time.Time
+}
+
+// NewOverdueCutoff computes the cutoff in now's location, so all-day todos turn overdue at
+// the local midnight after their day
+func NewOverdueCutoff(now time.Time) OverdueCutoff {
+	return OverdueCutoff{
+		Now:   now,
+		Today: AllDayDate(now),
+	}
+}
+
+// DayRange is one calendar day in a timezone: timed todos fall on it when due at or after
+// Start and before End, all-day todos when their stored day equals Date
+type DayRange struct {
+	Start time.Time
+	End   time.Time
+	Date  time.Time
+}
+
+// NewDayRange computes the range of day's calendar day in day's location
+func NewDayRange(day time.Time) DayRange {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	return DayRange{
+		Start: start,
+		End:   start.AddDate(0, 0, 1),
+		Date:  AllDayDate(start),
+	}
+}
+
+// Bases a completion rate can count todos by
+const (
+	CompletionByCreated = "created"
+	CompletionByDue     = "due"
+)
+
+// CompletionWindow is the period of a completion rate: the todos created, or due, at or after
+// Start and before End. All-day due dates are compared by day, against StartDate and EndDate.
+type CompletionWindow struct {
+	By        string
+	Start     time.Time
+	End       time.Time
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// NewCompletionWindow computes the window from the start of from's calendar day to the end
+// of to's, in their location
+func NewCompletionWindow(from, to time.Time, by string) CompletionWindow {
+	start := NewDayRange(from)
+	end := NewDayRange(to)
+	return CompletionWindow{
+		By:        by,
+		Start:     start.Start,
+		End:       end.End,
+		StartDate: start.Date,
+		EndDate:   end.Date.AddDate(0, 0, 1),
+	}
+}
+
+// CompletionRate is the share of a user's todos in a window that are done: Completed is the
+// numerator, Total the denominator, and Rate is 0 when the window has no todos
+type CompletionRate struct {
+	From      string  `json:"from" example:"2025-10-01"`
+	To        string  `json:"to" example:"2025-10-31"`
+	By        string  `json:"by" example:"created"`
+	Completed int64   `json:"completed" example:"12"`
+	Total     int64   `json:"total" example:"16"`
+	Rate      float64 `json:"rate" example:"0.75"`
+}
+
+// NewCompletionRate builds the completion rate of a window from its counts
+func NewCompletionRate(window CompletionWindow, completed, total int64) *CompletionRate {
+	rate := &CompletionRate{
+		From:      window.StartDate.Format(time.DateOnly),
+		To:        window.EndDate.AddDate(0, 0, -1).Format(time.DateOnly),
+		By:        window.By,
+		Completed: completed,
+		Total:     total,
+	}
+	if total > 0 {
+		rate.Rate = float64(completed) / float64(total)
+	}
+	return rate
+}
+
+// TodoStatsResponse summarizes a user's todos. Completed counts the done status, and
+// CompletionPercentage is the share of todos that are done, 0 when there are none.
+type TodoStatsResponse struct {
+	Total                int64            `json:"total" example:"20"`
+	Completed            int64            `json:"completed" example:"12"`
+	Pending              int64            `json:"pending" example:"5"`
+	InProgress           int64            `json:"inProgress" example:"3"`
+	Overdue              int64            `json:"overdue" example:"2"`
+	CompletionPercentage float64          `json:"completionPercentage" example:"60"`
+	ByStatus             map[string]int64 `json:"byStatus"`
+}
+
+// NewTodoStatsResponse builds the summary from the counts per status and the overdue count
+func NewTodoStatsResponse(counts map[string]int64, overdue int64) *TodoStatsResponse {
+	stats := &TodoStatsResponse{
+		Completed:  counts[DoneStatus()],
+		Pending:    counts[TodoStatusPending],
+		InProgress: counts[TodoStatusInProgress],
+		Overdue:    overdue,
+		ByStatus:   make(map[string]int64, len(counts)),
+	}
+	for status, count := range counts {
+		stats.Total += count
+		stats.ByStatus[status] = count
+	}
+	if stats.Total > 0 {
+		stats.CompletionPercentage = math.Round(float64(stats.Completed)*10000/float64(stats.Total)) / 100
+	}
+	return stats
+}
+
+// MaxInsightTags caps the most-used tags listed in a user's insights
+const MaxInsightTags = 5
+
+// DayCount is the number of todos created on a calendar day
+type DayCount struct {
+	Date  string `json:"date" example:"2025-03-14"`
+	Count int64  `json:"count" example:"9"`
+}
+
+// TagCount is the number of todos carrying a tag
+type TagCount struct {
+	Tag   string `json:"tag" example:"work"`
+	Count int64  `json:"count" example:"42"`
+}
+
+// UserInsights is a user's year in review over the todos they created during Year in
+// Timezone. CompletionRate is the share of them that are done, 0 when there are none.
+// BusiestDay is the day the most were created and AverageCompletionHours the mean time from
+// creating a done todo to its last update; each is null when there is nothing to measure.
+type UserInsights struct {
+	Year                   int        `json:"year" example:"2025"`
+	Timezone               string     `json:"timezone" example:"Europe/Berlin"`
+	Created                int64      `json:"created" example:"240"`
+	Completed              int64      `json:"completed" example:"180"`
+	CompletionRate         float64    `json:"completionRate" example:"0.75"`
+	BusiestDay             *DayCount  `json:"busiestDay"`
+	TopTags                []TagCount `json:"topTags"`
+	AverageCompletionHours *float64   `json:"averageCompletionHours" example:"30.5"`
+}
+
+// MaxAgendaOverdue caps the overdue todos listed in an agenda
+const MaxAgendaOverdue = 100
+
+// Agenda lists a user's not-done todos for one day: those due before the day started and
+// those due on it, each ordered by priority, highest first, then due time
+type Agenda struct {
+	Date         string  `json:"date"`
+	Overdue      []*Todo `json:"overdue"`
+	OverdueTotal int64   `json:"overdueTotal"`
+	Due          []*Todo `json:"due"`
+}
+
+// AllDayDate returns t's calendar day, in t's own location, as midnight UTC. All-day due
+// dates are stored this way so that the day can be placed in the reader's timezone.
+func AllDayDate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
 }
 
 // TodoStatus constants
@@ -103,32 +696,114 @@ const (
 	TodoPriorityHigh   = "high"
 )
 
+// Configured statuses, the default status for new todos and the status that counts as done
+var (
+	statuses      = []string{TodoStatusPending, TodoStatusInProgress, TodoStatusCompleted}
+	defaultStatus = TodoStatusPending
+	doneStatus    = TodoStatusCompleted
+)
+
+// SetStatuses configures the allowed statuses, the default status and the done status
+func SetStatuses(values []string, defaultValue, doneValue string) {
+	configured := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			configured = append(configured, value)
+		}
+	}
+
+	statuses = configured
+	defaultStatus = strings.TrimSpace(defaultValue)
+	doneStatus = strings.TrimSpace(doneValue)
+}
+
+// Statuses returns the configured statuses
+func Statuses() []string {
+	return slices.Clone(statuses)
+}
+
+// DefaultStatus returns the configured default status
+func DefaultStatus() string {
+	return defaultStatus
+}
+
+// DoneStatus returns the configured status that marks a todo as done
+func DoneStatus() string {
+	return doneStatus
+}
+
 // IsValidStatus checks if the status is valid
 func IsValidStatus(status string) bool {
-	switch status {
-	case TodoStatusPending, TodoStatusInProgress, TodoStatusCompleted:
-		return true
-	default:
-		return false
+	return slices.Contains(statuses, status)
+}
+
+// DefaultDescriptionMax is the default maximum length of a todo description, in characters
+const DefaultDescriptionMax = 5000
+
+// Configured maximum length of a todo description, in characters
+var descriptionMax = DefaultDescriptionMax
+
+// SetDescriptionMax configures the maximum length of a todo description, in characters
+func SetDescriptionMax(max int) {
+	descriptionMax = max
+}
+
+// DescriptionMax returns the configured maximum length of a todo description
+func DescriptionMax() int {
+	return descriptionMax
+}
+
+// IsValidDescription checks that the description fits the configured maximum length
+func IsValidDescription(description string) bool {
+	return utf8.RuneCountInString(description) <= descriptionMax
+}
+
+// Configured priority levels (ordered from lowest to highest) and the default priority
+var (
+	priorities      = []string{TodoPriorityLow, TodoPriorityMedium, TodoPriorityHigh}
+	defaultPriority = TodoPriorityMedium
+)
+
+// SetPriorities configures the allowed priority levels and the default priority
+func SetPriorities(levels []string, defaultLevel string) {
+	configured := make([]string, 0, len(levels))
+	for _, level := range levels {
+		if level = strings.TrimSpace(level); level != "" {
+			configured = append(configured, level)
+		}
 	}
+
+	priorities = configured
+	defaultPriority = strings.TrimSpace(defaultLevel)
+}
+
+// Priorities returns the configured priority levels
+func Priorities() []string {
+	return slices.Clone(priorities)
+}
+
+// DefaultPriority returns the configured default priority
+func DefaultPriority() string {
+	return defaultPriority
+}
+
+// PriorityRank returns the position of priority among the configured levels, higher for
+// more important levels and -1 for unknown ones
+func PriorityRank(priority string) int {
+	return slices.Index(priorities, priority)
 }
 
 // IsValidPriority checks if the priority is valid
 func IsValidPriority(priority string) bool {
-	switch priority {
-	case TodoPriorityLow, TodoPriorityMedium, TodoPriorityHigh:
-		return true
-	default:
-		return false
-	}
+	return slices.Contains(priorities, priority)
 }
 
 // SetDefaults sets default values for the todo
 func (t *Todo) SetDefaults() {
 	if t.Status == "" {
-		t.Status = TodoStatusPending
+		t.Status = DefaultStatus()
 	}
 	if t.Priority == "" {
-		t.Priority = TodoPriorityMedium
+		t.Priority = DefaultPriority()
 	}
 }
@@ -0,0 +1,353 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTodoListResponse_HasMore(t *testing.T) {
+	page := func(n int) []*Todo {
+		todos := make([]*Todo, n)
+		for i := range todos {
+			todos[i] = &Todo{}
+		}
+		return todos
+	}
+
+	tests := []struct {
+		name     string
+		count    int
+		total    int64
+		offset   int
+		expected bool
+	}{
+		{"empty result", 0, 0, 0, false},
+		{"single page holds everything", 5, 5, 0, false},
+		{"first page of many", 10, 25, 0, true},
+		{"page ending one before total", 10, 21, 10, true},
+		{"page ending exactly at total", 10, 20, 10, false},
+		{"last partial page", 5, 25, 20, false},
+		{"offset past total", 0, 25, 30, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := NewTodoListResponse(page(tt.count), tt.total, 10, tt.offset)
+
+			assert.Equal(t, tt.expected, response.HasMore)
+			assert.Equal(t, tt.total, response.Total)
+			assert.Equal(t, tt.offset, response.Offset)
+		})
+	}
+}
+
+func TestPageCount(t *testing.T) {
+	tests := []struct {
+		name          string
+		total         int64
+		limit         int
+		offset        int
+		expectedPages int
+		expectedPage  int
+	}{
+		{"empty result", 0, 10, 0, 0, 1},
+		{"partial last page", 25, 10, 0, 3, 1},
+		{"exact multiple", 20, 10, 10, 2, 2},
+		{"offset inside a page", 25, 10, 15, 3, 2},
+		{"zero limit holds everything", 25, 0, 0, 1, 1},
+		{"zero limit and no items", 0, 0, 0, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			totalPages, page := PageCount(tt.total, tt.limit, tt.offset)
+
+			assert.Equal(t, tt.expectedPages, totalPages)
+			assert.Equal(t, tt.expectedPage, page)
+		})
+	}
+}
+
+func TestNewTodoListResponse_Pages(t *testing.T) {
+	// Act
+	response := NewTodoListResponse([]*Todo{}, 25, 10, 10)
+
+	// Assert
+	assert.Equal(t, 3, response.TotalPages)
+	assert.Equal(t, 2, response.Page)
+}
+
+func TestNewDueDistributionBounds(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	assert.NoError(t, err)
+
+	t.Run("today ends at the next midnight and the week six days later", func(t *testing.T) {
+		now := time.Date(2025, 10, 15, 23, 59, 59, 0, time.UTC)
+
+		bounds := NewDueDistributionBounds(now)
+
+		assert.Equal(t, now, bounds.Now)
+		assert.Equal(t, time.Date(2025, 10, 16, 0, 0, 0, 0, time.UTC), bounds.EndOfToday)
+		assert.Equal(t, time.Date(2025, 10, 22, 0, 0, 0, 0, time.UTC), bounds.EndOfWeek)
+	})
+
+	t.Run("days follow the timezone of now", func(t *testing.T) {
+		// 23:30 UTC is already the next day in London during BST
+		now := time.Date(2025, 7, 1, 23, 30, 0, 0, time.UTC).In(london)
+
+		bounds := NewDueDistributionBounds(now)
+
+		assert.Equal(t, time.Date(2025, 7, 2, 23, 0, 0, 0, time.UTC), bounds.EndOfToday.UTC())
+		assert.Equal(t, time.Date(2025, 7, 8, 23, 0, 0, 0, time.UTC), bounds.EndOfWeek.UTC())
+	})
+
+	t.Run("week spanning a daylight saving change ends at local midnight", func(t *testing.T) {
+		// Clocks go back on 26 October 2025 in London
+		now := time.Date(2025, 10, 24, 9, 0, 0, 0, london)
+
+		bounds := NewDueDistributionBounds(now)
+
+		assert.Equal(t, time.Date(2025, 10, 24, 23, 0, 0, 0, time.UTC), bounds.EndOfToday.UTC())
+		assert.Equal(t, time.Date(2025, 10, 31, 0, 0, 0, 0, time.UTC), bounds.EndOfWeek.UTC())
+	})
+}
+
+func TestNewOverdueCutoff(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	// Todos due on 15 October 2025: one all-day, one timed at midnight UTC
+	dueDate := time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC)
+	allDay := &Todo{DueDate: &dueDate, AllDay: true}
+	timed := &Todo{DueDate: &dueDate}
+
+	overdue := func(todo *Todo, cutoff OverdueCutoff) bool {
+		if todo.AllDay {
+			return todo.DueDate.Before(cutoff.Today)
+		}
+		return todo.DueDate.Before(cutoff.Now)
+	}
+
+	t.Run("all-day todo is not overdue during its day", func(t *testing.T) {
+		cutoff := NewOverdueCutoff(time.Date(2025, 10, 15, 23, 59, 59, 0, newYork))
+
+		assert.Equal(t, time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC), cutoff.Today)
+		assert.False(t, overdue(allDay, cutoff))
+		assert.True(t, overdue(timed, cutoff))
+	})
+
+	t.Run("all-day todo is overdue once its day ends in the timezone", func(t *testing.T) {
+		cutoff := NewOverdueCutoff(time.Date(2025, 10, 16, 0, 0, 0, 0, newYork))
+
+		assert.True(t, overdue(allDay, cutoff))
+	})
+
+	t.Run("day follows the timezone of now", func(t *testing.T) {
+		// 02:00 UTC on 16 October is still 15 October in New York
+		cutoff := NewOverdueCutoff(time.Date(2025, 10, 16, 2, 0, 0, 0, time.UTC).In(newYork))
+
+		assert.False(t, overdue(allDay, cutoff))
+	})
+}
+
+func TestNewDayRange(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	t.Run("covers the local calendar day", func(t *testing.T) {
+		day := NewDayRange(time.Date(2025, 10, 15, 23, 30, 0, 0, newYork))
+
+		assert.True(t, day.Start.Equal(time.Date(2025, 10, 15, 4, 0, 0, 0, time.UTC)))
+		assert.True(t, day.End.Equal(time.Date(2025, 10, 16, 4, 0, 0, 0, time.UTC)))
+		assert.Equal(t, time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC), day.Date)
+	})
+
+	t.Run("daylight saving change makes a 25 hour day", func(t *testing.T) {
+		day := NewDayRange(time.Date(2025, 11, 2, 12, 0, 0, 0, newYork))
+
+		assert.Equal(t, 25*time.Hour, day.End.Sub(day.Start))
+	})
+}
+
+func TestAllDayDate(t *testing.T) {
+	t.Run("keeps the calendar day of the given offset", func(t *testing.T) {
+		late := time.Date(2025, 10, 15, 23, 0, 0, 0, time.FixedZone("UTC-5", -5*60*60))
+
+		assert.Equal(t, time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC), AllDayDate(late))
+	})
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []string
+		expected []string
+	}{
+		{"nil", nil, []string{}},
+		{"already normalized", []string{"work", "home"}, []string{"work", "home"}},
+		{"mixed case and whitespace", []string{" Work", "HOME "}, []string{"work", "home"}},
+		{"duplicates after normalizing", []string{"work", "Work", " WORK "}, []string{"work"}},
+		{"blanks are dropped", []string{"", "  ", "urgent"}, []string{"urgent"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NormalizeTags(tt.tags))
+		})
+	}
+}
+
+func TestGetTodosQueryParams_Normalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   []string
+		expected []string
+	}{
+		{"none", nil, nil},
+		{"single", []string{"pending"}, []string{"pending"}},
+		{"comma-separated", []string{"pending, in_progress"}, []string{"pending", "in_progress"}},
+		{"repeated and mixed", []string{"pending", "in_progress,completed"}, []string{"pending", "in_progress", "completed"}},
+		{"blanks and repeats are dropped", []string{"pending,,pending", " "}, []string{"pending"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := GetTodosQueryParams{Status: tt.status}
+
+			q.Normalize()
+
+			assert.Equal(t, tt.expected, q.Status)
+		})
+	}
+}
+
+func TestTodoCursor(t *testing.T) {
+	id := "01K7KZ6T7Q0S4E9M2W8H3XJ5VD"
+
+	t.Run("round trips a todo ID", func(t *testing.T) {
+		decoded, err := DecodeTodoCursor(EncodeTodoCursor(id))
+
+		assert.NoError(t, err)
+		assert.Equal(t, id, decoded)
+	})
+
+	t.Run("empty cursor starts at the first page", func(t *testing.T) {
+		decoded, err := DecodeTodoCursor("")
+
+		assert.NoError(t, err)
+		assert.Empty(t, decoded)
+	})
+
+	t.Run("rejects cursors that are not encoded ULIDs", func(t *testing.T) {
+		for _, cursor := range []string{"not base64!", EncodeTodoCursor("todo-1"), id} {
+			_, err := DecodeTodoCursor(cursor)
+			assert.ErrorIs(t, err, ErrInvalidCursor, cursor)
+		}
+	})
+}
+
+func TestCursorPage(t *testing.T) {
+	todos := []*Todo{{ID: "01K7KZ6T7Q0S4E9M2W8H3XJ5VF"}, {ID: "01K7KZ6T7Q0S4E9M2W8H3XJ5VE"}, {ID: "01K7KZ6T7Q0S4E9M2W8H3XJ5VD"}}
+
+	t.Run("extra row yields a cursor after the last kept todo", func(t *testing.T) {
+		page, next := CursorPage(todos, 2)
+
+		assert.Len(t, page, 2)
+		assert.Equal(t, EncodeTodoCursor("01K7KZ6T7Q0S4E9M2W8H3XJ5VE"), next)
+	})
+
+	t.Run("last page has no cursor", func(t *testing.T) {
+		page, next := CursorPage(todos, 3)
+
+		assert.Len(t, page, 3)
+		assert.Empty(t, next)
+	})
+}
+
+func TestTodo_SubtaskProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		subtasks []Subtask
+		expected int
+	}{
+		{"no subtasks", nil, 0},
+		{"none done", []Subtask{{Title: "a"}, {Title: "b"}}, 0},
+		{"rounded down", []Subtask{{Title: "a", Done: true}, {Title: "b"}, {Title: "c"}}, 33},
+		{"all done", []Subtask{{Title: "a", Done: true}, {Title: "b", Done: true}}, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todo := &Todo{Subtasks: tt.subtasks}
+
+			assert.Equal(t, tt.expected, todo.SubtaskProgress())
+		})
+	}
+}
+
+func TestNewTodoSort(t *testing.T) {
+	tests := []struct {
+		field, order string
+		expected     TodoSort
+	}{
+		{"", "", TodoSort{Field: TodoSortCreatedAt, Order: SortDescending}},
+		{TodoSortPriority, "", TodoSort{Field: TodoSortPriority, Order: SortDescending}},
+		{TodoSortDueDate, "", TodoSort{Field: TodoSortDueDate, Order: SortAscending}},
+		{TodoSortTitle, "", TodoSort{Field: TodoSortTitle, Order: SortAscending}},
+		{"", SortAscending, TodoSort{Field: TodoSortCreatedAt, Order: SortAscending}},
+		{TodoSortDueDate, SortDescending, TodoSort{Field: TodoSortDueDate, Order: SortDescending}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, NewTodoSort(tt.field, tt.order), "%q %q", tt.field, tt.order)
+	}
+}
+
+func TestNewTodoStatsResponse(t *testing.T) {
+	t.Run("percentage of done todos", func(t *testing.T) {
+		stats := NewTodoStatsResponse(map[string]int64{
+			TodoStatusPending:    1,
+			TodoStatusInProgress: 1,
+			TodoStatusCompleted:  1,
+		}, 1)
+
+		assert.Equal(t, int64(3), stats.Total)
+		assert.Equal(t, int64(1), stats.Completed)
+		assert.Equal(t, int64(1), stats.Pending)
+		assert.Equal(t, int64(1), stats.InProgress)
+		assert.Equal(t, int64(1), stats.Overdue)
+		assert.Equal(t, 33.33, stats.CompletionPercentage)
+	})
+
+	t.Run("no todos gives zero percent", func(t *testing.T) {
+		stats := NewTodoStatsResponse(map[string]int64{}, 0)
+
+		assert.Equal(t, &TodoStatsResponse{ByStatus: map[string]int64{}}, stats)
+	})
+}
+
+func TestNewCompletionRate(t *testing.T) {
+	window := NewCompletionWindow(time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 10, 31, 0, 0, 0, 0, time.UTC), CompletionByCreated)
+
+	t.Run("window covers whole days", func(t *testing.T) {
+		assert.Equal(t, time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC), window.Start)
+		assert.Equal(t, time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC), window.End)
+	})
+
+	t.Run("rate is completed over total", func(t *testing.T) {
+		rate := NewCompletionRate(window, 1, 3)
+
+		assert.InDelta(t, 1.0/3, rate.Rate, 1e-9)
+		assert.Equal(t, "2025-10-01", rate.From)
+		assert.Equal(t, "2025-10-31", rate.To)
+	})
+
+	t.Run("empty window does not divide by zero", func(t *testing.T) {
+		rate := NewCompletionRate(window, 0, 0)
+
+		assert.Equal(t, 0.0, rate.Rate)
+		assert.Equal(t, int64(0), rate.Total)
+	})
+}
@@ -11,10 +11,26 @@ type User struct {
 	Password  string    `json:"-" db:"password_hash"`
 	Email     string    `json:"email,omitempty" db:"email" validate:"omitempty,email"`
 	Image     string    `json:"image,omitempty" db:"image" validate:"omitempty,url"`
+	Role      string    `json:"role" db:"role"`
 	CreatedAt time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
 }
 
+// User roles. Admins can use the /admin endpoints on top of everything users can do.
+const (
+	UserRoleUser  = "user"
+	UserRoleAdmin = "admin"
+)
+
+// UserTOTP holds a user's two-factor authentication state. Secret is encrypted and only
+// counts once Enabled is set by a verified code; LastStep is the time step of the last
+// accepted code, so no code is accepted twice.
+type UserTOTP struct {
+	Secret   string
+	Enabled  bool
+	LastStep int64
+}
+
 // CreateUserRequest represents the request to create a new user
 type CreateUserRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=50"`
@@ -33,7 +49,33 @@ type UpdateUserRequest struct {
 // UpdatePasswordRequest represents the request to update user password
 type UpdatePasswordRequest struct {
 	CurrentPassword string `json:"currentPassword" validate:"required"`
-	NewPassword     string `json:"newPassword" validate:"required,min=6,max=100"`
+	NewPassword     string `json:"newPassword" validate:"required,min=6,max=100,nefield=CurrentPassword"`
+
+	// LogoutOtherSessions ends every session of the user except the one making the request
+	LogoutOtherSessions bool `json:"logoutOtherSessions"`
+}
+
+// DeleteAccountRequest confirms the deletion of the authenticated user's account
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// Policies for the todos of a user who deletes their account
+const (
+	UserDeletePolicyCascade   = "cascade"
+	UserDeletePolicyBlock     = "block"
+	UserDeletePolicyAnonymize = "anonymize"
+)
+
+// PasswordResetRequest represents the request for a password reset token
+type PasswordResetRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest represents the request to set a new password with a reset token
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required,max=64"`
+	NewPassword string `json:"newPassword" validate:"required,min=6,max=100"`
 }
 
 // UserResponse represents the user response (without sensitive data)
@@ -42,6 +84,7 @@ type UserResponse struct {
 	Username  string    `json:"username"`
 	Email     string    `json:"email,omitempty"`
 	Image     string    `json:"image,omitempty"`
+	Role      string    `json:"role"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
@@ -53,7 +96,16 @@ func (u *User) ToResponse() *UserResponse {
 		Username:  u.Username,
 		Email:     u.Email,
 		Image:     u.Image,
+		Role:      u.RoleOrDefault(),
 		CreatedAt: u.CreatedAt,
 		UpdatedAt: u.UpdatedAt,
 	}
 }
+
+// RoleOrDefault returns the user's role, treating an unset role as UserRoleUser
+func (u *User) RoleOrDefault() string {
+	if u.Role == "" {
+		return UserRoleUser
+	}
+	return u.Role
+}
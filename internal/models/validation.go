@@ -0,0 +1,69 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes a validation rule that a request field failed
+type FieldError struct {
+	Field string `json:"field" example:"title"`
+	Rule  string `json:"rule" example:"max"`
+	Param string `json:"param,omitempty" example:"200"`
+}
+
+// RegisterValidations registers the custom validation tags used by the models
+func RegisterValidations(v *validator.Validate) {
+	// todo_priority validates against the configured priority levels
+	v.RegisterValidation("todo_priority", func(fl validator.FieldLevel) bool {
+		return IsValidPriority(fl.Field().String())
+	})
+
+	// todo_status validates against the configured statuses
+	v.RegisterValidation("todo_status", func(fl validator.FieldLevel) bool {
+		return IsValidStatus(fl.Field().String())
+	})
+
+	// todo_description validates against the configured maximum description length
+	v.RegisterValidation("todo_description", func(fl validator.FieldLevel) bool {
+		return IsValidDescription(fl.Field().String())
+	})
+}
+
+// FieldErrors converts a validator error into one FieldError per failed rule, naming fields
+// as they appear in JSON. Errors that are not validation errors yield nil.
+func FieldErrors(err error) []FieldError {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil
+	}
+
+	fieldErrors := make([]FieldError, len(validationErrors))
+	for i, fe := range validationErrors {
+		fieldErrors[i] = FieldError{
+			Field: jsonFieldName(fe.Namespace()),
+			Rule:  fe.Tag(),
+			Param: fe.Param(),
+		}
+	}
+
+	return fieldErrors
+}
+
+// jsonFieldName turns a validator namespace such as "CreateTodoRequest.DueDateText" into
+// the camelCase JSON name "dueDateText" used by the request models
+func jsonFieldName(namespace string) string {
+	parts := strings.Split(namespace, ".")
+	if len(parts) > 1 {
+		parts = parts[1:]
+	}
+	for i, part := range parts {
+		runes := []rune(part)
+		runes[0] = unicode.ToLower(runes[0])
+		parts[i] = string(runes)
+	}
+	return strings.Join(parts, ".")
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"fmt"
+	"time"
 
 	"go-fiber/internal/repository/interfaces"
 	mongoRepo "go-fiber/internal/repository/mongodb"
@@ -20,57 +21,84 @@ const (
 	MongoDB    DatabaseType = "mongodb"
 )
 
-// RepositoryFactory creates repository instances based on database type
+// DatabaseTypeForDriver returns the database type of a configured driver name
+func DatabaseTypeForDriver(driver string) DatabaseType {
+	if driver == "postgres" {
+		return PostgreSQL
+	}
+	return MongoDB
+}
+
+// RepositoryFactory creates repository instances based on database type. Users and todos
+// can be stored in different databases; the schema repository follows the todos.
 type RepositoryFactory struct {
-	dbType DatabaseType
-	logger zerolog.Logger
+	userDBType     DatabaseType
+	todoDBType     DatabaseType
+	acquireTimeout time.Duration
+	logger         zerolog.Logger
 }
 
 // NewRepositoryFactory creates a new repository factory
-func NewRepositoryFactory(dbType DatabaseType, logger zerolog.Logger) *RepositoryFactory {
+// acquireTimeout bounds how long PostgreSQL repositories wait for a pooled connection (0 waits indefinitely)
+func NewRepositoryFactory(userDBType, todoDBType DatabaseType, acquireTimeout time.Duration, logger zerolog.Logger) *RepositoryFactory {
 	return &RepositoryFactory{
-		dbType: dbType,
-		logger: logger,
+		userDBType:     userDBType,
+		todoDBType:     todoDBType,
+		acquireTimeout: acquireTimeout,
+		logger:         logger,
 	}
 }
 
-// CreateUserRepository creates a user repository based on database type
+// CreateUserRepository creates a user repository based on the user database type
 func (f *RepositoryFactory) CreateUserRepository(pgDB *pgxpool.Pool, mongoDB *mongo.Database) (interfaces.UserRepository, error) {
-	switch f.dbType {
-	case PostgreSQL:
-		if pgDB == nil {
-			return nil, fmt.Errorf("PostgreSQL connection is required for PostgreSQL repository")
-		}
-		return postgresRepo.NewUserRepository(pgDB, f.logger), nil
-	case MongoDB:
-		if mongoDB == nil {
-			return nil, fmt.Errorf("MongoDB connection is required for MongoDB repository")
-		}
-		return mongoRepo.NewUserRepository(mongoDB, f.logger), nil
-	default:
-		return nil, fmt.Errorf("unsupported database type: %s", f.dbType)
+	if err := requireConnection(f.userDBType, pgDB, mongoDB); err != nil {
+		return nil, err
 	}
+
+	if f.userDBType == PostgreSQL {
+		return postgresRepo.NewUserRepository(postgresRepo.WithAcquireTimeout(pgDB, f.acquireTimeout), f.logger), nil
+	}
+	return mongoRepo.NewUserRepository(mongoDB, f.logger), nil
 }
 
-// CreateTodoRepository creates a todo repository based on database type
+// CreateTodoRepository creates a todo repository based on the todo database type
 func (f *RepositoryFactory) CreateTodoRepository(pgDB *pgxpool.Pool, mongoDB *mongo.Database) (interfaces.TodoRepository, error) {
-	switch f.dbType {
-	case PostgreSQL:
-		if pgDB == nil {
-			return nil, fmt.Errorf("PostgreSQL connection is required for PostgreSQL repository")
-		}
-		return postgresRepo.NewTodoRepository(pgDB, f.logger), nil
-	case MongoDB:
-		if mongoDB == nil {
-			return nil, fmt.Errorf("MongoDB connection is required for MongoDB repository")
-		}
-		return mongoRepo.NewTodoRepository(mongoDB, f.logger), nil
-	default:
-		return nil, fmt.Errorf("unsupported database type: %s", f.dbType)
+	if err := requireConnection(f.todoDBType, pgDB, mongoDB); err != nil {
+		return nil, err
+	}
+
+	if f.todoDBType == PostgreSQL {
+		return postgresRepo.NewTodoRepository(postgresRepo.WithAcquireTimeout(pgDB, f.acquireTimeout), f.logger), nil
 	}
+	return mongoRepo.NewTodoRepository(mongoDB, f.logger), nil
 }
 
-// CreateRepositories creates all repositories based on database type
+// CreateSchemaRepository creates a schema repository based on the todo database type
+func (f *RepositoryFactory) CreateSchemaRepository(pgDB *pgxpool.Pool, mongoDB *mongo.Database) (interfaces.SchemaRepository, error) {
+	if err := requireConnection(f.todoDBType, pgDB, mongoDB); err != nil {
+		return nil, err
+	}
+
+	if f.todoDBType == PostgreSQL {
+		return postgresRepo.NewSchemaRepository(postgresRepo.WithAcquireTimeout(pgDB, f.acquireTimeout), f.logger), nil
+	}
+	return mongoRepo.NewSchemaRepository(f.logger), nil
+}
+
+// CreateAPIKeyRepository creates an API key repository based on the user database type, as
+// keys belong to users
+func (f *RepositoryFactory) CreateAPIKeyRepository(pgDB *pgxpool.Pool, mongoDB *mongo.Database) (interfaces.APIKeyRepository, error) {
+	if err := requireConnection(f.userDBType, pgDB, mongoDB); err != nil {
+		return nil, err
+	}
+
+	if f.userDBType == PostgreSQL {
+		return postgresRepo.NewAPIKeyRepository(postgresRepo.WithAcquireTimeout(pgDB, f.acquireTimeout), f.logger), nil
+	}
+	return mongoRepo.NewAPIKeyRepository(mongoDB, f.logger), nil
+}
+
+// CreateRepositories creates all repositories based on their database types
 func (f *RepositoryFactory) CreateRepositories(pgDB *pgxpool.Pool, mongoDB *mongo.Database) (*interfaces.Repositories, error) {
 	userRepo, err := f.CreateUserRepository(pgDB, mongoDB)
 	if err != nil {
@@ -88,12 +116,30 @@ func (f *RepositoryFactory) CreateRepositories(pgDB *pgxpool.Pool, mongoDB *mong
 	}, nil
 }
 
-// GetDatabaseType returns the current database type
-func (f *RepositoryFactory) GetDatabaseType() DatabaseType {
-	return f.dbType
+// GetDatabaseTypes returns the database types of the user and todo repositories
+func (f *RepositoryFactory) GetDatabaseTypes() (user, todo DatabaseType) {
+	return f.userDBType, f.todoDBType
 }
 
-// SetDatabaseType sets the database type
+// SetDatabaseType stores both users and todos in the given database type
 func (f *RepositoryFactory) SetDatabaseType(dbType DatabaseType) {
-	f.dbType = dbType
+	f.userDBType = dbType
+	f.todoDBType = dbType
+}
+
+// requireConnection checks that the connection a database type needs is open
+func requireConnection(dbType DatabaseType, pgDB *pgxpool.Pool, mongoDB *mongo.Database) error {
+	switch dbType {
+	case PostgreSQL:
+		if pgDB == nil {
+			return fmt.Errorf("PostgreSQL connection is required for PostgreSQL repository")
+		}
+	case MongoDB:
+		if mongoDB == nil {
+			return fmt.Errorf("MongoDB connection is required for MongoDB repository")
+		}
+	default:
+		return fmt.Errorf("unsupported database type: %s", dbType)
+	}
+	return nil
 }
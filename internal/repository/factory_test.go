@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestDatabaseTypeForDriver(t *testing.T) {
+	assert.Equal(t, PostgreSQL, DatabaseTypeForDriver("postgres"))
+	assert.Equal(t, MongoDB, DatabaseTypeForDriver("mongodb"))
+}
+
+func TestRequireConnection(t *testing.T) {
+	pgDB := &pgxpool.Pool{}
+	mongoDB := &mongo.Database{}
+
+	assert.NoError(t, requireConnection(PostgreSQL, pgDB, nil))
+	assert.NoError(t, requireConnection(MongoDB, nil, mongoDB))
+	assert.Error(t, requireConnection(PostgreSQL, nil, mongoDB))
+	assert.Error(t, requireConnection(MongoDB, pgDB, nil))
+	assert.Error(t, requireConnection("sqlite", pgDB, mongoDB))
+}
@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"go-fiber/internal/models"
+)
+
+// APIKeyRepository defines the interface for API key data operations
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) (*models.APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	ListByUserID(ctx context.Context, userID string) ([]*models.APIKey, error)
+	Delete(ctx context.Context, id, userID string) error
+	DeleteByUserID(ctx context.Context, userID string) (int64, error)
+	UpdateLastUsed(ctx context.Context, id string, usedAt time.Time) error
+}
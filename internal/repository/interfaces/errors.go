@@ -0,0 +1,24 @@
+package interfaces
+
+import "errors"
+
+// ErrUnavailable is returned when the backing store cannot serve a request right now,
+// e.g. because no database connection could be acquired in time
+var ErrUnavailable = errors.New("repository temporarily unavailable")
+
+// ErrTodoNotFound is returned when a todo does not exist or has been deleted
+var ErrTodoNotFound = errors.New("todo not found")
+
+// ErrUserHasActiveTodos is returned when an account cannot be deleted because the user still
+// has todos that are not done
+var ErrUserHasActiveTodos = errors.New("user has active todos")
+
+// ErrUsernameTaken is returned when a user cannot be created because the username is in use
+var ErrUsernameTaken = errors.New("username already exists")
+
+// ErrEmailTaken is returned when a user cannot be created because the email is in use
+var ErrEmailTaken = errors.New("email already exists")
+
+// ErrAPIKeyNotFound is returned when an API key does not exist, belongs to another user or
+// has been revoked
+var ErrAPIKeyNotFound = errors.New("api key not found")
@@ -0,0 +1,16 @@
+package interfaces
+
+import "context"
+
+// SchemaRepository reports the state of the database schema
+type SchemaRepository interface {
+	// GetSchemaVersion returns the applied migration version, or "" when migrations are not managed
+	GetSchemaVersion(ctx context.Context) (string, error)
+}
+
+// IndexEnsurer is implemented by repositories that create their own indexes instead of
+// relying on migrations
+type IndexEnsurer interface {
+	// EnsureIndexes creates any missing indexes; indexes that already exist are left as they are
+	EnsureIndexes(ctx context.Context) error
+}
@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"time"
 
 	"go-fiber/internal/models"
 )
@@ -9,18 +10,47 @@ import (
 // TodoRepository defines the interface for todo data operations
 type TodoRepository interface {
 	Create(ctx context.Context, todo *models.Todo) (*models.Todo, error)
+	CreateBatch(ctx context.Context, todos []*models.Todo) ([]*models.Todo, error)
 	GetByID(ctx context.Context, id string) (*models.Todo, error)
+	GetByIDForUser(ctx context.Context, id, userID string) (*models.Todo, error)
 	GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error)
+	GetByUserIDCursor(ctx context.Context, userID string, cursor string, snapshot *time.Time, limit int) ([]*models.Todo, string, error)
+	StreamByUserID(ctx context.Context, userID string, fn func(*models.Todo) error) error
 	Update(ctx context.Context, todo *models.Todo) (*models.Todo, error)
-	Delete(ctx context.Context, id string) error
-	UpdateStatus(ctx context.Context, id, status string) error
+	PartialUpdate(ctx context.Context, userID, id string, patch *models.PatchTodoRequest) (*models.Todo, error)
+	Delete(ctx context.Context, userID, id string) error
+	UpdateStatus(ctx context.Context, userID, id, status string) error
+	GetFiltered(ctx context.Context, userID string, filter models.TodoFilter, sort models.TodoSort, limit, offset int) ([]*models.Todo, int64, error)
 	GetByStatus(ctx context.Context, userID, status string, limit, offset int) ([]*models.Todo, int64, error)
+	GetByStatuses(ctx context.Context, userID string, statuses []string, limit, offset int) ([]*models.Todo, int64, error)
 	GetByPriority(ctx context.Context, userID, priority string, limit, offset int) ([]*models.Todo, int64, error)
-	GetOverdue(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error)
+	GetByTag(ctx context.Context, userID, tag string, limit, offset int) ([]*models.Todo, int64, error)
+	GetOverdue(ctx context.Context, userID string, cutoff models.OverdueCutoff, limit, offset int) ([]*models.Todo, int64, error)
+	GetMostOverdue(ctx context.Context, userID string, cutoff models.OverdueCutoff) (*models.Todo, error)
 	GetUpcoming(ctx context.Context, userID string, days int, limit, offset int) ([]*models.Todo, int64, error)
-	Search(ctx context.Context, userID, query string, limit, offset int) ([]*models.Todo, int64, error)
+	GetRecentlyUpdated(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error)
+	GetDueOn(ctx context.Context, userID string, day models.DayRange) ([]*models.Todo, error)
+	GetUndated(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error)
+	GetDueDistribution(ctx context.Context, userID string, bounds models.DueDistributionBounds) (*models.DueDistribution, error)
+	CountCompletion(ctx context.Context, userID string, window models.CompletionWindow) (completed, total int64, err error)
+	GetBusiestDay(ctx context.Context, userID string, window models.CompletionWindow) (*models.DayCount, error)
+	CountTopTags(ctx context.Context, userID string, window models.CompletionWindow, limit int) ([]models.TagCount, error)
+	AverageCompletionTime(ctx context.Context, userID string, window models.CompletionWindow) (time.Duration, error)
+	Search(ctx context.Context, userID, query, mode string, limit, offset int) ([]*models.Todo, int64, error)
+	CountByUserID(ctx context.Context, userID string) (int64, error)
 	CountByStatus(ctx context.Context, userID string) (map[string]int64, error)
-	MarkCompleted(ctx context.Context, id string) error
-	BulkUpdateStatus(ctx context.Context, ids []string, status string) error
-	DeleteCompleted(ctx context.Context, userID string) error
+	CountOverdue(ctx context.Context, userID string, cutoff models.OverdueCutoff) (int64, error)
+	MarkCompleted(ctx context.Context, userID, id string) error
+	BulkUpdateStatusForUser(ctx context.Context, userID string, ids []string, status string) (int64, error)
+	BulkReschedule(ctx context.Context, userID string, ids []string, dueDate *time.Time, shift time.Duration) (int64, error)
+	BulkUpdatePriority(ctx context.Context, userID string, filter models.TodoFilter, priority string, dryRun bool) (int64, error)
+	BulkPatch(ctx context.Context, userID string, ids []string, patch *models.PatchTodoRequest, dryRun bool) (int64, error)
+	DeleteCompleted(ctx context.Context, userID string) (int64, error)
+	GetDeleted(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error)
+	Restore(ctx context.Context, userID, id string) error
+	HardDelete(ctx context.Context, userID, id string) error
+	PurgeDeleted(ctx context.Context, olderThan time.Time) (int64, error)
+	CountAllByStatus(ctx context.Context) (map[string]int64, error)
+	CountAllOverdue(ctx context.Context) (int64, error)
+	Merge(ctx context.Context, target *models.Todo, sourceID string) (*models.Todo, error)
 }
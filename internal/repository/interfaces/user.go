@@ -14,8 +14,13 @@ type UserRepository interface {
 	GetByUsername(ctx context.Context, username string) (*models.User, error)
 	Update(ctx context.Context, user *models.User) (*models.User, error)
 	Delete(ctx context.Context, id string) error
+	DeleteAccount(ctx context.Context, id, policy string) error
 	UpdateImage(ctx context.Context, id, imageURL string) error
 	UpdatePassword(ctx context.Context, id, hashedPassword string) error
+	UpdateRole(ctx context.Context, id, role string) error
+	GetTOTP(ctx context.Context, id string) (*models.UserTOTP, error)
+	UpdateTOTP(ctx context.Context, id, encryptedSecret string, enabled bool) error
+	UseTOTPStep(ctx context.Context, id string, step int64) (bool, error)
 	List(ctx context.Context, limit, offset int) ([]*models.User, int64, error)
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
 	ExistsByUsername(ctx context.Context, username string) (bool, error)
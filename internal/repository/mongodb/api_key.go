@@ -0,0 +1,183 @@
+package mongodb
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-fiber/internal/models"
+	"go-fiber/internal/repository/interfaces"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoAPIKey represents an API key document in MongoDB
+type MongoAPIKey struct {
+	ID         string     `bson:"_id"`
+	UserID     string     `bson:"userId"`
+	Name       string     `bson:"name"`
+	Prefix     string     `bson:"prefix"`
+	KeyHash    string     `bson:"keyHash"`
+	Scopes     []string   `bson:"scopes"`
+	CreatedAt  time.Time  `bson:"createdAt"`
+	LastUsedAt *time.Time `bson:"lastUsedAt,omitempty"`
+}
+
+// apiKeyRepository implements the APIKeyRepository interface for MongoDB
+type apiKeyRepository struct {
+	collection *mongo.Collection
+	logger     zerolog.Logger
+}
+
+// NewAPIKeyRepository creates a new MongoDB API key repository
+func NewAPIKeyRepository(db *mongo.Database, logger zerolog.Logger) interfaces.APIKeyRepository {
+	return &apiKeyRepository{
+		collection: db.Collection("api_keys"),
+		logger:     logger,
+	}
+}
+
+// EnsureIndexes creates the unique key hash index and the index used to list a user's keys
+func (r *apiKeyRepository) EnsureIndexes(ctx context.Context) error {
+	if _, err := r.collection.Indexes().CreateMany(ctx, apiKeyIndexes()); err != nil {
+		r.logger.Error().Err(err).Msg("Failed to create API key indexes.")
+		return fmt.Errorf("failed to create api key indexes: %w", err)
+	}
+
+	return nil
+}
+
+// apiKeyIndexes returns the api_keys collection indexes
+func apiKeyIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "keyHash", Value: 1}},
+			Options: options.Index().SetName("api_keys_key_hash").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("api_keys_user"),
+		},
+	}
+}
+
+// Create stores a new API key
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	entropy := ulid.Monotonic(rand.Reader, 0)
+	id := ulid.MustNew(ulid.Timestamp(time.Now()), entropy)
+
+	mongoKey := &MongoAPIKey{
+		ID:        id.String(),
+		UserID:    key.UserID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		KeyHash:   key.KeyHash,
+		Scopes:    key.Scopes,
+		CreatedAt: time.Now(),
+	}
+
+	if _, err := r.collection.InsertOne(ctx, mongoKey); err != nil {
+		r.logger.Error().Err(err).Str("user_id", key.UserID).Msg("Failed to create API key.")
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	r.logger.Info().Str("api_key_id", mongoKey.ID).Str("user_id", mongoKey.UserID).Msg("API key created successfully.")
+	return mongoKey.toModel(), nil
+}
+
+// GetByHash retrieves the API key with the given hash
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var mongoKey MongoAPIKey
+	err := r.collection.FindOne(ctx, bson.M{"keyHash": keyHash}).Decode(&mongoKey)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, interfaces.ErrAPIKeyNotFound
+		}
+		r.logger.Error().Err(err).Msg("Failed to get API key by hash.")
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	return mongoKey.toModel(), nil
+}
+
+// ListByUserID retrieves a user's API keys, newest first
+func (r *apiKeyRepository) ListByUserID(ctx context.Context, userID string) ([]*models.APIKey, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"userId": userID}, opts)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to list API keys.")
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var mongoKeys []MongoAPIKey
+	if err := cursor.All(ctx, &mongoKeys); err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to decode API keys.")
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	keys := make([]*models.APIKey, 0, len(mongoKeys))
+	for i := range mongoKeys {
+		keys = append(keys, mongoKeys[i].toModel())
+	}
+	return keys, nil
+}
+
+// Delete revokes one of the user's API keys
+func (r *apiKeyRepository) Delete(ctx context.Context, id, userID string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "userId": userID})
+	if err != nil {
+		r.logger.Error().Err(err).Str("api_key_id", id).Str("user_id", userID).Msg("Failed to delete API key.")
+		return fmt.Errorf("failed to delete api key: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return interfaces.ErrAPIKeyNotFound
+	}
+
+	r.logger.Info().Str("api_key_id", id).Str("user_id", userID).Msg("API key deleted successfully.")
+	return nil
+}
+
+// DeleteByUserID revokes all of the user's API keys and returns how many were revoked
+func (r *apiKeyRepository) DeleteByUserID(ctx context.Context, userID string) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"userId": userID})
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to delete API keys of user.")
+		return 0, fmt.Errorf("failed to delete api keys: %w", err)
+	}
+
+	r.logger.Info().Str("user_id", userID).Int64("deleted", result.DeletedCount).Msg("API keys of user deleted.")
+	return result.DeletedCount, nil
+}
+
+// UpdateLastUsed records when the API key was last used
+func (r *apiKeyRepository) UpdateLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"lastUsedAt": usedAt}})
+	if err != nil {
+		r.logger.Error().Err(err).Str("api_key_id", id).Msg("Failed to update API key last used time.")
+		return fmt.Errorf("failed to update api key last used time: %w", err)
+	}
+
+	return nil
+}
+
+// toModel converts the document to the API key model
+func (k *MongoAPIKey) toModel() *models.APIKey {
+	return &models.APIKey{
+		ID:         k.ID,
+		UserID:     k.UserID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		KeyHash:    k.KeyHash,
+		Scopes:     k.Scopes,
+		CreatedAt:  k.CreatedAt,
+		LastUsedAt: k.LastUsedAt,
+	}
+}
@@ -0,0 +1,18 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestAPIKeyIndexes(t *testing.T) {
+	indexes := apiKeyIndexes()
+
+	assert.Len(t, indexes, 2)
+	assert.Equal(t, bson.D{{Key: "keyHash", Value: 1}}, indexes[0].Keys)
+	assert.True(t, *indexes[0].Options.Unique)
+	assert.Equal(t, bson.D{{Key: "userId", Value: 1}, {Key: "_id", Value: -1}}, indexes[1].Keys)
+	assert.Nil(t, indexes[1].Options.Unique)
+}
@@ -0,0 +1,26 @@
+package mongodb
+
+import (
+	"context"
+
+	"go-fiber/internal/repository/interfaces"
+
+	"github.com/rs/zerolog"
+)
+
+// schemaRepository implements the SchemaRepository interface for MongoDB
+type schemaRepository struct {
+	logger zerolog.Logger
+}
+
+// NewSchemaRepository creates a new MongoDB schema repository
+func NewSchemaRepository(logger zerolog.Logger) interfaces.SchemaRepository {
+	return &schemaRepository{
+		logger: logger,
+	}
+}
+
+// GetSchemaVersion reports that MongoDB collections and indexes are not versioned by migrations
+func (r *schemaRepository) GetSchemaVersion(ctx context.Context) (string, error) {
+	return "", nil
+}
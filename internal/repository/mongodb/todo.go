@@ -3,7 +3,10 @@ package mongodb
 import (
 	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"go-fiber/internal/models"
@@ -14,20 +17,24 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 // MongoTodo represents a todo document in MongoDB
 type MongoTodo struct {
-	ID          string     `bson:"_id" json:"id"`
-	UserID      string     `bson:"userId" json:"userId"`
-	Title       string     `bson:"title" json:"title"`
-	Description string     `bson:"description,omitempty" json:"description,omitempty"`
-	Status      string     `bson:"status" json:"status"`
-	Priority    string     `bson:"priority,omitempty" json:"priority,omitempty"`
-	DueDate     *time.Time `bson:"dueDate,omitempty" json:"dueDate,omitempty"`
-	CreatedAt   time.Time  `bson:"createdAt" json:"createdAt"`
-	UpdatedAt   time.Time  `bson:"updatedAt" json:"updatedAt"`
-	DeletedAt   *time.Time `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
+	ID          string           `bson:"_id" json:"id"`
+	UserID      string           `bson:"userId" json:"userId"`
+	Title       string           `bson:"title" json:"title"`
+	Description string           `bson:"description,omitempty" json:"description,omitempty"`
+	Status      string           `bson:"status" json:"status"`
+	Priority    string           `bson:"priority,omitempty" json:"priority,omitempty"`
+	DueDate     *time.Time       `bson:"dueDate,omitempty" json:"dueDate,omitempty"`
+	AllDay      bool             `bson:"allDay,omitempty" json:"allDay,omitempty"`
+	Tags        []string         `bson:"tags,omitempty" json:"tags,omitempty"`
+	Subtasks    []models.Subtask `bson:"subtasks,omitempty" json:"subtasks,omitempty"`
+	CreatedAt   time.Time        `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time        `bson:"updatedAt" json:"updatedAt"`
+	DeletedAt   *time.Time       `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
 }
 
 // todoRepository implements the TodoRepository interface for MongoDB
@@ -44,6 +51,39 @@ func NewTodoRepository(db *mongo.Database, logger zerolog.Logger) interfaces.Tod
 	}
 }
 
+// EnsureIndexes creates the indexes todo queries rely on. Creating an index that already
+// exists with the same options is a no-op, so this is safe to run on every startup.
+func (r *todoRepository) EnsureIndexes(ctx context.Context) error {
+	if _, err := r.collection.Indexes().CreateMany(ctx, todoIndexes()); err != nil {
+		r.logger.Error().Err(err).Msg("Failed to create todo indexes.")
+		return fmt.Errorf("failed to create todo indexes: %w", err)
+	}
+
+	return nil
+}
+
+// todoIndexes returns the todos collection indexes: the text index used by Search, and
+// indexes for the per-user status and due date filters. A collection has at most one text
+// index, so it keeps the name and weights scripts/mongo-init.js gives it.
+func todoIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "title", Value: "text"}, {Key: "description", Value: "text"}},
+			Options: options.Index().
+				SetName("todo_text_search").
+				SetWeights(bson.D{{Key: "title", Value: 10}, {Key: "description", Value: 5}}),
+		},
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "status", Value: 1}},
+			Options: options.Index().SetName("todos_user_status"),
+		},
+		{
+			Keys:    bson.D{{Key: "dueDate", Value: 1}},
+			Options: options.Index().SetName("todos_due_date"),
+		},
+	}
+}
+
 // Create creates a new todo
 func (r *todoRepository) Create(ctx context.Context, todo *models.Todo) (*models.Todo, error) {
 	// Generate ULID for new todo
@@ -55,12 +95,12 @@ func (r *todoRepository) Create(ctx context.Context, todo *models.Todo) (*models
 	// Set defaults
 	status := todo.Status
 	if status == "" {
-		status = models.TodoStatusPending
+		status = models.DefaultStatus()
 	}
 
 	priority := todo.Priority
 	if priority == "" {
-		priority = models.TodoPriorityMedium
+		priority = models.DefaultPriority()
 	}
 
 	mongoTodo := &MongoTodo{
@@ -71,6 +111,9 @@ func (r *todoRepository) Create(ctx context.Context, todo *models.Todo) (*models
 		Status:      status,
 		Priority:    priority,
 		DueDate:     todo.DueDate,
+		AllDay:      todo.AllDay,
+		Tags:        todo.Tags,
+		Subtasks:    todo.Subtasks,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
@@ -86,6 +129,88 @@ func (r *todoRepository) Create(ctx context.Context, todo *models.Todo) (*models
 	return result, nil
 }
 
+// primaryTransaction returns the options every transaction runs with. Transactions must
+// read from the primary, whatever read preference the client uses for plain reads.
+func primaryTransaction() *options.TransactionOptions {
+	return options.Transaction().SetReadPreference(readpref.Primary())
+}
+
+// illegalOperationCode is the server error code a standalone MongoDB answers transactions with
+const illegalOperationCode = 20
+
+// transactionsUnsupported reports whether err is the server refusing a transaction because it
+// is not part of a replica set or sharded cluster
+func transactionsUnsupported(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorCode(illegalOperationCode)
+}
+
+// CreateBatch creates todos in one transaction, so either all of them are inserted or none.
+// A standalone server cannot run transactions, so there they are inserted in order without
+// one, and the todos before a failed insert stay created. IDs come from a single monotonic
+// source, so the todos sort in the order given.
+func (r *todoRepository) CreateBatch(ctx context.Context, todos []*models.Todo) ([]*models.Todo, error) {
+	entropy := ulid.Monotonic(rand.Reader, 0)
+	now := time.Now()
+
+	mongoTodos := make([]*MongoTodo, len(todos))
+	documents := make([]interface{}, len(todos))
+	for i, todo := range todos {
+		status := todo.Status
+		if status == "" {
+			status = models.DefaultStatus()
+		}
+
+		priority := todo.Priority
+		if priority == "" {
+			priority = models.DefaultPriority()
+		}
+
+		mongoTodos[i] = &MongoTodo{
+			ID:          ulid.MustNew(ulid.Timestamp(now), entropy).String(),
+			UserID:      todo.UserID,
+			Title:       todo.Title,
+			Description: todo.Description,
+			Status:      status,
+			Priority:    priority,
+			DueDate:     todo.DueDate,
+			AllDay:      todo.AllDay,
+			Tags:        todo.Tags,
+			Subtasks:    todo.Subtasks,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		documents[i] = mongoTodos[i]
+	}
+
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		r.logger.Error().Err(err).Msg("Failed to start session for todo batch.")
+		return nil, fmt.Errorf("failed to create todos: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return r.collection.InsertMany(sc, documents)
+	}, primaryTransaction())
+	if transactionsUnsupported(err) {
+		r.logger.Debug().Int("count", len(todos)).Msg("Transactions unsupported, creating todos without one.")
+		_, err = r.collection.InsertMany(ctx, documents)
+	}
+	if err != nil {
+		r.logger.Error().Err(err).Int("count", len(todos)).Msg("Failed to create todos.")
+		return nil, fmt.Errorf("failed to create todos: %w", err)
+	}
+
+	results := make([]*models.Todo, len(mongoTodos))
+	for i, mongoTodo := range mongoTodos {
+		results[i] = r.mongoTodoToModel(mongoTodo)
+	}
+
+	r.logger.Info().Int("count", len(results)).Msg("Todos created successfully.")
+	return results, nil
+}
+
 // GetByID retrieves a todo by ID
 func (r *todoRepository) GetByID(ctx context.Context, id string) (*models.Todo, error) {
 	filter := bson.M{
@@ -96,8 +221,8 @@ func (r *todoRepository) GetByID(ctx context.Context, id string) (*models.Todo,
 	var mongoTodo MongoTodo
 	err := r.collection.FindOne(ctx, filter).Decode(&mongoTodo)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("todo not found")
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, interfaces.ErrTodoNotFound
 		}
 		r.logger.Error().Err(err).Str("todo_id", id).Msg("Failed to get todo by ID.")
 		return nil, fmt.Errorf("failed to get todo: %w", err)
@@ -106,6 +231,28 @@ func (r *todoRepository) GetByID(ctx context.Context, id string) (*models.Todo,
 	return r.mongoTodoToModel(&mongoTodo), nil
 }
 
+// GetByIDForUser retrieves a todo by ID only if it belongs to the user, so todos of other
+// users are not found
+func (r *todoRepository) GetByIDForUser(ctx context.Context, id, userID string) (*models.Todo, error) {
+	filter := bson.M{
+		"_id":       id,
+		"userId":    userID,
+		"deletedAt": bson.M{"$exists": false},
+	}
+
+	var mongoTodo MongoTodo
+	err := r.collection.FindOne(ctx, filter).Decode(&mongoTodo)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, interfaces.ErrTodoNotFound
+		}
+		r.logger.Error().Err(err).Str("todo_id", id).Str("user_id", userID).Msg("Failed to get todo by ID for user.")
+		return nil, fmt.Errorf("failed to get todo: %w", err)
+	}
+
+	return r.mongoTodoToModel(&mongoTodo), nil
+}
+
 // GetByUserID retrieves todos by user ID with pagination
 func (r *todoRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error) {
 	filter := bson.M{
@@ -147,6 +294,93 @@ func (r *todoRepository) GetByUserID(ctx context.Context, userID string, limit,
 	return todos, total, nil
 }
 
+// GetByUserIDCursor retrieves a page of todos by user ID, newest first, starting after the
+// todo the cursor points to. ULIDs sort by creation time, so the ID alone is a stable key.
+// With a snapshot, only todos created at or before it are returned.
+func (r *todoRepository) GetByUserIDCursor(ctx context.Context, userID string, cursor string, snapshot *time.Time, limit int) ([]*models.Todo, string, error) {
+	afterID, err := models.DecodeTodoCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	opts := options.Find().
+		SetLimit(int64(limit + 1)).
+		SetSort(bson.M{"_id": -1})
+
+	mongoCursor, err := r.collection.Find(ctx, cursorFilter(userID, afterID, snapshot), opts)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get todos by cursor.")
+		return nil, "", fmt.Errorf("failed to get todos: %w", err)
+	}
+	defer mongoCursor.Close(ctx)
+
+	var mongoTodos []MongoTodo
+	if err := mongoCursor.All(ctx, &mongoTodos); err != nil {
+		r.logger.Error().Err(err).Msg("Failed to decode todos.")
+		return nil, "", fmt.Errorf("failed to decode todos: %w", err)
+	}
+
+	todos := make([]*models.Todo, len(mongoTodos))
+	for i, mongoTodo := range mongoTodos {
+		todos[i] = r.mongoTodoToModel(&mongoTodo)
+	}
+
+	todos, nextCursor := models.CursorPage(todos, limit)
+	return todos, nextCursor, nil
+}
+
+// streamBatchSize is the number of todos fetched per round trip when streaming
+const streamBatchSize = 500
+
+// StreamByUserID calls fn for each of a user's todos, newest first, decoding them one at a
+// time from the cursor. It stops at the first error fn returns.
+func (r *todoRepository) StreamByUserID(ctx context.Context, userID string, fn func(*models.Todo) error) error {
+	opts := options.Find().
+		SetBatchSize(streamBatchSize).
+		SetSort(bson.M{"_id": -1})
+
+	mongoCursor, err := r.collection.Find(ctx, cursorFilter(userID, "", nil), opts)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to stream todos.")
+		return fmt.Errorf("failed to stream todos: %w", err)
+	}
+	defer mongoCursor.Close(ctx)
+
+	for mongoCursor.Next(ctx) {
+		var mongoTodo MongoTodo
+		if err := mongoCursor.Decode(&mongoTodo); err != nil {
+			r.logger.Error().Err(err).Msg("Failed to decode todo.")
+			return fmt.Errorf("failed to decode todo: %w", err)
+		}
+		if err := fn(r.mongoTodoToModel(&mongoTodo)); err != nil {
+			return err
+		}
+	}
+
+	if err := mongoCursor.Err(); err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to stream todos.")
+		return fmt.Errorf("failed to stream todos: %w", err)
+	}
+
+	return nil
+}
+
+// cursorFilter matches a user's todos created before afterID, or all of them when afterID is empty,
+// leaving out todos created after snapshot when one is given
+func cursorFilter(userID, afterID string, snapshot *time.Time) bson.M {
+	filter := bson.M{
+		"userId":    userID,
+		"deletedAt": bson.M{"$exists": false},
+	}
+	if afterID != "" {
+		filter["_id"] = bson.M{"$lt": afterID}
+	}
+	if snapshot != nil {
+		filter["createdAt"] = bson.M{"$lte": *snapshot}
+	}
+	return filter
+}
+
 // Update updates a todo
 func (r *todoRepository) Update(ctx context.Context, todo *models.Todo) (*models.Todo, error) {
 	filter := bson.M{
@@ -161,6 +395,9 @@ func (r *todoRepository) Update(ctx context.Context, todo *models.Todo) (*models
 			"status":      todo.Status,
 			"priority":    todo.Priority,
 			"dueDate":     todo.DueDate,
+			"allDay":      todo.AllDay,
+			"tags":        todo.Tags,
+			"subtasks":    todo.Subtasks,
 			"updatedAt":   time.Now(),
 		},
 	}
@@ -169,8 +406,8 @@ func (r *todoRepository) Update(ctx context.Context, todo *models.Todo) (*models
 	var mongoTodo MongoTodo
 	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&mongoTodo)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("todo not found")
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, interfaces.ErrTodoNotFound
 		}
 		r.logger.Error().Err(err).Str("todo_id", todo.ID).Msg("Failed to update todo.")
 		return nil, fmt.Errorf("failed to update todo: %w", err)
@@ -181,10 +418,36 @@ func (r *todoRepository) Update(ctx context.Context, todo *models.Todo) (*models
 	return result, nil
 }
 
-// Delete soft deletes a todo
-func (r *todoRepository) Delete(ctx context.Context, id string) error {
+// PartialUpdate sets only the fields present in patch on a todo owned by userID, clearing
+// the description when it is empty and the due date when it is null
+func (r *todoRepository) PartialUpdate(ctx context.Context, userID, id string, patch *models.PatchTodoRequest) (*models.Todo, error) {
+	filter := bson.M{
+		"_id":       id,
+		"userId":    userID,
+		"deletedAt": bson.M{"$exists": false},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var mongoTodo MongoTodo
+	err := r.collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": todoPatchSet(patch)}, opts).Decode(&mongoTodo)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, interfaces.ErrTodoNotFound
+		}
+		r.logger.Error().Err(err).Str("todo_id", id).Str("user_id", userID).Msg("Failed to partially update todo.")
+		return nil, fmt.Errorf("failed to update todo: %w", err)
+	}
+
+	result := r.mongoTodoToModel(&mongoTodo)
+	r.logger.Info().Str("todo_id", result.ID).Msg("Todo partially updated successfully.")
+	return result, nil
+}
+
+// Delete soft deletes a todo of the user. Todos of other users are reported as not found.
+func (r *todoRepository) Delete(ctx context.Context, userID, id string) error {
 	filter := bson.M{
 		"_id":       id,
+		"userId":    userID,
 		"deletedAt": bson.M{"$exists": false},
 	}
 
@@ -197,22 +460,109 @@ func (r *todoRepository) Delete(ctx context.Context, id string) error {
 
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		r.logger.Error().Err(err).Str("todo_id", id).Msg("Failed to delete todo.")
+		r.logger.Error().Err(err).Str("todo_id", id).Str("user_id", userID).Msg("Failed to delete todo.")
 		return fmt.Errorf("failed to delete todo: %w", err)
 	}
 
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("todo not found")
+		return interfaces.ErrTodoNotFound
 	}
 
-	r.logger.Info().Str("todo_id", id).Msg("Todo deleted successfully.")
+	r.logger.Info().Str("todo_id", id).Str("user_id", userID).Msg("Todo deleted successfully.")
 	return nil
 }
 
-// UpdateStatus updates a todo's status
-func (r *todoRepository) UpdateStatus(ctx context.Context, id, status string) error {
+// GetFiltered retrieves the user's todos matching every set field of filter in the order of
+// sort, with pagination. The total counts the same matches.
+func (r *todoRepository) GetFiltered(ctx context.Context, userID string, filter models.TodoFilter, sort models.TodoSort, limit, offset int) ([]*models.Todo, int64, error) {
+	query := todoFilterQuery(userID, filter)
+
+	// Get total count
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count filtered todos.")
+		return nil, 0, fmt.Errorf("failed to count todos: %w", err)
+	}
+
+	// Stored fields sort directly; computed sort keys need an aggregation
+	sortKeys, order := todoSortKeys(sort)
+	var cursor *mongo.Cursor
+	if sortKeys == nil {
+		opts := options.Find().
+			SetLimit(int64(limit)).
+			SetSkip(int64(offset)).
+			SetSort(order)
+		cursor, err = r.collection.Find(ctx, query, opts)
+	} else {
+		cursor, err = r.collection.Aggregate(ctx, []bson.M{
+			{"$match": query},
+			{"$addFields": sortKeys},
+			{"$sort": order},
+			{"$skip": int64(offset)},
+			{"$limit": int64(limit)},
+		})
+	}
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get filtered todos.")
+		return nil, 0, fmt.Errorf("failed to get todos: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var mongoTodos []MongoTodo
+	if err := cursor.All(ctx, &mongoTodos); err != nil {
+		r.logger.Error().Err(err).Msg("Failed to decode todos.")
+		return nil, 0, fmt.Errorf("failed to decode todos: %w", err)
+	}
+
+	todos := make([]*models.Todo, len(mongoTodos))
+	for i, mongoTodo := range mongoTodos {
+		todos[i] = r.mongoTodoToModel(&mongoTodo)
+	}
+
+	return todos, total, nil
+}
+
+// todoSortKeys returns the sort document for sort, with the computed keys it sorts on or
+// nil when it only uses stored fields. Priorities rank by their configured level, and todos
+// without a due date or with an unknown priority are kept last in either direction.
+func todoSortKeys(sort models.TodoSort) (bson.M, bson.D) {
+	direction := 1
+	if sort.Descending() {
+		direction = -1
+	}
+
+	switch sort.Field {
+	case models.TodoSortDueDate:
+		return bson.M{
+			"sortMissing": bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$dueDate", nil}}, nil}},
+		}, bson.D{
+			{Key: "sortMissing", Value: 1},
+			{Key: "dueDate", Value: direction},
+			{Key: "_id", Value: direction},
+		}
+	case models.TodoSortPriority:
+		rank := bson.M{"$indexOfArray": bson.A{models.Priorities(), "$priority"}}
+		return bson.M{
+			"sortMissing": bson.M{"$lt": bson.A{rank, 0}},
+			"sortRank":    rank,
+		}, bson.D{
+			{Key: "sortMissing", Value: 1},
+			{Key: "sortRank", Value: direction},
+			{Key: "_id", Value: direction},
+		}
+	case models.TodoSortTitle:
+		return nil, bson.D{{Key: "title", Value: direction}, {Key: "_id", Value: direction}}
+	default:
+		return nil, bson.D{{Key: "createdAt", Value: direction}, {Key: "_id", Value: direction}}
+	}
+}
+
+// UpdateStatus updates the status of a todo of the user. Todos of other users are reported
+// as not found.
+func (r *todoRepository) UpdateStatus(ctx context.Context, userID, id, status string) error {
 	filter := bson.M{
 		"_id":       id,
+		"userId":    userID,
 		"deletedAt": bson.M{"$exists": false},
 	}
 
@@ -230,7 +580,7 @@ func (r *todoRepository) UpdateStatus(ctx context.Context, id, status string) er
 	}
 
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("todo not found")
+		return interfaces.ErrTodoNotFound
 	}
 
 	r.logger.Info().Str("todo_id", id).Str("status", status).Msg("Todo status updated successfully.")
@@ -279,6 +629,11 @@ func (r *todoRepository) GetByStatus(ctx context.Context, userID, status string,
 	return todos, total, nil
 }
 
+// GetByStatuses retrieves todos in any of statuses with pagination
+func (r *todoRepository) GetByStatuses(ctx context.Context, userID string, statuses []string, limit, offset int) ([]*models.Todo, int64, error) {
+	return r.GetFiltered(ctx, userID, models.TodoFilter{Statuses: statuses}, models.NewTodoSort("", ""), limit, offset)
+}
+
 // GetByPriority retrieves todos by priority with pagination
 func (r *todoRepository) GetByPriority(ctx context.Context, userID, priority string, limit, offset int) ([]*models.Todo, int64, error) {
 	filter := bson.M{
@@ -321,16 +676,54 @@ func (r *todoRepository) GetByPriority(ctx context.Context, userID, priority str
 	return todos, total, nil
 }
 
-// GetOverdue retrieves overdue todos with pagination
-func (r *todoRepository) GetOverdue(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error) {
-	now := time.Now()
+// GetByTag retrieves todos carrying a tag with pagination
+func (r *todoRepository) GetByTag(ctx context.Context, userID, tag string, limit, offset int) ([]*models.Todo, int64, error) {
+	// Matching an array field against a value matches documents whose array contains it
 	filter := bson.M{
 		"userId":    userID,
-		"dueDate":   bson.M{"$lt": now},
-		"status":    bson.M{"$ne": models.TodoStatusCompleted},
+		"tags":      tag,
 		"deletedAt": bson.M{"$exists": false},
 	}
 
+	// Get total count
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Str("tag", tag).Msg("Failed to count todos by tag.")
+		return nil, 0, fmt.Errorf("failed to count todos: %w", err)
+	}
+
+	// Get todos with pagination
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset)).
+		SetSort(bson.M{"createdAt": -1})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Str("tag", tag).Msg("Failed to get todos by tag.")
+		return nil, 0, fmt.Errorf("failed to get todos: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var mongoTodos []MongoTodo
+	if err := cursor.All(ctx, &mongoTodos); err != nil {
+		r.logger.Error().Err(err).Msg("Failed to decode todos.")
+		return nil, 0, fmt.Errorf("failed to decode todos: %w", err)
+	}
+
+	todos := make([]*models.Todo, len(mongoTodos))
+	for i, mongoTodo := range mongoTodos {
+		todos[i] = r.mongoTodoToModel(&mongoTodo)
+	}
+
+	return todos, total, nil
+}
+
+// GetOverdue retrieves overdue todos with pagination
+func (r *todoRepository) GetOverdue(ctx context.Context, userID string, cutoff models.OverdueCutoff, limit, offset int) ([]*models.Todo, int64, error) {
+	filter := overdueFilter(cutoff)
+	filter["userId"] = userID
+
 	// Get total count
 	total, err := r.collection.CountDocuments(ctx, filter)
 	if err != nil {
@@ -365,6 +758,39 @@ func (r *todoRepository) GetOverdue(ctx context.Context, userID string, limit, o
 	return todos, total, nil
 }
 
+// GetMostOverdue retrieves the not-done todo with the oldest past due date
+func (r *todoRepository) GetMostOverdue(ctx context.Context, userID string, cutoff models.OverdueCutoff) (*models.Todo, error) {
+	filter := overdueFilter(cutoff)
+	filter["userId"] = userID
+
+	opts := options.FindOne().SetSort(bson.M{"dueDate": 1})
+
+	var mongoTodo MongoTodo
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&mongoTodo)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, interfaces.ErrTodoNotFound
+		}
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get most overdue todo.")
+		return nil, fmt.Errorf("failed to get most overdue todo: %w", err)
+	}
+
+	return r.mongoTodoToModel(&mongoTodo), nil
+}
+
+// overdueFilter matches todos overdue at the cutoff that are not in the configured done
+// status: timed todos due before now and all-day todos whose day is before today
+func overdueFilter(cutoff models.OverdueCutoff) bson.M {
+	return bson.M{
+		"$or": bson.A{
+			bson.M{"allDay": bson.M{"$ne": true}, "dueDate": bson.M{"$lt": cutoff.Now}},
+			bson.M{"allDay": true, "dueDate": bson.M{"$lt": cutoff.Today}},
+		},
+		"status":    bson.M{"$ne": models.DoneStatus()},
+		"deletedAt": bson.M{"$exists": false},
+	}
+}
+
 // GetUpcoming retrieves upcoming todos with pagination
 func (r *todoRepository) GetUpcoming(ctx context.Context, userID string, days int, limit, offset int) ([]*models.Todo, int64, error) {
 	now := time.Now()
@@ -376,7 +802,7 @@ func (r *todoRepository) GetUpcoming(ctx context.Context, userID string, days in
 			"$gte": now,
 			"$lte": futureDate,
 		},
-		"status":    bson.M{"$ne": models.TodoStatusCompleted},
+		"status":    bson.M{"$ne": models.DoneStatus()},
 		"deletedAt": bson.M{"$exists": false},
 	}
 
@@ -414,31 +840,30 @@ func (r *todoRepository) GetUpcoming(ctx context.Context, userID string, days in
 	return todos, total, nil
 }
 
-// Search searches todos with pagination
-func (r *todoRepository) Search(ctx context.Context, userID, query string, limit, offset int) ([]*models.Todo, int64, error) {
+// GetRecentlyUpdated retrieves todos of any status, most recently updated first, with pagination
+func (r *todoRepository) GetRecentlyUpdated(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error) {
 	filter := bson.M{
 		"userId":    userID,
 		"deletedAt": bson.M{"$exists": false},
-		"$text":     bson.M{"$search": query},
 	}
 
 	// Get total count
 	total, err := r.collection.CountDocuments(ctx, filter)
 	if err != nil {
-		r.logger.Error().Err(err).Str("user_id", userID).Str("query", query).Msg("Failed to count search todos.")
-		return nil, 0, fmt.Errorf("failed to count search todos: %w", err)
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count recently updated todos.")
+		return nil, 0, fmt.Errorf("failed to count todos: %w", err)
 	}
 
 	// Get todos with pagination
 	opts := options.Find().
 		SetLimit(int64(limit)).
 		SetSkip(int64(offset)).
-		SetSort(bson.M{"score": bson.M{"$meta": "textScore"}})
+		SetSort(recentlyUpdatedSort())
 
 	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
-		r.logger.Error().Err(err).Str("user_id", userID).Str("query", query).Msg("Failed to search todos.")
-		return nil, 0, fmt.Errorf("failed to search todos: %w", err)
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get recently updated todos.")
+		return nil, 0, fmt.Errorf("failed to get recently updated todos: %w", err)
 	}
 	defer cursor.Close(ctx)
 
@@ -456,79 +881,697 @@ func (r *todoRepository) Search(ctx context.Context, userID, query string, limit
 	return todos, total, nil
 }
 
-// CountByStatus returns count of todos by status
-func (r *todoRepository) CountByStatus(ctx context.Context, userID string) (map[string]int64, error) {
-	pipeline := []bson.M{
-		{
-			"$match": bson.M{
-				"userId":    userID,
-				"deletedAt": bson.M{"$exists": false},
-			},
-		},
-		{
-			"$group": bson.M{
-				"_id":   "$status",
-				"count": bson.M{"$sum": 1},
-			},
-		},
+// recentlyUpdatedSort orders by last update, newest first, breaking ties by ID so pages stay
+// stable. It is a bson.D because the order of sort keys matters.
+func recentlyUpdatedSort() bson.D {
+	return bson.D{
+		{Key: "updatedAt", Value: -1},
+		{Key: "_id", Value: -1},
 	}
+}
 
-	cursor, err := r.collection.Aggregate(ctx, pipeline)
+// GetDueOn retrieves the not-done todos due on a day, earliest first
+func (r *todoRepository) GetDueOn(ctx context.Context, userID string, day models.DayRange) ([]*models.Todo, error) {
+	filter := dueOnFilter(day)
+	filter["userId"] = userID
+
+	opts := options.Find().SetSort(bson.D{{Key: "dueDate", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
-		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get todo status counts.")
-		return nil, fmt.Errorf("failed to get todo status counts: %w", err)
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get todos due on day.")
+		return nil, fmt.Errorf("failed to get todos due on day: %w", err)
 	}
 	defer cursor.Close(ctx)
 
-	counts := make(map[string]int64)
-	for cursor.Next(ctx) {
-		var result struct {
-			Status string `bson:"_id"`
-			Count  int64  `bson:"count"`
-		}
-		if err := cursor.Decode(&result); err != nil {
-			r.logger.Error().Err(err).Msg("Failed to decode status count.")
-			continue
-		}
-		counts[result.Status] = result.Count
+	var mongoTodos []MongoTodo
+	if err := cursor.All(ctx, &mongoTodos); err != nil {
+		r.logger.Error().Err(err).Msg("Failed to decode todos.")
+		return nil, fmt.Errorf("failed to decode todos: %w", err)
 	}
 
-	return counts, nil
+	todos := make([]*models.Todo, len(mongoTodos))
+	for i, mongoTodo := range mongoTodos {
+		todos[i] = r.mongoTodoToModel(&mongoTodo)
+	}
+
+	return todos, nil
 }
 
-// MarkCompleted marks a todo as completed
-func (r *todoRepository) MarkCompleted(ctx context.Context, id string) error {
-	filter := bson.M{
-		"_id":       id,
+// dueOnFilter matches not-done todos due on the day: timed todos due within its range and
+// all-day todos stored for its date
+func dueOnFilter(day models.DayRange) bson.M {
+	return bson.M{
+		"$or": bson.A{
+			bson.M{"allDay": bson.M{"$ne": true}, "dueDate": bson.M{"$gte": day.Start, "$lt": day.End}},
+			bson.M{"allDay": true, "dueDate": day.Date},
+		},
+		"status":    bson.M{"$ne": models.DoneStatus()},
 		"deletedAt": bson.M{"$exists": false},
 	}
+}
 
-	update := bson.M{
-		"$set": bson.M{
-			"status":    models.TodoStatusCompleted,
+// GetUndated retrieves not-done todos without a due date with pagination
+func (r *todoRepository) GetUndated(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error) {
+	filter := undatedFilter()
+	filter["userId"] = userID
+
+	// Get total count
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count undated todos.")
+		return nil, 0, fmt.Errorf("failed to count undated todos: %w", err)
+	}
+
+	// Get todos with pagination
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset)).
+		SetSort(bson.M{"createdAt": -1})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get undated todos.")
+		return nil, 0, fmt.Errorf("failed to get undated todos: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var mongoTodos []MongoTodo
+	if err := cursor.All(ctx, &mongoTodos); err != nil {
+		r.logger.Error().Err(err).Msg("Failed to decode todos.")
+		return nil, 0, fmt.Errorf("failed to decode todos: %w", err)
+	}
+
+	todos := make([]*models.Todo, len(mongoTodos))
+	for i, mongoTodo := range mongoTodos {
+		todos[i] = r.mongoTodoToModel(&mongoTodo)
+	}
+
+	return todos, total, nil
+}
+
+// undatedFilter matches todos without a due date that are not in the configured done status.
+// Matching dueDate against null covers both a missing field and an explicit null.
+func undatedFilter() bson.M {
+	return bson.M{
+		"dueDate":   nil,
+		"status":    bson.M{"$ne": models.DoneStatus()},
+		"deletedAt": bson.M{"$exists": false},
+	}
+}
+
+// GetDueDistribution counts the user's not-done todos in each due date bucket in one aggregation
+func (r *todoRepository) GetDueDistribution(ctx context.Context, userID string, bounds models.DueDistributionBounds) (*models.DueDistribution, error) {
+	cursor, err := r.collection.Aggregate(ctx, dueDistributionPipeline(userID, bounds))
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get due distribution.")
+		return nil, fmt.Errorf("failed to get due distribution: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var distribution models.DueDistribution
+	for cursor.Next(ctx) {
+		var result struct {
+			Bucket string `bson:"_id"`
+			Count  int64  `bson:"count"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			r.logger.Error().Err(err).Msg("Failed to decode due distribution bucket.")
+			return nil, fmt.Errorf("failed to decode due distribution: %w", err)
+		}
+
+		switch result.Bucket {
+		case "overdue":
+			distribution.Overdue = result.Count
+		case "today":
+			distribution.Today = result.Count
+		case "thisWeek":
+			distribution.ThisWeek = result.Count
+		case "later":
+			distribution.Later = result.Count
+		case "undated":
+			distribution.Undated = result.Count
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to read due distribution.")
+		return nil, fmt.Errorf("failed to get due distribution: %w", err)
+	}
+
+	return &distribution, nil
+}
+
+// dueDistributionPipeline groups the user's not-done todos by due date bucket. Undated todos
+// are matched first because null sorts before every date in BSON comparisons.
+func dueDistributionPipeline(userID string, bounds models.DueDistributionBounds) []bson.M {
+	return []bson.M{
+		{
+			"$match": bson.M{
+				"userId":    userID,
+				"status":    bson.M{"$ne": models.DoneStatus()},
+				"deletedAt": bson.M{"$exists": false},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id": bson.M{
+					"$switch": bson.M{
+						"branches": bson.A{
+							bson.M{"case": bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$dueDate", nil}}, nil}}, "then": "undated"},
+							bson.M{"case": dueBefore(bounds.Now, bounds.Today), "then": "overdue"},
+							bson.M{"case": dueBefore(bounds.EndOfToday, bounds.Today.AddDate(0, 0, 1)), "then": "today"},
+							bson.M{"case": dueBefore(bounds.EndOfWeek, bounds.Today.AddDate(0, 0, 7)), "then": "thisWeek"},
+						},
+						"default": "later",
+					},
+				},
+				"count": bson.M{"$sum": 1},
+			},
+		},
+	}
+}
+
+// dueBefore is an aggregation expression comparing timed todos' due date with timed and
+// all-day todos' due date with allDay
+func dueBefore(timed, allDay time.Time) bson.M {
+	return bson.M{
+		"$cond": bson.A{
+			"$allDay",
+			bson.M{"$lt": bson.A{"$dueDate", allDay}},
+			bson.M{"$lt": bson.A{"$dueDate", timed}},
+		},
+	}
+}
+
+// CountCompletion counts the user's done todos and all their todos in the window in one aggregation
+func (r *todoRepository) CountCompletion(ctx context.Context, userID string, window models.CompletionWindow) (int64, int64, error) {
+	cursor, err := r.collection.Aggregate(ctx, completionPipeline(userID, window))
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count todo completion.")
+		return 0, 0, fmt.Errorf("failed to count todo completion: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	// An empty window yields no group at all, so the counts stay zero
+	var result struct {
+		Completed int64 `bson:"completed"`
+		Total     int64 `bson:"total"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			r.logger.Error().Err(err).Msg("Failed to decode todo completion.")
+			return 0, 0, fmt.Errorf("failed to decode todo completion: %w", err)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to read todo completion.")
+		return 0, 0, fmt.Errorf("failed to count todo completion: %w", err)
+	}
+
+	return result.Completed, result.Total, nil
+}
+
+// GetBusiestDay finds the calendar day, in the window's location, on which the user created
+// the most of their todos in the window, the earliest on a tie. It returns nil when the
+// window has no todos.
+func (r *todoRepository) GetBusiestDay(ctx context.Context, userID string, window models.CompletionWindow) (*models.DayCount, error) {
+	cursor, err := r.collection.Aggregate(ctx, busiestDayPipeline(userID, window))
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get busiest day.")
+		return nil, fmt.Errorf("failed to get busiest day: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	if !cursor.Next(ctx) {
+		if err := cursor.Err(); err != nil {
+			r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to read busiest day.")
+			return nil, fmt.Errorf("failed to get busiest day: %w", err)
+		}
+		return nil, nil
+	}
+
+	var result struct {
+		Date  string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	if err := cursor.Decode(&result); err != nil {
+		r.logger.Error().Err(err).Msg("Failed to decode busiest day.")
+		return nil, fmt.Errorf("failed to decode busiest day: %w", err)
+	}
+
+	return &models.DayCount{Date: result.Date, Count: result.Count}, nil
+}
+
+// CountTopTags counts the user's todos created in the window carrying each tag and returns
+// the limit most used, ties ordered by tag
+func (r *todoRepository) CountTopTags(ctx context.Context, userID string, window models.CompletionWindow, limit int) ([]models.TagCount, error) {
+	cursor, err := r.collection.Aggregate(ctx, topTagsPipeline(userID, window, limit))
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count top tags.")
+		return nil, fmt.Errorf("failed to count top tags: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	tags := make([]models.TagCount, 0, limit)
+	for cursor.Next(ctx) {
+		var result struct {
+			Tag   string `bson:"_id"`
+			Count int64  `bson:"count"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			r.logger.Error().Err(err).Msg("Failed to decode tag count.")
+			return nil, fmt.Errorf("failed to decode tag count: %w", err)
+		}
+		tags = append(tags, models.TagCount{Tag: result.Tag, Count: result.Count})
+	}
+	if err := cursor.Err(); err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to read tag counts.")
+		return nil, fmt.Errorf("failed to count top tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// AverageCompletionTime averages, over the user's done todos created in the window, the time
+// from creation to the last update, which for a done todo is usually when it was completed.
+// It is 0 when none are done.
+func (r *todoRepository) AverageCompletionTime(ctx context.Context, userID string, window models.CompletionWindow) (time.Duration, error) {
+	cursor, err := r.collection.Aggregate(ctx, completionTimePipeline(userID, window))
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to average completion time.")
+		return 0, fmt.Errorf("failed to average completion time: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	// No done todos yields no group at all, so the average stays zero
+	var result struct {
+		Milliseconds float64 `bson:"milliseconds"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			r.logger.Error().Err(err).Msg("Failed to decode completion time.")
+			return 0, fmt.Errorf("failed to decode completion time: %w", err)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to read completion time.")
+		return 0, fmt.Errorf("failed to average completion time: %w", err)
+	}
+
+	return time.Duration(result.Milliseconds * float64(time.Millisecond)), nil
+}
+
+// createdInWindow matches the user's todos created in the window
+func createdInWindow(userID string, window models.CompletionWindow) bson.M {
+	return bson.M{
+		"userId":    userID,
+		"deletedAt": bson.M{"$exists": false},
+		"createdAt": bson.M{"$gte": window.Start, "$lt": window.End},
+	}
+}
+
+// busiestDayPipeline groups the user's todos in the window by their creation day in the
+// window's location and keeps the day with the most
+func busiestDayPipeline(userID string, window models.CompletionWindow) []bson.M {
+	return []bson.M{
+		{"$match": createdInWindow(userID, window)},
+		{
+			"$group": bson.M{
+				"_id": bson.M{"$dateToString": bson.M{
+					"format":   "%Y-%m-%d",
+					"date":     "$createdAt",
+					"timezone": window.Start.Location().String(),
+				}},
+				"count": bson.M{"$sum": 1},
+			},
+		},
+		{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		{"$limit": 1},
+	}
+}
+
+// topTagsPipeline counts the user's todos in the window by tag and keeps the limit most used
+func topTagsPipeline(userID string, window models.CompletionWindow, limit int) []bson.M {
+	return []bson.M{
+		{"$match": createdInWindow(userID, window)},
+		{"$unwind": "$tags"},
+		{
+			"$group": bson.M{
+				"_id":   "$tags",
+				"count": bson.M{"$sum": 1},
+			},
+		},
+		{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		{"$limit": limit},
+	}
+}
+
+// completionTimePipeline averages the milliseconds between creating and last updating the
+// user's done todos in the window
+func completionTimePipeline(userID string, window models.CompletionWindow) []bson.M {
+	match := createdInWindow(userID, window)
+	match["status"] = models.DoneStatus()
+
+	return []bson.M{
+		{"$match": match},
+		{
+			"$group": bson.M{
+				"_id":          nil,
+				"milliseconds": bson.M{"$avg": bson.M{"$subtract": bson.A{"$updatedAt", "$createdAt"}}},
+			},
+		},
+	}
+}
+
+// completionPipeline counts the user's todos in the window, and how many of them are done
+func completionPipeline(userID string, window models.CompletionWindow) []bson.M {
+	match := bson.M{
+		"userId":    userID,
+		"deletedAt": bson.M{"$exists": false},
+	}
+	if window.By == models.CompletionByDue {
+		match["$or"] = bson.A{
+			bson.M{"allDay": bson.M{"$ne": true}, "dueDate": bson.M{"$gte": window.Start, "$lt": window.End}},
+			bson.M{"allDay": true, "dueDate": bson.M{"$gte": window.StartDate, "$lt": window.EndDate}},
+		}
+	} else {
+		match["createdAt"] = bson.M{"$gte": window.Start, "$lt": window.End}
+	}
+
+	return []bson.M{
+		{"$match": match},
+		{
+			"$group": bson.M{
+				"_id":       nil,
+				"completed": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", models.DoneStatus()}}, 1, 0}}},
+				"total":     bson.M{"$sum": 1},
+			},
+		},
+	}
+}
+
+// Search searches the title and description of todos with pagination. Words and phrase
+// searches use the todos text index, best matches first; prefix searches match the start of
+// words with regular expressions, newest first, since $text cannot match prefixes.
+func (r *todoRepository) Search(ctx context.Context, userID, query, mode string, limit, offset int) ([]*models.Todo, int64, error) {
+	filter := searchFilter(userID, query, mode)
+
+	// Get total count
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		if isTextIndexMissing(err) {
+			r.logger.Error().Err(err).Msg("Failed to search todos: the todos text index is missing.")
+			return nil, 0, fmt.Errorf("failed to search todos: text index is missing: %w", err)
+		}
+		r.logger.Error().Err(err).Str("user_id", userID).Str("query", query).Msg("Failed to count search todos.")
+		return nil, 0, fmt.Errorf("failed to count search todos: %w", err)
+	}
+
+	// Get todos with pagination
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+	if mode == models.SearchModePrefix {
+		opts.SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}})
+	} else {
+		opts.SetSort(bson.M{"score": bson.M{"$meta": "textScore"}})
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Str("query", query).Msg("Failed to search todos.")
+		return nil, 0, fmt.Errorf("failed to search todos: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var mongoTodos []MongoTodo
+	if err := cursor.All(ctx, &mongoTodos); err != nil {
+		r.logger.Error().Err(err).Msg("Failed to decode todos.")
+		return nil, 0, fmt.Errorf("failed to decode todos: %w", err)
+	}
+
+	todos := make([]*models.Todo, len(mongoTodos))
+	for i, mongoTodo := range mongoTodos {
+		todos[i] = r.mongoTodoToModel(&mongoTodo)
+	}
+
+	return todos, total, nil
+}
+
+// searchFilter builds the filter for a search in mode, matching PostgreSQL's behavior: words
+// mode quotes each term because $text otherwise matches todos containing any of them, and
+// phrase mode quotes the whole query. Prefix mode requires a word starting with each term in
+// the title or description.
+func searchFilter(userID, query, mode string) bson.M {
+	filter := bson.M{
+		"userId":    userID,
+		"deletedAt": bson.M{"$exists": false},
+	}
+
+	terms := models.SearchTerms(query)
+	switch mode {
+	case models.SearchModePrefix:
+		conditions := make(bson.A, len(terms))
+		for i, term := range terms {
+			pattern := bson.M{"$regex": `\b` + regexp.QuoteMeta(term), "$options": "i"}
+			conditions[i] = bson.M{"$or": bson.A{bson.M{"title": pattern}, bson.M{"description": pattern}}}
+		}
+		filter["$and"] = conditions
+	case models.SearchModePhrase:
+		filter["$text"] = bson.M{"$search": `"` + strings.Join(terms, " ") + `"`}
+	default:
+		quoted := make([]string, len(terms))
+		for i, term := range terms {
+			quoted[i] = `"` + term + `"`
+		}
+		filter["$text"] = bson.M{"$search": strings.Join(quoted, " ")}
+	}
+
+	return filter
+}
+
+// isTextIndexMissing reports whether err is MongoDB refusing a $text query because the
+// collection has no text index
+func isTextIndexMissing(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorCode(27)
+}
+
+// CountByUserID returns the number of todos owned by a user
+func (r *todoRepository) CountByUserID(ctx context.Context, userID string) (int64, error) {
+	filter := bson.M{
+		"userId":    userID,
+		"deletedAt": bson.M{"$exists": false},
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count todos by user ID.")
+		return 0, fmt.Errorf("failed to count todos: %w", err)
+	}
+
+	return total, nil
+}
+
+// CountOverdue returns the number of the user's not-done todos that are overdue at cutoff
+func (r *todoRepository) CountOverdue(ctx context.Context, userID string, cutoff models.OverdueCutoff) (int64, error) {
+	filter := overdueFilter(cutoff)
+	filter["userId"] = userID
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count overdue todos.")
+		return 0, fmt.Errorf("failed to count overdue todos: %w", err)
+	}
+
+	return total, nil
+}
+
+// CountByStatus returns count of todos by status
+func (r *todoRepository) CountByStatus(ctx context.Context, userID string) (map[string]int64, error) {
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"userId":    userID,
+				"deletedAt": bson.M{"$exists": false},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":   "$status",
+				"count": bson.M{"$sum": 1},
+			},
+		},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get todo status counts.")
+		return nil, fmt.Errorf("failed to get todo status counts: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	counts := make(map[string]int64)
+	for cursor.Next(ctx) {
+		var result struct {
+			Status string `bson:"_id"`
+			Count  int64  `bson:"count"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			r.logger.Error().Err(err).Msg("Failed to decode status count.")
+			continue
+		}
+		counts[result.Status] = result.Count
+	}
+
+	return counts, nil
+}
+
+// CountAllByStatus returns count of todos by status across all users
+func (r *todoRepository) CountAllByStatus(ctx context.Context) (map[string]int64, error) {
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"deletedAt": bson.M{"$exists": false},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":   "$status",
+				"count": bson.M{"$sum": 1},
+			},
+		},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		r.logger.Error().Err(err).Msg("Failed to get global todo status counts.")
+		return nil, fmt.Errorf("failed to get global todo status counts: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	counts := make(map[string]int64)
+	for cursor.Next(ctx) {
+		var result struct {
+			Status string `bson:"_id"`
+			Count  int64  `bson:"count"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			r.logger.Error().Err(err).Msg("Failed to decode global status count.")
+			continue
+		}
+		counts[result.Status] = result.Count
+	}
+
+	return counts, nil
+}
+
+// CountAllOverdue returns the number of overdue todos across all users, placing all-day
+// todos in UTC days
+func (r *todoRepository) CountAllOverdue(ctx context.Context) (int64, error) {
+	filter := overdueFilter(models.NewOverdueCutoff(time.Now().UTC()))
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		r.logger.Error().Err(err).Msg("Failed to count global overdue todos.")
+		return 0, fmt.Errorf("failed to count global overdue todos: %w", err)
+	}
+
+	return total, nil
+}
+
+// Merge saves the merged target's description and due date and soft deletes the source
+// in one transaction (requires MongoDB to run as a replica set or sharded cluster)
+func (r *todoRepository) Merge(ctx context.Context, target *models.Todo, sourceID string) (*models.Todo, error) {
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		r.logger.Error().Err(err).Msg("Failed to start session for todo merge.")
+		return nil, fmt.Errorf("failed to merge todos: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	merged, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		now := time.Now()
+
+		deleted, err := r.collection.UpdateOne(sc,
+			bson.M{"_id": sourceID, "userId": target.UserID, "deletedAt": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"deletedAt": now, "updatedAt": now}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if deleted.MatchedCount == 0 {
+			return nil, interfaces.ErrTodoNotFound
+		}
+
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		var mongoTodo MongoTodo
+		err = r.collection.FindOneAndUpdate(sc,
+			bson.M{"_id": target.ID, "userId": target.UserID, "deletedAt": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"description": target.Description, "dueDate": target.DueDate, "allDay": target.AllDay, "tags": target.Tags, "updatedAt": now}},
+			opts,
+		).Decode(&mongoTodo)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, interfaces.ErrTodoNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &mongoTodo, nil
+	}, primaryTransaction())
+	if err != nil {
+		if errors.Is(err, interfaces.ErrTodoNotFound) {
+			return nil, interfaces.ErrTodoNotFound
+		}
+		r.logger.Error().Err(err).Str("todo_id", target.ID).Str("source_id", sourceID).Msg("Failed to merge todos.")
+		return nil, fmt.Errorf("failed to merge todos: %w", err)
+	}
+
+	result := r.mongoTodoToModel(merged.(*MongoTodo))
+	r.logger.Info().Str("todo_id", result.ID).Str("source_id", sourceID).Msg("Todos merged successfully.")
+	return result, nil
+}
+
+// MarkCompleted marks a todo of the user as completed. Todos of other users are reported as
+// not found.
+func (r *todoRepository) MarkCompleted(ctx context.Context, userID, id string) error {
+	filter := bson.M{
+		"_id":       id,
+		"userId":    userID,
+		"deletedAt": bson.M{"$exists": false},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":    models.DoneStatus(),
 			"updatedAt": time.Now(),
 		},
 	}
 
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		r.logger.Error().Err(err).Str("todo_id", id).Msg("Failed to mark todo as completed.")
+		r.logger.Error().Err(err).Str("todo_id", id).Str("user_id", userID).Msg("Failed to mark todo as completed.")
 		return fmt.Errorf("failed to mark todo as completed: %w", err)
 	}
 
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("todo not found")
+		return interfaces.ErrTodoNotFound
 	}
 
 	r.logger.Info().Str("todo_id", id).Msg("Todo marked as completed.")
 	return nil
 }
 
-// BulkUpdateStatus updates status for multiple todos
-func (r *todoRepository) BulkUpdateStatus(ctx context.Context, ids []string, status string) error {
+// BulkUpdateStatusForUser sets the status of the user's todos among ids and returns how many
+// were updated. Todos owned by other users, and todos already in that status, are left
+// untouched.
+func (r *todoRepository) BulkUpdateStatusForUser(ctx context.Context, userID string, ids []string, status string) (int64, error) {
 	filter := bson.M{
 		"_id":       bson.M{"$in": ids},
+		"userId":    userID,
 		"deletedAt": bson.M{"$exists": false},
+		"status":    bson.M{"$ne": status},
 	}
 
 	update := bson.M{
@@ -540,19 +1583,152 @@ func (r *todoRepository) BulkUpdateStatus(ctx context.Context, ids []string, sta
 
 	result, err := r.collection.UpdateMany(ctx, filter, update)
 	if err != nil {
-		r.logger.Error().Err(err).Strs("todo_ids", ids).Str("status", status).Msg("Failed to bulk update todo status.")
-		return fmt.Errorf("failed to bulk update todo status: %w", err)
+		r.logger.Error().Err(err).Str("user_id", userID).Strs("todo_ids", ids).Str("status", status).Msg("Failed to bulk update todo status.")
+		return 0, fmt.Errorf("failed to bulk update todo status: %w", err)
 	}
 
-	r.logger.Info().Strs("todo_ids", ids).Str("status", status).Int64("updated_count", result.ModifiedCount).Msg("Todos status updated in bulk.")
-	return nil
+	r.logger.Info().Str("user_id", userID).Strs("todo_ids", ids).Str("status", status).Int64("updated_count", result.ModifiedCount).Msg("Todos status updated in bulk.")
+	return result.ModifiedCount, nil
 }
 
-// DeleteCompleted soft deletes all completed todos for a user
-func (r *todoRepository) DeleteCompleted(ctx context.Context, userID string) error {
+// BulkReschedule sets the due date of the user's todos to dueDate or, when dueDate is nil,
+// moves it by shift. Todos without a due date are shifted relative to now.
+func (r *todoRepository) BulkReschedule(ctx context.Context, userID string, ids []string, dueDate *time.Time, shift time.Duration) (int64, error) {
 	filter := bson.M{
+		"_id":       bson.M{"$in": ids},
 		"userId":    userID,
-		"status":    models.TodoStatusCompleted,
+		"deletedAt": bson.M{"$exists": false},
+	}
+
+	now := time.Now()
+	var update any
+	if dueDate != nil {
+		update = bson.M{
+			"$set": bson.M{
+				"dueDate":   *dueDate,
+				"updatedAt": now,
+			},
+		}
+	} else {
+		// An update pipeline can compute the new due date from the stored one
+		update = mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"dueDate":   bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$dueDate", now}}, shift.Milliseconds()}},
+				"updatedAt": now,
+			}}},
+		}
+	}
+
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Strs("todo_ids", ids).Msg("Failed to bulk reschedule todos.")
+		return 0, fmt.Errorf("failed to bulk reschedule todos: %w", err)
+	}
+
+	r.logger.Info().Str("user_id", userID).Strs("todo_ids", ids).Int64("updated_count", result.ModifiedCount).Msg("Todos rescheduled in bulk.")
+	return result.MatchedCount, nil
+}
+
+// BulkUpdatePriority sets the priority of the user's todos matching filter and returns how
+// many matched. With dryRun set it only counts the matching todos.
+func (r *todoRepository) BulkUpdatePriority(ctx context.Context, userID string, filter models.TodoFilter, priority string, dryRun bool) (int64, error) {
+	query := todoFilterQuery(userID, filter)
+
+	if dryRun {
+		matched, err := r.collection.CountDocuments(ctx, query)
+		if err != nil {
+			r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count todos for bulk priority update.")
+			return 0, fmt.Errorf("failed to count todos: %w", err)
+		}
+		return matched, nil
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"priority":  priority,
+			"updatedAt": time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateMany(ctx, query, update)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Str("priority", priority).Msg("Failed to bulk update todo priority.")
+		return 0, fmt.Errorf("failed to bulk update todo priority: %w", err)
+	}
+
+	r.logger.Info().Str("user_id", userID).Str("priority", priority).Int64("updated_count", result.ModifiedCount).Msg("Todo priorities updated in bulk.")
+	return result.MatchedCount, nil
+}
+
+// BulkPatch applies the fields present in patch to the user's todos with the given IDs and
+// returns how many matched. With dryRun set it only counts the matching todos.
+func (r *todoRepository) BulkPatch(ctx context.Context, userID string, ids []string, patch *models.PatchTodoRequest, dryRun bool) (int64, error) {
+	filter := bson.M{
+		"_id":       bson.M{"$in": ids},
+		"userId":    userID,
+		"deletedAt": bson.M{"$exists": false},
+	}
+
+	if dryRun {
+		matched, err := r.collection.CountDocuments(ctx, filter)
+		if err != nil {
+			r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count todos for bulk patch.")
+			return 0, fmt.Errorf("failed to count todos: %w", err)
+		}
+		return matched, nil
+	}
+
+	result, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": todoPatchSet(patch)})
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Strs("todo_ids", ids).Msg("Failed to bulk patch todos.")
+		return 0, fmt.Errorf("failed to bulk patch todos: %w", err)
+	}
+
+	r.logger.Info().Str("user_id", userID).Strs("todo_ids", ids).Int64("updated_count", result.ModifiedCount).Msg("Todos patched in bulk.")
+	return result.MatchedCount, nil
+}
+
+// todoFilterQuery matches a user's todos that match filter
+func todoFilterQuery(userID string, filter models.TodoFilter) bson.M {
+	query := bson.M{
+		"userId":    userID,
+		"deletedAt": bson.M{"$exists": false},
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if len(filter.Statuses) > 0 {
+		query["status"] = bson.M{"$in": filter.Statuses}
+	}
+	if filter.Priority != "" {
+		query["priority"] = filter.Priority
+	}
+	switch {
+	case filter.Tag != "" && filter.TagCaseInsensitive:
+		query["tags"] = bson.M{"$regex": "^" + regexp.QuoteMeta(filter.Tag) + "$", "$options": "i"}
+	case filter.Tag != "":
+		query["tags"] = filter.Tag
+	}
+
+	due := bson.M{}
+	if filter.DueFrom != nil {
+		due["$gte"] = *filter.DueFrom
+	}
+	if filter.DueBefore != nil {
+		due["$lt"] = *filter.DueBefore
+	}
+	if len(due) > 0 {
+		query["dueDate"] = due
+	}
+
+	return query
+}
+
+// DeleteCompleted soft deletes all completed todos for a user and returns how many were deleted
+func (r *todoRepository) DeleteCompleted(ctx context.Context, userID string) (int64, error) {
+	filter := bson.M{
+		"userId":    userID,
+		"status":    models.DoneStatus(),
 		"deletedAt": bson.M{"$exists": false},
 	}
 
@@ -566,13 +1742,152 @@ func (r *todoRepository) DeleteCompleted(ctx context.Context, userID string) err
 	result, err := r.collection.UpdateMany(ctx, filter, update)
 	if err != nil {
 		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to delete completed todos.")
-		return fmt.Errorf("failed to delete completed todos: %w", err)
+		return 0, fmt.Errorf("failed to delete completed todos: %w", err)
 	}
 
 	r.logger.Info().Str("user_id", userID).Int64("deleted_count", result.ModifiedCount).Msg("Completed todos deleted.")
+	return result.ModifiedCount, nil
+}
+
+// GetDeleted retrieves soft-deleted todos with pagination, most recently deleted first
+func (r *todoRepository) GetDeleted(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error) {
+	filter := bson.M{
+		"userId":    userID,
+		"deletedAt": bson.M{"$exists": true},
+	}
+
+	// Get total count
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count deleted todos.")
+		return nil, 0, fmt.Errorf("failed to count deleted todos: %w", err)
+	}
+
+	// Get todos with pagination
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset)).
+		SetSort(bson.M{"deletedAt": -1})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get deleted todos.")
+		return nil, 0, fmt.Errorf("failed to get deleted todos: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var mongoTodos []MongoTodo
+	if err := cursor.All(ctx, &mongoTodos); err != nil {
+		r.logger.Error().Err(err).Msg("Failed to decode todos.")
+		return nil, 0, fmt.Errorf("failed to decode todos: %w", err)
+	}
+
+	todos := make([]*models.Todo, len(mongoTodos))
+	for i, mongoTodo := range mongoTodos {
+		todos[i] = r.mongoTodoToModel(&mongoTodo)
+	}
+
+	return todos, total, nil
+}
+
+// Restore clears the deletion mark of a todo in the user's trash. Todos that are not in the
+// user's trash are reported as not found.
+func (r *todoRepository) Restore(ctx context.Context, userID, id string) error {
+	filter := trashedTodoFilter(userID, id)
+
+	update := bson.M{
+		"$unset": bson.M{"deletedAt": ""},
+		"$set":   bson.M{"updatedAt": time.Now()},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		r.logger.Error().Err(err).Str("todo_id", id).Str("user_id", userID).Msg("Failed to restore todo.")
+		return fmt.Errorf("failed to restore todo: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return interfaces.ErrTodoNotFound
+	}
+
+	r.logger.Info().Str("todo_id", id).Str("user_id", userID).Msg("Todo restored successfully.")
 	return nil
 }
 
+// HardDelete permanently removes a todo the user has already soft deleted. Todos that are
+// not in the user's trash are reported as not found.
+func (r *todoRepository) HardDelete(ctx context.Context, userID, id string) error {
+	result, err := r.collection.DeleteOne(ctx, trashedTodoFilter(userID, id))
+	if err != nil {
+		r.logger.Error().Err(err).Str("todo_id", id).Str("user_id", userID).Msg("Failed to permanently delete todo.")
+		return fmt.Errorf("failed to permanently delete todo: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return interfaces.ErrTodoNotFound
+	}
+
+	r.logger.Info().Str("todo_id", id).Str("user_id", userID).Msg("Todo permanently deleted.")
+	return nil
+}
+
+// PurgeDeleted permanently removes todos of every user that were soft deleted before olderThan
+// and returns how many were removed
+func (r *todoRepository) PurgeDeleted(ctx context.Context, olderThan time.Time) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, purgeDeletedFilter(olderThan))
+	if err != nil {
+		r.logger.Error().Err(err).Time("older_than", olderThan).Msg("Failed to purge deleted todos.")
+		return 0, fmt.Errorf("failed to purge deleted todos: %w", err)
+	}
+
+	r.logger.Info().Time("older_than", olderThan).Int64("purged_count", result.DeletedCount).Msg("Deleted todos purged.")
+	return result.DeletedCount, nil
+}
+
+// purgeDeletedFilter matches todos soft deleted before olderThan; $lt never matches a missing
+// deletedAt, so live todos are left alone
+func purgeDeletedFilter(olderThan time.Time) bson.M {
+	return bson.M{"deletedAt": bson.M{"$lt": olderThan}}
+}
+
+// trashedTodoFilter matches the todo with the given ID only if the user owns it and has
+// soft deleted it
+func trashedTodoFilter(userID, id string) bson.M {
+	return bson.M{
+		"_id":       id,
+		"userId":    userID,
+		"deletedAt": bson.M{"$exists": true},
+	}
+}
+
+// todoPatchSet builds the $set document for the fields present in patch; a null due date
+// is stored as null, as Update does
+func todoPatchSet(patch *models.PatchTodoRequest) bson.M {
+	set := bson.M{"updatedAt": time.Now()}
+	if patch.Title != nil {
+		set["title"] = *patch.Title
+	}
+	if patch.Description != nil {
+		set["description"] = *patch.Description
+	}
+	if patch.Status != nil {
+		set["status"] = *patch.Status
+	}
+	if patch.Priority != nil {
+		set["priority"] = *patch.Priority
+	}
+	if patch.DueDate.Set {
+		set["dueDate"] = patch.DueDate.Value
+	}
+	if patch.AllDay != nil {
+		set["allDay"] = *patch.AllDay
+	}
+	if patch.Tags != nil {
+		set["tags"] = patch.Tags
+	}
+	return set
+}
+
 // mongoTodoToModel converts a MongoDB todo document to a model todo
 func (r *todoRepository) mongoTodoToModel(mongoTodo *MongoTodo) *models.Todo {
 	return &models.Todo{
@@ -583,7 +1898,11 @@ func (r *todoRepository) mongoTodoToModel(mongoTodo *MongoTodo) *models.Todo {
 		Status:      mongoTodo.Status,
 		Priority:    mongoTodo.Priority,
 		DueDate:     mongoTodo.DueDate,
+		AllDay:      mongoTodo.AllDay,
+		Tags:        mongoTodo.Tags,
+		Subtasks:    mongoTodo.Subtasks,
 		CreatedAt:   mongoTodo.CreatedAt,
 		UpdatedAt:   mongoTodo.UpdatedAt,
+		DeletedAt:   mongoTodo.DeletedAt,
 	}
 }
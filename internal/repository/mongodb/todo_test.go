@@ -0,0 +1,394 @@
+package mongodb
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-fiber/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func TestOverdueFilter(t *testing.T) {
+	t.Cleanup(func() {
+		models.SetStatuses([]string{models.TodoStatusPending, models.TodoStatusInProgress, models.TodoStatusCompleted}, models.TodoStatusPending, models.TodoStatusCompleted)
+	})
+
+	cutoff := models.NewOverdueCutoff(time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC))
+
+	t.Run("excludes the default done status", func(t *testing.T) {
+		filter := overdueFilter(cutoff)
+
+		assert.Equal(t, bson.M{"$ne": models.TodoStatusCompleted}, filter["status"])
+	})
+
+	t.Run("compares timed todos with now and all-day todos with today", func(t *testing.T) {
+		filter := overdueFilter(cutoff)
+
+		assert.Equal(t, bson.A{
+			bson.M{"allDay": bson.M{"$ne": true}, "dueDate": bson.M{"$lt": cutoff.Now}},
+			bson.M{"allDay": true, "dueDate": bson.M{"$lt": time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC)}},
+		}, filter["$or"])
+	})
+
+	t.Run("excludes the configured done status", func(t *testing.T) {
+		models.SetStatuses([]string{"todo", "blocked", "review", "done"}, "todo", "done")
+
+		filter := overdueFilter(cutoff)
+
+		assert.Equal(t, bson.M{"$ne": "done"}, filter["status"])
+	})
+}
+
+func TestDueOnFilter(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+	day := models.NewDayRange(time.Date(2025, 10, 15, 8, 0, 0, 0, newYork))
+
+	filter := dueOnFilter(day)
+
+	assert.Equal(t, bson.A{
+		bson.M{"allDay": bson.M{"$ne": true}, "dueDate": bson.M{
+			"$gte": time.Date(2025, 10, 15, 4, 0, 0, 0, time.UTC).In(newYork),
+			"$lt":  time.Date(2025, 10, 16, 4, 0, 0, 0, time.UTC).In(newYork),
+		}},
+		bson.M{"allDay": true, "dueDate": time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC)},
+	}, filter["$or"])
+	assert.Equal(t, bson.M{"$exists": false}, filter["deletedAt"])
+}
+
+func TestUndatedFilter(t *testing.T) {
+	t.Cleanup(func() {
+		models.SetStatuses([]string{models.TodoStatusPending, models.TodoStatusInProgress, models.TodoStatusCompleted}, models.TodoStatusPending, models.TodoStatusCompleted)
+	})
+
+	t.Run("excludes dated and done todos", func(t *testing.T) {
+		filter := undatedFilter()
+
+		assert.Contains(t, filter, "dueDate")
+		assert.Nil(t, filter["dueDate"])
+		assert.Equal(t, bson.M{"$ne": models.TodoStatusCompleted}, filter["status"])
+		assert.Equal(t, bson.M{"$exists": false}, filter["deletedAt"])
+	})
+
+	t.Run("excludes the configured done status", func(t *testing.T) {
+		models.SetStatuses([]string{"todo", "blocked", "review", "done"}, "todo", "done")
+
+		filter := undatedFilter()
+
+		assert.Equal(t, bson.M{"$ne": "done"}, filter["status"])
+	})
+}
+
+func TestDueDistributionPipeline(t *testing.T) {
+	t.Cleanup(func() {
+		models.SetStatuses([]string{models.TodoStatusPending, models.TodoStatusInProgress, models.TodoStatusCompleted}, models.TodoStatusPending, models.TodoStatusCompleted)
+	})
+
+	bounds := models.NewDueDistributionBounds(time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC))
+
+	t.Run("matches the user's not-done todos", func(t *testing.T) {
+		models.SetStatuses([]string{"todo", "blocked", "review", "done"}, "todo", "done")
+
+		pipeline := dueDistributionPipeline("user-1", bounds)
+
+		match := pipeline[0]["$match"].(bson.M)
+		assert.Equal(t, "user-1", match["userId"])
+		assert.Equal(t, bson.M{"$ne": "done"}, match["status"])
+		assert.Equal(t, bson.M{"$exists": false}, match["deletedAt"])
+	})
+
+	t.Run("checks undated before the date bounds in order", func(t *testing.T) {
+		pipeline := dueDistributionPipeline("user-1", bounds)
+
+		bucket := pipeline[1]["$group"].(bson.M)["_id"].(bson.M)["$switch"].(bson.M)
+		branches := bucket["branches"].(bson.A)
+
+		then := make([]string, len(branches))
+		for i, branch := range branches {
+			then[i] = branch.(bson.M)["then"].(string)
+		}
+		assert.Equal(t, []string{"undated", "overdue", "today", "thisWeek"}, then)
+		assert.Equal(t, dueBefore(bounds.Now, bounds.Today), branches[1].(bson.M)["case"])
+		assert.Equal(t, dueBefore(bounds.EndOfToday, time.Date(2025, 10, 16, 0, 0, 0, 0, time.UTC)), branches[2].(bson.M)["case"])
+		assert.Equal(t, dueBefore(bounds.EndOfWeek, time.Date(2025, 10, 22, 0, 0, 0, 0, time.UTC)), branches[3].(bson.M)["case"])
+		assert.Equal(t, "later", bucket["default"])
+	})
+}
+
+func TestDueBefore(t *testing.T) {
+	timed := time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC)
+	allDay := time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC)
+
+	t.Run("picks the bound by the all-day flag", func(t *testing.T) {
+		expr := dueBefore(timed, allDay)
+
+		cond := expr["$cond"].(bson.A)
+		assert.Equal(t, "$allDay", cond[0])
+		assert.Equal(t, bson.M{"$lt": bson.A{"$dueDate", allDay}}, cond[1])
+		assert.Equal(t, bson.M{"$lt": bson.A{"$dueDate", timed}}, cond[2])
+	})
+}
+
+func TestRecentlyUpdatedSort(t *testing.T) {
+	t.Run("sorts by update time with the ID as tie-breaker", func(t *testing.T) {
+		sort := recentlyUpdatedSort()
+
+		assert.Equal(t, bson.D{{Key: "updatedAt", Value: -1}, {Key: "_id", Value: -1}}, sort)
+	})
+}
+
+func TestCursorFilter(t *testing.T) {
+	t.Run("first page", func(t *testing.T) {
+		filter := cursorFilter("user-1", "", nil)
+
+		assert.Equal(t, "user-1", filter["userId"])
+		assert.NotContains(t, filter, "_id")
+	})
+
+	t.Run("continues after the cursor ID", func(t *testing.T) {
+		filter := cursorFilter("user-1", "01K7KZ6T7Q0S4E9M2W8H3XJ5VD", nil)
+
+		assert.Equal(t, bson.M{"$lt": "01K7KZ6T7Q0S4E9M2W8H3XJ5VD"}, filter["_id"])
+		assert.Equal(t, bson.M{"$exists": false}, filter["deletedAt"])
+		assert.NotContains(t, filter, "createdAt")
+	})
+
+	t.Run("leaves out todos created after the snapshot", func(t *testing.T) {
+		snapshot := time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC)
+		filter := cursorFilter("user-1", "01K7KZ6T7Q0S4E9M2W8H3XJ5VD", &snapshot)
+
+		assert.Equal(t, bson.M{"$lte": snapshot}, filter["createdAt"])
+		assert.Equal(t, bson.M{"$lt": "01K7KZ6T7Q0S4E9M2W8H3XJ5VD"}, filter["_id"])
+	})
+}
+
+func TestTodoFilterQuery(t *testing.T) {
+	t.Run("empty filter matches all of the user's todos", func(t *testing.T) {
+		query := todoFilterQuery("user-1", models.TodoFilter{})
+
+		assert.Equal(t, bson.M{"userId": "user-1", "deletedAt": bson.M{"$exists": false}}, query)
+	})
+
+	t.Run("combines status, priority, tag and due range", func(t *testing.T) {
+		from := time.Date(2025, 10, 13, 0, 0, 0, 0, time.UTC)
+		before := from.AddDate(0, 0, 7)
+
+		query := todoFilterQuery("user-1", models.TodoFilter{Status: "pending", Priority: "high", Tag: "work", DueFrom: &from, DueBefore: &before})
+
+		assert.Equal(t, "pending", query["status"])
+		assert.Equal(t, "high", query["priority"])
+		assert.Equal(t, "work", query["tags"])
+		assert.Equal(t, bson.M{"$gte": from, "$lt": before}, query["dueDate"])
+	})
+
+	t.Run("several statuses match any of them", func(t *testing.T) {
+		query := todoFilterQuery("user-1", models.TodoFilter{Statuses: []string{"pending", "in_progress"}})
+
+		assert.Equal(t, bson.M{"$in": []string{"pending", "in_progress"}}, query["status"])
+	})
+
+	t.Run("tag matches exactly by default", func(t *testing.T) {
+		query := todoFilterQuery("user-1", models.TodoFilter{Tag: "Work"})
+
+		assert.Equal(t, "Work", query["tags"])
+	})
+
+	t.Run("case-insensitive tag matches the whole tag ignoring case", func(t *testing.T) {
+		query := todoFilterQuery("user-1", models.TodoFilter{Tag: "C++", TagCaseInsensitive: true})
+
+		assert.Equal(t, bson.M{"$regex": `^C\+\+$`, "$options": "i"}, query["tags"])
+	})
+}
+
+func TestTodoSortKeys(t *testing.T) {
+	t.Run("stored fields sort without computed keys", func(t *testing.T) {
+		keys, order := todoSortKeys(models.NewTodoSort(models.TodoSortTitle, models.SortAscending))
+
+		assert.Nil(t, keys)
+		assert.Equal(t, bson.D{{Key: "title", Value: 1}, {Key: "_id", Value: 1}}, order)
+	})
+
+	t.Run("priority ranks by configured level", func(t *testing.T) {
+		keys, order := todoSortKeys(models.NewTodoSort(models.TodoSortPriority, ""))
+
+		assert.Equal(t, bson.M{"$indexOfArray": bson.A{[]string{"low", "medium", "high"}, "$priority"}}, keys["sortRank"])
+		assert.Equal(t, bson.D{{Key: "sortMissing", Value: 1}, {Key: "sortRank", Value: -1}, {Key: "_id", Value: -1}}, order)
+	})
+
+	t.Run("undated todos come last", func(t *testing.T) {
+		keys, order := todoSortKeys(models.NewTodoSort(models.TodoSortDueDate, models.SortDescending))
+
+		assert.Contains(t, keys, "sortMissing")
+		assert.Equal(t, bson.E{Key: "sortMissing", Value: 1}, order[0])
+		assert.Equal(t, bson.E{Key: "dueDate", Value: -1}, order[1])
+	})
+}
+
+func TestPrimaryTransaction(t *testing.T) {
+	opts := primaryTransaction()
+
+	assert.Equal(t, readpref.PrimaryMode, opts.ReadPreference.Mode())
+}
+
+func TestTransactionsUnsupported(t *testing.T) {
+	standalone := mongo.CommandError{Code: 20, Message: "Transaction numbers are only allowed on a replica set member or mongos"}
+
+	assert.True(t, transactionsUnsupported(standalone))
+	assert.True(t, transactionsUnsupported(fmt.Errorf("insert: %w", standalone)))
+	assert.False(t, transactionsUnsupported(mongo.CommandError{Code: 11000, Message: "duplicate key"}))
+	assert.False(t, transactionsUnsupported(errors.New("connection reset")))
+	assert.False(t, transactionsUnsupported(nil))
+}
+
+func TestTrashedTodoFilter(t *testing.T) {
+	filter := trashedTodoFilter("user-1", "todo-1")
+
+	assert.Equal(t, bson.M{
+		"_id":       "todo-1",
+		"userId":    "user-1",
+		"deletedAt": bson.M{"$exists": true},
+	}, filter)
+}
+
+func TestSearchFilter(t *testing.T) {
+	base := func(extra bson.M) bson.M {
+		filter := bson.M{"userId": "user-1", "deletedAt": bson.M{"$exists": false}}
+		for k, v := range extra {
+			filter[k] = v
+		}
+		return filter
+	}
+
+	t.Run("single word", func(t *testing.T) {
+		filter := searchFilter("user-1", "milk", models.SearchModeWords)
+
+		assert.Equal(t, base(bson.M{"$text": bson.M{"$search": `"milk"`}}), filter)
+	})
+
+	t.Run("multi-word requires every word", func(t *testing.T) {
+		filter := searchFilter("user-1", "buy  milk", models.SearchModeWords)
+
+		assert.Equal(t, base(bson.M{"$text": bson.M{"$search": `"buy" "milk"`}}), filter)
+	})
+
+	t.Run("phrase quotes the whole query", func(t *testing.T) {
+		filter := searchFilter("user-1", `buy "milk"`, models.SearchModePhrase)
+
+		assert.Equal(t, base(bson.M{"$text": bson.M{"$search": `"buy milk"`}}), filter)
+	})
+
+	t.Run("prefix matches the start of words in title or description", func(t *testing.T) {
+		filter := searchFilter("user-1", "gro mil", models.SearchModePrefix)
+
+		prefix := func(term string) bson.M {
+			pattern := bson.M{"$regex": `\b` + term, "$options": "i"}
+			return bson.M{"$or": bson.A{bson.M{"title": pattern}, bson.M{"description": pattern}}}
+		}
+		assert.Equal(t, base(bson.M{"$and": bson.A{prefix("gro"), prefix("mil")}}), filter)
+		assert.NotContains(t, filter, "$text")
+	})
+}
+
+func TestPurgeDeletedFilter(t *testing.T) {
+	olderThan := time.Date(2025, 9, 16, 0, 0, 0, 0, time.UTC)
+
+	filter := purgeDeletedFilter(olderThan)
+
+	assert.Equal(t, bson.M{"deletedAt": bson.M{"$lt": olderThan}}, filter)
+}
+
+func TestTodoPatchSet(t *testing.T) {
+	description := ""
+	set := todoPatchSet(&models.PatchTodoRequest{
+		Description: &description,
+		DueDate:     models.NullableTime{Set: true},
+	})
+
+	assert.Equal(t, "", set["description"])
+	assert.Contains(t, set, "dueDate")
+	assert.Nil(t, set["dueDate"])
+	assert.Contains(t, set, "updatedAt")
+	assert.NotContains(t, set, "title")
+	assert.NotContains(t, set, "tags")
+}
+
+func TestCompletionPipeline(t *testing.T) {
+	from := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 10, 31, 0, 0, 0, 0, time.UTC)
+
+	t.Run("counts todos created in the window", func(t *testing.T) {
+		window := models.NewCompletionWindow(from, to, models.CompletionByCreated)
+		match := completionPipeline("user-1", window)[0]["$match"].(bson.M)
+
+		assert.Equal(t, bson.M{"$gte": window.Start, "$lt": window.End}, match["createdAt"])
+		assert.NotContains(t, match, "$or")
+	})
+
+	t.Run("counts todos due in the window, all-day ones by day", func(t *testing.T) {
+		window := models.NewCompletionWindow(from, to, models.CompletionByDue)
+		match := completionPipeline("user-1", window)[0]["$match"].(bson.M)
+
+		assert.NotContains(t, match, "createdAt")
+		assert.Equal(t, bson.A{
+			bson.M{"allDay": bson.M{"$ne": true}, "dueDate": bson.M{"$gte": window.Start, "$lt": window.End}},
+			bson.M{"allDay": true, "dueDate": bson.M{"$gte": window.StartDate, "$lt": window.EndDate}},
+		}, match["$or"])
+	})
+}
+
+func TestInsightsPipelines(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	window := models.NewCompletionWindow(time.Date(2025, 1, 1, 0, 0, 0, 0, tokyo), time.Date(2025, 12, 31, 0, 0, 0, 0, tokyo), models.CompletionByCreated)
+
+	t.Run("busiest day groups by local creation day", func(t *testing.T) {
+		pipeline := busiestDayPipeline("user-1", window)
+
+		assert.Equal(t, bson.M{"$gte": window.Start, "$lt": window.End}, pipeline[0]["$match"].(bson.M)["createdAt"])
+		day := pipeline[1]["$group"].(bson.M)["_id"].(bson.M)["$dateToString"].(bson.M)
+		assert.Equal(t, "Asia/Tokyo", day["timezone"])
+		assert.Equal(t, 1, pipeline[3]["$limit"])
+	})
+
+	t.Run("top tags unwinds tags and keeps the limit", func(t *testing.T) {
+		pipeline := topTagsPipeline("user-1", window, 5)
+
+		assert.Equal(t, "$tags", pipeline[1]["$unwind"])
+		assert.Equal(t, 5, pipeline[4]["$limit"])
+	})
+
+	t.Run("completion time only averages done todos", func(t *testing.T) {
+		match := completionTimePipeline("user-1", window)[0]["$match"].(bson.M)
+
+		assert.Equal(t, models.DoneStatus(), match["status"])
+		assert.Equal(t, bson.M{"$gte": window.Start, "$lt": window.End}, match["createdAt"])
+	})
+}
+
+func TestTodoIndexes(t *testing.T) {
+	indexes := todoIndexes()
+
+	keys := make([]bson.D, len(indexes))
+	for i, index := range indexes {
+		keys[i] = index.Keys.(bson.D)
+	}
+	assert.Equal(t, []bson.D{
+		{{Key: "title", Value: "text"}, {Key: "description", Value: "text"}},
+		{{Key: "userId", Value: 1}, {Key: "status", Value: 1}},
+		{{Key: "dueDate", Value: 1}},
+	}, keys)
+
+	// Fixed names keep creation idempotent across restarts
+	for _, index := range indexes {
+		assert.NotNil(t, index.Options.Name)
+	}
+
+	// The text index is the one scripts/mongo-init.js creates, as only one is allowed
+	assert.Equal(t, "todo_text_search", *indexes[0].Options.Name)
+	assert.Equal(t, bson.D{{Key: "title", Value: 10}, {Key: "description", Value: 5}}, indexes[0].Options.Weights)
+}
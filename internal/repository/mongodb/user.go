@@ -3,7 +3,9 @@ package mongodb
 import (
 	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"go-fiber/internal/models"
@@ -23,6 +25,10 @@ type MongoUser struct {
 	PasswordHash string     `bson:"passwordHash" json:"-"`
 	Email        string     `bson:"email,omitempty" json:"email,omitempty"`
 	Image        string     `bson:"image,omitempty" json:"image,omitempty"`
+	Role         string     `bson:"role,omitempty" json:"role"`
+	TOTPSecret   string     `bson:"totpSecret,omitempty" json:"-"`
+	TOTPEnabled  bool       `bson:"totpEnabled,omitempty" json:"-"`
+	TOTPLastStep int64      `bson:"totpLastStep,omitempty" json:"-"`
 	CreatedAt    time.Time  `bson:"createdAt" json:"createdAt"`
 	UpdatedAt    time.Time  `bson:"updatedAt" json:"updatedAt"`
 	DeletedAt    *time.Time `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
@@ -31,6 +37,7 @@ type MongoUser struct {
 // userRepository implements the UserRepository interface for MongoDB
 type userRepository struct {
 	collection *mongo.Collection
+	todos      *mongo.Collection
 	logger     zerolog.Logger
 }
 
@@ -38,10 +45,50 @@ type userRepository struct {
 func NewUserRepository(db *mongo.Database, logger zerolog.Logger) interfaces.UserRepository {
 	return &userRepository{
 		collection: db.Collection("users"),
+		todos:      db.Collection("todos"),
 		logger:     logger,
 	}
 }
 
+// EnsureIndexes creates the unique username and email indexes. Creating an index that already
+// exists with the same options is a no-op, so this is safe to run on every startup.
+func (r *userRepository) EnsureIndexes(ctx context.Context) error {
+	if _, err := r.collection.Indexes().CreateMany(ctx, userIndexes()); err != nil {
+		r.logger.Error().Err(err).Msg("Failed to create user indexes.")
+		return fmt.Errorf("failed to create user indexes: %w", err)
+	}
+
+	return nil
+}
+
+// duplicateUserError maps a duplicate key error on the users collection to ErrEmailTaken or
+// ErrUsernameTaken, depending on the violated index. Other errors map to nil.
+func duplicateUserError(err error) error {
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	if strings.Contains(err.Error(), "index: email_1") {
+		return interfaces.ErrEmailTaken
+	}
+	return interfaces.ErrUsernameTaken
+}
+
+// userIndexes returns the users collection indexes. Their names and options match the ones
+// scripts/mongo-init.js creates, so databases set up by it need no changes. Email is
+// optional, so its index is sparse and only covers users that have one.
+func userIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "username", Value: 1}},
+			Options: options.Index().SetName("username_1").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetName("email_1").SetUnique(true).SetSparse(true),
+		},
+	}
+}
+
 // Create creates a new user
 func (r *userRepository) Create(ctx context.Context, user *models.User) (*models.User, error) {
 	// Generate ULID for new user
@@ -55,12 +102,17 @@ func (r *userRepository) Create(ctx context.Context, user *models.User) (*models
 		PasswordHash: user.Password,
 		Email:        user.Email,
 		Image:        user.Image,
+		Role:         models.UserRoleUser,
 		CreatedAt:    now,
 		UpdatedAt:    now,
 	}
 
 	_, err := r.collection.InsertOne(ctx, mongoUser)
 	if err != nil {
+		// The unique indexes catch registrations that raced past the existence checks
+		if taken := duplicateUserError(err); taken != nil {
+			return nil, taken
+		}
 		r.logger.Error().Err(err).Str("username", user.Username).Msg("Failed to create user.")
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
@@ -194,6 +246,109 @@ func (r *userRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// errUserNotFound aborts an account deletion transaction for a missing user
+var errUserNotFound = errors.New("user not found")
+
+// DeleteAccount soft deletes a user and applies the delete policy to their todos. Cascade and
+// block change the user and their todos in one transaction (requires MongoDB to run as a
+// replica set or sharded cluster); anonymize only updates the user document.
+func (r *userRepository) DeleteAccount(ctx context.Context, id, policy string) error {
+	var err error
+	switch policy {
+	case models.UserDeletePolicyCascade, models.UserDeletePolicyBlock:
+		err = r.deleteAccountWithTodos(ctx, id, policy)
+	case models.UserDeletePolicyAnonymize:
+		var result *mongo.UpdateResult
+		result, err = r.collection.UpdateOne(ctx,
+			bson.M{"_id": id, "deletedAt": bson.M{"$exists": false}},
+			anonymizedUserUpdate(id, time.Now()),
+		)
+		if err == nil && result.MatchedCount == 0 {
+			err = errUserNotFound
+		}
+	default:
+		return fmt.Errorf("unknown user delete policy: %s", policy)
+	}
+
+	switch {
+	case errors.Is(err, errUserNotFound):
+		return fmt.Errorf("user not found")
+	case errors.Is(err, interfaces.ErrUserHasActiveTodos):
+		return interfaces.ErrUserHasActiveTodos
+	case err != nil:
+		r.logger.Error().Err(err).Str("user_id", id).Str("policy", policy).Msg("Failed to delete user account.")
+		return fmt.Errorf("failed to delete user account: %w", err)
+	}
+
+	r.logger.Info().Str("user_id", id).Str("policy", policy).Msg("User account deleted successfully.")
+	return nil
+}
+
+// deleteAccountWithTodos soft deletes the user in a transaction that also soft deletes their
+// todos under the cascade policy, or aborts while any are not done under the block policy
+func (r *userRepository) deleteAccountWithTodos(ctx context.Context, id, policy string) error {
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		now := time.Now()
+		todos := bson.M{"userId": id, "deletedAt": bson.M{"$exists": false}}
+
+		if policy == models.UserDeletePolicyBlock {
+			active := bson.M{"userId": id, "deletedAt": bson.M{"$exists": false}, "status": bson.M{"$ne": models.DoneStatus()}}
+			count, err := r.todos.CountDocuments(sc, active, options.Count().SetLimit(1))
+			if err != nil {
+				return nil, err
+			}
+			if count > 0 {
+				return nil, interfaces.ErrUserHasActiveTodos
+			}
+		}
+
+		result, err := r.collection.UpdateOne(sc,
+			bson.M{"_id": id, "deletedAt": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"deletedAt": now, "updatedAt": now}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 0 {
+			return nil, errUserNotFound
+		}
+
+		if policy == models.UserDeletePolicyCascade {
+			if _, err := r.todos.UpdateMany(sc, todos, bson.M{"$set": bson.M{"deletedAt": now, "updatedAt": now}}); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}, primaryTransaction())
+	return err
+}
+
+// anonymizedUserUpdate strips a user's personal data and soft deletes them. The username is
+// replaced rather than removed, as it must stay unique.
+func anonymizedUserUpdate(id string, now time.Time) bson.M {
+	return bson.M{
+		"$set": bson.M{
+			"username":     "deleted-" + id,
+			"passwordHash": "",
+			"deletedAt":    now,
+			"updatedAt":    now,
+		},
+		"$unset": bson.M{
+			"email":        "",
+			"image":        "",
+			"totpSecret":   "",
+			"totpEnabled":  "",
+			"totpLastStep": "",
+		},
+	}
+}
+
 // UpdateImage updates a user's image
 func (r *userRepository) UpdateImage(ctx context.Context, id, imageURL string) error {
 	filter := bson.M{
@@ -250,6 +405,113 @@ func (r *userRepository) UpdatePassword(ctx context.Context, id, hashedPassword
 	return nil
 }
 
+// UpdateRole sets a user's role
+func (r *userRepository) UpdateRole(ctx context.Context, id, role string) error {
+	filter := bson.M{
+		"_id":       id,
+		"deletedAt": bson.M{"$exists": false},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"role":      role,
+			"updatedAt": time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", id).Msg("Failed to update user role.")
+		return fmt.Errorf("failed to update user role: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	r.logger.Info().Str("user_id", id).Str("role", role).Msg("User role updated successfully.")
+	return nil
+}
+
+// GetTOTP retrieves the two-factor authentication state of a user
+func (r *userRepository) GetTOTP(ctx context.Context, id string) (*models.UserTOTP, error) {
+	filter := bson.M{
+		"_id":       id,
+		"deletedAt": bson.M{"$exists": false},
+	}
+
+	var mongoUser MongoUser
+	err := r.collection.FindOne(ctx, filter).Decode(&mongoUser)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("user not found")
+		}
+		r.logger.Error().Err(err).Str("user_id", id).Msg("Failed to get user TOTP state.")
+		return nil, fmt.Errorf("failed to get user TOTP state: %w", err)
+	}
+
+	return &models.UserTOTP{
+		Secret:   mongoUser.TOTPSecret,
+		Enabled:  mongoUser.TOTPEnabled,
+		LastStep: mongoUser.TOTPLastStep,
+	}, nil
+}
+
+// UpdateTOTP stores the encrypted TOTP secret of a user and whether two-factor
+// authentication is enabled
+func (r *userRepository) UpdateTOTP(ctx context.Context, id, encryptedSecret string, enabled bool) error {
+	filter := bson.M{
+		"_id":       id,
+		"deletedAt": bson.M{"$exists": false},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"totpSecret":  encryptedSecret,
+			"totpEnabled": enabled,
+			"updatedAt":   time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", id).Msg("Failed to update user TOTP state.")
+		return fmt.Errorf("failed to update user TOTP state: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	r.logger.Info().Str("user_id", id).Bool("enabled", enabled).Msg("User TOTP state updated successfully.")
+	return nil
+}
+
+// UseTOTPStep records step as the last accepted TOTP time step of a user. It reports false
+// when a code of the same or a later step was already accepted, so no code works twice.
+func (r *userRepository) UseTOTPStep(ctx context.Context, id string, step int64) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx, totpStepFilter(id, step), bson.M{"$set": bson.M{"totpLastStep": step}})
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", id).Msg("Failed to record TOTP step.")
+		return false, fmt.Errorf("failed to record TOTP step: %w", err)
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+// totpStepFilter matches the user only while no code of step or a later one was accepted,
+// so recording the step is a single atomic update
+func totpStepFilter(id string, step int64) bson.M {
+	return bson.M{
+		"_id":       id,
+		"deletedAt": bson.M{"$exists": false},
+		"$or": bson.A{
+			bson.M{"totpLastStep": bson.M{"$exists": false}},
+			bson.M{"totpLastStep": bson.M{"$lt": step}},
+		},
+	}
+}
+
 // List retrieves users with pagination
 func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*models.User, int64, error) {
 	filter := bson.M{"deletedAt": bson.M{"$exists": false}}
@@ -324,14 +586,21 @@ func (r *userRepository) ExistsByUsername(ctx context.Context, username string)
 	return count > 0, nil
 }
 
-// mongoUserToModel converts a MongoDB user document to a model user
+// mongoUserToModel converts a MongoDB user document to a model user. Documents created
+// before roles existed have none and are plain users.
 func (r *userRepository) mongoUserToModel(mongoUser *MongoUser) *models.User {
+	role := mongoUser.Role
+	if role == "" {
+		role = models.UserRoleUser
+	}
+
 	return &models.User{
 		ID:        mongoUser.ID,
 		Username:  mongoUser.Username,
 		Password:  mongoUser.PasswordHash,
 		Email:     mongoUser.Email,
 		Image:     mongoUser.Image,
+		Role:      role,
 		CreatedAt: mongoUser.CreatedAt,
 		UpdatedAt: mongoUser.UpdatedAt,
 	}
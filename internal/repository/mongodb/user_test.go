@@ -0,0 +1,66 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-fiber/internal/models"
+	"go-fiber/internal/repository/interfaces"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestAnonymizedUserUpdate(t *testing.T) {
+	now := time.Date(2025, 10, 20, 9, 0, 0, 0, time.UTC)
+	update := anonymizedUserUpdate("user-1", now)
+
+	set := update["$set"].(bson.M)
+	assert.Equal(t, "deleted-user-1", set["username"])
+	assert.Equal(t, "", set["passwordHash"])
+	assert.Equal(t, now, set["deletedAt"])
+
+	unset := update["$unset"].(bson.M)
+	for _, field := range []string{"email", "image", "totpSecret", "totpEnabled", "totpLastStep"} {
+		assert.Contains(t, unset, field)
+	}
+}
+
+func TestUserIndexes(t *testing.T) {
+	indexes := userIndexes()
+
+	assert.Len(t, indexes, 2)
+	assert.Equal(t, bson.D{{Key: "username", Value: 1}}, indexes[0].Keys)
+	assert.True(t, *indexes[0].Options.Unique)
+	assert.Equal(t, "username_1", *indexes[0].Options.Name)
+
+	// Users without an email must not collide with each other
+	assert.Equal(t, bson.D{{Key: "email", Value: 1}}, indexes[1].Keys)
+	assert.True(t, *indexes[1].Options.Unique)
+	assert.True(t, *indexes[1].Options.Sparse)
+	assert.Equal(t, "email_1", *indexes[1].Options.Name)
+}
+
+func TestDuplicateUserError(t *testing.T) {
+	duplicate := func(index string) error {
+		return mongo.WriteException{WriteErrors: mongo.WriteErrors{{
+			Code:    11000,
+			Message: "E11000 duplicate key error collection: todoapp.users index: " + index + " dup key: { ... }",
+		}}}
+	}
+
+	assert.Equal(t, interfaces.ErrUsernameTaken, duplicateUserError(duplicate("username_1")))
+	assert.Equal(t, interfaces.ErrEmailTaken, duplicateUserError(duplicate("email_1")))
+	assert.Nil(t, duplicateUserError(mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 121, Message: "Document failed validation"}}}))
+	assert.Nil(t, duplicateUserError(errors.New("connection reset")))
+}
+
+func TestMongoUserToModel_Role(t *testing.T) {
+	repo := &userRepository{}
+
+	// Documents created before roles existed have none
+	assert.Equal(t, models.UserRoleUser, repo.mongoUserToModel(&MongoUser{ID: "user-1"}).Role)
+	assert.Equal(t, models.UserRoleAdmin, repo.mongoUserToModel(&MongoUser{ID: "user-2", Role: models.UserRoleAdmin}).Role)
+}
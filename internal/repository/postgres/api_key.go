@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-fiber/internal/models"
+	"go-fiber/internal/repository/interfaces"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+)
+
+// apiKeyColumns lists the api_keys columns in the order scanAPIKey reads them
+const apiKeyColumns = `id::text, user_id::text, name, prefix, key_hash, scopes, created_at, last_used_at`
+
+// apiKeyRepository implements the APIKeyRepository interface for PostgreSQL
+type apiKeyRepository struct {
+	db     DBTX
+	logger zerolog.Logger
+}
+
+// NewAPIKeyRepository creates a new PostgreSQL API key repository
+func NewAPIKeyRepository(db DBTX, logger zerolog.Logger) interfaces.APIKeyRepository {
+	return &apiKeyRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create stores a new API key
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	row := r.db.QueryRow(ctx,
+		`INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+apiKeyColumns,
+		key.UserID, key.Name, key.Prefix, key.KeyHash, key.Scopes,
+	)
+
+	result, err := scanAPIKey(row)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", key.UserID).Msg("Failed to create API key.")
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	r.logger.Info().Str("api_key_id", result.ID).Str("user_id", result.UserID).Msg("API key created successfully.")
+	return result, nil
+}
+
+// GetByHash retrieves the API key with the given hash
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1`, keyHash)
+
+	key, err := scanAPIKey(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, interfaces.ErrAPIKeyNotFound
+		}
+		r.logger.Error().Err(err).Msg("Failed to get API key by hash.")
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	return key, nil
+}
+
+// ListByUserID retrieves a user's API keys, newest first
+func (r *apiKeyRepository) ListByUserID(ctx context.Context, userID string) ([]*models.APIKey, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE user_id = $1 ORDER BY id DESC`,
+		userID,
+	)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to list API keys.")
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]*models.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to scan API key.")
+			return nil, fmt.Errorf("failed to list api keys: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to list API keys.")
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Delete revokes one of the user's API keys
+func (r *apiKeyRepository) Delete(ctx context.Context, id, userID string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM api_keys WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		r.logger.Error().Err(err).Str("api_key_id", id).Str("user_id", userID).Msg("Failed to delete API key.")
+		return fmt.Errorf("failed to delete api key: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return interfaces.ErrAPIKeyNotFound
+	}
+
+	r.logger.Info().Str("api_key_id", id).Str("user_id", userID).Msg("API key deleted successfully.")
+	return nil
+}
+
+// DeleteByUserID revokes all of the user's API keys and returns how many were revoked
+func (r *apiKeyRepository) DeleteByUserID(ctx context.Context, userID string) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM api_keys WHERE user_id = $1`, userID)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to delete API keys of user.")
+		return 0, fmt.Errorf("failed to delete api keys: %w", err)
+	}
+
+	r.logger.Info().Str("user_id", userID).Int64("deleted", tag.RowsAffected()).Msg("API keys of user deleted.")
+	return tag.RowsAffected(), nil
+}
+
+// UpdateLastUsed records when the API key was last used
+func (r *apiKeyRepository) UpdateLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	if _, err := r.db.Exec(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, id, usedAt); err != nil {
+		r.logger.Error().Err(err).Str("api_key_id", id).Msg("Failed to update API key last used time.")
+		return fmt.Errorf("failed to update api key last used time: %w", err)
+	}
+
+	return nil
+}
+
+// scanAPIKey scans a row selected with apiKeyColumns
+func scanAPIKey(row pgx.Row) (*models.APIKey, error) {
+	var key models.APIKey
+	var lastUsedAt pgtype.Timestamptz
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, &key.Scopes, &key.CreatedAt, &lastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	return &key, nil
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go-fiber/internal/repository/interfaces"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DBTX is the subset of the pgx API used by the repositories (and by sqlc)
+type DBTX interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// acquireTimeoutPool bounds how long a query waits for a pooled connection,
+// so a saturated pool fails fast instead of blocking until the request times out
+type acquireTimeoutPool struct {
+	pool    *pgxpool.Pool
+	timeout time.Duration
+}
+
+// WithAcquireTimeout wraps the pool so connection acquisition is limited to timeout.
+// A timeout of zero or less returns the pool unchanged.
+func WithAcquireTimeout(pool *pgxpool.Pool, timeout time.Duration) DBTX {
+	if timeout <= 0 {
+		return pool
+	}
+	return &acquireTimeoutPool{pool: pool, timeout: timeout}
+}
+
+// acquire gets a connection, reporting an exhausted pool as interfaces.ErrUnavailable
+func (p *acquireTimeoutPool) acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	acquireCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	conn, err := p.pool.Acquire(acquireCtx)
+	if err != nil {
+		// Only the acquisition deadline maps to unavailable; the caller's own cancellation is passed through
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			return nil, fmt.Errorf("%w: no database connection available within %s", interfaces.ErrUnavailable, p.timeout)
+		}
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// Exec acquires a connection, executes sql and releases the connection
+func (p *acquireTimeoutPool) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	conn, err := p.acquire(ctx)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	defer conn.Release()
+
+	return conn.Exec(ctx, sql, args...)
+}
+
+// Query acquires a connection and runs sql; the connection is released once the rows are closed
+func (p *acquireTimeoutPool) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	conn, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(ctx, sql, args...)
+	if err != nil {
+		conn.Release()
+		return nil, err
+	}
+
+	return &releasingRows{Rows: rows, conn: conn}, nil
+}
+
+// QueryRow acquires a connection and runs sql; the connection is released once the row is scanned
+func (p *acquireTimeoutPool) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	conn, err := p.acquire(ctx)
+	if err != nil {
+		return errRow{err: err}
+	}
+
+	return &releasingRow{row: conn.QueryRow(ctx, sql, args...), conn: conn}
+}
+
+// releasingRows releases its connection back to the pool when the rows are exhausted or closed
+type releasingRows struct {
+	pgx.Rows
+	conn *pgxpool.Conn
+	once sync.Once
+}
+
+func (r *releasingRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.release()
+	return false
+}
+
+func (r *releasingRows) Close() {
+	r.Rows.Close()
+	r.release()
+}
+
+func (r *releasingRows) release() {
+	r.once.Do(r.conn.Release)
+}
+
+// releasingRow releases its connection back to the pool after Scan
+type releasingRow struct {
+	row  pgx.Row
+	conn *pgxpool.Conn
+}
+
+func (r *releasingRow) Scan(dest ...interface{}) error {
+	defer r.conn.Release()
+	return r.row.Scan(dest...)
+}
+
+// errRow is a row that fails every Scan with err
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(dest ...interface{}) error {
+	return r.err
+}
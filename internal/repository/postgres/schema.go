@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"go-fiber/internal/repository/interfaces"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog"
+)
+
+// pgUndefinedTable is the SQLSTATE for a missing table
+const pgUndefinedTable = "42P01"
+
+// schemaRepository implements the SchemaRepository interface for PostgreSQL
+type schemaRepository struct {
+	db     DBTX
+	logger zerolog.Logger
+}
+
+// NewSchemaRepository creates a new PostgreSQL schema repository
+func NewSchemaRepository(db DBTX, logger zerolog.Logger) interfaces.SchemaRepository {
+	return &schemaRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetSchemaVersion returns the latest migration applied by goose
+func (r *schemaRepository) GetSchemaVersion(ctx context.Context) (string, error) {
+	// goose removes the row of a migration when it is rolled back, so the highest applied version is current
+	var version int64
+	err := r.db.QueryRow(ctx,
+		`SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied`,
+	).Scan(&version)
+	if err != nil {
+		// Without the goose table the schema was not created by migrations
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUndefinedTable {
+			return "", nil
+		}
+		r.logger.Error().Err(err).Msg("Failed to get schema version.")
+		return "", fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	// goose records version 0 when it creates its table, before any migration runs
+	if version == 0 {
+		return "", nil
+	}
+
+	return strconv.FormatInt(version, 10), nil
+}
@@ -2,26 +2,32 @@ package postgres
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"go-fiber/internal/models"
 	"go-fiber/internal/repository/interfaces"
 	"go-fiber/internal/repository/postgres/queries"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
 	"github.com/rs/zerolog"
 )
 
 // todoRepository implements the TodoRepository interface for PostgreSQL
 type todoRepository struct {
-	db      *pgxpool.Pool
+	db      DBTX
 	queries *queries.Queries
 	logger  zerolog.Logger
 }
 
 // NewTodoRepository creates a new PostgreSQL todo repository
-func NewTodoRepository(db *pgxpool.Pool, logger zerolog.Logger) interfaces.TodoRepository {
+func NewTodoRepository(db DBTX, logger zerolog.Logger) interfaces.TodoRepository {
 	return &todoRepository{
 		db:      db,
 		queries: queries.New(db),
@@ -40,7 +46,7 @@ func (r *todoRepository) Create(ctx context.Context, todo *models.Todo) (*models
 	if todo.Priority != "" {
 		priority = pgtype.Text{String: todo.Priority, Valid: true}
 	} else {
-		priority = pgtype.Text{String: models.TodoPriorityMedium, Valid: true}
+		priority = pgtype.Text{String: models.DefaultPriority(), Valid: true}
 	}
 	if todo.DueDate != nil {
 		dueDate = pgtype.Timestamptz{Time: *todo.DueDate, Valid: true}
@@ -49,31 +55,137 @@ func (r *todoRepository) Create(ctx context.Context, todo *models.Todo) (*models
 	// Set default status if not provided
 	status := todo.Status
 	if status == "" {
-		status = models.TodoStatusPending
+		status = models.DefaultStatus()
 	}
 
-	dbTodo, err := r.queries.CreateTodo(ctx, queries.CreateTodoParams{
-		UserID:      todo.UserID,
-		Title:       todo.Title,
-		Description: description,
-		Status:      status,
-		Priority:    priority,
-		DueDate:     dueDate,
-	})
+	subtasks, err := subtasksJSON(todo.Subtasks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create todo: %w", err)
+	}
+
+	row := r.db.QueryRow(ctx,
+		`INSERT INTO todos (user_id, title, description, status, priority, due_date, tags, all_day, subtasks)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING `+todoColumns,
+		todo.UserID, todo.Title, description, status, priority, dueDate, tagsOrEmpty(todo.Tags), todo.AllDay, subtasks,
+	)
+
+	result, err := r.scanTodo(row)
 	if err != nil {
 		r.logger.Error().Err(err).Str("user_id", todo.UserID).Str("title", todo.Title).Msg("Failed to create todo.")
 		return nil, fmt.Errorf("failed to create todo: %w", err)
 	}
 
-	result := r.mapDBTodoToModel(dbTodo)
 	r.logger.Info().Str("todo_id", result.ID).Str("user_id", result.UserID).Msg("Todo created successfully.")
 	return result, nil
 }
 
+// CreateBatch creates todos with a single multi-row insert, so either all of them are
+// inserted or none. The created todos are returned in the order given.
+func (r *todoRepository) CreateBatch(ctx context.Context, todos []*models.Todo) ([]*models.Todo, error) {
+	if len(todos) == 0 {
+		return []*models.Todo{}, nil
+	}
+
+	// IDs are generated here rather than by the column default so the returned rows, whose
+	// order RETURNING does not guarantee, can be matched to their input. Monotonic entropy
+	// keeps them ascending in input order, as the default would.
+	entropy := ulid.Monotonic(rand.Reader, 0)
+	now := ulid.Timestamp(time.Now())
+
+	const columnCount = 10
+	ids := make([]string, len(todos))
+	values := make([]string, len(todos))
+	args := make([]any, 0, len(todos)*columnCount)
+	for i, todo := range todos {
+		ids[i] = ulid.MustNew(now, entropy).String()
+
+		var description pgtype.Text
+		if todo.Description != "" {
+			description = pgtype.Text{String: todo.Description, Valid: true}
+		}
+
+		priority := todo.Priority
+		if priority == "" {
+			priority = models.DefaultPriority()
+		}
+
+		var dueDate pgtype.Timestamptz
+		if todo.DueDate != nil {
+			dueDate = pgtype.Timestamptz{Time: *todo.DueDate, Valid: true}
+		}
+
+		status := todo.Status
+		if status == "" {
+			status = models.DefaultStatus()
+		}
+
+		subtasks, err := subtasksJSON(todo.Subtasks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create todos: %w", err)
+		}
+
+		placeholders := make([]string, columnCount)
+		for j := range placeholders {
+			placeholders[j] = fmt.Sprintf("$%d", i*columnCount+j+1)
+		}
+		placeholders[0] += "::text::ulid"
+		values[i] = "(" + strings.Join(placeholders, ", ") + ")"
+		args = append(args, ids[i], todo.UserID, todo.Title, description, status, priority, dueDate, tagsOrEmpty(todo.Tags), todo.AllDay, subtasks)
+	}
+
+	rows, err := r.db.Query(ctx,
+		`INSERT INTO todos (id, user_id, title, description, status, priority, due_date, tags, all_day, subtasks)
+		VALUES `+strings.Join(values, ", ")+`
+		RETURNING `+todoColumns,
+		args...,
+	)
+	if err != nil {
+		r.logger.Error().Err(err).Int("count", len(todos)).Msg("Failed to create todos.")
+		return nil, fmt.Errorf("failed to create todos: %w", err)
+	}
+
+	created, err := r.scanTodos(rows)
+	if err != nil {
+		r.logger.Error().Err(err).Int("count", len(todos)).Msg("Failed to scan created todos.")
+		return nil, fmt.Errorf("failed to create todos: %w", err)
+	}
+
+	results, err := inIDOrder(created, ids)
+	if err != nil {
+		r.logger.Error().Err(err).Int("count", len(todos)).Msg("Failed to match created todos.")
+		return nil, fmt.Errorf("failed to create todos: %w", err)
+	}
+
+	r.logger.Info().Int("count", len(results)).Msg("Todos created successfully.")
+	return results, nil
+}
+
+// inIDOrder arranges todos in the order of ids, failing when any of them is missing
+func inIDOrder(todos []*models.Todo, ids []string) ([]*models.Todo, error) {
+	byID := make(map[string]*models.Todo, len(todos))
+	for _, todo := range todos {
+		byID[todo.ID] = todo
+	}
+
+	ordered := make([]*models.Todo, len(ids))
+	for i, id := range ids {
+		todo, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("todo %s was not returned", id)
+		}
+		ordered[i] = todo
+	}
+	return ordered, nil
+}
+
 // GetByID retrieves a todo by ID
 func (r *todoRepository) GetByID(ctx context.Context, id string) (*models.Todo, error) {
 	dbTodo, err := r.queries.GetTodoByID(ctx, id)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, interfaces.ErrTodoNotFound
+		}
 		r.logger.Error().Err(err).Str("todo_id", id).Msg("Failed to get todo by ID.")
 		return nil, fmt.Errorf("failed to get todo: %w", err)
 	}
@@ -81,6 +193,24 @@ func (r *todoRepository) GetByID(ctx context.Context, id string) (*models.Todo,
 	return r.mapDBTodoToModel(dbTodo), nil
 }
 
+// GetByIDForUser retrieves a todo by ID only if it belongs to the user, so todos of other
+// users are not found
+func (r *todoRepository) GetByIDForUser(ctx context.Context, id, userID string) (*models.Todo, error) {
+	todo, err := r.scanTodo(r.db.QueryRow(ctx,
+		`SELECT `+todoColumns+` FROM todos WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`,
+		id, userID,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, interfaces.ErrTodoNotFound
+		}
+		r.logger.Error().Err(err).Str("todo_id", id).Str("user_id", userID).Msg("Failed to get todo by ID for user.")
+		return nil, fmt.Errorf("failed to get todo: %w", err)
+	}
+
+	return todo, nil
+}
+
 // GetByUserID retrieves todos by user ID with pagination
 func (r *todoRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error) {
 	// Get total count
@@ -109,6 +239,84 @@ func (r *todoRepository) GetByUserID(ctx context.Context, userID string, limit,
 	return todos, total, nil
 }
 
+// GetByUserIDCursor retrieves a page of todos by user ID, newest first, starting after the
+// todo the cursor points to. ULIDs sort by creation time, so the ID alone is a stable key.
+// With a snapshot, only todos created at or before it are returned, so todos added while
+// paging never show up.
+func (r *todoRepository) GetByUserIDCursor(ctx context.Context, userID string, cursor string, snapshot *time.Time, limit int) ([]*models.Todo, string, error) {
+	afterID, err := models.DecodeTodoCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	where, args := cursorWhere(userID, afterID, snapshot)
+	args = append(args, limit+1)
+	query := fmt.Sprintf(`SELECT `+todoColumns+` FROM todos
+		WHERE %s
+		ORDER BY id DESC
+		LIMIT $%d`, where, len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get todos by cursor.")
+		return nil, "", fmt.Errorf("failed to get todos: %w", err)
+	}
+
+	todos, err := r.scanTodos(rows)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to scan todos by cursor.")
+		return nil, "", fmt.Errorf("failed to get todos: %w", err)
+	}
+
+	todos, nextCursor := models.CursorPage(todos, limit)
+	return todos, nextCursor, nil
+}
+
+// cursorWhere builds the WHERE clause and its arguments for a cursor page of a user's todos
+// after afterID, limited to todos created at or before snapshot when one is given
+func cursorWhere(userID, afterID string, snapshot *time.Time) (string, []any) {
+	conditions := []string{"user_id = $1", "deleted_at IS NULL"}
+	args := []any{userID}
+
+	if afterID != "" {
+		args = append(args, afterID)
+		conditions = append(conditions, fmt.Sprintf("id < $%d::text::ulid", len(args)))
+	}
+	if snapshot != nil {
+		args = append(args, *snapshot)
+		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", len(args)))
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// streamBatchSize is the number of todos fetched per query when streaming
+const streamBatchSize = 500
+
+// StreamByUserID calls fn for each of a user's todos, newest first. Todos are read in
+// keyset-paginated batches so only one batch is held in memory at a time. It stops at the
+// first error fn returns.
+func (r *todoRepository) StreamByUserID(ctx context.Context, userID string, fn func(*models.Todo) error) error {
+	cursor := ""
+	for {
+		todos, nextCursor, err := r.GetByUserIDCursor(ctx, userID, cursor, nil, streamBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to stream todos: %w", err)
+		}
+
+		for _, todo := range todos {
+			if err := fn(todo); err != nil {
+				return err
+			}
+		}
+
+		if nextCursor == "" {
+			return nil
+		}
+		cursor = nextCursor
+	}
+}
+
 // Update updates a todo
 func (r *todoRepository) Update(ctx context.Context, todo *models.Todo) (*models.Todo, error) {
 	var description, priority pgtype.Text
@@ -124,47 +332,128 @@ func (r *todoRepository) Update(ctx context.Context, todo *models.Todo) (*models
 		dueDate = pgtype.Timestamptz{Time: *todo.DueDate, Valid: true}
 	}
 
-	dbTodo, err := r.queries.UpdateTodo(ctx, queries.UpdateTodoParams{
-		ID:          todo.ID,
-		Title:       todo.Title,
-		Description: description,
-		Status:      todo.Status,
-		Priority:    priority,
-		DueDate:     dueDate,
-	})
+	subtasks, err := subtasksJSON(todo.Subtasks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update todo: %w", err)
+	}
+
+	row := r.db.QueryRow(ctx,
+		`UPDATE todos
+		SET title = $2, description = $3, status = $4, priority = $5, due_date = $6, tags = $7, all_day = $8, subtasks = $9, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+todoColumns,
+		todo.ID, todo.Title, description, todo.Status, priority, dueDate, tagsOrEmpty(todo.Tags), todo.AllDay, subtasks,
+	)
+
+	result, err := r.scanTodo(row)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, interfaces.ErrTodoNotFound
+		}
 		r.logger.Error().Err(err).Str("todo_id", todo.ID).Msg("Failed to update todo.")
 		return nil, fmt.Errorf("failed to update todo: %w", err)
 	}
 
-	result := r.mapDBTodoToModel(dbTodo)
 	r.logger.Info().Str("todo_id", result.ID).Msg("Todo updated successfully.")
 	return result, nil
 }
 
-// Delete soft deletes a todo
-func (r *todoRepository) Delete(ctx context.Context, id string) error {
-	err := r.queries.SoftDeleteTodo(ctx, id)
+// PartialUpdate sets only the fields present in patch on a todo owned by userID, clearing
+// the description when it is empty and the due date when it is null. Column names are fixed;
+// only the values come from the patch.
+func (r *todoRepository) PartialUpdate(ctx context.Context, userID, id string, patch *models.PatchTodoRequest) (*models.Todo, error) {
+	assignments, args := todoPatchAssignments(patch, []any{id, userID})
+
+	row := r.db.QueryRow(ctx,
+		`UPDATE todos SET `+assignments+`
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+		RETURNING `+todoColumns,
+		args...,
+	)
+
+	result, err := r.scanTodo(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, interfaces.ErrTodoNotFound
+		}
+		r.logger.Error().Err(err).Str("todo_id", id).Str("user_id", userID).Msg("Failed to partially update todo.")
+		return nil, fmt.Errorf("failed to update todo: %w", err)
+	}
+
+	r.logger.Info().Str("todo_id", result.ID).Msg("Todo partially updated successfully.")
+	return result, nil
+}
+
+// Delete soft deletes a todo of the user. Todos of other users are reported as not found.
+func (r *todoRepository) Delete(ctx context.Context, userID, id string) error {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE todos SET deleted_at = NOW(), updated_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`,
+		id, userID,
+	)
 	if err != nil {
-		r.logger.Error().Err(err).Str("todo_id", id).Msg("Failed to delete todo.")
+		r.logger.Error().Err(err).Str("todo_id", id).Str("user_id", userID).Msg("Failed to delete todo.")
 		return fmt.Errorf("failed to delete todo: %w", err)
 	}
 
-	r.logger.Info().Str("todo_id", id).Msg("Todo deleted successfully.")
+	if tag.RowsAffected() == 0 {
+		return interfaces.ErrTodoNotFound
+	}
+
+	r.logger.Info().Str("todo_id", id).Str("user_id", userID).Msg("Todo deleted successfully.")
 	return nil
 }
 
-// UpdateStatus updates a todo's status
-func (r *todoRepository) UpdateStatus(ctx context.Context, id, status string) error {
-	err := r.queries.UpdateTodoStatus(ctx, queries.UpdateTodoStatusParams{
-		ID:     id,
-		Status: status,
-	})
+// GetFiltered retrieves the user's todos matching every set field of filter in the order of
+// sort, with pagination. The total counts the same matches.
+func (r *todoRepository) GetFiltered(ctx context.Context, userID string, filter models.TodoFilter, sort models.TodoSort, limit, offset int) ([]*models.Todo, int64, error) {
+	where, args := todoFilterWhere(userID, filter)
+
+	// Get total count
+	var total int64
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM todos WHERE `+where, args...).Scan(&total); err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count filtered todos.")
+		return nil, 0, fmt.Errorf("failed to count todos: %w", err)
+	}
+
+	// Get todos
+	orderBy, args := todoOrderBy(sort, args)
+	args = append(args, limit, offset)
+	rows, err := r.db.Query(ctx,
+		fmt.Sprintf(`SELECT `+todoColumns+` FROM todos WHERE %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d`, where, orderBy, len(args)-1, len(args)),
+		args...,
+	)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get filtered todos.")
+		return nil, 0, fmt.Errorf("failed to get todos: %w", err)
+	}
+
+	todos, err := r.scanTodos(rows)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to scan filtered todos.")
+		return nil, 0, fmt.Errorf("failed to get todos: %w", err)
+	}
+
+	return todos, total, nil
+}
+
+// UpdateStatus updates the status of a todo of the user. Todos of other users are reported
+// as not found.
+func (r *todoRepository) UpdateStatus(ctx context.Context, userID, id, status string) error {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE todos SET status = $3, updated_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`,
+		id, userID, status,
+	)
 	if err != nil {
 		r.logger.Error().Err(err).Str("todo_id", id).Str("status", status).Msg("Failed to update todo status.")
 		return fmt.Errorf("failed to update todo status: %w", err)
 	}
 
+	if tag.RowsAffected() == 0 {
+		return interfaces.ErrTodoNotFound
+	}
+
 	r.logger.Info().Str("todo_id", id).Str("status", status).Msg("Todo status updated successfully.")
 	return nil
 }
@@ -201,6 +490,11 @@ func (r *todoRepository) GetByStatus(ctx context.Context, userID, status string,
 	return todos, total, nil
 }
 
+// GetByStatuses retrieves todos in any of statuses with pagination
+func (r *todoRepository) GetByStatuses(ctx context.Context, userID string, statuses []string, limit, offset int) ([]*models.Todo, int64, error) {
+	return r.GetFiltered(ctx, userID, models.TodoFilter{Statuses: statuses}, models.NewTodoSort("", ""), limit, offset)
+}
+
 // GetByPriority retrieves todos by priority with pagination
 func (r *todoRepository) GetByPriority(ctx context.Context, userID, priority string, limit, offset int) ([]*models.Todo, int64, error) {
 	// Get total count
@@ -233,95 +527,420 @@ func (r *todoRepository) GetByPriority(ctx context.Context, userID, priority str
 	return todos, total, nil
 }
 
+// GetByTag retrieves todos carrying a tag with pagination
+func (r *todoRepository) GetByTag(ctx context.Context, userID, tag string, limit, offset int) ([]*models.Todo, int64, error) {
+	// Get total count
+	var total int64
+	err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM todos
+		WHERE user_id = $1 AND tags @> ARRAY[$2]::text[] AND deleted_at IS NULL`,
+		userID, tag,
+	).Scan(&total)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Str("tag", tag).Msg("Failed to count todos by tag.")
+		return nil, 0, fmt.Errorf("failed to count todos: %w", err)
+	}
+
+	// Get todos
+	rows, err := r.db.Query(ctx,
+		`SELECT `+todoColumns+` FROM todos
+		WHERE user_id = $1 AND tags @> ARRAY[$2]::text[] AND deleted_at IS NULL
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4`,
+		userID, tag, limit, offset,
+	)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Str("tag", tag).Msg("Failed to get todos by tag.")
+		return nil, 0, fmt.Errorf("failed to get todos: %w", err)
+	}
+
+	todos, err := r.scanTodos(rows)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Str("tag", tag).Msg("Failed to scan todos by tag.")
+		return nil, 0, fmt.Errorf("failed to get todos: %w", err)
+	}
+
+	return todos, total, nil
+}
+
 // GetOverdue retrieves overdue todos with pagination
-func (r *todoRepository) GetOverdue(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error) {
+func (r *todoRepository) GetOverdue(ctx context.Context, userID string, cutoff models.OverdueCutoff, limit, offset int) ([]*models.Todo, int64, error) {
 	// Get total count
-	total, err := r.queries.CountOverdueTodos(ctx, userID)
+	var total int64
+	err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM todos
+		WHERE user_id = $1 AND `+overdueCondition(3, 4)+` AND status <> $2 AND deleted_at IS NULL`,
+		userID, models.DoneStatus(), cutoff.Now, cutoff.Today,
+	).Scan(&total)
 	if err != nil {
 		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count overdue todos.")
 		return nil, 0, fmt.Errorf("failed to count overdue todos: %w", err)
 	}
 
 	// Get todos
-	dbTodos, err := r.queries.GetOverdueTodos(ctx, queries.GetOverdueTodosParams{
-		UserID: userID,
-		Limit:  int32(limit),
-		Offset: int32(offset),
-	})
+	rows, err := r.db.Query(ctx,
+		`SELECT `+todoColumns+` FROM todos
+		WHERE user_id = $1 AND `+overdueCondition(3, 4)+` AND status <> $2 AND deleted_at IS NULL
+		ORDER BY due_date ASC
+		LIMIT $5 OFFSET $6`,
+		userID, models.DoneStatus(), cutoff.Now, cutoff.Today, limit, offset,
+	)
 	if err != nil {
 		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get overdue todos.")
 		return nil, 0, fmt.Errorf("failed to get overdue todos: %w", err)
 	}
 
-	todos := make([]*models.Todo, len(dbTodos))
-	for i, dbTodo := range dbTodos {
-		todos[i] = r.mapDBTodoToModel(dbTodo)
+	todos, err := r.scanTodos(rows)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to scan overdue todos.")
+		return nil, 0, fmt.Errorf("failed to get overdue todos: %w", err)
 	}
 
 	return todos, total, nil
 }
 
-// GetUpcoming retrieves upcoming todos with pagination
+// GetMostOverdue retrieves the not-done todo with the oldest past due date
+func (r *todoRepository) GetMostOverdue(ctx context.Context, userID string, cutoff models.OverdueCutoff) (*models.Todo, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+todoColumns+` FROM todos
+		WHERE user_id = $1 AND `+overdueCondition(3, 4)+` AND status <> $2 AND deleted_at IS NULL
+		ORDER BY due_date ASC
+		LIMIT 1`,
+		userID, models.DoneStatus(), cutoff.Now, cutoff.Today,
+	)
+
+	todo, err := r.scanTodo(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, interfaces.ErrTodoNotFound
+		}
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get most overdue todo.")
+		return nil, fmt.Errorf("failed to get most overdue todo: %w", err)
+	}
+
+	return todo, nil
+}
+
+// GetUpcoming retrieves not-done todos due within the next days days, soonest first, with
+// pagination
 func (r *todoRepository) GetUpcoming(ctx context.Context, userID string, days int, limit, offset int) ([]*models.Todo, int64, error) {
-	// Note: The SQLC queries need to be updated to handle dynamic intervals
-	// For now, we'll implement a basic version
-	dbTodos, err := r.queries.GetUpcomingTodos(ctx, queries.GetUpcomingTodosParams{
-		UserID: userID,
-		Limit:  int32(limit),
-		Offset: int32(offset),
-	})
+	where, args := upcomingWhere(userID, time.Now(), days)
+
+	// Get total count
+	var total int64
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM todos WHERE `+where, args...).Scan(&total)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count upcoming todos.")
+		return nil, 0, fmt.Errorf("failed to count upcoming todos: %w", err)
+	}
+
+	// Get todos
+	args = append(args, limit, offset)
+	rows, err := r.db.Query(ctx,
+		`SELECT `+todoColumns+` FROM todos WHERE `+where+`
+		ORDER BY due_date ASC, id ASC
+		LIMIT $5 OFFSET $6`,
+		args...,
+	)
 	if err != nil {
 		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get upcoming todos.")
 		return nil, 0, fmt.Errorf("failed to get upcoming todos: %w", err)
 	}
 
-	// Get count
-	total, err := r.queries.CountUpcomingTodos(ctx, userID)
+	todos, err := r.scanTodos(rows)
 	if err != nil {
-		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count upcoming todos.")
-		return nil, 0, fmt.Errorf("failed to count upcoming todos: %w", err)
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to scan upcoming todos.")
+		return nil, 0, fmt.Errorf("failed to get upcoming todos: %w", err)
 	}
 
-	todos := make([]*models.Todo, len(dbTodos))
-	for i, dbTodo := range dbTodos {
-		todos[i] = r.mapDBTodoToModel(dbTodo)
+	return todos, total, nil
+}
+
+// GetRecentlyUpdated retrieves todos of any status, most recently updated first, with pagination.
+// Todos updated at the same instant are ordered by ID so pages stay stable.
+func (r *todoRepository) GetRecentlyUpdated(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error) {
+	// Get total count
+	total, err := r.queries.CountTodosByUserID(ctx, userID)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count recently updated todos.")
+		return nil, 0, fmt.Errorf("failed to count todos: %w", err)
+	}
+
+	// Get todos
+	rows, err := r.db.Query(ctx,
+		`SELECT `+todoColumns+` FROM todos
+		WHERE user_id = $1 AND deleted_at IS NULL
+		ORDER BY updated_at DESC, id DESC
+		LIMIT $2 OFFSET $3`,
+		userID, limit, offset,
+	)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get recently updated todos.")
+		return nil, 0, fmt.Errorf("failed to get recently updated todos: %w", err)
+	}
+
+	todos, err := r.scanTodos(rows)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to scan recently updated todos.")
+		return nil, 0, fmt.Errorf("failed to get recently updated todos: %w", err)
 	}
 
 	return todos, total, nil
 }
 
-// Search searches todos with pagination
-func (r *todoRepository) Search(ctx context.Context, userID, query string, limit, offset int) ([]*models.Todo, int64, error) {
+// GetDueOn retrieves the not-done todos due on a day, earliest first: timed todos due
+// within its range and all-day todos stored for its date
+func (r *todoRepository) GetDueOn(ctx context.Context, userID string, day models.DayRange) ([]*models.Todo, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+todoColumns+` FROM todos
+		WHERE user_id = $1 AND status <> $2 AND deleted_at IS NULL
+			AND (NOT all_day AND due_date >= $3 AND due_date < $4 OR all_day AND due_date = $5)
+		ORDER BY due_date ASC, id ASC`,
+		userID, models.DoneStatus(), day.Start, day.End, day.Date,
+	)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get todos due on day.")
+		return nil, fmt.Errorf("failed to get todos due on day: %w", err)
+	}
+
+	todos, err := r.scanTodos(rows)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to scan todos due on day.")
+		return nil, fmt.Errorf("failed to get todos due on day: %w", err)
+	}
+
+	return todos, nil
+}
+
+// GetUndated retrieves not-done todos without a due date with pagination
+func (r *todoRepository) GetUndated(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error) {
 	// Get total count
-	total, err := r.queries.CountSearchTodos(ctx, queries.CountSearchTodosParams{
-		UserID:         userID,
-		PlaintoTsquery: query,
-	})
+	var total int64
+	err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM todos
+		WHERE user_id = $1 AND due_date IS NULL AND status <> $2 AND deleted_at IS NULL`,
+		userID, models.DoneStatus(),
+	).Scan(&total)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count undated todos.")
+		return nil, 0, fmt.Errorf("failed to count undated todos: %w", err)
+	}
+
+	// Get todos
+	rows, err := r.db.Query(ctx,
+		`SELECT `+todoColumns+` FROM todos
+		WHERE user_id = $1 AND due_date IS NULL AND status <> $2 AND deleted_at IS NULL
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4`,
+		userID, models.DoneStatus(), limit, offset,
+	)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get undated todos.")
+		return nil, 0, fmt.Errorf("failed to get undated todos: %w", err)
+	}
+
+	todos, err := r.scanTodos(rows)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to scan undated todos.")
+		return nil, 0, fmt.Errorf("failed to get undated todos: %w", err)
+	}
+
+	return todos, total, nil
+}
+
+// GetDueDistribution counts the user's not-done todos in each due date bucket in one scan
+func (r *todoRepository) GetDueDistribution(ctx context.Context, userID string, bounds models.DueDistributionBounds) (*models.DueDistribution, error) {
+	var distribution models.DueDistribution
+	err := r.db.QueryRow(ctx,
+		`SELECT
+			COUNT(*) FILTER (WHERE `+overdueCondition(3, 6)+`),
+			COUNT(*) FILTER (WHERE NOT all_day AND due_date >= $3 AND due_date < $4 OR all_day AND due_date = $6),
+			COUNT(*) FILTER (WHERE NOT all_day AND due_date >= $4 AND due_date < $5 OR all_day AND due_date > $6 AND due_date < $7),
+			COUNT(*) FILTER (WHERE NOT all_day AND due_date >= $5 OR all_day AND due_date >= $7),
+			COUNT(*) FILTER (WHERE due_date IS NULL)
+		FROM todos
+		WHERE user_id = $1 AND status <> $2 AND deleted_at IS NULL`,
+		userID, models.DoneStatus(), bounds.Now, bounds.EndOfToday, bounds.EndOfWeek, bounds.Today, bounds.Today.AddDate(0, 0, 7),
+	).Scan(
+		&distribution.Overdue,
+		&distribution.Today,
+		&distribution.ThisWeek,
+		&distribution.Later,
+		&distribution.Undated,
+	)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get due distribution.")
+		return nil, fmt.Errorf("failed to get due distribution: %w", err)
+	}
+
+	return &distribution, nil
+}
+
+// CountCompletion counts the user's done todos and all their todos in the window in one scan
+func (r *todoRepository) CountCompletion(ctx context.Context, userID string, window models.CompletionWindow) (int64, int64, error) {
+	condition := `created_at >= $3 AND created_at < $4`
+	args := []any{userID, models.DoneStatus(), window.Start, window.End}
+	if window.By == models.CompletionByDue {
+		condition = `(NOT all_day AND due_date >= $3 AND due_date < $4 OR all_day AND due_date >= $5 AND due_date < $6)`
+		args = append(args, window.StartDate, window.EndDate)
+	}
+
+	var completed, total int64
+	err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FILTER (WHERE status = $2), COUNT(*)
+		FROM todos
+		WHERE user_id = $1 AND deleted_at IS NULL AND `+condition,
+		args...,
+	).Scan(&completed, &total)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count todo completion.")
+		return 0, 0, fmt.Errorf("failed to count todo completion: %w", err)
+	}
+
+	return completed, total, nil
+}
+
+// GetBusiestDay finds the calendar day, in the window's location, on which the user created
+// the most of their todos in the window, the earliest on a tie. It returns nil when the
+// window has no todos.
+func (r *todoRepository) GetBusiestDay(ctx context.Context, userID string, window models.CompletionWindow) (*models.DayCount, error) {
+	var day models.DayCount
+	err := r.db.QueryRow(ctx,
+		`SELECT to_char(created_at AT TIME ZONE $4, 'YYYY-MM-DD') AS day, COUNT(*)
+		FROM todos
+		WHERE user_id = $1 AND deleted_at IS NULL AND created_at >= $2 AND created_at < $3
+		GROUP BY day
+		ORDER BY COUNT(*) DESC, day ASC
+		LIMIT 1`,
+		userID, window.Start, window.End, window.Start.Location().String(),
+	).Scan(&day.Date, &day.Count)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get busiest day.")
+		return nil, fmt.Errorf("failed to get busiest day: %w", err)
+	}
+
+	return &day, nil
+}
+
+// CountTopTags counts the user's todos created in the window carrying each tag and returns
+// the limit most used, ties ordered by tag
+func (r *todoRepository) CountTopTags(ctx context.Context, userID string, window models.CompletionWindow, limit int) ([]models.TagCount, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT tag, COUNT(*)
+		FROM todos CROSS JOIN LATERAL unnest(tags) AS tag
+		WHERE user_id = $1 AND deleted_at IS NULL AND created_at >= $2 AND created_at < $3
+		GROUP BY tag
+		ORDER BY COUNT(*) DESC, tag ASC
+		LIMIT $4`,
+		userID, window.Start, window.End, limit,
+	)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count top tags.")
+		return nil, fmt.Errorf("failed to count top tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make([]models.TagCount, 0, limit)
+	for rows.Next() {
+		var tag models.TagCount
+		if err := rows.Scan(&tag.Tag, &tag.Count); err != nil {
+			r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to scan tag count.")
+			return nil, fmt.Errorf("failed to count top tags: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to read tag counts.")
+		return nil, fmt.Errorf("failed to count top tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// AverageCompletionTime averages, over the user's done todos created in the window, the time
+// from creation to the last update, which for a done todo is usually when it was completed.
+// It is 0 when none are done.
+func (r *todoRepository) AverageCompletionTime(ctx context.Context, userID string, window models.CompletionWindow) (time.Duration, error) {
+	var seconds float64
+	err := r.db.QueryRow(ctx,
+		`SELECT COALESCE(EXTRACT(EPOCH FROM AVG(updated_at - created_at)), 0)::float8
+		FROM todos
+		WHERE user_id = $1 AND status = $2 AND deleted_at IS NULL AND created_at >= $3 AND created_at < $4`,
+		userID, models.DoneStatus(), window.Start, window.End,
+	).Scan(&seconds)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to average completion time.")
+		return 0, fmt.Errorf("failed to average completion time: %w", err)
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// Search searches the title and description of todos with pagination, best matches first.
+// mode picks how the query is turned into a tsquery; see searchTSQuery.
+func (r *todoRepository) Search(ctx context.Context, userID, query, mode string, limit, offset int) ([]*models.Todo, int64, error) {
+	tsquery, arg := searchTSQuery(query, mode)
+	where := `user_id = $1 AND deleted_at IS NULL AND ` + searchDocument + ` @@ ` + tsquery
+
+	// Get total count
+	var total int64
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM todos WHERE `+where, userID, arg).Scan(&total)
 	if err != nil {
 		r.logger.Error().Err(err).Str("user_id", userID).Str("query", query).Msg("Failed to count search todos.")
 		return nil, 0, fmt.Errorf("failed to count search todos: %w", err)
 	}
 
 	// Get todos
-	dbTodos, err := r.queries.SearchTodos(ctx, queries.SearchTodosParams{
-		UserID:         userID,
-		PlaintoTsquery: query,
-		Limit:          int32(limit),
-		Offset:         int32(offset),
-	})
+	rows, err := r.db.Query(ctx,
+		`SELECT `+todoColumns+` FROM todos WHERE `+where+`
+		ORDER BY ts_rank(`+searchDocument+`, `+tsquery+`) DESC, created_at DESC, id DESC
+		LIMIT $3 OFFSET $4`,
+		userID, arg, limit, offset,
+	)
 	if err != nil {
 		r.logger.Error().Err(err).Str("user_id", userID).Str("query", query).Msg("Failed to search todos.")
 		return nil, 0, fmt.Errorf("failed to search todos: %w", err)
 	}
 
-	todos := make([]*models.Todo, len(dbTodos))
-	for i, dbTodo := range dbTodos {
-		todos[i] = r.mapDBTodoToModel(dbTodo)
+	todos, err := r.scanTodos(rows)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Str("query", query).Msg("Failed to scan search todos.")
+		return nil, 0, fmt.Errorf("failed to search todos: %w", err)
 	}
 
 	return todos, total, nil
 }
 
+// CountByUserID returns the number of todos owned by a user
+func (r *todoRepository) CountByUserID(ctx context.Context, userID string) (int64, error) {
+	total, err := r.queries.CountTodosByUserID(ctx, userID)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count todos by user ID.")
+		return 0, fmt.Errorf("failed to count todos: %w", err)
+	}
+
+	return total, nil
+}
+
+// CountOverdue returns the number of the user's not-done todos that are overdue at cutoff
+func (r *todoRepository) CountOverdue(ctx context.Context, userID string, cutoff models.OverdueCutoff) (int64, error) {
+	var total int64
+	err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM todos
+		WHERE user_id = $1 AND `+overdueCondition(3, 4)+` AND status <> $2 AND deleted_at IS NULL`,
+		userID, models.DoneStatus(), cutoff.Now, cutoff.Today,
+	).Scan(&total)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count overdue todos.")
+		return 0, fmt.Errorf("failed to count overdue todos: %w", err)
+	}
+
+	return total, nil
+}
+
 // CountByStatus returns count of todos by status
 func (r *todoRepository) CountByStatus(ctx context.Context, userID string) (map[string]int64, error) {
 	rows, err := r.queries.GetTodoStatusCounts(ctx, userID)
@@ -338,51 +957,419 @@ func (r *todoRepository) CountByStatus(ctx context.Context, userID string) (map[
 	return counts, nil
 }
 
-// MarkCompleted marks a todo as completed
-func (r *todoRepository) MarkCompleted(ctx context.Context, id string) error {
-	err := r.queries.MarkTodoCompleted(ctx, id)
+// MarkCompleted marks a todo of the user as completed. Todos of other users are reported as
+// not found.
+func (r *todoRepository) MarkCompleted(ctx context.Context, userID, id string) error {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE todos SET status = $3, updated_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`,
+		id, userID, models.DoneStatus(),
+	)
 	if err != nil {
-		r.logger.Error().Err(err).Str("todo_id", id).Msg("Failed to mark todo as completed.")
+		r.logger.Error().Err(err).Str("todo_id", id).Str("user_id", userID).Msg("Failed to mark todo as completed.")
 		return fmt.Errorf("failed to mark todo as completed: %w", err)
 	}
 
+	if tag.RowsAffected() == 0 {
+		return interfaces.ErrTodoNotFound
+	}
+
 	r.logger.Info().Str("todo_id", id).Msg("Todo marked as completed.")
 	return nil
 }
 
-// BulkUpdateStatus updates status for multiple todos
-func (r *todoRepository) BulkUpdateStatus(ctx context.Context, ids []string, status string) error {
-	// Convert []string to []interface{}
-	interfaceIds := make([]interface{}, len(ids))
-	for i, id := range ids {
-		interfaceIds[i] = id
+// BulkUpdateStatusForUser sets the status of the user's todos among ids and returns how many
+// were updated. Todos owned by other users, and todos already in that status, are left
+// untouched.
+func (r *todoRepository) BulkUpdateStatusForUser(ctx context.Context, userID string, ids []string, status string) (int64, error) {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE todos SET status = $3, updated_at = NOW()
+		WHERE user_id = $1 AND id = ANY($2::text[]::ulid[]) AND deleted_at IS NULL AND status <> $3`,
+		userID, ids, status,
+	)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Strs("todo_ids", ids).Str("status", status).Msg("Failed to bulk update todo status.")
+		return 0, fmt.Errorf("failed to bulk update todo status: %w", err)
 	}
 
-	err := r.queries.BulkUpdateTodoStatus(ctx, queries.BulkUpdateTodoStatusParams{
-		Column1: interfaceIds,
-		Status:  status,
-	})
+	r.logger.Info().Str("user_id", userID).Strs("todo_ids", ids).Str("status", status).Int64("updated_count", tag.RowsAffected()).Msg("Todos status updated in bulk.")
+	return tag.RowsAffected(), nil
+}
+
+// BulkReschedule sets the due date of the user's todos to dueDate or, when dueDate is nil,
+// moves it by shift. Todos without a due date are shifted relative to now.
+func (r *todoRepository) BulkReschedule(ctx context.Context, userID string, ids []string, dueDate *time.Time, shift time.Duration) (int64, error) {
+	var sql string
+	var value any
+	if dueDate != nil {
+		sql = `UPDATE todos SET due_date = $3, updated_at = NOW()
+		WHERE user_id = $1 AND id = ANY($2::text[]::ulid[]) AND deleted_at IS NULL`
+		value = *dueDate
+	} else {
+		sql = `UPDATE todos SET due_date = COALESCE(due_date, NOW()) + make_interval(secs => $3), updated_at = NOW()
+		WHERE user_id = $1 AND id = ANY($2::text[]::ulid[]) AND deleted_at IS NULL`
+		value = shift.Seconds()
+	}
+
+	tag, err := r.db.Exec(ctx, sql, userID, ids, value)
 	if err != nil {
-		r.logger.Error().Err(err).Strs("todo_ids", ids).Str("status", status).Msg("Failed to bulk update todo status.")
-		return fmt.Errorf("failed to bulk update todo status: %w", err)
+		r.logger.Error().Err(err).Str("user_id", userID).Strs("todo_ids", ids).Msg("Failed to bulk reschedule todos.")
+		return 0, fmt.Errorf("failed to bulk reschedule todos: %w", err)
 	}
 
-	r.logger.Info().Strs("todo_ids", ids).Str("status", status).Msg("Todos status updated in bulk.")
-	return nil
+	r.logger.Info().Str("user_id", userID).Strs("todo_ids", ids).Int64("updated_count", tag.RowsAffected()).Msg("Todos rescheduled in bulk.")
+	return tag.RowsAffected(), nil
+}
+
+// BulkUpdatePriority sets the priority of the user's todos matching filter and returns how
+// many were updated. With dryRun set it only counts the matching todos.
+func (r *todoRepository) BulkUpdatePriority(ctx context.Context, userID string, filter models.TodoFilter, priority string, dryRun bool) (int64, error) {
+	where, args := todoFilterWhere(userID, filter)
+
+	if dryRun {
+		var matched int64
+		if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM todos WHERE `+where, args...).Scan(&matched); err != nil {
+			r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count todos for bulk priority update.")
+			return 0, fmt.Errorf("failed to count todos: %w", err)
+		}
+		return matched, nil
+	}
+
+	args = append(args, priority)
+	tag, err := r.db.Exec(ctx,
+		fmt.Sprintf(`UPDATE todos SET priority = $%d, updated_at = NOW() WHERE %s`, len(args), where),
+		args...,
+	)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Str("priority", priority).Msg("Failed to bulk update todo priority.")
+		return 0, fmt.Errorf("failed to bulk update todo priority: %w", err)
+	}
+
+	r.logger.Info().Str("user_id", userID).Str("priority", priority).Int64("updated_count", tag.RowsAffected()).Msg("Todo priorities updated in bulk.")
+	return tag.RowsAffected(), nil
+}
+
+// BulkPatch applies the fields present in patch to the user's todos with the given IDs and
+// returns how many were updated. With dryRun set it only counts the matching todos.
+func (r *todoRepository) BulkPatch(ctx context.Context, userID string, ids []string, patch *models.PatchTodoRequest, dryRun bool) (int64, error) {
+	const where = `user_id = $1 AND id = ANY($2::text[]::ulid[]) AND deleted_at IS NULL`
+
+	if dryRun {
+		var matched int64
+		if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM todos WHERE `+where, userID, ids).Scan(&matched); err != nil {
+			r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count todos for bulk patch.")
+			return 0, fmt.Errorf("failed to count todos: %w", err)
+		}
+		return matched, nil
+	}
+
+	assignments, args := todoPatchAssignments(patch, []any{userID, ids})
+	tag, err := r.db.Exec(ctx, `UPDATE todos SET `+assignments+` WHERE `+where, args...)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Strs("todo_ids", ids).Msg("Failed to bulk patch todos.")
+		return 0, fmt.Errorf("failed to bulk patch todos: %w", err)
+	}
+
+	r.logger.Info().Str("user_id", userID).Strs("todo_ids", ids).Int64("updated_count", tag.RowsAffected()).Msg("Todos patched in bulk.")
+	return tag.RowsAffected(), nil
+}
+
+// todoPatchAssignments builds the SET list for the fields present in patch from fixed column
+// names, appending their values to args. An empty description or a null due date is stored as NULL.
+func todoPatchAssignments(patch *models.PatchTodoRequest, args []any) (string, []any) {
+	assignments := []string{"updated_at = NOW()"}
+
+	set := func(column string, value any) {
+		args = append(args, value)
+		assignments = append(assignments, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	if patch.Title != nil {
+		set("title", *patch.Title)
+	}
+	if patch.Description != nil {
+		set("description", pgtype.Text{String: *patch.Description, Valid: *patch.Description != ""})
+	}
+	if patch.Status != nil {
+		set("status", *patch.Status)
+	}
+	if patch.Priority != nil {
+		set("priority", *patch.Priority)
+	}
+	if patch.DueDate.Set {
+		var dueDate pgtype.Timestamptz
+		if patch.DueDate.Value != nil {
+			dueDate = pgtype.Timestamptz{Time: *patch.DueDate.Value, Valid: true}
+		}
+		set("due_date", dueDate)
+	}
+	if patch.AllDay != nil {
+		set("all_day", *patch.AllDay)
+	}
+	if patch.Tags != nil {
+		set("tags", patch.Tags)
+	}
+
+	return strings.Join(assignments, ", "), args
+}
+
+// upcomingWhere builds the WHERE clause and its arguments selecting a user's not-done todos due
+// between now and days days later. The count and page queries share it so both use the same bound.
+func upcomingWhere(userID string, now time.Time, days int) (string, []any) {
+	return "user_id = $1 AND status <> $2 AND deleted_at IS NULL AND due_date BETWEEN $3 AND $3 + make_interval(days => $4)",
+		[]any{userID, models.DoneStatus(), now, days}
+}
+
+// todoFilterWhere builds the WHERE clause and its arguments selecting a user's todos that match filter
+func todoFilterWhere(userID string, filter models.TodoFilter) (string, []any) {
+	conditions := []string{"user_id = $1", "deleted_at IS NULL"}
+	args := []any{userID}
+
+	add := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Status != "" {
+		add("status = $%d", filter.Status)
+	}
+	if len(filter.Statuses) > 0 {
+		add("status = ANY($%d::text[])", filter.Statuses)
+	}
+	if filter.Priority != "" {
+		add("priority = $%d", filter.Priority)
+	}
+	switch {
+	case filter.Tag != "" && filter.TagCaseInsensitive:
+		add("EXISTS (SELECT 1 FROM unnest(tags) AS tag WHERE lower(tag) = lower($%d))", filter.Tag)
+	case filter.Tag != "":
+		add("tags @> ARRAY[$%d]::text[]", filter.Tag)
+	}
+	if filter.DueFrom != nil {
+		add("due_date >= $%d", *filter.DueFrom)
+	}
+	if filter.DueBefore != nil {
+		add("due_date < $%d", *filter.DueBefore)
+	}
+
+	return strings.Join(conditions, " AND "), args
 }
 
-// DeleteCompleted soft deletes all completed todos for a user
-func (r *todoRepository) DeleteCompleted(ctx context.Context, userID string) error {
-	err := r.queries.SoftDeleteCompletedTodos(ctx, userID)
+// todoOrderBy builds the ORDER BY clause for sort from fixed column names, never from the
+// requested field itself. Sorting by priority appends the configured levels to args so
+// todos rank by level; todos without a due date or with an unknown priority come last.
+func todoOrderBy(sort models.TodoSort, args []any) (string, []any) {
+	direction := "ASC"
+	if sort.Descending() {
+		direction = "DESC"
+	}
+
+	var key string
+	switch sort.Field {
+	case models.TodoSortDueDate:
+		key = "due_date " + direction + " NULLS LAST"
+	case models.TodoSortPriority:
+		args = append(args, models.Priorities())
+		key = fmt.Sprintf("array_position($%d::text[], priority) %s NULLS LAST", len(args), direction)
+	case models.TodoSortTitle:
+		key = "title " + direction
+	default:
+		key = "created_at " + direction
+	}
+
+	return key + ", id " + direction, args
+}
+
+// DeleteCompleted soft deletes all completed todos for a user and returns how many were deleted
+func (r *todoRepository) DeleteCompleted(ctx context.Context, userID string) (int64, error) {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE todos SET deleted_at = NOW(), updated_at = NOW()
+		WHERE user_id = $1 AND status = $2 AND deleted_at IS NULL`,
+		userID, models.DoneStatus(),
+	)
 	if err != nil {
 		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to delete completed todos.")
-		return fmt.Errorf("failed to delete completed todos: %w", err)
+		return 0, fmt.Errorf("failed to delete completed todos: %w", err)
 	}
 
-	r.logger.Info().Str("user_id", userID).Msg("Completed todos deleted.")
+	r.logger.Info().Str("user_id", userID).Int64("deleted_count", tag.RowsAffected()).Msg("Completed todos deleted.")
+	return tag.RowsAffected(), nil
+}
+
+// GetDeleted retrieves soft-deleted todos with pagination, most recently deleted first
+func (r *todoRepository) GetDeleted(ctx context.Context, userID string, limit, offset int) ([]*models.Todo, int64, error) {
+	// Get total count
+	var total int64
+	err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM todos WHERE user_id = $1 AND deleted_at IS NOT NULL`,
+		userID,
+	).Scan(&total)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to count deleted todos.")
+		return nil, 0, fmt.Errorf("failed to count deleted todos: %w", err)
+	}
+
+	// Get todos
+	rows, err := r.db.Query(ctx,
+		`SELECT `+todoColumns+` FROM todos
+		WHERE user_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+		LIMIT $2 OFFSET $3`,
+		userID, limit, offset,
+	)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get deleted todos.")
+		return nil, 0, fmt.Errorf("failed to get deleted todos: %w", err)
+	}
+
+	todos, err := r.scanTodos(rows)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to scan deleted todos.")
+		return nil, 0, fmt.Errorf("failed to get deleted todos: %w", err)
+	}
+
+	return todos, total, nil
+}
+
+// Restore clears the deletion mark of a todo in the user's trash. Todos that are not in the
+// user's trash are reported as not found.
+func (r *todoRepository) Restore(ctx context.Context, userID, id string) error {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE todos SET deleted_at = NULL, updated_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL`,
+		id, userID,
+	)
+	if err != nil {
+		r.logger.Error().Err(err).Str("todo_id", id).Str("user_id", userID).Msg("Failed to restore todo.")
+		return fmt.Errorf("failed to restore todo: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return interfaces.ErrTodoNotFound
+	}
+
+	r.logger.Info().Str("todo_id", id).Str("user_id", userID).Msg("Todo restored successfully.")
+	return nil
+}
+
+// HardDelete permanently removes a todo the user has already soft deleted. Todos that are
+// not in the user's trash are reported as not found.
+func (r *todoRepository) HardDelete(ctx context.Context, userID, id string) error {
+	tag, err := r.db.Exec(ctx,
+		`DELETE FROM todos WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL`,
+		id, userID,
+	)
+	if err != nil {
+		r.logger.Error().Err(err).Str("todo_id", id).Str("user_id", userID).Msg("Failed to permanently delete todo.")
+		return fmt.Errorf("failed to permanently delete todo: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return interfaces.ErrTodoNotFound
+	}
+
+	r.logger.Info().Str("todo_id", id).Str("user_id", userID).Msg("Todo permanently deleted.")
 	return nil
 }
 
+// PurgeDeleted permanently removes todos of every user that were soft deleted before olderThan
+// and returns how many were removed
+func (r *todoRepository) PurgeDeleted(ctx context.Context, olderThan time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx,
+		`DELETE FROM todos WHERE deleted_at IS NOT NULL AND deleted_at < $1`,
+		olderThan,
+	)
+	if err != nil {
+		r.logger.Error().Err(err).Time("older_than", olderThan).Msg("Failed to purge deleted todos.")
+		return 0, fmt.Errorf("failed to purge deleted todos: %w", err)
+	}
+
+	r.logger.Info().Time("older_than", olderThan).Int64("purged_count", tag.RowsAffected()).Msg("Deleted todos purged.")
+	return tag.RowsAffected(), nil
+}
+
+// CountAllByStatus returns count of todos by status across all users
+func (r *todoRepository) CountAllByStatus(ctx context.Context) (map[string]int64, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT status, COUNT(*) FROM todos WHERE deleted_at IS NULL GROUP BY status`,
+	)
+	if err != nil {
+		r.logger.Error().Err(err).Msg("Failed to get global todo status counts.")
+		return nil, fmt.Errorf("failed to get global todo status counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var status string
+		var count int64
+		if err := rows.Scan(&status, &count); err != nil {
+			r.logger.Error().Err(err).Msg("Failed to scan global todo status count.")
+			return nil, fmt.Errorf("failed to get global todo status counts: %w", err)
+		}
+		counts[status] = count
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error().Err(err).Msg("Failed to read global todo status counts.")
+		return nil, fmt.Errorf("failed to get global todo status counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// CountAllOverdue returns the number of overdue todos across all users, placing all-day
+// todos in UTC days
+func (r *todoRepository) CountAllOverdue(ctx context.Context) (int64, error) {
+	cutoff := models.NewOverdueCutoff(time.Now().UTC())
+
+	var total int64
+	err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM todos WHERE `+overdueCondition(2, 3)+` AND status <> $1 AND deleted_at IS NULL`,
+		models.DoneStatus(), cutoff.Now, cutoff.Today,
+	).Scan(&total)
+	if err != nil {
+		r.logger.Error().Err(err).Msg("Failed to count global overdue todos.")
+		return 0, fmt.Errorf("failed to count global overdue todos: %w", err)
+	}
+
+	return total, nil
+}
+
+// Merge saves the merged target's description and due date and soft deletes the source
+// in a single statement, so neither change is applied without the other
+func (r *todoRepository) Merge(ctx context.Context, target *models.Todo, sourceID string) (*models.Todo, error) {
+	var description pgtype.Text
+	var dueDate pgtype.Timestamptz
+
+	if target.Description != "" {
+		description = pgtype.Text{String: target.Description, Valid: true}
+	}
+	if target.DueDate != nil {
+		dueDate = pgtype.Timestamptz{Time: *target.DueDate, Valid: true}
+	}
+
+	row := r.db.QueryRow(ctx,
+		`WITH source AS (
+			UPDATE todos SET deleted_at = NOW(), updated_at = NOW()
+			WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+				AND EXISTS (SELECT 1 FROM todos WHERE id = $3 AND user_id = $2 AND deleted_at IS NULL)
+			RETURNING id
+		)
+		UPDATE todos SET description = $4, due_date = $5, all_day = $6, tags = $7, updated_at = NOW()
+		WHERE id = $3 AND user_id = $2 AND deleted_at IS NULL AND EXISTS (SELECT 1 FROM source)
+		RETURNING `+todoColumns,
+		sourceID, target.UserID, target.ID, description, dueDate, target.AllDay, tagsOrEmpty(target.Tags),
+	)
+
+	result, err := r.scanTodo(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, interfaces.ErrTodoNotFound
+		}
+		r.logger.Error().Err(err).Str("todo_id", target.ID).Str("source_id", sourceID).Msg("Failed to merge todos.")
+		return nil, fmt.Errorf("failed to merge todos: %w", err)
+	}
+
+	r.logger.Info().Str("todo_id", result.ID).Str("source_id", sourceID).Msg("Todos merged successfully.")
+	return result, nil
+}
+
 // mapDBTodoToModel converts a database todo to a model todo
 func (r *todoRepository) mapDBTodoToModel(dbTodo queries.Todo) *models.Todo {
 	todo := &models.Todo{
@@ -403,6 +1390,106 @@ func (r *todoRepository) mapDBTodoToModel(dbTodo queries.Todo) *models.Todo {
 	if dbTodo.DueDate.Valid {
 		todo.DueDate = &dbTodo.DueDate.Time
 	}
+	if dbTodo.DeletedAt.Valid {
+		todo.DeletedAt = &dbTodo.DeletedAt.Time
+	}
+	if len(dbTodo.Tags) > 0 {
+		todo.Tags = dbTodo.Tags
+	}
+	todo.AllDay = dbTodo.AllDay
+	if len(dbTodo.Subtasks) > 0 {
+		if err := json.Unmarshal(dbTodo.Subtasks, &todo.Subtasks); err != nil {
+			r.logger.Warn().Err(err).Str("todo_id", todo.ID).Msg("Failed to decode todo subtasks.")
+		}
+	}
 
 	return todo
 }
+
+// tagsOrEmpty maps nil tags to an empty array for the NOT NULL tags column
+func tagsOrEmpty(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// subtasksJSON encodes subtasks for the NOT NULL subtasks column, mapping nil to an empty array
+func subtasksJSON(subtasks []models.Subtask) ([]byte, error) {
+	if subtasks == nil {
+		subtasks = []models.Subtask{}
+	}
+	return json.Marshal(subtasks)
+}
+
+// todoColumns lists the columns selected by hand-written todo queries, in scanTodo order
+const todoColumns = `id, user_id, title, description, status, priority, due_date, created_at, updated_at, deleted_at, tags, all_day, subtasks`
+
+// searchDocument is the text searched by Search; it matches the idx_todos_search expression
+// so the GIN index is used
+const searchDocument = `to_tsvector('english', title || ' ' || COALESCE(description, ''))`
+
+// searchTSQuery returns the tsquery expression for a search in mode, reading its text from $2,
+// and the argument to pass for it. Words mode requires every word, phrase mode requires them
+// next to each other in order, and prefix mode requires a word starting with each term.
+func searchTSQuery(query, mode string) (string, string) {
+	switch mode {
+	case models.SearchModePhrase:
+		return `phraseto_tsquery('english', $2)`, query
+	case models.SearchModePrefix:
+		terms := models.SearchTerms(query)
+		for i, term := range terms {
+			terms[i] = term + ":*"
+		}
+		return `to_tsquery('english', $2)`, strings.Join(terms, " & ")
+	default:
+		return `plainto_tsquery('english', $2)`, query
+	}
+}
+
+// overdueCondition matches todos overdue at the cutoff whose now and today are passed as the
+// given parameters: timed todos due before now and all-day todos whose day is before today
+func overdueCondition(nowParam, todayParam int) string {
+	return fmt.Sprintf("(NOT all_day AND due_date < $%d OR all_day AND due_date < $%d)", nowParam, todayParam)
+}
+
+// scanTodo scans a row selected with todoColumns into a model todo
+func (r *todoRepository) scanTodo(row pgx.Row) (*models.Todo, error) {
+	var dbTodo queries.Todo
+	err := row.Scan(
+		&dbTodo.ID,
+		&dbTodo.UserID,
+		&dbTodo.Title,
+		&dbTodo.Description,
+		&dbTodo.Status,
+		&dbTodo.Priority,
+		&dbTodo.DueDate,
+		&dbTodo.CreatedAt,
+		&dbTodo.UpdatedAt,
+		&dbTodo.DeletedAt,
+		&dbTodo.Tags,
+		&dbTodo.AllDay,
+		&dbTodo.Subtasks,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.mapDBTodoToModel(dbTodo), nil
+}
+
+// scanTodos scans all rows selected with todoColumns and closes them
+func (r *todoRepository) scanTodos(rows pgx.Rows) ([]*models.Todo, error) {
+	defer rows.Close()
+
+	todos := make([]*models.Todo, 0)
+	for rows.Next() {
+		todo, err := r.scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+
+	return todos, rows.Err()
+}
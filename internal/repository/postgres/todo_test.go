@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"go-fiber/internal/models"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTodoOrderBy(t *testing.T) {
+	t.Run("maps fields to fixed columns", func(t *testing.T) {
+		tests := []struct {
+			sort     models.TodoSort
+			expected string
+		}{
+			{models.NewTodoSort("", ""), "created_at DESC, id DESC"},
+			{models.NewTodoSort(models.TodoSortTitle, ""), "title ASC, id ASC"},
+			{models.NewTodoSort(models.TodoSortDueDate, models.SortDescending), "due_date DESC NULLS LAST, id DESC"},
+			{models.TodoSort{Field: "title; DROP TABLE todos", Order: "sideways"}, "created_at ASC, id ASC"},
+		}
+
+		for _, tt := range tests {
+			orderBy, args := todoOrderBy(tt.sort, []any{"user-1"})
+
+			assert.Equal(t, tt.expected, orderBy)
+			assert.Equal(t, []any{"user-1"}, args)
+		}
+	})
+
+	t.Run("priority ranks by configured level", func(t *testing.T) {
+		orderBy, args := todoOrderBy(models.NewTodoSort(models.TodoSortPriority, ""), []any{"user-1", "pending"})
+
+		assert.Equal(t, "array_position($3::text[], priority) DESC NULLS LAST, id DESC", orderBy)
+		assert.Equal(t, []any{"user-1", "pending", []string{"low", "medium", "high"}}, args)
+	})
+}
+
+func TestCursorWhere(t *testing.T) {
+	t.Run("first page", func(t *testing.T) {
+		where, args := cursorWhere("user-1", "", nil)
+
+		assert.Equal(t, "user_id = $1 AND deleted_at IS NULL", where)
+		assert.Equal(t, []any{"user-1"}, args)
+	})
+
+	t.Run("leaves out todos created after the snapshot", func(t *testing.T) {
+		snapshot := time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC)
+		where, args := cursorWhere("user-1", "01K7KZ6T7Q0S4E9M2W8H3XJ5VD", &snapshot)
+
+		assert.Equal(t, "user_id = $1 AND deleted_at IS NULL AND id < $2::text::ulid AND created_at <= $3", where)
+		assert.Equal(t, []any{"user-1", "01K7KZ6T7Q0S4E9M2W8H3XJ5VD", snapshot}, args)
+	})
+}
+
+func TestTodoPatchAssignments(t *testing.T) {
+	t.Run("sets only the present fields after the existing args", func(t *testing.T) {
+		status, priority := models.TodoStatusCompleted, models.TodoPriorityLow
+		assignments, args := todoPatchAssignments(&models.PatchTodoRequest{
+			Status:   &status,
+			Priority: &priority,
+			Tags:     []string{"work"},
+		}, []any{"user-1", []string{"todo-1"}})
+
+		assert.Equal(t, "updated_at = NOW(), status = $3, priority = $4, tags = $5", assignments)
+		assert.Equal(t, []any{"user-1", []string{"todo-1"}, status, priority, []string{"work"}}, args)
+	})
+
+	t.Run("a null due date is stored as NULL", func(t *testing.T) {
+		assignments, args := todoPatchAssignments(&models.PatchTodoRequest{DueDate: models.NullableTime{Set: true}}, []any{"user-1"})
+
+		assert.Equal(t, "updated_at = NOW(), due_date = $2", assignments)
+		assert.Equal(t, []any{"user-1", pgtype.Timestamptz{}}, args)
+	})
+}
+
+func TestUpcomingWhere(t *testing.T) {
+	now := time.Date(2025, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	t.Run("bounds the due date by the requested days", func(t *testing.T) {
+		where, args := upcomingWhere("user-1", now, 1)
+
+		assert.Equal(t, "user_id = $1 AND status <> $2 AND deleted_at IS NULL AND due_date BETWEEN $3 AND $3 + make_interval(days => $4)", where)
+		assert.Equal(t, []any{"user-1", models.DoneStatus(), now, 1}, args)
+	})
+
+	t.Run("different days select different windows", func(t *testing.T) {
+		_, oneDay := upcomingWhere("user-1", now, 1)
+		_, thirtyDays := upcomingWhere("user-1", now, 30)
+
+		assert.Equal(t, 1, oneDay[3])
+		assert.Equal(t, 30, thirtyDays[3])
+		assert.NotEqual(t, oneDay, thirtyDays)
+	})
+}
+
+func TestSearchTSQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		mode     string
+		expected string
+		arg      string
+	}{
+		{"single word", "milk", models.SearchModeWords, "plainto_tsquery('english', $2)", "milk"},
+		{"multi-word requires every word", "buy milk", models.SearchModeWords, "plainto_tsquery('english', $2)", "buy milk"},
+		{"phrase keeps word order", "buy milk", models.SearchModePhrase, "phraseto_tsquery('english', $2)", "buy milk"},
+		{"prefix single word", "gro", models.SearchModePrefix, "to_tsquery('english', $2)", "gro:*"},
+		{"prefix multi-word drops punctuation", "gro & mil:k!", models.SearchModePrefix, "to_tsquery('english', $2)", "gro:* & mil:* & k:*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tsquery, arg := searchTSQuery(tt.query, tt.mode)
+
+			assert.Equal(t, tt.expected, tsquery)
+			assert.Equal(t, tt.arg, arg)
+		})
+	}
+}
+
+func TestTodoFilterWhere_Statuses(t *testing.T) {
+	where, args := todoFilterWhere("user-1", models.TodoFilter{Statuses: []string{"pending", "in_progress"}, Priority: "high"})
+
+	assert.Equal(t, "user_id = $1 AND deleted_at IS NULL AND status = ANY($2::text[]) AND priority = $3", where)
+	assert.Equal(t, []any{"user-1", []string{"pending", "in_progress"}, "high"}, args)
+}
+
+func TestTodoFilterWhere_Tag(t *testing.T) {
+	t.Run("exact match by default", func(t *testing.T) {
+		where, args := todoFilterWhere("user-1", models.TodoFilter{Tag: "Work"})
+
+		assert.Equal(t, "user_id = $1 AND deleted_at IS NULL AND tags @> ARRAY[$2]::text[]", where)
+		assert.Equal(t, []any{"user-1", "Work"}, args)
+	})
+
+	t.Run("case-insensitive compares lowered tags", func(t *testing.T) {
+		where, args := todoFilterWhere("user-1", models.TodoFilter{Tag: "Work", TagCaseInsensitive: true})
+
+		assert.Equal(t, "user_id = $1 AND deleted_at IS NULL AND EXISTS (SELECT 1 FROM unnest(tags) AS tag WHERE lower(tag) = lower($2))", where)
+		assert.Equal(t, []any{"user-1", "Work"}, args)
+	})
+}
+
+func TestInIDOrder(t *testing.T) {
+	t.Run("follows the given ids", func(t *testing.T) {
+		todos := []*models.Todo{{ID: "b"}, {ID: "c"}, {ID: "a"}}
+
+		ordered, err := inIDOrder(todos, []string{"a", "b", "c"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []*models.Todo{todos[2], todos[0], todos[1]}, ordered)
+	})
+
+	t.Run("missing todo", func(t *testing.T) {
+		_, err := inIDOrder([]*models.Todo{{ID: "a"}}, []string{"a", "b"})
+
+		assert.Error(t, err)
+	})
+}
@@ -3,26 +3,32 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 
 	"go-fiber/internal/models"
 	"go-fiber/internal/repository/interfaces"
 	"go-fiber/internal/repository/postgres/queries"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog"
 )
 
 // userRepository implements the UserRepository interface for PostgreSQL
 type userRepository struct {
-	db      *pgxpool.Pool
+	db      DBTX
 	queries *queries.Queries
 	logger  zerolog.Logger
 }
 
+// pgUniqueViolation is the SQLSTATE for a unique constraint violation
+const pgUniqueViolation = "23505"
+
 // NewUserRepository creates a new PostgreSQL user repository
-func NewUserRepository(db *pgxpool.Pool, logger zerolog.Logger) interfaces.UserRepository {
+func NewUserRepository(db DBTX, logger zerolog.Logger) interfaces.UserRepository {
 	return &userRepository{
 		db:      db,
 		queries: queries.New(db),
@@ -48,6 +54,10 @@ func (r *userRepository) Create(ctx context.Context, user *models.User) (*models
 		Image:        image,
 	})
 	if err != nil {
+		// The unique constraints catch registrations that raced past the existence checks
+		if taken := duplicateUserError(err); taken != nil {
+			return nil, taken
+		}
 		r.logger.Error().Err(err).Str("username", user.Username).Msg("Failed to create user.")
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
@@ -56,6 +66,7 @@ func (r *userRepository) Create(ctx context.Context, user *models.User) (*models
 		ID:        fmt.Sprintf("%v", dbUser.ID), // Convert interface{} to string
 		Username:  dbUser.Username,
 		Password:  dbUser.PasswordHash,
+		Role:      models.UserRoleUser, // the column default
 		CreatedAt: dbUser.CreatedAt.Time,
 		UpdatedAt: dbUser.UpdatedAt.Time,
 	}
@@ -97,6 +108,10 @@ func (r *userRepository) GetByID(ctx context.Context, id string) (*models.User,
 		result.Image = dbUser.Image.String
 	}
 
+	if err := r.loadRoles(ctx, result); err != nil {
+		return nil, err
+	}
+
 	return result, nil
 }
 
@@ -127,6 +142,10 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.
 		result.Image = dbUser.Image.String
 	}
 
+	if err := r.loadRoles(ctx, result); err != nil {
+		return nil, err
+	}
+
 	return result, nil
 }
 
@@ -153,6 +172,10 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*m
 		result.Image = dbUser.Image.String
 	}
 
+	if err := r.loadRoles(ctx, result); err != nil {
+		return nil, err
+	}
+
 	return result, nil
 }
 
@@ -193,6 +216,10 @@ func (r *userRepository) Update(ctx context.Context, user *models.User) (*models
 		result.Image = dbUser.Image.String
 	}
 
+	if err := r.loadRoles(ctx, result); err != nil {
+		return nil, err
+	}
+
 	r.logger.Info().Str("user_id", result.ID).Msg("User updated successfully.")
 	return result, nil
 }
@@ -209,6 +236,80 @@ func (r *userRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// DeleteAccount soft deletes a user and applies the delete policy to their todos, each policy
+// in a single statement so the user and their todos change together
+func (r *userRepository) DeleteAccount(ctx context.Context, id, policy string) error {
+	var query string
+	args := []any{id}
+	switch policy {
+	case models.UserDeletePolicyCascade:
+		query = `WITH deleted_user AS (
+			UPDATE users SET deleted_at = NOW(), updated_at = NOW()
+			WHERE id = $1 AND deleted_at IS NULL
+			RETURNING id
+		), deleted_todos AS (
+			UPDATE todos SET deleted_at = NOW(), updated_at = NOW()
+			WHERE user_id IN (SELECT id FROM deleted_user) AND deleted_at IS NULL
+		)
+		SELECT COUNT(*) FROM deleted_user`
+	case models.UserDeletePolicyBlock:
+		query = `WITH deleted_user AS (
+			UPDATE users SET deleted_at = NOW(), updated_at = NOW()
+			WHERE id = $1 AND deleted_at IS NULL
+				AND NOT EXISTS (SELECT 1 FROM todos WHERE user_id = $1 AND status <> $2 AND deleted_at IS NULL)
+			RETURNING id
+		)
+		SELECT COUNT(*) FROM deleted_user`
+		args = append(args, models.DoneStatus())
+	case models.UserDeletePolicyAnonymize:
+		// The username is replaced rather than cleared, as it must stay unique
+		query = `WITH deleted_user AS (
+			UPDATE users SET username = 'deleted-' || id, email = NULL, image = NULL, password_hash = '',
+				totp_secret = NULL, totp_enabled = FALSE, totp_last_step = NULL,
+				deleted_at = NOW(), updated_at = NOW()
+			WHERE id = $1 AND deleted_at IS NULL
+			RETURNING id
+		)
+		SELECT COUNT(*) FROM deleted_user`
+	default:
+		return fmt.Errorf("unknown user delete policy: %s", policy)
+	}
+
+	var deleted int64
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&deleted); err != nil {
+		r.logger.Error().Err(err).Str("user_id", id).Str("policy", policy).Msg("Failed to delete user account.")
+		return fmt.Errorf("failed to delete user account: %w", err)
+	}
+	if deleted == 0 {
+		return r.accountNotDeleted(ctx, id, policy)
+	}
+
+	r.logger.Info().Str("user_id", id).Str("policy", policy).Msg("User account deleted successfully.")
+	return nil
+}
+
+// accountNotDeleted explains why DeleteAccount matched no user: either the user is gone or,
+// under the block policy, they still have active todos
+func (r *userRepository) accountNotDeleted(ctx context.Context, id, policy string) error {
+	if policy != models.UserDeletePolicyBlock {
+		return fmt.Errorf("user not found")
+	}
+
+	var exists bool
+	err := r.db.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`,
+		id,
+	).Scan(&exists)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", id).Msg("Failed to check user existence.")
+		return fmt.Errorf("failed to delete user account: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("user not found")
+	}
+	return interfaces.ErrUserHasActiveTodos
+}
+
 // UpdateImage updates a user's image
 func (r *userRepository) UpdateImage(ctx context.Context, id, imageURL string) error {
 	var image pgtype.Text
@@ -244,6 +345,117 @@ func (r *userRepository) UpdatePassword(ctx context.Context, id, hashedPassword
 	return nil
 }
 
+// UpdateRole sets a user's role
+func (r *userRepository) UpdateRole(ctx context.Context, id, role string) error {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE users SET role = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`,
+		id, role,
+	)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", id).Msg("Failed to update user role.")
+		return fmt.Errorf("failed to update user role: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	r.logger.Info().Str("user_id", id).Str("role", role).Msg("User role updated successfully.")
+	return nil
+}
+
+// loadRoles fills in the role of each user. The role column is read separately, like the
+// TOTP columns, so the generated user queries stay as they are.
+func (r *userRepository) loadRoles(ctx context.Context, users ...*models.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*models.User, len(users))
+	ids := make([]string, len(users))
+	for i, user := range users {
+		user.Role = models.UserRoleUser
+		byID[user.ID] = user
+		ids[i] = user.ID
+	}
+
+	rows, err := r.db.Query(ctx, `SELECT id::text, role FROM users WHERE id::text = ANY($1)`, ids)
+	if err != nil {
+		r.logger.Error().Err(err).Msg("Failed to get user roles.")
+		return fmt.Errorf("failed to get user roles: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, role string
+		if err := rows.Scan(&id, &role); err != nil {
+			return fmt.Errorf("failed to scan user role: %w", err)
+		}
+		if user, ok := byID[id]; ok {
+			user.Role = role
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get user roles: %w", err)
+	}
+
+	return nil
+}
+
+// GetTOTP retrieves the two-factor authentication state of a user
+func (r *userRepository) GetTOTP(ctx context.Context, id string) (*models.UserTOTP, error) {
+	var totp models.UserTOTP
+	err := r.db.QueryRow(ctx,
+		`SELECT COALESCE(totp_secret, ''), totp_enabled, COALESCE(totp_last_step, 0)
+		FROM users WHERE id = $1 AND deleted_at IS NULL`,
+		id,
+	).Scan(&totp.Secret, &totp.Enabled, &totp.LastStep)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("user not found")
+		}
+		r.logger.Error().Err(err).Str("user_id", id).Msg("Failed to get user TOTP state.")
+		return nil, fmt.Errorf("failed to get user TOTP state: %w", err)
+	}
+
+	return &totp, nil
+}
+
+// UpdateTOTP stores the encrypted TOTP secret of a user and whether two-factor
+// authentication is enabled
+func (r *userRepository) UpdateTOTP(ctx context.Context, id, encryptedSecret string, enabled bool) error {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE users SET totp_secret = $2, totp_enabled = $3, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL`,
+		id, encryptedSecret, enabled,
+	)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", id).Msg("Failed to update user TOTP state.")
+		return fmt.Errorf("failed to update user TOTP state: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	r.logger.Info().Str("user_id", id).Bool("enabled", enabled).Msg("User TOTP state updated successfully.")
+	return nil
+}
+
+// UseTOTPStep records step as the last accepted TOTP time step of a user. It reports false
+// when a code of the same or a later step was already accepted, so no code works twice.
+func (r *userRepository) UseTOTPStep(ctx context.Context, id string, step int64) (bool, error) {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE users SET totp_last_step = $2
+		WHERE id = $1 AND deleted_at IS NULL AND (totp_last_step IS NULL OR totp_last_step < $2)`,
+		id, step,
+	)
+	if err != nil {
+		r.logger.Error().Err(err).Str("user_id", id).Msg("Failed to record TOTP step.")
+		return false, fmt.Errorf("failed to record TOTP step: %w", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
 // List retrieves users with pagination
 func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*models.User, int64, error) {
 	// Get total count
@@ -283,6 +495,10 @@ func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*models
 		users[i] = user
 	}
 
+	if err := r.loadRoles(ctx, users...); err != nil {
+		return nil, 0, err
+	}
+
 	return users, total, nil
 }
 
@@ -311,3 +527,16 @@ func (r *userRepository) ExistsByUsername(ctx context.Context, username string)
 
 	return exists, nil
 }
+
+// duplicateUserError maps a unique violation on the users table to ErrEmailTaken or
+// ErrUsernameTaken, depending on the violated constraint. Other errors map to nil.
+func duplicateUserError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != pgUniqueViolation {
+		return nil
+	}
+	if strings.Contains(pgErr.ConstraintName, "email") {
+		return interfaces.ErrEmailTaken
+	}
+	return interfaces.ErrUsernameTaken
+}
@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go-fiber/internal/repository/interfaces"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuplicateUserError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{"username constraint", &pgconn.PgError{Code: "23505", ConstraintName: "users_username_key"}, interfaces.ErrUsernameTaken},
+		{"email constraint", &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"}, interfaces.ErrEmailTaken},
+		{"wrapped violation", fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"}), interfaces.ErrEmailTaken},
+		{"other constraint error", &pgconn.PgError{Code: "23502", ConstraintName: "users_username_key"}, nil},
+		{"not a postgres error", errors.New("connection reset"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, duplicateUserError(tt.err))
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// JobFunc is the work performed on each run of a job
+type JobFunc func(ctx context.Context) error
+
+// job is a named periodic job registered with the scheduler
+type job struct {
+	name     string
+	interval time.Duration
+	run      JobFunc
+}
+
+// Scheduler runs named periodic jobs on a bounded pool of workers.
+// Each job runs once per interval and never overlaps with itself; the worker
+// pool caps how many different jobs may run at the same time.
+type Scheduler struct {
+	workers chan struct{}
+	logger  zerolog.Logger
+
+	mu      sync.Mutex
+	jobs    []job
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+}
+
+// New creates a scheduler that runs at most workers jobs concurrently
+func New(workers int, logger zerolog.Logger) *Scheduler {
+	if workers < 1 {
+		workers = 1
+	}
+
+	return &Scheduler{
+		workers: make(chan struct{}, workers),
+		logger:  logger.With().Str("component", "scheduler").Logger(),
+	}
+}
+
+// Register adds a job that runs every interval once the scheduler is started.
+// Jobs must be registered before Start.
+func (s *Scheduler) Register(name string, interval time.Duration, run JobFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return fmt.Errorf("cannot register job %q after the scheduler has started", name)
+	}
+	if interval <= 0 {
+		return fmt.Errorf("job %q must have a positive interval", name)
+	}
+	for _, existing := range s.jobs {
+		if existing.name == name {
+			return fmt.Errorf("job %q is already registered", name)
+		}
+	}
+
+	s.jobs = append(s.jobs, job{name: name, interval: interval, run: run})
+	return nil
+}
+
+// Start launches all registered jobs. They stop when ctx is cancelled or Stop is called.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return
+	}
+	s.started = true
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, j)
+	}
+
+	s.logger.Info().Int("jobs", len(s.jobs)).Int("workers", cap(s.workers)).Msg("Scheduler started.")
+}
+
+// Stop cancels all jobs and waits for running ones to return
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	s.wg.Wait()
+
+	s.logger.Info().Msg("Scheduler stopped.")
+}
+
+// loop runs a job on its interval until ctx is cancelled
+func (s *Scheduler) loop(ctx context.Context, j job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Wait for a free worker
+		select {
+		case <-ctx.Done():
+			return
+		case s.workers <- struct{}{}:
+		}
+
+		s.execute(ctx, j)
+		<-s.workers
+	}
+}
+
+// execute runs a single iteration of a job, recovering from panics
+func (s *Scheduler) execute(ctx context.Context, j job) {
+	start := time.Now()
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error().
+				Str("job", j.name).
+				Interface("panic", r).
+				Bytes("stack", debug.Stack()).
+				Msg("Scheduled job panicked.")
+		}
+	}()
+
+	if err := j.run(ctx); err != nil {
+		s.logger.Error().Err(err).Str("job", j.name).Dur("duration", time.Since(start)).Msg("Scheduled job failed.")
+		return
+	}
+
+	s.logger.Debug().Str("job", j.name).Dur("duration", time.Since(start)).Msg("Scheduled job completed.")
+}
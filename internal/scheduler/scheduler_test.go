@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_RunsRegisteredJob(t *testing.T) {
+	// Arrange
+	s := New(1, zerolog.Nop())
+
+	var runs atomic.Int32
+	require.NoError(t, s.Register("counter", 5*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}))
+
+	// Act
+	s.Start(context.Background())
+	defer s.Stop()
+
+	// Assert
+	assert.Eventually(t, func() bool { return runs.Load() >= 2 }, time.Second, time.Millisecond)
+}
+
+func TestScheduler_StopsOnContextCancel(t *testing.T) {
+	// Arrange
+	s := New(1, zerolog.Nop())
+
+	var runs atomic.Int32
+	require.NoError(t, s.Register("counter", 5*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+	require.Eventually(t, func() bool { return runs.Load() >= 1 }, time.Second, time.Millisecond)
+
+	// Act
+	cancel()
+	s.Stop()
+
+	// Assert
+	stopped := runs.Load()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, stopped, runs.Load())
+}
+
+func TestScheduler_RecoversFromPanic(t *testing.T) {
+	// Arrange
+	s := New(1, zerolog.Nop())
+
+	var runs atomic.Int32
+	require.NoError(t, s.Register("panics", 5*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		panic("boom")
+	}))
+
+	// Act
+	s.Start(context.Background())
+	defer s.Stop()
+
+	// Assert
+	assert.Eventually(t, func() bool { return runs.Load() >= 2 }, time.Second, time.Millisecond)
+}
+
+func TestScheduler_ContinuesAfterJobError(t *testing.T) {
+	// Arrange
+	s := New(1, zerolog.Nop())
+
+	var runs atomic.Int32
+	require.NoError(t, s.Register("fails", 5*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		return errors.New("temporary failure")
+	}))
+
+	// Act
+	s.Start(context.Background())
+	defer s.Stop()
+
+	// Assert
+	assert.Eventually(t, func() bool { return runs.Load() >= 2 }, time.Second, time.Millisecond)
+}
+
+func TestScheduler_Register(t *testing.T) {
+	noop := func(ctx context.Context) error { return nil }
+
+	t.Run("duplicate name", func(t *testing.T) {
+		s := New(1, zerolog.Nop())
+		require.NoError(t, s.Register("job", time.Minute, noop))
+
+		assert.Error(t, s.Register("job", time.Minute, noop))
+	})
+
+	t.Run("non-positive interval", func(t *testing.T) {
+		s := New(1, zerolog.Nop())
+
+		assert.Error(t, s.Register("job", 0, noop))
+	})
+
+	t.Run("after start", func(t *testing.T) {
+		s := New(1, zerolog.Nop())
+		s.Start(context.Background())
+		defer s.Stop()
+
+		assert.Error(t, s.Register("job", time.Minute, noop))
+	})
+}
@@ -1,12 +1,15 @@
 package server
 
 import (
+	"context"
 	"time"
 
 	"go-fiber/internal/database/mongodb"
 	"go-fiber/internal/database/postgres"
 	"go-fiber/internal/handlers"
+	"go-fiber/internal/metrics"
 	"go-fiber/internal/repository"
+	"go-fiber/internal/repository/interfaces"
 	"go-fiber/internal/services"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -15,25 +18,26 @@ import (
 
 // setupDependencies initializes repositories, services, and handlers
 func (s *Server) setupDependencies() error {
-	s.logger.Info().Str("driver", s.config.Database.Driver).Msg("Setting up repositories.")
+	s.logger.Info().
+		Str("user_driver", s.config.Database.UserDriver).
+		Str("todo_driver", s.config.Database.TodoDriver).
+		Msg("Setting up repositories.")
 
-	// Determine database type from config
-	var dbType repository.DatabaseType
-	if s.config.Database.Driver == "postgres" {
-		dbType = repository.PostgreSQL
-	} else {
-		dbType = repository.MongoDB
-	}
-
-	// Create repository factory
-	repoFactory := repository.NewRepositoryFactory(dbType, s.logger)
+	// Create repository factory, with users and todos each in their configured database
+	repoFactory := repository.NewRepositoryFactory(
+		repository.DatabaseTypeForDriver(s.config.Database.UserDriver),
+		repository.DatabaseTypeForDriver(s.config.Database.TodoDriver),
+		s.config.Database.AcquireTimeout,
+		s.logger,
+	)
 
-	// Setup database connections based on driver
+	// Setup a connection to each database the repositories use
 	var pgDB *pgxpool.Pool
+	var poolMonitor *postgres.SaturationMonitor
 	var mongoDB *mongo.Database
 	var err error
 
-	if s.config.Database.Driver == "postgres" {
+	if s.config.Database.Uses("postgres") {
 		// Setup PostgreSQL connection
 		pgConn, err := postgres.New(&s.config.Database, s.logger)
 		if err != nil {
@@ -42,12 +46,23 @@ func (s *Server) setupDependencies() error {
 		}
 		pgDB = pgConn.Pool
 		s.logger.Info().Msg("Successfully connected to PostgreSQL.")
-	} else {
+
+		if s.config.Database.PoolSaturationThreshold > 0 {
+			poolMonitor = postgres.NewSaturationMonitor(pgConn, s.config.Database.PoolSaturationThreshold, s.logger)
+			if err := s.scheduler.Register("db-pool-saturation", s.config.Database.PoolCheckInterval, poolMonitor.Check); err != nil {
+				return err
+			}
+		}
+	}
+
+	if s.config.Database.Uses("mongodb") {
 		// Setup MongoDB connection
 		mongoConfig := mongodb.Config{
 			URI:      s.config.Database.MongoURL,
 			Database: "todoapp", // Extract from URL or make configurable
 			Timeout:  10 * time.Second,
+
+			ReadPreference: s.config.Database.MongoReadPreference,
 		}
 
 		mongoConn, err := mongodb.NewConnection(mongoConfig, s.logger)
@@ -72,17 +87,118 @@ func (s *Server) setupDependencies() error {
 		return err
 	}
 
+	apiKeyRepo, err := repoFactory.CreateAPIKeyRepository(pgDB, mongoDB)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to create API key repository.")
+		return err
+	}
+
+	schemaRepo, err := repoFactory.CreateSchemaRepository(pgDB, mongoDB)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to create schema repository.")
+		return err
+	}
+
+	// MongoDB has no migrations, so its repositories create their own indexes (PostgreSQL
+	// repositories are skipped as they do not implement IndexEnsurer)
+	if mongoDB != nil {
+		if err := ensureIndexes(userRepo, todoRepo, apiKeyRepo); err != nil {
+			s.logger.Error().Err(err).Msg("Failed to create MongoDB indexes.")
+			return err
+		}
+		s.logger.Info().Msg("MongoDB indexes are in place.")
+	}
+
 	// Setup health check handler
 	s.healthHandler = handlers.NewHealthHandler(pgDB, mongoDB, s.redisClient, s.logger)
 
 	// Setup services
 	sessionStore := services.NewRedisSessionStore(s.redisClient, s.logger)
 	s.authService = services.NewAuthService(userRepo, sessionStore, &s.config.JWT, s.logger)
+	s.authService.SetPasswordMinScore(s.config.Auth.PasswordMinScore)
+	s.authService.SetPasswordReset(services.NewRedisResetTokenStore(s.redisClient, s.logger), services.NewLogResetTokenSender(s.logger, s.config.Auth.LogResetTokens), s.config.Auth.PasswordResetTTL)
+	if s.config.Auth.LockoutThreshold > 0 {
+		s.authService.SetLockout(services.NewRedisLoginAttemptStore(s.redisClient, s.logger), s.config.Auth.LockoutThreshold, s.config.Auth.LockoutWindow, s.config.Auth.LockoutDuration)
+	}
+	s.authService.SetUserDeletePolicy(s.config.User.DeletePolicy)
+	s.authService.SetAPIKeys(apiKeyRepo)
+	if len(s.config.Auth.AdminUsernames) > 0 {
+		if err := promoteAdmins(s.authService, s.config.Auth.AdminUsernames); err != nil {
+			s.logger.Error().Err(err).Msg("Failed to promote configured admins.")
+			return err
+		}
+	}
+	totpKey, err := s.config.Auth.TOTPKey()
+	if err != nil {
+		return err
+	}
+	if err := s.authService.SetTwoFactor(totpKey, s.config.Auth.TOTPIssuer); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to set up two-factor authentication.")
+		return err
+	}
+	s.authService.SetTwoFactorChallenges(services.NewRedisLoginAttemptStore(s.redisClient, s.logger))
+	s.apiKeyService = services.NewAPIKeyService(apiKeyRepo, userRepo, s.logger)
+	todoService := services.NewTodoService(todoRepo, &s.config.Todo, s.logger)
+	if s.config.Todo.TrashRetentionDays > 0 {
+		if err := s.scheduler.Register("trash-purge", services.TrashPurgeInterval, todoService.PurgeTrash); err != nil {
+			return err
+		}
+	}
 
 	// Setup handlers
 	s.authHandler = handlers.NewAuthHandler(s.authService, s.validator, s.logger)
-	s.todoHandler = handlers.NewTodoHandler(todoRepo, s.validator, s.logger)
+	s.adminHandler = handlers.NewAdminHandler(userRepo, s.validator, s.logger)
+	s.apiKeyHandler = handlers.NewAPIKeyHandler(s.apiKeyService, s.validator, s.logger)
+	s.todoHandler = handlers.NewTodoHandler(todoRepo, todoService, s.config.Server.CacheMaxAge, s.validator, s.logger)
+	s.todoHandler.SetSearchConcurrency(s.config.Database.MaxConcurrentSearches)
+	s.todoHandler.SetEndOfDayDueDates(s.config.Todo.EndOfDayDueDates)
+	s.todoHandler.SetImportLimits(s.config.Todo.ImportMaxItems, s.config.Todo.ImportChunkSize)
+	s.todoHandler.SetImportBodyLimit(s.config.Todo.ImportBodyLimit)
+	s.todoHandler.SetTagCaseInsensitive(s.config.Todo.TagCaseInsensitive)
+	defaultTimezone, err := s.config.Server.DefaultLocation()
+	if err != nil {
+		return err
+	}
+	s.todoHandler.SetDefaultTimezone(defaultTimezone)
+	s.metricsHandler = handlers.NewMetricsHandler(todoRepo, s.config.Metrics.CacheTTL, s.logger)
+	if poolMonitor != nil {
+		s.metricsHandler.SetPoolSaturation(poolMonitor.Saturation)
+	}
+	if s.config.Metrics.Enabled {
+		s.metrics = metrics.NewRegistry(s.logger)
+		if pgDB != nil {
+			s.metrics.RegisterGauge("todo_api_db_pool_connections", "PostgreSQL pool connections by state.", metrics.PostgresPool(pgDB))
+		}
+		s.metrics.RegisterGauge("todo_api_redis_pool_connections", "Redis pool connections by state.", metrics.RedisPool(s.redisClient))
+		s.metrics.RegisterGauge("todo_api_active_sessions", "Number of active sessions.", metrics.ActiveSessions(sessionStore))
+		s.metricsHandler.SetRegistry(s.metrics)
+	}
+	s.metaHandler = handlers.NewMetaHandler(schemaRepo, s.config.Database.TodoDriver, s.logger)
 
 	s.logger.Info().Msg("Successfully initialized all dependencies.")
 	return nil
 }
+
+// ensureIndexes creates the indexes of every repository that manages its own
+func ensureIndexes(repos ...any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, repo := range repos {
+		if ensurer, ok := repo.(interfaces.IndexEnsurer); ok {
+			if err := ensurer.EnsureIndexes(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// promoteAdmins gives the admin role to the users listed in the configuration
+func promoteAdmins(authService *services.AuthService, usernames []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return authService.PromoteAdmins(ctx, usernames)
+}
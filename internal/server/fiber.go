@@ -1,7 +1,13 @@
 package server
 
 import (
+	"errors"
+	"strings"
+
+	"go-fiber/internal/models"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
 )
 
 // setupFiberApp creates and configures the Fiber application
@@ -9,20 +15,34 @@ func (s *Server) setupFiberApp() {
 	s.app = fiber.New(fiber.Config{
 		ReadTimeout:  s.config.Server.ReadTimeout,
 		WriteTimeout: s.config.Server.WriteTimeout,
+		BodyLimit:    s.config.Server.BodyLimit,
 		ErrorHandler: s.customErrorHandler(),
 		AppName:      "Go Fiber Todo API v1.0.0",
+
+		// Forwarded headers are only honoured when sent by a configured proxy
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          s.config.Server.TrustedProxies,
 	})
 }
 
-// customErrorHandler handles errors globally
+// customErrorHandler handles errors globally, answering in the standard JSON error shape.
+// Unmatched routes reach it as 404 and known paths requested with another method as 405.
 func (s *Server) customErrorHandler() fiber.ErrorHandler {
 	return func(c *fiber.Ctx, err error) error {
 		code := fiber.StatusInternalServerError
-		if e, ok := err.(*fiber.Error); ok {
-			code = e.Code
+		message := "An unexpected error occurred"
+
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			code = fiberErr.Code
+			message = fiberErr.Message
 		}
 
-		s.logger.Error().
+		event := s.logger.Warn()
+		if code >= fiber.StatusInternalServerError {
+			event = s.logger.Error()
+		}
+		event.
 			Err(err).
 			Int("status", code).
 			Str("method", c.Method()).
@@ -30,9 +50,15 @@ func (s *Server) customErrorHandler() fiber.ErrorHandler {
 			Str("ip", c.IP()).
 			Msg("Request error.")
 
-		return c.Status(code).JSON(fiber.Map{
-			"error":   "Internal Server Error",
-			"message": err.Error(),
+		return c.Status(code).JSON(models.ErrorResponse{
+			Error:   utils.StatusMessage(code),
+			Message: message,
+			Code:    errorCode(code),
 		})
 	}
 }
+
+// errorCode turns an HTTP status into a machine-readable code such as NOT_FOUND
+func errorCode(status int) string {
+	return strings.ToUpper(strings.ReplaceAll(utils.StatusMessage(status), " ", "_"))
+}
@@ -1,33 +1,59 @@
 package server
 
 import (
-	"os"
+	"go-fiber/internal/middleware"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/limiter"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 )
 
 // setupMiddleware configures all middleware
 func (s *Server) setupMiddleware() {
+	// Request ID middleware (first, so every later middleware and handler can use the ID)
+	s.app.Use(middleware.RequestID())
+
+	// Request metrics (registered before recovery so requests that panic are counted as 500s)
+	if s.metrics != nil {
+		s.app.Use(middleware.RequestMetrics(s.metrics))
+	}
+
+	// Structured access log (also before recovery, for the same reason)
+	s.app.Use(middleware.RequestLogger(s.logger))
+
 	// Recovery middleware
 	s.app.Use(recover.New())
 
-	// Logger middleware
-	if s.config.Server.Environment != "production" {
-		s.app.Use(logger.New(logger.Config{
-			Format: "${time} ${status} - ${method} ${path} - ${ip} - ${latency}\n",
-			Output: os.Stdout,
-		}))
+	// Request timeout (cancels the user context handlers pass to repositories)
+	if s.config.Server.RequestTimeout > 0 {
+		s.app.Use(middleware.RequestTimeout(s.config.Server.RequestTimeout))
+	}
+
+	// Public URL middleware (validates forwarded host headers)
+	s.app.Use(middleware.PublicURL(s.config.Server.PublicURL))
+
+	// Response compression (outside the body logger and timestamp rewriting, which need the
+	// uncompressed body; streamed exports are compressed as they are written)
+	if s.config.Server.Compression {
+		s.app.Use(middleware.Compress(s.config.Server.CompressionLevel))
+	}
+
+	// Request/response body logging for debugging clients (rejected in production by config validation)
+	if s.config.Log.Bodies {
+		s.app.Use(middleware.BodyLogger(s.logger, s.config.Log.BodyLimit))
+	}
+
+	// Timestamps in the client's timezone (registered inside the body logger so it logs what is sent)
+	if s.config.Server.LocalizeTimestamps {
+		s.app.Use(middleware.LocalizeTimestamps())
 	}
 
 	// CORS middleware
 	s.app.Use(cors.New(cors.Config{
 		AllowOrigins:     "*",
 		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders:     "Origin,Content-Type,Accept,Authorization",
+		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,X-API-Key,X-Timezone",
 		AllowCredentials: false,
 	}))
 
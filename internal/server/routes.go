@@ -1,6 +1,7 @@
 package server
 
 import (
+	"go-fiber/docs"
 	"go-fiber/internal/middleware"
 
 	fiberSwagger "github.com/swaggo/fiber-swagger"
@@ -8,28 +9,58 @@ import (
 
 // setupRoutes configures all application routes
 func (s *Server) setupRoutes() {
-	// Swagger documentation
+	// Swagger documentation (reflect the configured base path)
+	docs.SwaggerInfo.BasePath = s.config.Server.BasePath
 	s.app.Get("/swagger/*", fiberSwagger.WrapHandler)
 
 	// Health check routes
 	s.healthHandler.RegisterRoutes(s.app)
 
+	// Deployment meta routes
+	s.metaHandler.RegisterRoutes(s.app)
+
+	// Metrics routes (aggregate only, opt-in)
+	if s.config.Metrics.Enabled {
+		s.metricsHandler.RegisterRoutes(s.app)
+	}
+
 	// API routes
-	api := s.app.Group("/api/v1")
+	api := s.app.Group(s.config.Server.BasePath)
 
 	// Auth routes (no middleware required)
+	// Endpoints that check a password, code or token share one stricter per-IP limit
 	auth := api.Group("/auth")
+	authRateLimit := middleware.AuthRateLimit()
 	auth.Post("/register", s.authHandler.Register)
-	auth.Post("/login", s.authHandler.Login)
+	auth.Post("/login", authRateLimit, s.authHandler.Login)
+	auth.Post("/login/email", authRateLimit, s.authHandler.LoginByEmail)
 	auth.Post("/refresh", s.authHandler.RefreshToken)
+	auth.Post("/password/reset-request", authRateLimit, s.authHandler.RequestPasswordReset)
+	auth.Post("/password/reset", authRateLimit, s.authHandler.ResetPassword)
+	auth.Post("/2fa/challenge", authRateLimit, s.authHandler.TwoFactorChallenge)
 	auth.Post("/logout", middleware.AuthMiddleware(s.authService, s.logger), s.authHandler.Logout)
+	auth.Post("/logout/all", middleware.AuthMiddleware(s.authService, s.logger), s.authHandler.LogoutAll)
 	auth.Get("/me", middleware.AuthMiddleware(s.authService, s.logger), s.authHandler.Me)
+	auth.Delete("/me", middleware.AuthMiddleware(s.authService, s.logger), s.authHandler.DeleteAccount)
+	auth.Get("/security", middleware.AuthMiddleware(s.authService, s.logger), s.authHandler.Security)
+	auth.Get("/sessions", middleware.AuthMiddleware(s.authService, s.logger), s.authHandler.ListSessions)
+	auth.Delete("/sessions/:id", middleware.AuthMiddleware(s.authService, s.logger), s.authHandler.RevokeSession)
+	auth.Patch("/password", middleware.AuthMiddleware(s.authService, s.logger), s.authHandler.ChangePassword)
+	auth.Post("/2fa/enable", middleware.AuthMiddleware(s.authService, s.logger), s.authHandler.EnableTwoFactor)
+	auth.Post("/2fa/verify", middleware.AuthMiddleware(s.authService, s.logger), s.authHandler.VerifyTwoFactor)
+	auth.Get("/me/insights", middleware.AuthMiddleware(s.authService, s.logger), s.todoHandler.GetInsights)
 
 	// Protected routes
 	authMiddleware := middleware.AuthMiddleware(s.authService, s.logger)
 
-	// Todo routes
-	s.todoHandler.RegisterRoutes(api, authMiddleware)
+	// API key management routes (JWT only, so a key cannot mint further keys)
+	s.apiKeyHandler.RegisterRoutes(api, authMiddleware)
+
+	// Admin routes (JWT with the admin role)
+	s.adminHandler.RegisterRoutes(api, authMiddleware)
+
+	// Todo routes (an X-API-Key header or a JWT)
+	s.todoHandler.RegisterRoutes(api, middleware.APIKeyMiddleware(s.apiKeyService, authMiddleware, s.logger))
 
 	s.logger.Info().Msg("Routes setup completed.")
 }
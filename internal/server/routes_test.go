@@ -0,0 +1,274 @@
+package server
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-fiber/internal/config"
+	"go-fiber/internal/handlers"
+	"go-fiber/internal/middleware"
+	"go-fiber/internal/mocks"
+	"go-fiber/internal/models"
+	"go-fiber/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestServer creates a server wired with mock repositories
+func setupTestServer(cfg *config.Config) *Server {
+	logger := config.NewTestLogger()
+	s := New(cfg, logger)
+
+	authService := services.NewAuthService(new(mocks.MockUserRepository), new(mocks.MockSessionStore), &cfg.JWT, logger)
+	s.authService = authService
+	s.authHandler = handlers.NewAuthHandler(authService, s.validator, logger)
+	s.apiKeyService = services.NewAPIKeyService(new(mocks.MockAPIKeyRepository), new(mocks.MockUserRepository), logger)
+	s.apiKeyHandler = handlers.NewAPIKeyHandler(s.apiKeyService, s.validator, logger)
+	s.adminHandler = handlers.NewAdminHandler(new(mocks.MockUserRepository), s.validator, logger)
+	todoRepo := new(mocks.MockTodoRepository)
+	s.todoHandler = handlers.NewTodoHandler(todoRepo, services.NewTodoService(todoRepo, &cfg.Todo, logger), cfg.Server.CacheMaxAge, s.validator, logger)
+	s.metricsHandler = handlers.NewMetricsHandler(todoRepo, cfg.Metrics.CacheTTL, logger)
+	s.healthHandler = handlers.NewHealthHandler(nil, nil, nil, logger)
+	s.metaHandler = handlers.NewMetaHandler(new(mocks.MockSchemaRepository), cfg.Database.Driver, logger)
+
+	s.setupFiberApp()
+	s.setupRoutes()
+
+	return s
+}
+
+func TestSetupRoutes_BasePath(t *testing.T) {
+	t.Run("routes respond under a custom prefix", func(t *testing.T) {
+		// Arrange
+		cfg := config.NewTestConfig()
+		cfg.Server.BasePath = "/api/todo-service"
+		s := setupTestServer(cfg)
+
+		// Act
+		resp, err := s.GetApp().Test(httptest.NewRequest("GET", "/api/todo-service/todos", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 401, resp.StatusCode)
+	})
+
+	t.Run("default prefix is not registered under a custom prefix", func(t *testing.T) {
+		// Arrange
+		cfg := config.NewTestConfig()
+		cfg.Server.BasePath = "/api/todo-service"
+		s := setupTestServer(cfg)
+
+		// Act
+		resp, err := s.GetApp().Test(httptest.NewRequest("GET", "/api/v1/todos", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+
+	t.Run("health endpoints remain at root", func(t *testing.T) {
+		// Arrange
+		cfg := config.NewTestConfig()
+		cfg.Server.BasePath = "/api/todo-service"
+		s := setupTestServer(cfg)
+
+		// Act
+		resp, err := s.GetApp().Test(httptest.NewRequest("GET", "/live", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+}
+
+func TestSetupRoutes_Auth(t *testing.T) {
+	protected := []struct {
+		method string
+		path   string
+	}{
+		{"GET", "/api/v1/auth/sessions"},
+		{"DELETE", "/api/v1/auth/sessions/abc"},
+		{"POST", "/api/v1/auth/2fa/enable"},
+		{"POST", "/api/v1/auth/2fa/verify"},
+		{"GET", "/api/v1/admin/users"},
+		{"GET", "/api/v1/auth/me/insights"},
+	}
+
+	for _, route := range protected {
+		t.Run(route.method+" "+route.path+" requires authentication", func(t *testing.T) {
+			// Arrange
+			s := setupTestServer(config.NewTestConfig())
+
+			// Act
+			resp, err := s.GetApp().Test(httptest.NewRequest(route.method, route.path, nil))
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, 401, resp.StatusCode)
+		})
+	}
+
+	t.Run("insights do not accept API keys", func(t *testing.T) {
+		// Arrange
+		s := setupTestServer(config.NewTestConfig())
+		req := httptest.NewRequest("GET", "/api/v1/auth/me/insights", nil)
+		req.Header.Set("X-API-Key", "tdk_not-checked")
+
+		// Act
+		resp, err := s.GetApp().Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 401, resp.StatusCode)
+	})
+
+	public := []string{
+		"/api/v1/auth/login/email",
+		"/api/v1/auth/password/reset-request",
+		"/api/v1/auth/password/reset",
+		"/api/v1/auth/2fa/challenge",
+	}
+
+	for _, path := range public {
+		t.Run("POST "+path+" is registered", func(t *testing.T) {
+			// Arrange
+			s := setupTestServer(config.NewTestConfig())
+
+			// Act
+			resp, err := s.GetApp().Test(httptest.NewRequest("POST", path, nil))
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, 400, resp.StatusCode)
+		})
+	}
+}
+
+func TestSetupRoutes_Metrics(t *testing.T) {
+	t.Run("metrics endpoint is not registered by default", func(t *testing.T) {
+		// Arrange
+		s := setupTestServer(config.NewTestConfig())
+
+		// Act
+		resp, err := s.GetApp().Test(httptest.NewRequest("GET", "/metrics/todos", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+}
+
+func TestCustomErrorHandler(t *testing.T) {
+	t.Run("unknown path returns a JSON 404", func(t *testing.T) {
+		// Arrange
+		s := setupTestServer(config.NewTestConfig())
+
+		// Act
+		resp, err := s.GetApp().Test(httptest.NewRequest("GET", "/api/v1/does-not-exist", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("Content-Type"), "application/json")
+
+		var body models.ErrorResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "Not Found", body.Error)
+		assert.Equal(t, "NOT_FOUND", body.Code)
+	})
+
+	t.Run("wrong method on an existing route returns a JSON 405", func(t *testing.T) {
+		// Arrange
+		s := setupTestServer(config.NewTestConfig())
+
+		// Act
+		resp, err := s.GetApp().Test(httptest.NewRequest("DELETE", "/meta/schema-version", nil))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 405, resp.StatusCode)
+
+		var body models.ErrorResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "Method Not Allowed", body.Error)
+		assert.Equal(t, "METHOD_NOT_ALLOWED", body.Code)
+	})
+}
+
+func TestBodyLimits(t *testing.T) {
+	t.Run("body over the server limit returns a JSON 413", func(t *testing.T) {
+		// Arrange
+		cfg := config.NewTestConfig()
+		cfg.Server.BodyLimit = 64
+		s := setupTestServer(cfg)
+
+		// The limit is enforced while the request is read, which app.Test reports as an
+		// error instead of a response, so this goes through a real listener
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		go s.GetApp().Listener(ln)
+		defer s.GetApp().Shutdown()
+
+		// Act
+		resp, err := http.Post("http://"+ln.Addr().String()+"/api/v1/auth/register", "application/json", strings.NewReader(`{"username":"`+strings.Repeat("a", 64)+`"}`))
+
+		// Assert
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, 413, resp.StatusCode)
+
+		var body models.ErrorResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "Request Entity Too Large", body.Error)
+		assert.Equal(t, "REQUEST_ENTITY_TOO_LARGE", body.Code)
+	})
+
+	t.Run("body over a route limit returns the same JSON 413", func(t *testing.T) {
+		// Arrange
+		s := setupTestServer(config.NewTestConfig())
+		s.GetApp().Post("/limited", middleware.BodyLimit(16), func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusNoContent)
+		})
+
+		// Act
+		resp, err := s.GetApp().Test(httptest.NewRequest("POST", "/limited", strings.NewReader(strings.Repeat("x", 17))))
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 413, resp.StatusCode)
+
+		var body models.ErrorResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "Request Entity Too Large", body.Error)
+		assert.Equal(t, "Request body must not be larger than 16 bytes", body.Message)
+		assert.Equal(t, "REQUEST_ENTITY_TOO_LARGE", body.Code)
+	})
+}
+
+func TestSetupMiddleware_CORS(t *testing.T) {
+	t.Run("preflight allows the API key and timezone headers", func(t *testing.T) {
+		// Arrange
+		s := New(config.NewTestConfig(), config.NewTestLogger())
+		s.setupFiberApp()
+		s.setupMiddleware()
+
+		req := httptest.NewRequest("OPTIONS", "/api/v1/todos", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "GET")
+
+		// Act
+		resp, err := s.GetApp().Test(req)
+
+		// Assert
+		require.NoError(t, err)
+		allowed := resp.Header.Get("Access-Control-Allow-Headers")
+		assert.Contains(t, allowed, "X-API-Key")
+		assert.Contains(t, allowed, "X-Timezone")
+	})
+}
@@ -9,9 +9,11 @@ import (
 
 	"go-fiber/internal/config"
 	"go-fiber/internal/handlers"
+	"go-fiber/internal/metrics"
+	"go-fiber/internal/models"
+	"go-fiber/internal/scheduler"
 	"go-fiber/internal/services"
-
-	_ "go-fiber/docs" // Import generated docs
+	"go-fiber/internal/utils"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -26,27 +28,52 @@ type Server struct {
 	logger      zerolog.Logger
 	redisClient *redis.Client
 	validator   *validator.Validate
+	scheduler   *scheduler.Scheduler
+	metrics     *metrics.Registry
 
 	// Services
-	authService *services.AuthService
+	authService   *services.AuthService
+	apiKeyService *services.APIKeyService
 
 	// Handlers
-	authHandler   *handlers.AuthHandler
-	todoHandler   *handlers.TodoHandler
-	healthHandler *handlers.HealthHandler
+	authHandler    *handlers.AuthHandler
+	apiKeyHandler  *handlers.APIKeyHandler
+	adminHandler   *handlers.AdminHandler
+	todoHandler    *handlers.TodoHandler
+	healthHandler  *handlers.HealthHandler
+	metricsHandler *handlers.MetricsHandler
+	metaHandler    *handlers.MetaHandler
 }
 
 // New creates a new server instance with all dependencies
 func New(cfg *config.Config, logger zerolog.Logger) *Server {
+	// Apply configured todo domain values before any validation happens
+	models.SetPriorities(cfg.Todo.Priorities, cfg.Todo.DefaultPriority)
+	models.SetStatuses(cfg.Todo.Statuses, cfg.Todo.DefaultStatus, cfg.Todo.DoneStatus)
+	models.SetDescriptionMax(cfg.Todo.DescriptionMax)
+	models.SetOptionalFieldsPolicy(cfg.Server.JSONOptionalFields)
+	utils.SetExposeErrorDetails(cfg.Server.ExposeErrorDetails)
+
+	validate := validator.New()
+	models.RegisterValidations(validate)
+
 	return &Server{
 		config:    cfg,
 		logger:    logger,
-		validator: validator.New(),
+		validator: validate,
+		scheduler: scheduler.New(cfg.Scheduler.Workers, logger),
 	}
 }
 
 // Initialize sets up all dependencies and configurations
 func (s *Server) Initialize() error {
+	if s.config.JWT.SecretGenerated {
+		s.logger.Warn().Msg("JWT_SECRET is not set, using a generated secret. Tokens will stop working when the server restarts.")
+	}
+	if s.config.Database.DriverDefaulted {
+		s.logger.Warn().Msg("DATABASE_DRIVER is not set and both database URLs are configured, using postgres. Set DATABASE_DRIVER to choose explicitly.")
+	}
+
 	// Setup Fiber app
 	s.setupFiberApp()
 
@@ -76,6 +103,9 @@ func (s *Server) Start() error {
 		return err
 	}
 
+	// Start background jobs registered during initialization
+	s.scheduler.Start(context.Background())
+
 	// Start server in a goroutine
 	go func() {
 		address := s.config.GetAddress()
@@ -105,6 +135,9 @@ func (s *Server) Start() error {
 		return err
 	}
 
+	// Stop background jobs before closing the connections they use
+	s.scheduler.Stop()
+
 	// Close Redis connection
 	if s.redisClient != nil {
 		if err := s.redisClient.Close(); err != nil {
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-fiber/internal/models"
+	"go-fiber/internal/repository/interfaces"
+
+	"github.com/rs/zerolog"
+)
+
+// ErrInvalidAPIKey is returned when an API key is malformed, unknown or revoked, or its user
+// no longer exists
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// apiKeyDisplayLength is how many leading characters of a key are kept to tell keys apart
+const apiKeyDisplayLength = len(models.APIKeyPrefix) + 8
+
+// apiKeyLastUsedResolution is how stale a key's last used time may get before a request
+// updates it, so busy keys do not write on every request
+const apiKeyLastUsedResolution = time.Minute
+
+// APIKeyService manages API keys and authenticates the requests that carry them
+type APIKeyService struct {
+	apiKeyRepo interfaces.APIKeyRepository
+	userRepo   interfaces.UserRepository
+	logger     zerolog.Logger
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(apiKeyRepo interfaces.APIKeyRepository, userRepo interfaces.UserRepository, logger zerolog.Logger) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+		logger:     logger,
+	}
+}
+
+// Create generates a new API key for the user. The plaintext key is returned only here; just
+// its hash is stored.
+func (s *APIKeyService) Create(ctx context.Context, userID string, req *models.CreateAPIKeyRequest) (string, *models.APIKey, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	key := models.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	apiKey, err := s.apiKeyRepo.Create(ctx, &models.APIKey{
+		UserID:  userID,
+		Name:    req.Name,
+		Prefix:  key[:apiKeyDisplayLength],
+		KeyHash: hashAPIKey(key),
+		Scopes:  req.Scopes,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	s.logger.Info().Str("user_id", userID).Str("api_key_id", apiKey.ID).Strs("scopes", apiKey.Scopes).Msg("API key created.")
+	return key, apiKey, nil
+}
+
+// List returns the user's API keys, newest first
+func (s *APIKeyService) List(ctx context.Context, userID string) ([]*models.APIKey, error) {
+	keys, err := s.apiKeyRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke deletes one of the user's API keys; it stops working at once. Keys of other users
+// are reported as interfaces.ErrAPIKeyNotFound.
+func (s *APIKeyService) Revoke(ctx context.Context, userID, keyID string) error {
+	if err := s.apiKeyRepo.Delete(ctx, keyID, userID); err != nil {
+		if errors.Is(err, interfaces.ErrAPIKeyNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	s.logger.Info().Str("user_id", userID).Str("api_key_id", keyID).Msg("API key revoked.")
+	return nil
+}
+
+// Authenticate resolves a plaintext API key to the key and the user it belongs to
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (*models.APIKey, *models.User, error) {
+	if !strings.HasPrefix(key, models.APIKeyPrefix) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.apiKeyRepo.GetByHash(ctx, hashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, interfaces.ErrAPIKeyNotFound) {
+			return nil, nil, ErrInvalidAPIKey
+		}
+		return nil, nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	// A deleted user's keys stop working even though they are kept
+	user, err := s.userRepo.GetByID(ctx, apiKey.UserID)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("api_key_id", apiKey.ID).Str("user_id", apiKey.UserID).Msg("API key user not found.")
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyLastUsedResolution {
+		if err := s.apiKeyRepo.UpdateLastUsed(ctx, apiKey.ID, now); err != nil {
+			s.logger.Warn().Err(err).Str("api_key_id", apiKey.ID).Msg("Failed to record API key use.")
+		}
+	}
+
+	return apiKey, user, nil
+}
+
+// hashAPIKey returns the stored hash of an API key. Keys are random, so a fast hash is
+// enough and lets keys be looked up by hash.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
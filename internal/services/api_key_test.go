@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-fiber/internal/mocks"
+	"go-fiber/internal/models"
+	"go-fiber/internal/repository/interfaces"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyService_Create(t *testing.T) {
+	// Arrange
+	mockAPIKeyRepo := new(mocks.MockAPIKeyRepository)
+	service := NewAPIKeyService(mockAPIKeyRepo, new(mocks.MockUserRepository), zerolog.Nop())
+	ctx := context.Background()
+
+	var stored *models.APIKey
+	mockAPIKeyRepo.On("Create", ctx, mock.AnythingOfType("*models.APIKey")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*models.APIKey) }).
+		Return(&models.APIKey{ID: "key-1", UserID: "user-1", Name: "ci", Scopes: []string{models.APIKeyScopeTodosRead}}, nil)
+
+	// Act
+	key, apiKey, err := service.Create(ctx, "user-1", &models.CreateAPIKeyRequest{Name: "ci", Scopes: []string{models.APIKeyScopeTodosRead}})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "key-1", apiKey.ID)
+	assert.True(t, strings.HasPrefix(key, models.APIKeyPrefix))
+	assert.Equal(t, "user-1", stored.UserID)
+	assert.Equal(t, key[:apiKeyDisplayLength], stored.Prefix)
+	assert.Equal(t, hashAPIKey(key), stored.KeyHash)
+	assert.NotContains(t, stored.KeyHash, key)
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	const key = models.APIKeyPrefix + "secret"
+	user := &models.User{ID: "user-1", Username: "alice"}
+
+	t.Run("valid key resolves to its user and records the use", func(t *testing.T) {
+		// Arrange
+		mockAPIKeyRepo := new(mocks.MockAPIKeyRepository)
+		mockUserRepo := new(mocks.MockUserRepository)
+		service := NewAPIKeyService(mockAPIKeyRepo, mockUserRepo, zerolog.Nop())
+		ctx := context.Background()
+
+		stored := &models.APIKey{ID: "key-1", UserID: "user-1", Scopes: []string{models.APIKeyScopeTodosRead}}
+		mockAPIKeyRepo.On("GetByHash", ctx, hashAPIKey(key)).Return(stored, nil)
+		mockUserRepo.On("GetByID", ctx, "user-1").Return(user, nil)
+		mockAPIKeyRepo.On("UpdateLastUsed", ctx, "key-1", mock.AnythingOfType("time.Time")).Return(nil)
+
+		// Act
+		apiKey, result, err := service.Authenticate(ctx, key)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, stored, apiKey)
+		assert.Equal(t, user, result)
+		mockAPIKeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("recently used key is not written again", func(t *testing.T) {
+		// Arrange
+		mockAPIKeyRepo := new(mocks.MockAPIKeyRepository)
+		mockUserRepo := new(mocks.MockUserRepository)
+		service := NewAPIKeyService(mockAPIKeyRepo, mockUserRepo, zerolog.Nop())
+		ctx := context.Background()
+
+		lastUsed := time.Now().Add(-10 * time.Second)
+		mockAPIKeyRepo.On("GetByHash", ctx, hashAPIKey(key)).Return(&models.APIKey{ID: "key-1", UserID: "user-1", LastUsedAt: &lastUsed}, nil)
+		mockUserRepo.On("GetByID", ctx, "user-1").Return(user, nil)
+
+		// Act
+		_, _, err := service.Authenticate(ctx, key)
+
+		// Assert
+		require.NoError(t, err)
+		mockAPIKeyRepo.AssertNotCalled(t, "UpdateLastUsed", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("key without the prefix is rejected without a lookup", func(t *testing.T) {
+		// Arrange
+		mockAPIKeyRepo := new(mocks.MockAPIKeyRepository)
+		service := NewAPIKeyService(mockAPIKeyRepo, new(mocks.MockUserRepository), zerolog.Nop())
+
+		// Act
+		_, _, err := service.Authenticate(context.Background(), "secret")
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+		mockAPIKeyRepo.AssertNotCalled(t, "GetByHash", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		// Arrange
+		mockAPIKeyRepo := new(mocks.MockAPIKeyRepository)
+		service := NewAPIKeyService(mockAPIKeyRepo, new(mocks.MockUserRepository), zerolog.Nop())
+		ctx := context.Background()
+		mockAPIKeyRepo.On("GetByHash", ctx, hashAPIKey(key)).Return(nil, interfaces.ErrAPIKeyNotFound)
+
+		// Act
+		_, _, err := service.Authenticate(ctx, key)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	})
+
+	t.Run("key of a deleted user", func(t *testing.T) {
+		// Arrange
+		mockAPIKeyRepo := new(mocks.MockAPIKeyRepository)
+		mockUserRepo := new(mocks.MockUserRepository)
+		service := NewAPIKeyService(mockAPIKeyRepo, mockUserRepo, zerolog.Nop())
+		ctx := context.Background()
+		mockAPIKeyRepo.On("GetByHash", ctx, hashAPIKey(key)).Return(&models.APIKey{ID: "key-1", UserID: "user-1"}, nil)
+		mockUserRepo.On("GetByID", ctx, "user-1").Return(nil, errors.New("user not found"))
+
+		// Act
+		_, _, err := service.Authenticate(ctx, key)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	})
+}
+
+func TestAPIKeyService_Revoke(t *testing.T) {
+	// Arrange
+	mockAPIKeyRepo := new(mocks.MockAPIKeyRepository)
+	service := NewAPIKeyService(mockAPIKeyRepo, new(mocks.MockUserRepository), zerolog.Nop())
+	ctx := context.Background()
+	mockAPIKeyRepo.On("Delete", ctx, "key-1", "user-1").Return(nil)
+	mockAPIKeyRepo.On("Delete", ctx, "key-2", "user-1").Return(interfaces.ErrAPIKeyNotFound)
+
+	// Act
+	revoked := service.Revoke(ctx, "user-1", "key-1")
+	missing := service.Revoke(ctx, "user-1", "key-2")
+
+	// Assert
+	assert.NoError(t, revoked)
+	assert.ErrorIs(t, missing, interfaces.ErrAPIKeyNotFound)
+}
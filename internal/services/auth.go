@@ -3,7 +3,11 @@ package services
 import (
 	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"go-fiber/internal/config"
@@ -23,6 +27,45 @@ type AuthService struct {
 	config       *config.JWTConfig
 	logger       zerolog.Logger
 	bcryptCost   int
+
+	// jwtKeys sign and verify tokens with the configured algorithm; jwtKeysErr is why they
+	// could not be loaded, failing every token operation
+	jwtKeys    *config.JWTKeys
+	jwtKeysErr error
+
+	// passwordMinScore rejects new passwords scoring below it; 0 disables the check
+	passwordMinScore int
+
+	// resetTokens and resetSender back the password reset flow; resetTTL is a token's lifetime
+	resetTokens ResetTokenStore
+	resetSender ResetTokenSender
+	resetTTL    time.Duration
+
+	// twoFactor makes logins of users with TOTP enabled wait for a code; totpCipher encrypts
+	// their secrets and is nil when no key is configured
+	twoFactor  bool
+	totpCipher *totpCipher
+	totpIssuer string
+
+	// challengeAttempts counts wrong codes per login challenge and marks answered
+	// challenges as used; nil leaves challenges usable until they expire
+	challengeAttempts LoginAttemptStore
+
+	// loginAttempts counts failed logins per account, locking it for lockoutDuration after
+	// lockoutThreshold failures within lockoutWindow; nil disables the lockout
+	loginAttempts    LoginAttemptStore
+	lockoutThreshold int64
+	lockoutWindow    time.Duration
+	lockoutDuration  time.Duration
+
+	// deletePolicy decides what happens to a user's todos when they delete their account
+	deletePolicy string
+
+	// apiKeyRepo revokes a deleted account's API keys; nil leaves them in place
+	apiKeyRepo interfaces.APIKeyRepository
+
+	dummyHashOnce sync.Once
+	dummyHash     []byte
 }
 
 // SessionStore interface for session management
@@ -30,7 +73,125 @@ type SessionStore interface {
 	Set(ctx context.Context, sessionID string, session *models.Session, expiration time.Duration) error
 	Get(ctx context.Context, sessionID string) (*models.Session, error)
 	Delete(ctx context.Context, sessionID string) error
-	DeleteUserSessions(ctx context.Context, userID string) error
+	DeleteUserSessions(ctx context.Context, userID string) (int64, error)
+	DeleteOtherUserSessions(ctx context.Context, userID, keepSessionID string) (int64, error)
+	GetUserSessions(ctx context.Context, userID string) ([]*models.Session, error)
+}
+
+// ResetTokenStore keeps single-use password reset tokens
+type ResetTokenStore interface {
+	Create(ctx context.Context, userID string, expiration time.Duration) (string, error)
+	Get(ctx context.Context, token string) (string, error)
+	Consume(ctx context.Context, token string) (string, error)
+}
+
+// LoginAttemptStore counts failed logins and keeps account lockouts
+type LoginAttemptStore interface {
+	RecordFailure(ctx context.Context, key string, window time.Duration) (int64, error)
+	Lock(ctx context.Context, key string, duration time.Duration) error
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// ResetTokenSender delivers a password reset token to the user it was issued for
+type ResetTokenSender interface {
+	SendResetToken(ctx context.Context, user *models.User, token string, expiresAt time.Time) error
+}
+
+// ErrSessionNotFound is returned for sessions that do not exist, have expired or belong to
+// another user
+var ErrSessionNotFound = errors.New("session not found")
+
+// ErrInvalidCredentials is returned when a login names an unknown user or the wrong password
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// ErrInvalidRefreshToken is returned for refresh tokens that fail verification
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// ErrInvalidSession is returned when a refresh token's session is missing or no longer active
+var ErrInvalidSession = errors.New("invalid session")
+
+// ErrSessionExpired is returned when a refresh token's session has expired
+var ErrSessionExpired = errors.New("session expired")
+
+// ErrInvalidCurrentPassword is returned when a password change is confirmed with the wrong
+// current password
+var ErrInvalidCurrentPassword = errors.New("invalid current password")
+
+// ErrIncorrectPassword is returned when an account deletion is confirmed with the wrong password
+var ErrIncorrectPassword = errors.New("incorrect password")
+
+// ErrInvalidResetToken is returned for password reset tokens that are unknown, expired or
+// already used
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+// LogResetTokenSender records issued reset tokens in the debug log. It stands in for a real
+// delivery channel such as email. The token itself is only logged when includeToken is set,
+// which is meant for development.
+type LogResetTokenSender struct {
+	logger       zerolog.Logger
+	includeToken bool
+}
+
+// NewLogResetTokenSender creates a reset token sender that logs at debug level
+func NewLogResetTokenSender(logger zerolog.Logger, includeToken bool) *LogResetTokenSender {
+	return &LogResetTokenSender{logger: logger, includeToken: includeToken}
+}
+
+// SendResetToken logs that a token was issued for the user
+func (s *LogResetTokenSender) SendResetToken(_ context.Context, user *models.User, token string, expiresAt time.Time) error {
+	event := s.logger.Debug().Str("user_id", user.ID).Time("expires_at", expiresAt)
+	if s.includeToken {
+		event = event.Str("reset_token", token)
+	}
+	event.Msg("Password reset token issued.")
+	return nil
+}
+
+// Two-factor authentication errors
+var (
+	ErrTwoFactorNotConfigured    = errors.New("two-factor authentication is not configured")
+	ErrTwoFactorAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled      = errors.New("two-factor authentication has not been set up")
+	ErrInvalidTwoFactorChallenge = errors.New("invalid or expired two-factor challenge")
+)
+
+// twoFactorChallengeTTL is how long a login can wait for its two-factor code
+const twoFactorChallengeTTL = 5 * time.Minute
+
+// maxTwoFactorChallengeAttempts is how many wrong codes a login challenge takes before it
+// is spent
+const maxTwoFactorChallengeAttempts = 5
+
+// TwoFactorRequiredError stops a login of a user with two-factor authentication enabled.
+// The challenge token is exchanged with a code for the session tokens.
+type TwoFactorRequiredError struct {
+	ChallengeToken string
+	ExpiresAt      time.Time
+}
+
+func (e *TwoFactorRequiredError) Error() string {
+	return "two-factor authentication required"
+}
+
+// AccountLockedError rejects a login to an account locked after repeated failed logins
+type AccountLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *AccountLockedError) Error() string {
+	return "account temporarily locked"
+}
+
+// WeakPasswordError rejects a new password that scores below the configured minimum
+type WeakPasswordError struct {
+	Score       int
+	MinScore    int
+	Suggestions []string
+}
+
+func (e *WeakPasswordError) Error() string {
+	return fmt.Sprintf("password is too easy to guess (score %d, minimum %d)", e.Score, e.MinScore)
 }
 
 // NewAuthService creates a new authentication service
@@ -40,17 +201,31 @@ func NewAuthService(
 	config *config.JWTConfig,
 	logger zerolog.Logger,
 ) *AuthService {
+	// Startup validation has already checked the keys, so this only fails with a config
+	// that skipped it
+	jwtKeys, err := config.Keys()
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to load JWT keys.")
+	}
+
 	return &AuthService{
 		userRepo:     userRepo,
 		sessionStore: sessionStore,
 		config:       config,
 		logger:       logger,
 		bcryptCost:   bcrypt.DefaultCost,
+		jwtKeys:      jwtKeys,
+		jwtKeysErr:   err,
+		deletePolicy: models.UserDeletePolicyCascade,
 	}
 }
 
 // Register creates a new user account
 func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest) (*models.RegisterResponse, error) {
+	if err := s.checkPasswordStrength(req.Password, req.Username, req.Email); err != nil {
+		return nil, err
+	}
+
 	// Check if username already exists
 	exists, err := s.userRepo.ExistsByUsername(ctx, req.Username)
 	if err != nil {
@@ -58,7 +233,7 @@ func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest)
 		return nil, fmt.Errorf("failed to check username: %w", err)
 	}
 	if exists {
-		return nil, fmt.Errorf("username already exists")
+		return nil, interfaces.ErrUsernameTaken
 	}
 
 	// Check if email already exists (if provided)
@@ -69,7 +244,7 @@ func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest)
 			return nil, fmt.Errorf("failed to check email: %w", err)
 		}
 		if exists {
-			return nil, fmt.Errorf("email already exists")
+			return nil, interfaces.ErrEmailTaken
 		}
 	}
 
@@ -90,6 +265,9 @@ func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest)
 
 	createdUser, err := s.userRepo.Create(ctx, user)
 	if err != nil {
+		if errors.Is(err, interfaces.ErrUsernameTaken) || errors.Is(err, interfaces.ErrEmailTaken) {
+			return nil, err
+		}
 		s.logger.Error().Err(err).Str("username", req.Username).Msg("Failed to create user.")
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
@@ -108,72 +286,182 @@ func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest) (*mod
 	user, err := s.userRepo.GetByUsername(ctx, req.Username)
 	if err != nil {
 		s.logger.Error().Err(err).Str("username", req.Username).Msg("Failed to get user by username.")
-		return nil, fmt.Errorf("invalid credentials")
+		user = nil
 	}
 
 	// Verify password
-	if err := s.verifyPassword(user.Password, req.Password); err != nil {
-		s.logger.Warn().Str("username", req.Username).Msg("Invalid password attempt.")
-		return nil, fmt.Errorf("invalid credentials")
+	if err := s.authenticate(ctx, user, req.Username, req.Password); err != nil {
+		s.logger.Warn().Err(err).Str("username", req.Username).Msg("Invalid login attempt.")
+		return nil, err
 	}
 
-	// Generate session ID
-	entropy := ulid.Monotonic(rand.Reader, 0)
-	sessionID := ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
+	response, err := s.completeLogin(ctx, user, req.UserAgent, req.IP)
+	if err != nil {
+		return nil, err
+	}
 
-	// Create session
-	session := &models.Session{
-		ID:        sessionID,
-		UserID:    user.ID,
-		CreatedAt: time.Now(),
-		ExpiresAt: time.Now().Add(s.config.RefreshExpiry),
-		IsActive:  true,
+	s.logger.Info().Str("user_id", user.ID).Str("username", user.Username).Str("ip", req.IP).Str("user_agent", req.UserAgent).Msg("User logged in successfully.")
+	return response, nil
+}
+
+// LoginByEmail authenticates a user by email and returns JWT tokens
+func (s *AuthService) LoginByEmail(ctx context.Context, req *models.LoginByEmailRequest) (*models.LoginResponse, error) {
+	// Get user by email
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		s.logger.Error().Err(err).Str("email", req.Email).Msg("Failed to get user by email.")
+		user = nil
 	}
 
-	// Store session
-	if err := s.sessionStore.Set(ctx, sessionID, session, s.config.RefreshExpiry); err != nil {
-		s.logger.Error().Err(err).Str("session_id", sessionID).Msg("Failed to store session.")
-		return nil, fmt.Errorf("failed to create session: %w", err)
+	// Verify password
+	if err := s.authenticate(ctx, user, req.Email, req.Password); err != nil {
+		s.logger.Warn().Err(err).Str("email", req.Email).Msg("Invalid login attempt.")
+		return nil, err
 	}
 
-	// Generate tokens
-	accessToken, err := s.generateAccessToken(user.ID, user.Username, sessionID)
+	response, err := s.completeLogin(ctx, user, req.UserAgent, req.IP)
 	if err != nil {
-		s.logger.Error().Err(err).Str("user_id", user.ID).Msg("Failed to generate access token.")
-		return nil, fmt.Errorf("failed to generate access token: %w", err)
+		return nil, err
 	}
 
-	refreshToken, err := s.generateRefreshToken(user.ID, user.Username, sessionID)
+	s.logger.Info().Str("user_id", user.ID).Str("email", req.Email).Str("ip", req.IP).Str("user_agent", req.UserAgent).Msg("User logged in successfully.")
+	return response, nil
+}
+
+// LoginByIdentifier authenticates a user by an identifier that is treated as an
+// email when it contains "@" and as a username otherwise
+func (s *AuthService) LoginByIdentifier(ctx context.Context, req *models.LoginRequest) (*models.LoginResponse, error) {
+	lookup, field := s.userRepo.GetByUsername, "username"
+	if strings.Contains(req.Identifier, "@") {
+		lookup, field = s.userRepo.GetByEmail, "email"
+	}
+
+	// Get user by email or username
+	user, err := lookup(ctx, req.Identifier)
 	if err != nil {
-		s.logger.Error().Err(err).Str("user_id", user.ID).Msg("Failed to generate refresh token.")
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+		s.logger.Error().Err(err).Str(field, req.Identifier).Msg("Failed to get user by login identifier.")
+		user = nil
 	}
 
-	s.logger.Info().Str("user_id", user.ID).Str("username", user.Username).Msg("User logged in successfully.")
+	// Verify password
+	if err := s.authenticate(ctx, user, req.Identifier, req.Password); err != nil {
+		s.logger.Warn().Err(err).Str(field, req.Identifier).Msg("Invalid login attempt.")
+		return nil, err
+	}
 
-	return &models.LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresAt:    time.Now().Add(s.config.AccessExpiry),
-		User:         user.ToResponse(),
-	}, nil
+	response, err := s.completeLogin(ctx, user, req.UserAgent, req.IP)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info().Str("user_id", user.ID).Str(field, req.Identifier).Str("ip", req.IP).Str("user_agent", req.UserAgent).Msg("User logged in successfully.")
+	return response, nil
 }
 
-// LoginByEmail authenticates a user by email and returns JWT tokens
-func (s *AuthService) LoginByEmail(ctx context.Context, req *models.LoginByEmailRequest) (*models.LoginResponse, error) {
-	// Get user by email
-	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+// authenticate verifies the password of a login. user is nil when no account matched the
+// identifier. Failures are counted per account, and per identifier for unknown ones, so
+// unknown accounts lock like real ones.
+func (s *AuthService) authenticate(ctx context.Context, user *models.User, identifier, password string) error {
+	key := loginAttemptKey(user, identifier)
+	if err := s.checkLockout(ctx, key); err != nil {
+		return err
+	}
+
+	if user == nil {
+		s.compareDummyPassword(password)
+		return s.loginFailed(ctx, key)
+	}
+	if err := s.verifyPassword(user.Password, password); err != nil {
+		return s.loginFailed(ctx, key)
+	}
+
+	if s.loginAttempts != nil {
+		if err := s.loginAttempts.Reset(ctx, key); err != nil {
+			s.logger.Warn().Err(err).Str("user_id", user.ID).Msg("Failed to reset failed login count.")
+		}
+	}
+	return nil
+}
+
+// loginAttemptKey identifies the account a login is for
+func loginAttemptKey(user *models.User, identifier string) string {
+	if user != nil {
+		return "user:" + user.ID
+	}
+	return "identifier:" + strings.ToLower(strings.TrimSpace(identifier))
+}
+
+// checkLockout returns an AccountLockedError while the account is locked. Errors of the
+// store do not block logins.
+func (s *AuthService) checkLockout(ctx context.Context, key string) error {
+	if s.loginAttempts == nil {
+		return nil
+	}
+
+	retryAfter, err := s.loginAttempts.LockedFor(ctx, key)
 	if err != nil {
-		s.logger.Error().Err(err).Str("email", req.Email).Msg("Failed to get user by email.")
-		return nil, fmt.Errorf("invalid credentials")
+		s.logger.Warn().Err(err).Msg("Failed to check account lockout.")
+		return nil
 	}
+	if retryAfter > 0 {
+		return &AccountLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
 
-	// Verify password
-	if err := s.verifyPassword(user.Password, req.Password); err != nil {
-		s.logger.Warn().Str("email", req.Email).Msg("Invalid password attempt.")
-		return nil, fmt.Errorf("invalid credentials")
+// loginFailed counts a failed login and locks the account once the failures reach the
+// threshold, returning the error to reject the login with
+func (s *AuthService) loginFailed(ctx context.Context, key string) error {
+	if s.loginAttempts == nil {
+		return ErrInvalidCredentials
 	}
 
+	failures, err := s.loginAttempts.RecordFailure(ctx, key, s.lockoutWindow)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to record failed login.")
+		return ErrInvalidCredentials
+	}
+	if failures < s.lockoutThreshold {
+		return ErrInvalidCredentials
+	}
+
+	if err := s.loginAttempts.Lock(ctx, key, s.lockoutDuration); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to lock account.")
+		return ErrInvalidCredentials
+	}
+	s.logger.Warn().Int64("failures", failures).Dur("duration", s.lockoutDuration).Msg("Account locked after repeated failed logins.")
+	return &AccountLockedError{RetryAfter: s.lockoutDuration}
+}
+
+// completeLogin starts a session for a user whose password was verified, unless the user
+// has two-factor authentication enabled, in which case a TwoFactorRequiredError carries the
+// challenge to answer with a code
+func (s *AuthService) completeLogin(ctx context.Context, user *models.User, userAgent, ip string) (*models.LoginResponse, error) {
+	if s.twoFactor {
+		totp, err := s.userRepo.GetTOTP(ctx, user.ID)
+		if err != nil {
+			s.logger.Error().Err(err).Str("user_id", user.ID).Msg("Failed to get two-factor state on login.")
+			return nil, fmt.Errorf("failed to get two-factor state: %w", err)
+		}
+
+		if totp.Enabled {
+			challenge, expiresAt, err := s.generateChallengeToken(user.ID, user.Username)
+			if err != nil {
+				s.logger.Error().Err(err).Str("user_id", user.ID).Msg("Failed to generate two-factor challenge.")
+				return nil, fmt.Errorf("failed to generate two-factor challenge: %w", err)
+			}
+
+			s.logger.Info().Str("user_id", user.ID).Msg("Login waiting for two-factor code.")
+			return nil, &TwoFactorRequiredError{ChallengeToken: challenge, ExpiresAt: expiresAt}
+		}
+	}
+
+	return s.startSession(ctx, user, userAgent, ip)
+}
+
+// startSession stores a new session for an authenticated user and issues its tokens. The
+// user agent and IP of the login are kept on the session so the user can recognize it.
+func (s *AuthService) startSession(ctx context.Context, user *models.User, userAgent, ip string) (*models.LoginResponse, error) {
 	// Generate session ID
 	entropy := ulid.Monotonic(rand.Reader, 0)
 	sessionID := ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
@@ -185,6 +473,8 @@ func (s *AuthService) LoginByEmail(ctx context.Context, req *models.LoginByEmail
 		CreatedAt: time.Now(),
 		ExpiresAt: time.Now().Add(s.config.RefreshExpiry),
 		IsActive:  true,
+		UserAgent: userAgent,
+		IP:        ip,
 	}
 
 	// Store session
@@ -194,20 +484,18 @@ func (s *AuthService) LoginByEmail(ctx context.Context, req *models.LoginByEmail
 	}
 
 	// Generate tokens
-	accessToken, err := s.generateAccessToken(user.ID, user.Username, sessionID)
+	accessToken, err := s.generateAccessToken(user.ID, user.Username, user.RoleOrDefault(), sessionID)
 	if err != nil {
 		s.logger.Error().Err(err).Str("user_id", user.ID).Msg("Failed to generate access token.")
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	refreshToken, err := s.generateRefreshToken(user.ID, user.Username, sessionID)
+	refreshToken, err := s.generateRefreshToken(user.ID, user.Username, user.RoleOrDefault(), sessionID)
 	if err != nil {
 		s.logger.Error().Err(err).Str("user_id", user.ID).Msg("Failed to generate refresh token.")
 		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
-	s.logger.Info().Str("user_id", user.ID).Str("email", req.Email).Msg("User logged in successfully.")
-
 	return &models.LoginResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
@@ -222,24 +510,31 @@ func (s *AuthService) RefreshToken(ctx context.Context, req *models.RefreshToken
 	claims, err := s.validateToken(req.RefreshToken, models.TokenTypeRefresh)
 	if err != nil {
 		s.logger.Error().Err(err).Msg("Invalid refresh token.")
-		return nil, fmt.Errorf("invalid refresh token")
+		return nil, ErrInvalidRefreshToken
 	}
 
 	// Get session
 	session, err := s.sessionStore.Get(ctx, claims.SessionID)
 	if err != nil {
 		s.logger.Error().Err(err).Str("session_id", claims.SessionID).Msg("Failed to get session.")
-		return nil, fmt.Errorf("invalid session")
+		return nil, ErrInvalidSession
 	}
 
 	// Check if session is active and not expired
 	if !session.IsActive || time.Now().After(session.ExpiresAt) {
 		s.logger.Warn().Str("session_id", claims.SessionID).Msg("Session is inactive or expired.")
-		return nil, fmt.Errorf("session expired")
+		return nil, ErrSessionExpired
+	}
+
+	// Re-read the user so a changed role applies from the next access token
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("user_id", claims.UserID).Msg("Failed to get user for token refresh.")
+		return nil, ErrInvalidSession
 	}
 
 	// Generate new access token
-	accessToken, err := s.generateAccessToken(claims.UserID, claims.Username, claims.SessionID)
+	accessToken, err := s.generateAccessToken(user.ID, user.Username, user.RoleOrDefault(), claims.SessionID)
 	if err != nil {
 		s.logger.Error().Err(err).Str("user_id", claims.UserID).Msg("Failed to generate access token.")
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
@@ -273,6 +568,495 @@ func (s *AuthService) Logout(ctx context.Context, req *models.LogoutRequest) (*m
 	}, nil
 }
 
+// ChangePassword replaces the user's password after checking the current one, optionally
+// ending all of the user's sessions other than currentSessionID
+func (s *AuthService) ChangePassword(ctx context.Context, userID, currentSessionID string, req *models.UpdatePasswordRequest) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get user for password change.")
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Verify current password
+	if err := s.verifyPassword(user.Password, req.CurrentPassword); err != nil {
+		s.logger.Warn().Str("user_id", userID).Msg("Invalid current password on password change.")
+		return ErrInvalidCurrentPassword
+	}
+
+	if err := s.checkPasswordStrength(req.NewPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	// Hash and store new password
+	hashedPassword, err := s.hashPassword(req.NewPassword)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to hash password.")
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to update password.")
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	s.logger.Info().Str("user_id", userID).Msg("Password changed successfully.")
+
+	if req.LogoutOtherSessions {
+		if err := s.endOtherSessions(ctx, userID, currentSessionID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RequestPasswordReset issues a reset token for the user with the email and hands it to the
+// reset token sender. Unknown emails are not reported, so callers cannot learn which emails
+// have an account.
+func (s *AuthService) RequestPasswordReset(ctx context.Context, email string) error {
+	if s.resetTokens == nil || s.resetSender == nil {
+		return fmt.Errorf("password reset is not configured")
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		s.logger.Info().Err(err).Str("email", email).Msg("Password reset requested for unknown email.")
+		return nil
+	}
+
+	token, err := s.resetTokens.Create(ctx, user.ID, s.resetTTL)
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", user.ID).Msg("Failed to create password reset token.")
+		return fmt.Errorf("failed to create reset token: %w", err)
+	}
+
+	if err := s.resetSender.SendResetToken(ctx, user, token, time.Now().Add(s.resetTTL)); err != nil {
+		s.logger.Error().Err(err).Str("user_id", user.ID).Msg("Failed to send password reset token.")
+		return fmt.Errorf("failed to send reset token: %w", err)
+	}
+
+	s.logger.Info().Str("user_id", user.ID).Msg("Password reset requested.")
+	return nil
+}
+
+// ResetPassword sets a new password for the user a reset token was issued to and ends all of
+// their sessions. The token is used up by a successful reset; a weak new password leaves it
+// usable for another try.
+func (s *AuthService) ResetPassword(ctx context.Context, req *models.ResetPasswordRequest) error {
+	if s.resetTokens == nil {
+		return fmt.Errorf("password reset is not configured")
+	}
+
+	userID, err := s.resetTokens.Get(ctx, req.Token)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get user for password reset.")
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := s.checkPasswordStrength(req.NewPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hashPassword(req.NewPassword)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to hash password.")
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	// Use the token up before changing the password, so a concurrent reset with the same
+	// token fails here
+	if _, err := s.resetTokens.Consume(ctx, req.Token); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to update password.")
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if _, err := s.sessionStore.DeleteUserSessions(ctx, userID); err != nil {
+		s.logger.Warn().Err(err).Str("user_id", userID).Msg("Failed to end sessions after password reset.")
+	}
+
+	s.logger.Info().Str("user_id", userID).Msg("Password reset successfully.")
+	return nil
+}
+
+// EnableTwoFactor generates a new TOTP secret for the user and stores it encrypted. It
+// only takes effect once a code from it is confirmed with VerifyTwoFactor; calling it again
+// before then replaces the secret.
+func (s *AuthService) EnableTwoFactor(ctx context.Context, userID string) (*models.TOTPSetupResponse, error) {
+	if s.totpCipher == nil {
+		return nil, ErrTwoFactorNotConfigured
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get user for two-factor setup.")
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	totp, err := s.userRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get two-factor state.")
+		return nil, fmt.Errorf("failed to get two-factor state: %w", err)
+	}
+	if totp.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	key, err := newTOTPKey(s.totpIssuer, user.Username)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to generate TOTP secret.")
+		return nil, fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+
+	encrypted, err := s.totpCipher.encrypt(key.Secret())
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to encrypt TOTP secret.")
+		return nil, fmt.Errorf("failed to encrypt TOTP secret: %w", err)
+	}
+
+	if err := s.userRepo.UpdateTOTP(ctx, userID, encrypted, false); err != nil {
+		return nil, fmt.Errorf("failed to store TOTP secret: %w", err)
+	}
+
+	s.logger.Info().Str("user_id", userID).Msg("Two-factor setup started.")
+	return &models.TOTPSetupResponse{
+		Secret:     key.Secret(),
+		OTPAuthURL: key.URL(),
+	}, nil
+}
+
+// VerifyTwoFactor turns on two-factor authentication once the user proves their
+// authenticator has the secret from EnableTwoFactor
+func (s *AuthService) VerifyTwoFactor(ctx context.Context, userID, code string) error {
+	if s.totpCipher == nil {
+		return ErrTwoFactorNotConfigured
+	}
+
+	totp, err := s.userRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get two-factor state.")
+		return fmt.Errorf("failed to get two-factor state: %w", err)
+	}
+	if totp.Enabled {
+		return ErrTwoFactorAlreadyEnabled
+	}
+	if totp.Secret == "" {
+		return ErrTwoFactorNotEnrolled
+	}
+
+	if err := s.checkTOTPCode(ctx, userID, totp.Secret, code); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdateTOTP(ctx, userID, totp.Secret, true); err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	s.logger.Info().Str("user_id", userID).Msg("Two-factor authentication enabled.")
+	return nil
+}
+
+// CompleteTwoFactorLogin exchanges a login challenge and a code for session tokens
+func (s *AuthService) CompleteTwoFactorLogin(ctx context.Context, req *models.TOTPChallengeRequest) (*models.LoginResponse, error) {
+	if s.totpCipher == nil {
+		return nil, ErrTwoFactorNotConfigured
+	}
+
+	claims, err := s.validateToken(req.ChallengeToken, models.TokenTypeTOTPChallenge)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("Invalid two-factor challenge.")
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+
+	challengeKey := "challenge:" + claims.SessionID
+	if err := s.checkChallenge(ctx, challengeKey); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", claims.UserID).Msg("Failed to get user for two-factor login.")
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+
+	// Wrong codes lock the account like wrong passwords, across challenges
+	codeKey := "totp:" + user.ID
+	if err := s.checkLockout(ctx, codeKey); err != nil {
+		return nil, err
+	}
+
+	totp, err := s.userRepo.GetTOTP(ctx, user.ID)
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", user.ID).Msg("Failed to get two-factor state.")
+		return nil, fmt.Errorf("failed to get two-factor state: %w", err)
+	}
+	if !totp.Enabled {
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+
+	if err := s.checkTOTPCode(ctx, user.ID, totp.Secret, req.Code); err != nil {
+		if errors.Is(err, ErrInvalidTOTPCode) {
+			return nil, s.twoFactorCodeFailed(ctx, challengeKey, codeKey)
+		}
+		return nil, err
+	}
+
+	if err := s.useChallenge(ctx, challengeKey); err != nil {
+		return nil, err
+	}
+	if s.loginAttempts != nil {
+		if err := s.loginAttempts.Reset(ctx, codeKey); err != nil {
+			s.logger.Warn().Err(err).Str("user_id", user.ID).Msg("Failed to reset failed two-factor count.")
+		}
+	}
+
+	response, err := s.startSession(ctx, user, req.UserAgent, req.IP)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info().Str("user_id", user.ID).Str("ip", req.IP).Str("user_agent", req.UserAgent).Msg("User logged in successfully with two-factor code.")
+	return response, nil
+}
+
+// checkChallenge rejects a login challenge that was already answered or took too many
+// wrong codes. Unlike the lockout, it fails closed when the store is unavailable.
+func (s *AuthService) checkChallenge(ctx context.Context, key string) error {
+	if s.challengeAttempts == nil {
+		return nil
+	}
+
+	spent, err := s.challengeAttempts.LockedFor(ctx, key)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to check two-factor challenge.")
+		return fmt.Errorf("failed to check two-factor challenge: %w", err)
+	}
+	if spent > 0 {
+		s.logger.Warn().Msg("Spent two-factor challenge.")
+		return ErrInvalidTwoFactorChallenge
+	}
+	return nil
+}
+
+// useChallenge marks a login challenge as answered until it expires
+func (s *AuthService) useChallenge(ctx context.Context, key string) error {
+	if s.challengeAttempts == nil {
+		return nil
+	}
+
+	if err := s.challengeAttempts.Lock(ctx, key, twoFactorChallengeTTL); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to mark two-factor challenge as used.")
+		return fmt.Errorf("failed to mark two-factor challenge as used: %w", err)
+	}
+	return nil
+}
+
+// twoFactorCodeFailed counts a wrong code against both the challenge and the account,
+// returning the error to reject the code with
+func (s *AuthService) twoFactorCodeFailed(ctx context.Context, challengeKey, codeKey string) error {
+	if s.challengeAttempts != nil {
+		attempts, err := s.challengeAttempts.RecordFailure(ctx, challengeKey, twoFactorChallengeTTL)
+		if err != nil {
+			s.logger.Warn().Err(err).Msg("Failed to record wrong two-factor code.")
+		} else if attempts >= maxTwoFactorChallengeAttempts {
+			if err := s.useChallenge(ctx, challengeKey); err != nil {
+				return err
+			}
+		}
+	}
+
+	var locked *AccountLockedError
+	if errors.As(s.loginFailed(ctx, codeKey), &locked) {
+		return locked
+	}
+	return ErrInvalidTOTPCode
+}
+
+// checkTOTPCode accepts a code of the user's secret that was not used before, recording its
+// time step so it cannot be replayed
+func (s *AuthService) checkTOTPCode(ctx context.Context, userID, encryptedSecret, code string) error {
+	secret, err := s.totpCipher.decrypt(encryptedSecret)
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to decrypt TOTP secret.")
+		return fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+
+	step, ok := matchTOTPCode(secret, code, time.Now())
+	if !ok {
+		s.logger.Warn().Str("user_id", userID).Msg("Invalid two-factor code.")
+		return ErrInvalidTOTPCode
+	}
+
+	fresh, err := s.userRepo.UseTOTPStep(ctx, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to record two-factor code: %w", err)
+	}
+	if !fresh {
+		s.logger.Warn().Str("user_id", userID).Msg("Reused two-factor code.")
+		return ErrInvalidTOTPCode
+	}
+
+	return nil
+}
+
+// endOtherSessions deletes all of a user's sessions except the current one
+func (s *AuthService) endOtherSessions(ctx context.Context, userID, currentSessionID string) error {
+	if _, err := s.sessionStore.DeleteOtherUserSessions(ctx, userID, currentSessionID); err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to delete other user sessions.")
+		return fmt.Errorf("failed to end other sessions: %w", err)
+	}
+
+	s.logger.Info().Str("user_id", userID).Str("session_id", currentSessionID).Msg("Other sessions ended.")
+	return nil
+}
+
+// LogoutAll ends every session of the user, so none of their refresh tokens can be used again
+func (s *AuthService) LogoutAll(ctx context.Context, userID string) (*models.LogoutAllResponse, error) {
+	deleted, err := s.sessionStore.DeleteUserSessions(ctx, userID)
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to delete user sessions.")
+		return nil, fmt.Errorf("failed to delete sessions: %w", err)
+	}
+
+	s.logger.Info().Str("user_id", userID).Int64("sessions_deleted", deleted).Msg("User logged out of all sessions.")
+
+	return &models.LogoutAllResponse{
+		Message:         "Logged out of all sessions successfully",
+		SessionsDeleted: deleted,
+	}, nil
+}
+
+// DeleteAccount deletes the user's account after checking their password, handling their
+// todos by the configured delete policy, and ends all of their sessions. Under the block
+// policy it returns interfaces.ErrUserHasActiveTodos while any todo is not done.
+func (s *AuthService) DeleteAccount(ctx context.Context, userID, password string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get user for account deletion.")
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := s.verifyPassword(user.Password, password); err != nil {
+		s.logger.Warn().Str("user_id", userID).Msg("Invalid password on account deletion.")
+		return ErrIncorrectPassword
+	}
+
+	if err := s.userRepo.DeleteAccount(ctx, userID, s.deletePolicy); err != nil {
+		if errors.Is(err, interfaces.ErrUserHasActiveTodos) {
+			return err
+		}
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to delete account.")
+		return fmt.Errorf("failed to delete account: %w", err)
+	}
+
+	// The account is gone either way, so leftover sessions and API keys are only logged
+	if _, err := s.sessionStore.DeleteUserSessions(ctx, userID); err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to delete sessions of deleted account.")
+	}
+	if s.apiKeyRepo != nil {
+		if _, err := s.apiKeyRepo.DeleteByUserID(ctx, userID); err != nil {
+			s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to revoke API keys of deleted account.")
+		}
+	}
+
+	s.logger.Info().Str("user_id", userID).Str("policy", s.deletePolicy).Msg("Account deleted.")
+	return nil
+}
+
+// GetSecuritySummary summarizes the active sessions of the user for the account security page
+func (s *AuthService) GetSecuritySummary(ctx context.Context, userID, sessionID string) (*models.SecuritySummaryResponse, error) {
+	sessions, err := s.sessionStore.GetUserSessions(ctx, userID)
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get user sessions.")
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	summary := &models.SecuritySummaryResponse{}
+	now := time.Now()
+	for _, session := range sessions {
+		// Only count live sessions of the caller
+		if session.UserID != userID || !session.IsActive || now.After(session.ExpiresAt) {
+			continue
+		}
+
+		summary.ActiveSessions++
+		if session.ID == sessionID {
+			summary.CurrentSession = session
+		}
+		if summary.LastLoginAt == nil || session.CreatedAt.After(*summary.LastLoginAt) {
+			createdAt := session.CreatedAt
+			summary.LastLoginAt = &createdAt
+			summary.LastLoginIP = session.IP
+			summary.LastLoginUserAgent = session.UserAgent
+		}
+	}
+
+	return summary, nil
+}
+
+// ListSessions returns the live sessions of the user, newest first
+func (s *AuthService) ListSessions(ctx context.Context, userID, currentSessionID string) (*models.SessionListResponse, error) {
+	sessions, err := s.sessionStore.GetUserSessions(ctx, userID)
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get user sessions.")
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	response := &models.SessionListResponse{Sessions: []*models.Session{}}
+	now := time.Now()
+	for _, session := range sessions {
+		// Only list live sessions of the caller
+		if session.UserID != userID || !session.IsActive || now.After(session.ExpiresAt) {
+			continue
+		}
+
+		response.Sessions = append(response.Sessions, session)
+		if session.ID == currentSessionID {
+			response.CurrentSessionID = currentSessionID
+		}
+	}
+
+	sort.Slice(response.Sessions, func(i, j int) bool {
+		return response.Sessions[i].CreatedAt.After(response.Sessions[j].CreatedAt)
+	})
+
+	return response, nil
+}
+
+// RevokeSession ends one session of the user, so its refresh token can no longer be used.
+// Sessions of other users are reported as not found.
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	session, err := s.sessionStore.Get(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return ErrSessionNotFound
+		}
+		s.logger.Error().Err(err).Str("session_id", sessionID).Msg("Failed to get session.")
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if session.UserID != userID {
+		s.logger.Warn().Str("user_id", userID).Str("session_id", sessionID).Msg("Attempt to revoke another user's session.")
+		return ErrSessionNotFound
+	}
+
+	if err := s.sessionStore.Delete(ctx, sessionID); err != nil {
+		s.logger.Error().Err(err).Str("session_id", sessionID).Msg("Failed to delete session.")
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	s.logger.Info().Str("user_id", userID).Str("session_id", sessionID).Msg("Session revoked successfully.")
+	return nil
+}
+
 // GetAuthenticatedUser returns the authenticated user information
 func (s *AuthService) GetAuthenticatedUser(ctx context.Context, userID string) (*models.AuthUserResponse, error) {
 	user, err := s.userRepo.GetByID(ctx, userID)
@@ -286,62 +1070,131 @@ func (s *AuthService) GetAuthenticatedUser(ctx context.Context, userID string) (
 	}, nil
 }
 
+// PromoteAdmins gives the admin role to the users with the given usernames. Every username
+// must belong to an existing user; otherwise nobody is promoted, so that whoever registers
+// a listed name first cannot become an admin.
+func (s *AuthService) PromoteAdmins(ctx context.Context, usernames []string) error {
+	var users []*models.User
+	for _, username := range usernames {
+		username = strings.TrimSpace(username)
+		if username == "" {
+			continue
+		}
+
+		user, err := s.userRepo.GetByUsername(ctx, username)
+		if err != nil {
+			s.logger.Error().Err(err).Str("username", username).Msg("Failed to find user to promote to admin.")
+			return fmt.Errorf("failed to find admin %q: %w", username, err)
+		}
+		users = append(users, user)
+	}
+
+	for _, user := range users {
+		if user.Role == models.UserRoleAdmin {
+			continue
+		}
+
+		if err := s.userRepo.UpdateRole(ctx, user.ID, models.UserRoleAdmin); err != nil {
+			s.logger.Error().Err(err).Str("user_id", user.ID).Msg("Failed to promote user to admin.")
+			return fmt.Errorf("failed to promote user to admin: %w", err)
+		}
+		s.logger.Info().Str("user_id", user.ID).Str("username", user.Username).Msg("User promoted to admin.")
+	}
+
+	return nil
+}
+
 // ValidateAccessToken validates an access token and returns claims
 func (s *AuthService) ValidateAccessToken(tokenString string) (*models.Claims, error) {
 	return s.validateToken(tokenString, models.TokenTypeAccess)
 }
 
 // generateAccessToken generates a new access token
-func (s *AuthService) generateAccessToken(userID, username, sessionID string) (string, error) {
+func (s *AuthService) generateAccessToken(userID, username, role, sessionID string) (string, error) {
 	claims := &models.Claims{
 		UserID:    userID,
 		Username:  username,
+		Role:      role,
 		SessionID: sessionID,
 		Type:      models.TokenTypeAccess,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	return s.signToken(jwt.MapClaims{
 		"userId":    claims.UserID,
 		"username":  claims.Username,
+		"role":      claims.Role,
 		"sessionId": claims.SessionID,
 		"type":      claims.Type,
 		"iss":       s.config.Issuer,
 		"exp":       time.Now().Add(s.config.AccessExpiry).Unix(),
 		"iat":       time.Now().Unix(),
 	})
-
-	return token.SignedString([]byte(s.config.Secret))
 }
 
 // generateRefreshToken generates a new refresh token
-func (s *AuthService) generateRefreshToken(userID, username, sessionID string) (string, error) {
+func (s *AuthService) generateRefreshToken(userID, username, role, sessionID string) (string, error) {
 	claims := &models.Claims{
 		UserID:    userID,
 		Username:  username,
+		Role:      role,
 		SessionID: sessionID,
 		Type:      models.TokenTypeRefresh,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	return s.signToken(jwt.MapClaims{
 		"userId":    claims.UserID,
 		"username":  claims.Username,
+		"role":      claims.Role,
 		"sessionId": claims.SessionID,
 		"type":      claims.Type,
 		"iss":       s.config.Issuer,
 		"exp":       time.Now().Add(s.config.RefreshExpiry).Unix(),
 		"iat":       time.Now().Unix(),
 	})
+}
 
-	return token.SignedString([]byte(s.config.Secret))
+// generateChallengeToken generates a short-lived token for a login waiting for its
+// two-factor code. Its session ID only identifies the login attempt.
+func (s *AuthService) generateChallengeToken(userID, username string) (string, time.Time, error) {
+	challengeID := ulid.MustNew(ulid.Timestamp(time.Now()), rand.Reader).String()
+	expiresAt := time.Now().Add(twoFactorChallengeTTL)
+
+	signed, err := s.signToken(jwt.MapClaims{
+		"userId":    userID,
+		"username":  username,
+		"sessionId": challengeID,
+		"type":      models.TokenTypeTOTPChallenge,
+		"iss":       s.config.Issuer,
+		"exp":       expiresAt.Unix(),
+		"iat":       time.Now().Unix(),
+	})
+	return signed, expiresAt, err
+}
+
+// signToken signs claims with the configured algorithm
+func (s *AuthService) signToken(claims jwt.MapClaims) (string, error) {
+	if s.jwtKeysErr != nil {
+		return "", s.jwtKeysErr
+	}
+	if s.jwtKeys.SignKey == nil {
+		return "", fmt.Errorf("no jwt private key to sign tokens with")
+	}
+
+	return jwt.NewWithClaims(s.jwtKeys.Method, claims).SignedString(s.jwtKeys.SignKey)
 }
 
-// validateToken validates a JWT token and returns claims
+// validateToken validates a JWT token and returns claims. Tokens signed with any other
+// algorithm than the configured one are rejected.
 func (s *AuthService) validateToken(tokenString, expectedType string) (*models.Claims, error) {
+	if s.jwtKeysErr != nil {
+		return nil, s.jwtKeysErr
+	}
+
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		if token.Method.Alg() != s.jwtKeys.Method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(s.config.Secret), nil
+		return s.jwtKeys.VerifyKey, nil
 	})
 
 	if err != nil {
@@ -367,14 +1220,21 @@ func (s *AuthService) validateToken(tokenString, expectedType string) (*models.C
 	userID, _ := claims["userId"].(string)
 	username, _ := claims["username"].(string)
 	sessionID, _ := claims["sessionId"].(string)
+	role, _ := claims["role"].(string)
 
 	if userID == "" || username == "" || sessionID == "" {
 		return nil, fmt.Errorf("missing required claims")
 	}
 
+	// Tokens issued before roles existed carry none
+	if role == "" {
+		role = models.UserRoleUser
+	}
+
 	return &models.Claims{
 		UserID:    userID,
 		Username:  username,
+		Role:      role,
 		SessionID: sessionID,
 		Type:      tokenType,
 	}, nil
@@ -394,6 +1254,89 @@ func (s *AuthService) verifyPassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
 
+// compareDummyPassword compares the password against a throwaway hash so that unknown
+// identifiers take as long to reject as wrong passwords
+func (s *AuthService) compareDummyPassword(password string) {
+	s.dummyHashOnce.Do(func() {
+		s.dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), s.bcryptCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(s.dummyHash, []byte(password))
+}
+
+// SetPasswordMinScore makes registration and password change reject passwords whose
+// estimated strength, from 0 to 4, is below minScore; 0 disables the check
+func (s *AuthService) SetPasswordMinScore(minScore int) {
+	s.passwordMinScore = minScore
+}
+
+// checkPasswordStrength returns a *WeakPasswordError when the password scores below the
+// configured minimum, counting the user's own details as easy to guess
+func (s *AuthService) checkPasswordStrength(password string, userInputs ...string) error {
+	if s.passwordMinScore == 0 {
+		return nil
+	}
+
+	strength := estimatePasswordStrength(password, userInputs...)
+	if strength.Score >= s.passwordMinScore {
+		return nil
+	}
+	return &WeakPasswordError{
+		Score:       strength.Score,
+		MinScore:    s.passwordMinScore,
+		Suggestions: strength.Suggestions,
+	}
+}
+
+// SetPasswordReset enables the password reset flow, issuing tokens that expire after ttl
+func (s *AuthService) SetPasswordReset(tokens ResetTokenStore, sender ResetTokenSender, ttl time.Duration) {
+	s.resetTokens = tokens
+	s.resetSender = sender
+	s.resetTTL = ttl
+}
+
+// SetLockout locks an account for duration once threshold logins to it fail within window.
+// Each failure restarts the window, and a successful login clears the count.
+func (s *AuthService) SetLockout(attempts LoginAttemptStore, threshold int, window, duration time.Duration) {
+	s.loginAttempts = attempts
+	s.lockoutThreshold = int64(threshold)
+	s.lockoutWindow = window
+	s.lockoutDuration = duration
+}
+
+// SetTwoFactor turns on two-factor authentication. key is the 32-byte key TOTP secrets are
+// encrypted with; without one, users cannot set up two-factor authentication, but those who
+// already have it still cannot log in without a code. issuer names the app in authenticators.
+func (s *AuthService) SetTwoFactor(key []byte, issuer string) error {
+	s.twoFactor = true
+	s.totpIssuer = issuer
+	if len(key) == 0 {
+		return nil
+	}
+
+	totpCipher, err := newTOTPCipher(key)
+	if err != nil {
+		return err
+	}
+	s.totpCipher = totpCipher
+	return nil
+}
+
+// SetTwoFactorChallenges makes login challenges single-use, spending them after a
+// successful code or maxTwoFactorChallengeAttempts wrong ones
+func (s *AuthService) SetTwoFactorChallenges(attempts LoginAttemptStore) {
+	s.challengeAttempts = attempts
+}
+
+// SetUserDeletePolicy sets what happens to a user's todos when they delete their account
+func (s *AuthService) SetUserDeletePolicy(policy string) {
+	s.deletePolicy = policy
+}
+
+// SetAPIKeys sets the repository whose keys are revoked when an account is deleted
+func (s *AuthService) SetAPIKeys(apiKeyRepo interfaces.APIKeyRepository) {
+	s.apiKeyRepo = apiKeyRepo
+}
+
 // SetBcryptCost sets the bcrypt cost (useful for testing)
 func (s *AuthService) SetBcryptCost(cost int) {
 	s.bcryptCost = cost
@@ -1,17 +1,23 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"go-fiber/internal/config"
 	"go-fiber/internal/mocks"
 	"go-fiber/internal/models"
+	"go-fiber/internal/repository/interfaces"
 
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pquerna/otp/totp"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -104,6 +110,48 @@ func TestAuthService_Register(t *testing.T) {
 
 		mockUserRepo.AssertExpectations(t)
 	})
+
+	t.Run("username taken by a concurrent registration", func(t *testing.T) {
+		// Arrange
+		racingRepo := new(mocks.MockUserRepository)
+		service := NewAuthService(racingRepo, mockSessionStore, jwtConfig, logger)
+		service.SetBcryptCost(bcrypt.MinCost)
+		req := &models.RegisterRequest{
+			Username: "racinguser",
+			Password: "password123",
+		}
+
+		// The existence check passes, but another request inserts the user first
+		racingRepo.On("ExistsByUsername", ctx, "racinguser").Return(false, nil)
+		racingRepo.On("Create", ctx, mock.AnythingOfType("*models.User")).Return(nil, interfaces.ErrUsernameTaken)
+
+		// Act
+		result, err := service.Register(ctx, req)
+
+		// Assert
+		assert.ErrorIs(t, err, interfaces.ErrUsernameTaken)
+		assert.Nil(t, result)
+		racingRepo.AssertExpectations(t)
+	})
+
+	t.Run("weak password rejected when a minimum score is set", func(t *testing.T) {
+		// Arrange
+		weakService := NewAuthService(new(mocks.MockUserRepository), mockSessionStore, jwtConfig, logger)
+		weakService.SetPasswordMinScore(3)
+		req := &models.RegisterRequest{Username: "weakuser", Password: "Password1!"}
+
+		// Act
+		result, err := weakService.Register(ctx, req)
+
+		// Assert
+		assert.Nil(t, result)
+		var weak *WeakPasswordError
+		if assert.ErrorAs(t, err, &weak) {
+			assert.Equal(t, 3, weak.MinScore)
+			assert.Less(t, weak.Score, 3)
+			assert.NotEmpty(t, weak.Suggestions)
+		}
+	})
 }
 
 func TestAuthService_Login(t *testing.T) {
@@ -172,7 +220,7 @@ func TestAuthService_Login(t *testing.T) {
 		// Assert
 		assert.Error(t, err)
 		assert.Nil(t, result)
-		assert.Contains(t, err.Error(), "invalid credentials")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
 
 		mockUserRepo.AssertExpectations(t)
 	})
@@ -201,152 +249,1291 @@ func TestAuthService_Login(t *testing.T) {
 		// Assert
 		assert.Error(t, err)
 		assert.Nil(t, result)
-		assert.Contains(t, err.Error(), "invalid credentials")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
 
 		mockUserRepo.AssertExpectations(t)
 	})
 }
 
-func TestAuthService_ValidateAccessToken(t *testing.T) {
-	// Setup
-	mockUserRepo := new(mocks.MockUserRepository)
-	mockSessionStore := new(mocks.MockSessionStore)
-	logger := zerolog.Nop()
+func TestAuthService_LoginByIdentifier(t *testing.T) {
 	jwtConfig := &config.JWTConfig{
 		Secret:        "test-secret",
 		AccessExpiry:  time.Hour,
 		RefreshExpiry: 24 * time.Hour,
 		Issuer:        "test-issuer",
 	}
+	ctx := context.Background()
 
-	authService := NewAuthService(mockUserRepo, mockSessionStore, jwtConfig, logger)
+	password := "password123"
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	user := &models.User{
+		ID:       "test-id",
+		Username: "testuser",
+		Password: string(hashedPassword),
+		Email:    "test@example.com",
+	}
 
-	t.Run("valid token", func(t *testing.T) {
-		// Arrange - Generate a valid token
-		token, err := authService.generateAccessToken("user-id", "testuser", "session-id")
+	setup := func() (*AuthService, *mocks.MockUserRepository, *mocks.MockSessionStore) {
+		mockUserRepo := new(mocks.MockUserRepository)
+		mockSessionStore := new(mocks.MockSessionStore)
+		authService := NewAuthService(mockUserRepo, mockSessionStore, jwtConfig, zerolog.Nop())
+		authService.SetBcryptCost(bcrypt.MinCost)
+		return authService, mockUserRepo, mockSessionStore
+	}
+
+	t.Run("email-form identifier", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore := setup()
+		mockUserRepo.On("GetByEmail", ctx, "test@example.com").Return(user, nil)
+		mockSessionStore.On("Set", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("*models.Session"), mock.AnythingOfType("time.Duration")).Return(nil)
+
+		// Act
+		result, err := authService.LoginByIdentifier(ctx, &models.LoginRequest{Identifier: "test@example.com", Password: password})
+
+		// Assert
 		assert.NoError(t, err)
+		assert.NotEmpty(t, result.AccessToken)
+		assert.Equal(t, "testuser", result.User.Username)
+		mockUserRepo.AssertNotCalled(t, "GetByUsername", mock.Anything, mock.Anything)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("username-form identifier", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore := setup()
+		mockUserRepo.On("GetByUsername", ctx, "testuser").Return(user, nil)
+		mockSessionStore.On("Set", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("*models.Session"), mock.AnythingOfType("time.Duration")).Return(nil)
 
 		// Act
-		claims, err := authService.ValidateAccessToken(token)
+		result, err := authService.LoginByIdentifier(ctx, &models.LoginRequest{Identifier: "testuser", Password: password})
 
 		// Assert
 		assert.NoError(t, err)
-		assert.NotNil(t, claims)
-		assert.Equal(t, "user-id", claims.UserID)
-		assert.Equal(t, "testuser", claims.Username)
-		assert.Equal(t, "session-id", claims.SessionID)
-		assert.Equal(t, models.TokenTypeAccess, claims.Type)
+		assert.NotEmpty(t, result.AccessToken)
+		assert.Equal(t, "test@example.com", result.User.Email)
+		mockUserRepo.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+		mockUserRepo.AssertExpectations(t)
 	})
 
-	t.Run("invalid token", func(t *testing.T) {
+	t.Run("unknown identifier", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, _ := setup()
+		mockUserRepo.On("GetByEmail", ctx, "nobody@example.com").Return(nil, assert.AnError)
+
 		// Act
-		claims, err := authService.ValidateAccessToken("invalid-token")
+		result, err := authService.LoginByIdentifier(ctx, &models.LoginRequest{Identifier: "nobody@example.com", Password: password})
 
 		// Assert
-		assert.Error(t, err)
-		assert.Nil(t, claims)
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		assert.NotEmpty(t, authService.dummyHash)
 	})
 
-	t.Run("wrong token type", func(t *testing.T) {
-		// Arrange - Generate a refresh token instead of access token
-		token, err := authService.generateRefreshToken("user-id", "testuser", "session-id")
-		assert.NoError(t, err)
+	t.Run("wrong password", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, _ := setup()
+		mockUserRepo.On("GetByUsername", ctx, "testuser").Return(user, nil)
 
 		// Act
-		claims, err := authService.ValidateAccessToken(token)
+		result, err := authService.LoginByIdentifier(ctx, &models.LoginRequest{Identifier: "testuser", Password: "wrongpassword"})
 
 		// Assert
-		assert.Error(t, err)
-		assert.Nil(t, claims)
-		assert.Contains(t, err.Error(), "invalid token type")
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
 	})
 }
 
-func TestAuthService_RefreshToken(t *testing.T) {
-	// Setup
-	mockUserRepo := new(mocks.MockUserRepository)
-	mockSessionStore := new(mocks.MockSessionStore)
-	logger := zerolog.Nop()
+func TestAuthService_ChangePassword(t *testing.T) {
 	jwtConfig := &config.JWTConfig{
 		Secret:        "test-secret",
 		AccessExpiry:  time.Hour,
 		RefreshExpiry: 24 * time.Hour,
 		Issuer:        "test-issuer",
 	}
+	ctx := context.Background()
 
-	authService := NewAuthService(mockUserRepo, mockSessionStore, jwtConfig, logger)
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	user := &models.User{ID: "test-id", Username: "testuser", Password: string(hashedPassword)}
+
+	setup := func() (*AuthService, *mocks.MockUserRepository, *mocks.MockSessionStore) {
+		mockUserRepo := new(mocks.MockUserRepository)
+		mockSessionStore := new(mocks.MockSessionStore)
+		authService := NewAuthService(mockUserRepo, mockSessionStore, jwtConfig, zerolog.Nop())
+		authService.SetBcryptCost(bcrypt.MinCost)
+		return authService, mockUserRepo, mockSessionStore
+	}
+
+	t.Run("weak new password rejected when a minimum score is set", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, _ := setup()
+		authService.SetPasswordMinScore(3)
+		mockUserRepo.On("GetByID", ctx, "test-id").Return(user, nil)
+
+		// Act
+		err := authService.ChangePassword(ctx, "test-id", "", &models.UpdatePasswordRequest{
+			CurrentPassword: "password123",
+			NewPassword:     "testuser2025",
+		})
+
+		// Assert
+		var weak *WeakPasswordError
+		assert.ErrorAs(t, err, &weak)
+		assert.Contains(t, weak.Suggestions, "Avoid using your username or email")
+		mockUserRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("stores a hash of the new password", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore := setup()
+		mockUserRepo.On("GetByID", ctx, "test-id").Return(user, nil)
+		mockUserRepo.On("UpdatePassword", ctx, "test-id", mock.MatchedBy(func(hash string) bool {
+			return bcrypt.CompareHashAndPassword([]byte(hash), []byte("newpassword456")) == nil
+		})).Return(nil)
+
+		// Act
+		err := authService.ChangePassword(ctx, "test-id", "session-1", &models.UpdatePasswordRequest{
+			CurrentPassword: "password123",
+			NewPassword:     "newpassword456",
+		})
+
+		// Assert
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+		mockSessionStore.AssertNotCalled(t, "DeleteOtherUserSessions", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("wrong current password", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, _ := setup()
+		mockUserRepo.On("GetByID", ctx, "test-id").Return(user, nil)
+
+		// Act
+		err := authService.ChangePassword(ctx, "test-id", "session-1", &models.UpdatePasswordRequest{
+			CurrentPassword: "wrongpassword",
+			NewPassword:     "newpassword456",
+		})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidCurrentPassword)
+		mockUserRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ends other sessions but keeps the current one", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore := setup()
+		mockUserRepo.On("GetByID", ctx, "test-id").Return(user, nil)
+		mockUserRepo.On("UpdatePassword", ctx, "test-id", mock.AnythingOfType("string")).Return(nil)
+		mockSessionStore.On("DeleteOtherUserSessions", ctx, "test-id", "session-1").Return(int64(2), nil)
+
+		// Act
+		err := authService.ChangePassword(ctx, "test-id", "session-1", &models.UpdatePasswordRequest{
+			CurrentPassword:     "password123",
+			NewPassword:         "newpassword456",
+			LogoutOtherSessions: true,
+		})
+
+		// Assert
+		assert.NoError(t, err)
+		mockSessionStore.AssertExpectations(t)
+		mockSessionStore.AssertNotCalled(t, "DeleteUserSessions", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthService_PasswordReset(t *testing.T) {
+	jwtConfig := &config.JWTConfig{
+		Secret:        "test-secret",
+		AccessExpiry:  time.Hour,
+		RefreshExpiry: 24 * time.Hour,
+		Issuer:        "test-issuer",
+	}
 	ctx := context.Background()
+	user := &models.User{ID: "test-id", Username: "testuser", Email: "test@example.com"}
+
+	setup := func() (*AuthService, *mocks.MockUserRepository, *mocks.MockSessionStore, *mocks.MockResetTokenStore, *mocks.MockResetTokenSender) {
+		mockUserRepo := new(mocks.MockUserRepository)
+		mockSessionStore := new(mocks.MockSessionStore)
+		mockTokens := new(mocks.MockResetTokenStore)
+		mockSender := new(mocks.MockResetTokenSender)
+		authService := NewAuthService(mockUserRepo, mockSessionStore, jwtConfig, zerolog.Nop())
+		authService.SetBcryptCost(bcrypt.MinCost)
+		authService.SetPasswordReset(mockTokens, mockSender, 15*time.Minute)
+		return authService, mockUserRepo, mockSessionStore, mockTokens, mockSender
+	}
 
-	t.Run("successful token refresh", func(t *testing.T) {
+	t.Run("request sends a token to a known email", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, _, mockTokens, mockSender := setup()
+		mockUserRepo.On("GetByEmail", ctx, "test@example.com").Return(user, nil)
+		mockTokens.On("Create", ctx, "test-id", 15*time.Minute).Return("reset-token", nil)
+		mockSender.On("SendResetToken", ctx, user, "reset-token", mock.AnythingOfType("time.Time")).Return(nil)
+
+		// Act
+		err := authService.RequestPasswordReset(ctx, "test@example.com")
+
+		// Assert
+		assert.NoError(t, err)
+		mockTokens.AssertExpectations(t)
+		mockSender.AssertExpectations(t)
+	})
+
+	t.Run("request for an unknown email succeeds without a token", func(t *testing.T) {
 		// Arrange
-		refreshToken, err := authService.generateRefreshToken("user-id", "testuser", "session-id")
+		authService, mockUserRepo, _, mockTokens, _ := setup()
+		mockUserRepo.On("GetByEmail", ctx, "nobody@example.com").Return(nil, errors.New("user not found"))
+
+		// Act
+		err := authService.RequestPasswordReset(ctx, "nobody@example.com")
+
+		// Assert
 		assert.NoError(t, err)
+		mockTokens.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+	})
 
-		req := &models.RefreshTokenRequest{
-			RefreshToken: refreshToken,
-		}
+	t.Run("reset stores the new password, uses the token up and ends sessions", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore, mockTokens, _ := setup()
+		mockTokens.On("Get", ctx, "reset-token").Return("test-id", nil)
+		mockTokens.On("Consume", ctx, "reset-token").Return("test-id", nil)
+		mockUserRepo.On("GetByID", ctx, "test-id").Return(user, nil)
+		mockUserRepo.On("UpdatePassword", ctx, "test-id", mock.MatchedBy(func(hash string) bool {
+			return bcrypt.CompareHashAndPassword([]byte(hash), []byte("newpassword456")) == nil
+		})).Return(nil)
+		mockSessionStore.On("DeleteUserSessions", ctx, "test-id").Return(int64(2), nil)
 
-		session := &models.Session{
-			ID:        "session-id",
-			UserID:    "user-id",
-			IsActive:  true,
-			ExpiresAt: time.Now().Add(time.Hour),
-		}
+		// Act
+		err := authService.ResetPassword(ctx, &models.ResetPasswordRequest{Token: "reset-token", NewPassword: "newpassword456"})
 
-		mockSessionStore.On("Get", ctx, "session-id").Return(session, nil)
+		// Assert
+		assert.NoError(t, err)
+		mockTokens.AssertExpectations(t)
+		mockUserRepo.AssertExpectations(t)
+		mockSessionStore.AssertExpectations(t)
+	})
+
+	t.Run("invalid or expired token", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, _, mockTokens, _ := setup()
+		mockTokens.On("Get", ctx, "used-token").Return("", ErrInvalidResetToken)
 
 		// Act
-		result, err := authService.RefreshToken(ctx, req)
+		err := authService.ResetPassword(ctx, &models.ResetPasswordRequest{Token: "used-token", NewPassword: "newpassword456"})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidResetToken)
+		mockUserRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("weak new password keeps the token", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, _, mockTokens, _ := setup()
+		authService.SetPasswordMinScore(3)
+		mockTokens.On("Get", ctx, "reset-token").Return("test-id", nil)
+		mockUserRepo.On("GetByID", ctx, "test-id").Return(user, nil)
+
+		// Act
+		err := authService.ResetPassword(ctx, &models.ResetPasswordRequest{Token: "reset-token", NewPassword: "testuser2025"})
+
+		// Assert
+		var weak *WeakPasswordError
+		assert.ErrorAs(t, err, &weak)
+		mockTokens.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything)
+		mockUserRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestLogResetTokenSender(t *testing.T) {
+	user := &models.User{ID: "user-1"}
+
+	t.Run("token is left out by default", func(t *testing.T) {
+		// Arrange
+		var buf bytes.Buffer
+		sender := NewLogResetTokenSender(zerolog.New(&buf), false)
+
+		// Act
+		err := sender.SendResetToken(context.Background(), user, "secret-token", time.Now())
+
+		// Assert
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), "Password reset token issued.")
+		assert.NotContains(t, buf.String(), "secret-token")
+	})
+
+	t.Run("token is logged when enabled", func(t *testing.T) {
+		// Arrange
+		var buf bytes.Buffer
+		sender := NewLogResetTokenSender(zerolog.New(&buf), true)
+
+		// Act
+		err := sender.SendResetToken(context.Background(), user, "secret-token", time.Now())
 
 		// Assert
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), "secret-token")
+	})
+}
+
+func TestAuthService_LogoutAll(t *testing.T) {
+	jwtConfig := &config.JWTConfig{
+		Secret:        "test-secret",
+		AccessExpiry:  time.Hour,
+		RefreshExpiry: 24 * time.Hour,
+		Issuer:        "test-issuer",
+	}
+	ctx := context.Background()
+
+	t.Run("deletes every session and old refresh tokens stop working", func(t *testing.T) {
+		// Arrange
+		mockSessionStore := new(mocks.MockSessionStore)
+		authService := NewAuthService(new(mocks.MockUserRepository), mockSessionStore, jwtConfig, zerolog.Nop())
+		refreshToken, err := authService.generateRefreshToken("test-id", "testuser", models.UserRoleUser, "session-1")
 		assert.NoError(t, err)
-		assert.NotNil(t, result)
-		assert.NotEmpty(t, result.AccessToken)
+		mockSessionStore.On("DeleteUserSessions", ctx, "test-id").Return(int64(3), nil)
+		mockSessionStore.On("Get", ctx, "session-1").Return(nil, errors.New("session not found"))
+
+		// Act
+		response, err := authService.LogoutAll(ctx, "test-id")
+		_, refreshErr := authService.RefreshToken(ctx, &models.RefreshTokenRequest{RefreshToken: refreshToken})
 
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), response.SessionsDeleted)
+		assert.ErrorIs(t, refreshErr, ErrInvalidSession)
 		mockSessionStore.AssertExpectations(t)
 	})
 
-	t.Run("invalid refresh token", func(t *testing.T) {
+	t.Run("session store failure", func(t *testing.T) {
 		// Arrange
-		req := &models.RefreshTokenRequest{
-			RefreshToken: "invalid-token",
-		}
+		mockSessionStore := new(mocks.MockSessionStore)
+		authService := NewAuthService(new(mocks.MockUserRepository), mockSessionStore, jwtConfig, zerolog.Nop())
+		mockSessionStore.On("DeleteUserSessions", ctx, "test-id").Return(int64(0), errors.New("redis down"))
 
 		// Act
-		result, err := authService.RefreshToken(ctx, req)
+		response, err := authService.LogoutAll(ctx, "test-id")
 
 		// Assert
 		assert.Error(t, err)
-		assert.Nil(t, result)
-		assert.Contains(t, err.Error(), "invalid refresh token")
+		assert.Nil(t, response)
 	})
+}
 
-	t.Run("expired session", func(t *testing.T) {
+func TestAuthService_Sessions(t *testing.T) {
+	jwtConfig := &config.JWTConfig{
+		Secret:        "test-secret",
+		AccessExpiry:  time.Hour,
+		RefreshExpiry: 24 * time.Hour,
+		Issuer:        "test-issuer",
+	}
+	ctx := context.Background()
+
+	setup := func() (*AuthService, *mocks.MockUserRepository, *mocks.MockSessionStore) {
+		mockUserRepo := new(mocks.MockUserRepository)
+		mockSessionStore := new(mocks.MockSessionStore)
+		authService := NewAuthService(mockUserRepo, mockSessionStore, jwtConfig, zerolog.Nop())
+		authService.SetBcryptCost(bcrypt.MinCost)
+		return authService, mockUserRepo, mockSessionStore
+	}
+
+	t.Run("login records the user agent and IP", func(t *testing.T) {
 		// Arrange
-		refreshToken, err := authService.generateRefreshToken("user-id", "testuser", "session-id")
+		authService, mockUserRepo, mockSessionStore := setup()
+		hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+		mockUserRepo.On("GetByUsername", ctx, "testuser").Return(&models.User{ID: "test-id", Username: "testuser", Password: string(hashedPassword)}, nil)
+		mockSessionStore.On("Set", ctx, mock.AnythingOfType("string"), mock.MatchedBy(func(session *models.Session) bool {
+			return session.UserAgent == "Mozilla/5.0" && session.IP == "203.0.113.7"
+		}), mock.AnythingOfType("time.Duration")).Return(nil)
+
+		// Act
+		_, err := authService.Login(ctx, &models.LoginRequest{Username: "testuser", Password: "password123", UserAgent: "Mozilla/5.0", IP: "203.0.113.7"})
+
+		// Assert
 		assert.NoError(t, err)
+		mockSessionStore.AssertExpectations(t)
+	})
 
-		req := &models.RefreshTokenRequest{
-			RefreshToken: refreshToken,
-		}
+	t.Run("lists live sessions newest first", func(t *testing.T) {
+		// Arrange
+		authService, _, mockSessionStore := setup()
+		now := time.Now()
+		mockSessionStore.On("GetUserSessions", ctx, "test-id").Return([]*models.Session{
+			{ID: "old", UserID: "test-id", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(time.Hour), IsActive: true},
+			{ID: "expired", UserID: "test-id", CreatedAt: now.Add(-48 * time.Hour), ExpiresAt: now.Add(-time.Hour), IsActive: true},
+			{ID: "new", UserID: "test-id", CreatedAt: now, ExpiresAt: now.Add(time.Hour), IsActive: true},
+		}, nil)
 
-		session := &models.Session{
-			ID:        "session-id",
-			UserID:    "user-id",
-			IsActive:  true,
-			ExpiresAt: time.Now().Add(-time.Hour), // Expired
+		// Act
+		response, err := authService.ListSessions(ctx, "test-id", "old")
+
+		// Assert
+		assert.NoError(t, err)
+		if assert.Len(t, response.Sessions, 2) {
+			assert.Equal(t, "new", response.Sessions[0].ID)
+			assert.Equal(t, "old", response.Sessions[1].ID)
 		}
+		assert.Equal(t, "old", response.CurrentSessionID)
+	})
 
-		mockSessionStore.On("Get", ctx, "session-id").Return(session, nil)
+	t.Run("revokes an own session", func(t *testing.T) {
+		// Arrange
+		authService, _, mockSessionStore := setup()
+		mockSessionStore.On("Get", ctx, "session-1").Return(&models.Session{ID: "session-1", UserID: "test-id"}, nil)
+		mockSessionStore.On("Delete", ctx, "session-1").Return(nil)
 
 		// Act
-		result, err := authService.RefreshToken(ctx, req)
+		err := authService.RevokeSession(ctx, "test-id", "session-1")
 
 		// Assert
-		assert.Error(t, err)
-		assert.Nil(t, result)
-		assert.Contains(t, err.Error(), "session expired")
-
+		assert.NoError(t, err)
 		mockSessionStore.AssertExpectations(t)
 	})
+
+	t.Run("cannot revoke another user's session", func(t *testing.T) {
+		// Arrange
+		authService, _, mockSessionStore := setup()
+		mockSessionStore.On("Get", ctx, "session-2").Return(&models.Session{ID: "session-2", UserID: "other-id"}, nil)
+
+		// Act
+		err := authService.RevokeSession(ctx, "test-id", "session-2")
+
+		// Assert
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+		mockSessionStore.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown session", func(t *testing.T) {
+		// Arrange
+		authService, _, mockSessionStore := setup()
+		mockSessionStore.On("Get", ctx, "missing").Return(nil, ErrSessionNotFound)
+
+		// Act
+		err := authService.RevokeSession(ctx, "test-id", "missing")
+
+		// Assert
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+	})
+}
+
+func TestAuthService_GetSecuritySummary(t *testing.T) {
+	jwtConfig := &config.JWTConfig{
+		Secret:        "test-secret",
+		AccessExpiry:  time.Hour,
+		RefreshExpiry: 24 * time.Hour,
+		Issuer:        "test-issuer",
+	}
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("assembles the summary from live sessions of the caller", func(t *testing.T) {
+		// Arrange
+		mockSessionStore := new(mocks.MockSessionStore)
+		authService := NewAuthService(new(mocks.MockUserRepository), mockSessionStore, jwtConfig, zerolog.Nop())
+		current := &models.Session{ID: "session-1", UserID: "test-id", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(time.Hour), IsActive: true}
+		newest := &models.Session{ID: "session-2", UserID: "test-id", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour), IsActive: true, IP: "203.0.113.7", UserAgent: "Firefox"}
+		expired := &models.Session{ID: "session-3", UserID: "test-id", CreatedAt: now, ExpiresAt: now.Add(-time.Minute), IsActive: true, IP: "198.51.100.1"}
+		other := &models.Session{ID: "session-4", UserID: "other-id", CreatedAt: now, ExpiresAt: now.Add(time.Hour), IsActive: true}
+		mockSessionStore.On("GetUserSessions", ctx, "test-id").Return([]*models.Session{current, newest, expired, other}, nil)
+
+		// Act
+		summary, err := authService.GetSecuritySummary(ctx, "test-id", "session-1")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 2, summary.ActiveSessions)
+		assert.Equal(t, current, summary.CurrentSession)
+		if assert.NotNil(t, summary.LastLoginAt) {
+			assert.True(t, newest.CreatedAt.Equal(*summary.LastLoginAt))
+		}
+		assert.Equal(t, "203.0.113.7", summary.LastLoginIP)
+		assert.Equal(t, "Firefox", summary.LastLoginUserAgent)
+	})
+
+	t.Run("session store failure", func(t *testing.T) {
+		// Arrange
+		mockSessionStore := new(mocks.MockSessionStore)
+		authService := NewAuthService(new(mocks.MockUserRepository), mockSessionStore, jwtConfig, zerolog.Nop())
+		mockSessionStore.On("GetUserSessions", ctx, "test-id").Return(nil, errors.New("redis down"))
+
+		// Act
+		summary, err := authService.GetSecuritySummary(ctx, "test-id", "session-1")
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, summary)
+	})
+}
+
+func TestAuthService_ValidateAccessToken(t *testing.T) {
+	// Setup
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockSessionStore := new(mocks.MockSessionStore)
+	logger := zerolog.Nop()
+	jwtConfig := &config.JWTConfig{
+		Secret:        "test-secret",
+		AccessExpiry:  time.Hour,
+		RefreshExpiry: 24 * time.Hour,
+		Issuer:        "test-issuer",
+	}
+
+	authService := NewAuthService(mockUserRepo, mockSessionStore, jwtConfig, logger)
+
+	t.Run("valid token", func(t *testing.T) {
+		// Arrange - Generate a valid token
+		token, err := authService.generateAccessToken("user-id", "testuser", models.UserRoleUser, "session-id")
+		assert.NoError(t, err)
+
+		// Act
+		claims, err := authService.ValidateAccessToken(token)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NotNil(t, claims)
+		assert.Equal(t, "user-id", claims.UserID)
+		assert.Equal(t, "testuser", claims.Username)
+		assert.Equal(t, "session-id", claims.SessionID)
+		assert.Equal(t, models.UserRoleUser, claims.Role)
+		assert.Equal(t, models.TokenTypeAccess, claims.Type)
+	})
+
+	t.Run("admin role is carried in the token", func(t *testing.T) {
+		// Arrange
+		token, err := authService.generateAccessToken("user-id", "testuser", models.UserRoleAdmin, "session-id")
+		assert.NoError(t, err)
+
+		// Act
+		claims, err := authService.ValidateAccessToken(token)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, models.UserRoleAdmin, claims.Role)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		// Act
+		claims, err := authService.ValidateAccessToken("invalid-token")
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, claims)
+	})
+
+	t.Run("wrong token type", func(t *testing.T) {
+		// Arrange - Generate a refresh token instead of access token
+		token, err := authService.generateRefreshToken("user-id", "testuser", models.UserRoleUser, "session-id")
+		assert.NoError(t, err)
+
+		// Act
+		claims, err := authService.ValidateAccessToken(token)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, claims)
+		assert.Contains(t, err.Error(), "invalid token type")
+	})
+}
+
+func TestAuthService_RS256(t *testing.T) {
+	privatePEM, publicPEM := config.NewTestRSAKeyPEM()
+	hs256Config := &config.JWTConfig{
+		Secret:       "test-secret",
+		AccessExpiry: time.Hour,
+		Issuer:       "test-issuer",
+	}
+	rs256Config := &config.JWTConfig{
+		Algorithm:    config.JWTAlgorithmRS256,
+		PrivateKey:   privatePEM,
+		AccessExpiry: time.Hour,
+		Issuer:       "test-issuer",
+	}
+
+	hs256Service := NewAuthService(new(mocks.MockUserRepository), new(mocks.MockSessionStore), hs256Config, zerolog.Nop())
+	rs256Service := NewAuthService(new(mocks.MockUserRepository), new(mocks.MockSessionStore), rs256Config, zerolog.Nop())
+
+	t.Run("signs and validates with the key pair", func(t *testing.T) {
+		// Arrange
+		token, err := rs256Service.generateAccessToken("user-id", "testuser", models.UserRoleUser, "session-id")
+		require.NoError(t, err)
+
+		// Act
+		claims, err := rs256Service.ValidateAccessToken(token)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "user-id", claims.UserID)
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+		require.NoError(t, err)
+		assert.Equal(t, "RS256", parsed.Header["alg"])
+	})
+
+	t.Run("rejects tokens of the other algorithm", func(t *testing.T) {
+		// Arrange
+		hs256Token, err := hs256Service.generateAccessToken("user-id", "testuser", models.UserRoleUser, "session-id")
+		require.NoError(t, err)
+		rs256Token, err := rs256Service.generateAccessToken("user-id", "testuser", models.UserRoleUser, "session-id")
+		require.NoError(t, err)
+
+		// Act
+		_, rs256Err := rs256Service.ValidateAccessToken(hs256Token)
+		_, hs256Err := hs256Service.ValidateAccessToken(rs256Token)
+
+		// Assert
+		assert.ErrorContains(t, rs256Err, "unexpected signing method")
+		assert.ErrorContains(t, hs256Err, "unexpected signing method")
+	})
+
+	t.Run("rejects HS256 tokens signed with the public key", func(t *testing.T) {
+		// Arrange
+		forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"userId":    "user-id",
+			"username":  "testuser",
+			"role":      models.UserRoleAdmin,
+			"sessionId": "session-id",
+			"type":      models.TokenTypeAccess,
+			"exp":       time.Now().Add(time.Hour).Unix(),
+		}).SignedString([]byte(publicPEM))
+		require.NoError(t, err)
+
+		// Act
+		claims, err := rs256Service.ValidateAccessToken(forged)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, claims)
+	})
+}
+
+func TestAuthService_RefreshToken(t *testing.T) {
+	// Setup
+	logger := zerolog.Nop()
+	jwtConfig := &config.JWTConfig{
+		Secret:        "test-secret",
+		AccessExpiry:  time.Hour,
+		RefreshExpiry: 24 * time.Hour,
+		Issuer:        "test-issuer",
+	}
+	ctx := context.Background()
+
+	setup := func() (*AuthService, *mocks.MockUserRepository, *mocks.MockSessionStore) {
+		mockUserRepo := new(mocks.MockUserRepository)
+		mockSessionStore := new(mocks.MockSessionStore)
+		return NewAuthService(mockUserRepo, mockSessionStore, jwtConfig, logger), mockUserRepo, mockSessionStore
+	}
+
+	activeSession := &models.Session{
+		ID:        "session-id",
+		UserID:    "user-id",
+		IsActive:  true,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	t.Run("successful token refresh", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore := setup()
+		refreshToken, err := authService.generateRefreshToken("user-id", "testuser", models.UserRoleUser, "session-id")
+		assert.NoError(t, err)
+
+		req := &models.RefreshTokenRequest{
+			RefreshToken: refreshToken,
+		}
+
+		mockSessionStore.On("Get", ctx, "session-id").Return(activeSession, nil)
+		mockUserRepo.On("GetByID", ctx, "user-id").Return(&models.User{ID: "user-id", Username: "testuser"}, nil)
+
+		// Act
+		result, err := authService.RefreshToken(ctx, req)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NotNil(t, result)
+		assert.NotEmpty(t, result.AccessToken)
+
+		mockSessionStore.AssertExpectations(t)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("role comes from the user, not the refresh token", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore := setup()
+		refreshToken, _ := authService.generateRefreshToken("user-id", "testuser", models.UserRoleAdmin, "session-id")
+		mockSessionStore.On("Get", ctx, "session-id").Return(activeSession, nil)
+		mockUserRepo.On("GetByID", ctx, "user-id").Return(&models.User{ID: "user-id", Username: "testuser", Role: models.UserRoleUser}, nil)
+
+		// Act
+		result, err := authService.RefreshToken(ctx, &models.RefreshTokenRequest{RefreshToken: refreshToken})
+
+		// Assert
+		require.NoError(t, err)
+		claims, err := authService.ValidateAccessToken(result.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, models.UserRoleUser, claims.Role)
+	})
+
+	t.Run("deleted user", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore := setup()
+		refreshToken, _ := authService.generateRefreshToken("user-id", "testuser", models.UserRoleUser, "session-id")
+		mockSessionStore.On("Get", ctx, "session-id").Return(activeSession, nil)
+		mockUserRepo.On("GetByID", ctx, "user-id").Return(nil, errors.New("user not found"))
+
+		// Act
+		result, err := authService.RefreshToken(ctx, &models.RefreshTokenRequest{RefreshToken: refreshToken})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidSession)
+		assert.Nil(t, result)
+	})
+
+	t.Run("invalid refresh token", func(t *testing.T) {
+		// Arrange
+		authService, _, _ := setup()
+		req := &models.RefreshTokenRequest{
+			RefreshToken: "invalid-token",
+		}
+
+		// Act
+		result, err := authService.RefreshToken(ctx, req)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	})
+
+	t.Run("expired session", func(t *testing.T) {
+		// Arrange
+		authService, _, mockSessionStore := setup()
+		refreshToken, err := authService.generateRefreshToken("user-id", "testuser", models.UserRoleUser, "session-id")
+		assert.NoError(t, err)
+
+		req := &models.RefreshTokenRequest{
+			RefreshToken: refreshToken,
+		}
+
+		session := &models.Session{
+			ID:        "session-id",
+			UserID:    "user-id",
+			IsActive:  true,
+			ExpiresAt: time.Now().Add(-time.Hour), // Expired
+		}
+
+		mockSessionStore.On("Get", ctx, "session-id").Return(session, nil)
+
+		// Act
+		result, err := authService.RefreshToken(ctx, req)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, ErrSessionExpired)
+
+		mockSessionStore.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_TwoFactor(t *testing.T) {
+	jwtConfig := &config.JWTConfig{
+		Secret:        "test-secret",
+		AccessExpiry:  time.Hour,
+		RefreshExpiry: 24 * time.Hour,
+		Issuer:        "test-issuer",
+	}
+	ctx := context.Background()
+	key := make([]byte, 32)
+
+	password := "password123"
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	user := &models.User{
+		ID:       "test-id",
+		Username: "testuser",
+		Password: string(hashedPassword),
+		Email:    "test@example.com",
+	}
+
+	cipher, _ := newTOTPCipher(key)
+	encrypted, _ := cipher.encrypt(rfc6238Secret)
+	enabled := &models.UserTOTP{Secret: encrypted, Enabled: true}
+
+	setup := func() (*AuthService, *mocks.MockUserRepository, *mocks.MockSessionStore) {
+		mockUserRepo := new(mocks.MockUserRepository)
+		mockSessionStore := new(mocks.MockSessionStore)
+		authService := NewAuthService(mockUserRepo, mockSessionStore, jwtConfig, zerolog.Nop())
+		authService.SetBcryptCost(bcrypt.MinCost)
+		assert.NoError(t, authService.SetTwoFactor(key, "Test"))
+		return authService, mockUserRepo, mockSessionStore
+	}
+
+	currentCode := func() string {
+		code, _ := totp.GenerateCodeCustom(rfc6238Secret, time.Now(), totpOpts)
+		return code
+	}
+
+	t.Run("login with two-factor enabled returns a challenge", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore := setup()
+		mockUserRepo.On("GetByUsername", ctx, "testuser").Return(user, nil)
+		mockUserRepo.On("GetTOTP", ctx, "test-id").Return(enabled, nil)
+
+		// Act
+		result, err := authService.Login(ctx, &models.LoginRequest{Username: "testuser", Password: password})
+
+		// Assert
+		var challenge *TwoFactorRequiredError
+		assert.ErrorAs(t, err, &challenge)
+		assert.Nil(t, result)
+		assert.NotEmpty(t, challenge.ChallengeToken)
+		assert.WithinDuration(t, time.Now().Add(twoFactorChallengeTTL), challenge.ExpiresAt, time.Minute)
+		mockSessionStore.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("login with two-factor disabled starts a session", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore := setup()
+		mockUserRepo.On("GetByUsername", ctx, "testuser").Return(user, nil)
+		mockUserRepo.On("GetTOTP", ctx, "test-id").Return(&models.UserTOTP{}, nil)
+		mockSessionStore.On("Set", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("*models.Session"), mock.AnythingOfType("time.Duration")).Return(nil)
+
+		// Act
+		result, err := authService.Login(ctx, &models.LoginRequest{Username: "testuser", Password: password})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NotEmpty(t, result.AccessToken)
+		mockSessionStore.AssertExpectations(t)
+	})
+
+	t.Run("challenge with a valid code returns tokens", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore := setup()
+		token, _, _ := authService.generateChallengeToken("test-id", "testuser")
+		mockUserRepo.On("GetByID", ctx, "test-id").Return(user, nil)
+		mockUserRepo.On("GetTOTP", ctx, "test-id").Return(enabled, nil)
+		mockUserRepo.On("UseTOTPStep", ctx, "test-id", mock.AnythingOfType("int64")).Return(true, nil)
+		mockSessionStore.On("Set", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("*models.Session"), mock.AnythingOfType("time.Duration")).Return(nil)
+
+		// Act
+		result, err := authService.CompleteTwoFactorLogin(ctx, &models.TOTPChallengeRequest{ChallengeToken: token, Code: currentCode()})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NotEmpty(t, result.AccessToken)
+		assert.NotEmpty(t, result.RefreshToken)
+		mockUserRepo.AssertExpectations(t)
+		mockSessionStore.AssertExpectations(t)
+	})
+
+	t.Run("replayed code is rejected", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore := setup()
+		token, _, _ := authService.generateChallengeToken("test-id", "testuser")
+		mockUserRepo.On("GetByID", ctx, "test-id").Return(user, nil)
+		mockUserRepo.On("GetTOTP", ctx, "test-id").Return(enabled, nil)
+		mockUserRepo.On("UseTOTPStep", ctx, "test-id", mock.AnythingOfType("int64")).Return(false, nil)
+
+		// Act
+		result, err := authService.CompleteTwoFactorLogin(ctx, &models.TOTPChallengeRequest{ChallengeToken: token, Code: currentCode()})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidTOTPCode)
+		assert.Nil(t, result)
+		mockSessionStore.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("access token is not a challenge", func(t *testing.T) {
+		// Arrange
+		authService, _, _ := setup()
+		token, _ := authService.generateAccessToken("test-id", "testuser", models.UserRoleUser, "session-id")
+
+		// Act
+		result, err := authService.CompleteTwoFactorLogin(ctx, &models.TOTPChallengeRequest{ChallengeToken: token, Code: currentCode()})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidTwoFactorChallenge)
+		assert.Nil(t, result)
+	})
+
+	t.Run("answered challenge cannot be used again", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore := setup()
+		authService.SetTwoFactorChallenges(NewRedisLoginAttemptStore(newFakeRedis(), zerolog.Nop()))
+		token, _, _ := authService.generateChallengeToken("test-id", "testuser")
+		mockUserRepo.On("GetByID", ctx, "test-id").Return(user, nil)
+		mockUserRepo.On("GetTOTP", ctx, "test-id").Return(enabled, nil)
+		mockUserRepo.On("UseTOTPStep", ctx, "test-id", mock.AnythingOfType("int64")).Return(true, nil)
+		mockSessionStore.On("Set", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("*models.Session"), mock.AnythingOfType("time.Duration")).Return(nil)
+		_, err := authService.CompleteTwoFactorLogin(ctx, &models.TOTPChallengeRequest{ChallengeToken: token, Code: currentCode()})
+		require.NoError(t, err)
+
+		// Act
+		result, err := authService.CompleteTwoFactorLogin(ctx, &models.TOTPChallengeRequest{ChallengeToken: token, Code: currentCode()})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidTwoFactorChallenge)
+		assert.Nil(t, result)
+		mockSessionStore.AssertNumberOfCalls(t, "Set", 1)
+	})
+
+	t.Run("challenge is spent after too many wrong codes", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore := setup()
+		authService.SetTwoFactorChallenges(NewRedisLoginAttemptStore(newFakeRedis(), zerolog.Nop()))
+		token, _, _ := authService.generateChallengeToken("test-id", "testuser")
+		mockUserRepo.On("GetByID", ctx, "test-id").Return(user, nil)
+		mockUserRepo.On("GetTOTP", ctx, "test-id").Return(enabled, nil)
+		for i := 0; i < maxTwoFactorChallengeAttempts; i++ {
+			_, err := authService.CompleteTwoFactorLogin(ctx, &models.TOTPChallengeRequest{ChallengeToken: token, Code: "wrong"})
+			require.ErrorIs(t, err, ErrInvalidTOTPCode)
+		}
+
+		// Act
+		result, err := authService.CompleteTwoFactorLogin(ctx, &models.TOTPChallengeRequest{ChallengeToken: token, Code: currentCode()})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidTwoFactorChallenge)
+		assert.Nil(t, result)
+		mockUserRepo.AssertNotCalled(t, "UseTOTPStep", mock.Anything, mock.Anything, mock.Anything)
+		mockSessionStore.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("wrong codes across challenges lock the account", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore := setup()
+		authService.SetLockout(NewRedisLoginAttemptStore(newFakeRedis(), zerolog.Nop()), 3, 15*time.Minute, 10*time.Minute)
+		mockUserRepo.On("GetByID", ctx, "test-id").Return(user, nil)
+		mockUserRepo.On("GetTOTP", ctx, "test-id").Return(enabled, nil)
+		answer := func(code string) error {
+			token, _, _ := authService.generateChallengeToken("test-id", "testuser")
+			_, err := authService.CompleteTwoFactorLogin(ctx, &models.TOTPChallengeRequest{ChallengeToken: token, Code: code})
+			return err
+		}
+
+		// Act
+		first := answer("wrong")
+		second := answer("wrong")
+		third := answer("wrong")
+		whileLocked := answer(currentCode())
+
+		// Assert
+		assert.ErrorIs(t, first, ErrInvalidTOTPCode)
+		assert.ErrorIs(t, second, ErrInvalidTOTPCode)
+		var locked *AccountLockedError
+		assert.ErrorAs(t, third, &locked)
+		assert.Equal(t, 10*time.Minute, locked.RetryAfter)
+		assert.ErrorAs(t, whileLocked, &locked)
+		mockUserRepo.AssertNotCalled(t, "UseTOTPStep", mock.Anything, mock.Anything, mock.Anything)
+		mockSessionStore.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("enable then verify", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, _ := setup()
+		var stored string
+		mockUserRepo.On("GetByID", ctx, "test-id").Return(user, nil)
+		mockUserRepo.On("GetTOTP", ctx, "test-id").Return(&models.UserTOTP{}, nil).Once()
+		mockUserRepo.On("UpdateTOTP", ctx, "test-id", mock.AnythingOfType("string"), false).
+			Run(func(args mock.Arguments) { stored = args.String(2) }).Return(nil)
+
+		// Act
+		setupResponse, err := authService.EnableTwoFactor(ctx, "test-id")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NotEmpty(t, setupResponse.Secret)
+		assert.Contains(t, setupResponse.OTPAuthURL, "secret="+setupResponse.Secret)
+		assert.NotContains(t, stored, setupResponse.Secret)
+
+		// Arrange
+		code, _ := totp.GenerateCodeCustom(setupResponse.Secret, time.Now(), totpOpts)
+		mockUserRepo.On("GetTOTP", ctx, "test-id").Return(&models.UserTOTP{Secret: stored}, nil).Once()
+		mockUserRepo.On("UseTOTPStep", ctx, "test-id", mock.AnythingOfType("int64")).Return(true, nil)
+		mockUserRepo.On("UpdateTOTP", ctx, "test-id", stored, true).Return(nil)
+
+		// Act
+		err = authService.VerifyTwoFactor(ctx, "test-id", code)
+
+		// Assert
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("enable when already enabled", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, _ := setup()
+		mockUserRepo.On("GetByID", ctx, "test-id").Return(user, nil)
+		mockUserRepo.On("GetTOTP", ctx, "test-id").Return(enabled, nil)
+
+		// Act
+		result, err := authService.EnableTwoFactor(ctx, "test-id")
+
+		// Assert
+		assert.ErrorIs(t, err, ErrTwoFactorAlreadyEnabled)
+		assert.Nil(t, result)
+		mockUserRepo.AssertNotCalled(t, "UpdateTOTP", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("enable without an encryption key", func(t *testing.T) {
+		// Arrange
+		authService := NewAuthService(new(mocks.MockUserRepository), new(mocks.MockSessionStore), jwtConfig, zerolog.Nop())
+		assert.NoError(t, authService.SetTwoFactor(nil, "Test"))
+
+		// Act
+		result, err := authService.EnableTwoFactor(ctx, "test-id")
+
+		// Assert
+		assert.ErrorIs(t, err, ErrTwoFactorNotConfigured)
+		assert.Nil(t, result)
+	})
+}
+
+func TestAuthService_Lockout(t *testing.T) {
+	jwtConfig := &config.JWTConfig{
+		Secret:        "test-secret",
+		AccessExpiry:  time.Hour,
+		RefreshExpiry: 24 * time.Hour,
+		Issuer:        "test-issuer",
+	}
+	ctx := context.Background()
+
+	password := "password123"
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	user := &models.User{
+		ID:       "test-id",
+		Username: "testuser",
+		Password: string(hashedPassword),
+		Email:    "test@example.com",
+	}
+
+	setup := func() (*AuthService, *mocks.MockUserRepository, *mocks.MockSessionStore, *fakeRedis) {
+		mockUserRepo := new(mocks.MockUserRepository)
+		mockSessionStore := new(mocks.MockSessionStore)
+		client := newFakeRedis()
+		authService := NewAuthService(mockUserRepo, mockSessionStore, jwtConfig, zerolog.Nop())
+		authService.SetBcryptCost(bcrypt.MinCost)
+		authService.SetLockout(NewRedisLoginAttemptStore(client, zerolog.Nop()), 3, 15*time.Minute, 10*time.Minute)
+		return authService, mockUserRepo, mockSessionStore, client
+	}
+
+	login := func(authService *AuthService, password string) (*models.LoginResponse, error) {
+		return authService.Login(ctx, &models.LoginRequest{Username: "testuser", Password: password})
+	}
+
+	t.Run("failures crossing the threshold lock the account until the lock expires", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore, client := setup()
+		mockUserRepo.On("GetByUsername", ctx, "testuser").Return(user, nil)
+		mockSessionStore.On("Set", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("*models.Session"), mock.AnythingOfType("time.Duration")).Return(nil)
+
+		// Act
+		_, first := login(authService, "wrong")
+		_, second := login(authService, "wrong")
+		_, third := login(authService, "wrong")
+		_, whileLocked := login(authService, password)
+
+		// Assert
+		assert.ErrorIs(t, first, ErrInvalidCredentials)
+		assert.ErrorIs(t, second, ErrInvalidCredentials)
+		var locked *AccountLockedError
+		assert.ErrorAs(t, third, &locked)
+		assert.Equal(t, 10*time.Minute, locked.RetryAfter)
+		assert.ErrorAs(t, whileLocked, &locked)
+		mockSessionStore.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+		// Act - simulate Redis expiring the lock
+		delete(client.strings, "login_lock:user:test-id")
+		result, err := login(authService, password)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NotEmpty(t, result.AccessToken)
+	})
+
+	t.Run("successful login resets the count", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore, _ := setup()
+		mockUserRepo.On("GetByUsername", ctx, "testuser").Return(user, nil)
+		mockSessionStore.On("Set", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("*models.Session"), mock.AnythingOfType("time.Duration")).Return(nil)
+
+		// Act
+		login(authService, "wrong")
+		login(authService, "wrong")
+		_, success := login(authService, password)
+		login(authService, "wrong")
+		_, err := login(authService, "wrong")
+
+		// Assert
+		assert.NoError(t, success)
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("unknown accounts lock like real ones", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, _, _ := setup()
+		mockUserRepo.On("GetByEmail", ctx, "nobody@example.com").Return(nil, errors.New("user not found"))
+
+		// Act
+		var err error
+		for i := 0; i < 3; i++ {
+			_, err = authService.LoginByEmail(ctx, &models.LoginByEmailRequest{Email: "nobody@example.com", Password: "wrong"})
+		}
+
+		// Assert
+		var locked *AccountLockedError
+		assert.ErrorAs(t, err, &locked)
+	})
+}
+
+func TestAuthService_DeleteAccount(t *testing.T) {
+	jwtConfig := &config.JWTConfig{
+		Secret:        "test-secret",
+		AccessExpiry:  time.Hour,
+		RefreshExpiry: 24 * time.Hour,
+		Issuer:        "test-issuer",
+	}
+	ctx := context.Background()
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	user := &models.User{ID: "test-id", Username: "testuser", Password: string(hashedPassword)}
+
+	setup := func(policy string) (*AuthService, *mocks.MockUserRepository, *mocks.MockSessionStore) {
+		mockUserRepo := new(mocks.MockUserRepository)
+		mockSessionStore := new(mocks.MockSessionStore)
+		authService := NewAuthService(mockUserRepo, mockSessionStore, jwtConfig, zerolog.Nop())
+		authService.SetBcryptCost(bcrypt.MinCost)
+		if policy != "" {
+			authService.SetUserDeletePolicy(policy)
+		}
+		return authService, mockUserRepo, mockSessionStore
+	}
+
+	for _, policy := range []string{models.UserDeletePolicyCascade, models.UserDeletePolicyBlock, models.UserDeletePolicyAnonymize} {
+		t.Run(policy+" deletes the account and ends its sessions", func(t *testing.T) {
+			// Arrange
+			authService, mockUserRepo, mockSessionStore := setup(policy)
+			mockUserRepo.On("GetByID", ctx, "test-id").Return(user, nil)
+			mockUserRepo.On("DeleteAccount", ctx, "test-id", policy).Return(nil)
+			mockSessionStore.On("DeleteUserSessions", ctx, "test-id").Return(int64(2), nil)
+
+			// Act
+			err := authService.DeleteAccount(ctx, "test-id", "password123")
+
+			// Assert
+			assert.NoError(t, err)
+			mockUserRepo.AssertExpectations(t)
+			mockSessionStore.AssertExpectations(t)
+		})
+	}
+
+	t.Run("cascade is the default policy", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore := setup("")
+		mockUserRepo.On("GetByID", ctx, "test-id").Return(user, nil)
+		mockUserRepo.On("DeleteAccount", ctx, "test-id", models.UserDeletePolicyCascade).Return(nil)
+		mockSessionStore.On("DeleteUserSessions", ctx, "test-id").Return(int64(0), nil)
+
+		// Act
+		err := authService.DeleteAccount(ctx, "test-id", "password123")
+
+		// Assert
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("block with active todos keeps the account and sessions", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore := setup(models.UserDeletePolicyBlock)
+		mockUserRepo.On("GetByID", ctx, "test-id").Return(user, nil)
+		mockUserRepo.On("DeleteAccount", ctx, "test-id", models.UserDeletePolicyBlock).Return(interfaces.ErrUserHasActiveTodos)
+
+		// Act
+		err := authService.DeleteAccount(ctx, "test-id", "password123")
+
+		// Assert
+		assert.ErrorIs(t, err, interfaces.ErrUserHasActiveTodos)
+		mockSessionStore.AssertNotCalled(t, "DeleteUserSessions", mock.Anything, mock.Anything)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, _ := setup(models.UserDeletePolicyCascade)
+		mockUserRepo.On("GetByID", ctx, "test-id").Return(user, nil)
+
+		// Act
+		err := authService.DeleteAccount(ctx, "test-id", "wrong-password")
+
+		// Assert
+		assert.ErrorIs(t, err, ErrIncorrectPassword)
+		mockUserRepo.AssertNotCalled(t, "DeleteAccount", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("revokes the account's API keys", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore := setup(models.UserDeletePolicyCascade)
+		mockAPIKeyRepo := new(mocks.MockAPIKeyRepository)
+		authService.SetAPIKeys(mockAPIKeyRepo)
+		mockUserRepo.On("GetByID", ctx, "test-id").Return(user, nil)
+		mockUserRepo.On("DeleteAccount", ctx, "test-id", models.UserDeletePolicyCascade).Return(nil)
+		mockSessionStore.On("DeleteUserSessions", ctx, "test-id").Return(int64(0), nil)
+		mockAPIKeyRepo.On("DeleteByUserID", ctx, "test-id").Return(int64(2), nil)
+
+		// Act
+		err := authService.DeleteAccount(ctx, "test-id", "password123")
+
+		// Assert
+		assert.NoError(t, err)
+		mockAPIKeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("session cleanup failure does not fail the deletion", func(t *testing.T) {
+		// Arrange
+		authService, mockUserRepo, mockSessionStore := setup(models.UserDeletePolicyAnonymize)
+		mockUserRepo.On("GetByID", ctx, "test-id").Return(user, nil)
+		mockUserRepo.On("DeleteAccount", ctx, "test-id", models.UserDeletePolicyAnonymize).Return(nil)
+		mockSessionStore.On("DeleteUserSessions", ctx, "test-id").Return(int64(0), errors.New("redis down"))
+
+		// Act
+		err := authService.DeleteAccount(ctx, "test-id", "password123")
+
+		// Assert
+		assert.NoError(t, err)
+	})
+}
+
+func TestAuthService_PromoteAdmins(t *testing.T) {
+	jwtConfig := &config.JWTConfig{
+		Secret:        "test-secret",
+		AccessExpiry:  time.Hour,
+		RefreshExpiry: 24 * time.Hour,
+		Issuer:        "test-issuer",
+	}
+	ctx := context.Background()
+
+	t.Run("promotes listed users who are not admins yet", func(t *testing.T) {
+		// Arrange
+		mockUserRepo := new(mocks.MockUserRepository)
+		authService := NewAuthService(mockUserRepo, new(mocks.MockSessionStore), jwtConfig, zerolog.Nop())
+		mockUserRepo.On("GetByUsername", ctx, "alice").Return(&models.User{ID: "alice-id", Username: "alice", Role: models.UserRoleUser}, nil)
+		mockUserRepo.On("GetByUsername", ctx, "bob").Return(&models.User{ID: "bob-id", Username: "bob", Role: models.UserRoleAdmin}, nil)
+		mockUserRepo.On("UpdateRole", ctx, "alice-id", models.UserRoleAdmin).Return(nil)
+
+		// Act
+		err := authService.PromoteAdmins(ctx, []string{" alice ", "bob", ""})
+
+		// Assert
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+		mockUserRepo.AssertNumberOfCalls(t, "UpdateRole", 1)
+	})
+
+	t.Run("unknown username promotes nobody", func(t *testing.T) {
+		// Arrange
+		mockUserRepo := new(mocks.MockUserRepository)
+		authService := NewAuthService(mockUserRepo, new(mocks.MockSessionStore), jwtConfig, zerolog.Nop())
+		mockUserRepo.On("GetByUsername", ctx, "alice").Return(&models.User{ID: "alice-id", Username: "alice", Role: models.UserRoleUser}, nil)
+		mockUserRepo.On("GetByUsername", ctx, "ghost").Return(nil, errors.New("user not found"))
+
+		// Act
+		err := authService.PromoteAdmins(ctx, []string{"alice", "ghost"})
+
+		// Assert
+		assert.ErrorContains(t, err, `"ghost"`)
+		mockUserRepo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything, mock.Anything)
+	})
 }
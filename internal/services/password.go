@@ -0,0 +1,241 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// passwordStrength is an estimate of how hard a password is to guess
+type passwordStrength struct {
+	// Score ranges from 0 (guessed almost instantly) to 4 (very hard to guess)
+	Score int
+	// Entropy is the estimated number of bits an attacker has to guess
+	Entropy     float64
+	Suggestions []string
+}
+
+// commonPasswordWords are passwords, words and keyboard patterns guessing tools try first
+var commonPasswordWords = []string{
+	"password", "passwort", "qwerty", "qwertyuiop", "asdfgh", "asdfghjkl", "zxcvbn", "zxcvbnm",
+	"1qaz2wsx", "letmein", "welcome", "admin", "administrator", "login", "iloveyou", "monkey",
+	"dragon", "football", "baseball", "basketball", "soccer", "hockey", "master", "shadow",
+	"sunshine", "princess", "superman", "batman", "trustnoi", "whatever", "freedom", "hello",
+	"charlie", "michael", "jordan", "jennifer", "hunter", "ranger", "starwars", "computer",
+	"internet", "secret", "summer", "winter", "spring", "autumn", "january", "february",
+	"march", "april", "june", "july", "august", "september", "october", "november",
+	"december", "monday", "friday", "sunday", "love", "lovely", "money", "test", "testing",
+	"guest", "default", "changeme", "pass", "user", "todo", "todos", "qazwsx", "killer",
+	"pepper", "cookie", "cheese", "orange", "banana", "chocolate", "flower", "tigger",
+	"purple", "silver", "golden", "thomas", "robert", "daniel", "matthew", "andrew",
+	"joshua", "ashley", "jessica", "amanda", "nicole", "access", "mustang", "maggie",
+}
+
+// leetSubstitutions maps digits and symbols commonly swapped in for letters back to them
+var leetSubstitutions = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's', '!': 'i',
+}
+
+// estimatePasswordStrength scores a password by estimating its entropy. Common passwords and
+// words, even with digits or symbols swapped in for letters, and the user's own details
+// (such as the username) count as one guess from a short list. Runs of a repeated character
+// or of consecutive characters count as their first character. Every other character adds
+// the bits of the character classes the password uses.
+func estimatePasswordStrength(password string, userInputs ...string) passwordStrength {
+	runes := []rune(password)
+	lowered := make([]rune, len(runes))
+	normalized := make([]rune, len(runes))
+	for i, r := range runes {
+		lowered[i] = unicode.ToLower(r)
+		normalized[i] = lowered[i]
+		if sub, ok := leetSubstitutions[lowered[i]]; ok {
+			normalized[i] = sub
+		}
+	}
+
+	bitsPerChar := math.Log2(float64(passwordPool(runes)))
+	covered := make([]bool, len(runes))
+	var entropy float64
+	var suggestions []string
+
+	// The user's own details, then common words, longest first so they are matched whole
+	inputs := make([]string, 0, len(userInputs))
+	for _, input := range userInputs {
+		input, _, _ = strings.Cut(strings.ToLower(input), "@")
+		if len([]rune(input)) >= 3 {
+			inputs = append(inputs, input)
+		}
+	}
+	if matches := coverWords(normalized, covered, inputs); matches > 0 {
+		entropy += float64(matches)
+		suggestions = append(suggestions, "Avoid using your username or email")
+	}
+
+	words := make([]string, len(commonPasswordWords))
+	copy(words, commonPasswordWords)
+	sort.SliceStable(words, func(i, j int) bool { return len(words[i]) > len(words[j]) })
+	if matches := coverWords(normalized, covered, words); matches > 0 {
+		entropy += float64(matches) * math.Log2(float64(len(commonPasswordWords)))
+		suggestions = append(suggestions, "Avoid common passwords and words, even with letters swapped for digits or symbols")
+	}
+
+	repeats, sequences := 0, 0
+	for i := 0; i < len(runes); {
+		if covered[i] {
+			i++
+			continue
+		}
+
+		// Extend a run while the step between characters stays 0 (repeat) or ±1 (sequence)
+		end := i + 1
+		if end < len(runes) && !covered[end] {
+			step := lowered[end] - lowered[i]
+			for end < len(runes) && !covered[end] && step >= -1 && step <= 1 && lowered[end]-lowered[end-1] == step {
+				end++
+			}
+			if end-i >= 3 {
+				if step == 0 {
+					repeats++
+				} else {
+					sequences++
+				}
+				for j := i; j < end; j++ {
+					covered[j] = true
+				}
+				entropy += bitsPerChar + 1
+				i = end
+				continue
+			}
+		}
+
+		entropy += bitsPerChar
+		covered[i] = true
+		i++
+	}
+	if repeats > 0 {
+		suggestions = append(suggestions, "Avoid repeated characters like aaa")
+	}
+	if sequences > 0 {
+		suggestions = append(suggestions, "Avoid sequences like abc or 1234")
+	}
+
+	if len(runes) < 12 {
+		suggestions = append(suggestions, "Use at least 12 characters; a few unrelated words work well")
+	}
+	if passwordClasses(runes) == 1 {
+		suggestions = append(suggestions, "Mix in upper case letters, digits or symbols")
+	}
+
+	return passwordStrength{
+		Score:       passwordScore(entropy),
+		Entropy:     entropy,
+		Suggestions: suggestions,
+	}
+}
+
+// coverWords marks the uncovered occurrences of words in normalized as covered and returns
+// how many it found
+func coverWords(normalized []rune, covered []bool, words []string) int {
+	matches := 0
+	for _, word := range words {
+		target := []rune(word)
+		for i := 0; i+len(target) <= len(normalized); i++ {
+			if !runesMatch(normalized[i:i+len(target)], target) || anyCovered(covered[i:i+len(target)]) {
+				continue
+			}
+			for j := i; j < i+len(target); j++ {
+				covered[j] = true
+			}
+			matches++
+			i += len(target) - 1
+		}
+	}
+	return matches
+}
+
+// runesMatch reports whether a and b hold the same runes
+func runesMatch(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// anyCovered reports whether any position is already covered
+func anyCovered(covered []bool) bool {
+	for _, c := range covered {
+		if c {
+			return true
+		}
+	}
+	return false
+}
+
+// passwordPool returns the size of the character set spanned by the password's classes
+func passwordPool(runes []rune) int {
+	var lower, upper, digit, other bool
+	for _, r := range runes {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if other {
+		pool += 33
+	}
+	return max(pool, 1)
+}
+
+// passwordClasses counts the character classes (lower, upper, digit, other) used
+func passwordClasses(runes []rune) int {
+	classes := map[int]bool{}
+	for _, r := range runes {
+		switch {
+		case unicode.IsLower(r):
+			classes[0] = true
+		case unicode.IsUpper(r):
+			classes[1] = true
+		case unicode.IsDigit(r):
+			classes[2] = true
+		default:
+			classes[3] = true
+		}
+	}
+	return len(classes)
+}
+
+// passwordScore buckets an entropy estimate into a score from 0 to 4
+func passwordScore(entropy float64) int {
+	switch {
+	case entropy < 25:
+		return 0
+	case entropy < 35:
+		return 1
+	case entropy < 50:
+		return 2
+	case entropy < 65:
+		return 3
+	default:
+		return 4
+	}
+}
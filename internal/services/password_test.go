@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimatePasswordStrength(t *testing.T) {
+	t.Run("weak passwords score low", func(t *testing.T) {
+		for _, password := range []string{"Password1!", "P@ssw0rd", "qwertyuiop", "aaaaaaaaaaaa", "abcdefgh12345", "hunter22"} {
+			strength := estimatePasswordStrength(password)
+
+			assert.LessOrEqual(t, strength.Score, 1, password)
+			assert.NotEmpty(t, strength.Suggestions, password)
+		}
+	})
+
+	t.Run("strong passwords score high", func(t *testing.T) {
+		for _, password := range []string{"correct horse battery staple", "xK9#mQ2$vL", "mY dog eats 3 socks"} {
+			strength := estimatePasswordStrength(password)
+
+			assert.GreaterOrEqual(t, strength.Score, 3, password)
+		}
+	})
+
+	t.Run("common words are found behind substitutions", func(t *testing.T) {
+		strength := estimatePasswordStrength("P@55w0rd")
+
+		assert.Equal(t, 0, strength.Score)
+		assert.Contains(t, strength.Suggestions, "Avoid common passwords and words, even with letters swapped for digits or symbols")
+	})
+
+	t.Run("user details count as easy to guess", func(t *testing.T) {
+		without := estimatePasswordStrength("zanzibarite2025")
+		with := estimatePasswordStrength("zanzibarite2025", "zanzibarite", "zanzibarite@example.com")
+
+		assert.Less(t, with.Entropy, without.Entropy)
+		assert.Contains(t, with.Suggestions, "Avoid using your username or email")
+	})
+
+	t.Run("sequences and repeats are flagged", func(t *testing.T) {
+		assert.Contains(t, estimatePasswordStrength("Zq!abcdef").Suggestions, "Avoid sequences like abc or 1234")
+		assert.Contains(t, estimatePasswordStrength("Zq!7777777").Suggestions, "Avoid repeated characters like aaa")
+	})
+}
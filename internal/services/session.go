@@ -2,29 +2,38 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"time"
 
 	"go-fiber/internal/models"
 
+	"github.com/oklog/ulid/v2"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 )
 
-// RedisSessionStore implements SessionStore using Redis
+// scanBatchSize is the COUNT hint used when scanning session keys
+const scanBatchSize = 500
+
+// RedisSessionStore implements SessionStore using Redis.
+// Besides the session keys, it keeps a set of session IDs per user so that
+// per-user operations do not need to walk every session in Redis.
 type RedisSessionStore struct {
-	client redis.Cmdable
-	logger zerolog.Logger
-	prefix string
+	client     redis.Cmdable
+	logger     zerolog.Logger
+	prefix     string
+	userPrefix string
 }
 
 // NewRedisSessionStore creates a new Redis session store
 func NewRedisSessionStore(client redis.Cmdable, logger zerolog.Logger) *RedisSessionStore {
 	return &RedisSessionStore{
-		client: client,
-		logger: logger,
-		prefix: "session:",
+		client:     client,
+		logger:     logger,
+		prefix:     "session:",
+		userPrefix: "user_sessions:",
 	}
 }
 
@@ -45,6 +54,24 @@ func (s *RedisSessionStore) Set(ctx context.Context, sessionID string, session *
 		return fmt.Errorf("failed to store session: %w", err)
 	}
 
+	// Index the session under its user
+	userKey := s.getUserKey(session.UserID)
+	if err := s.client.SAdd(ctx, userKey, sessionID).Err(); err != nil {
+		s.logger.Error().Err(err).Str("session_id", sessionID).Str("user_id", session.UserID).Msg("Failed to index session.")
+		return fmt.Errorf("failed to index session: %w", err)
+	}
+
+	// Keep the index alive at least as long as its longest session
+	if expiration > 0 {
+		ttl, err := s.client.TTL(ctx, userKey).Result()
+		if err == nil && ttl < expiration {
+			err = s.client.Expire(ctx, userKey, expiration).Err()
+		}
+		if err != nil {
+			s.logger.Warn().Err(err).Str("user_id", session.UserID).Msg("Failed to extend session index expiration.")
+		}
+	}
+
 	s.logger.Debug().Str("session_id", sessionID).Dur("expiration", expiration).Msg("Session stored successfully.")
 	return nil
 }
@@ -57,7 +84,7 @@ func (s *RedisSessionStore) Get(ctx context.Context, sessionID string) (*models.
 	data, err := s.client.Get(ctx, key).Result()
 	if err != nil {
 		if err == redis.Nil {
-			return nil, fmt.Errorf("session not found")
+			return nil, ErrSessionNotFound
 		}
 		s.logger.Error().Err(err).Str("session_id", sessionID).Msg("Failed to get session from Redis.")
 		return nil, fmt.Errorf("failed to get session: %w", err)
@@ -78,6 +105,16 @@ func (s *RedisSessionStore) Get(ctx context.Context, sessionID string) (*models.
 func (s *RedisSessionStore) Delete(ctx context.Context, sessionID string) error {
 	key := s.getKey(sessionID)
 
+	// Read the owner first so the session can be removed from the user index
+	var session models.Session
+	data, err := s.client.Get(ctx, key).Result()
+	if err == nil {
+		err = json.Unmarshal([]byte(data), &session)
+	}
+	if err != nil && err != redis.Nil {
+		s.logger.Warn().Err(err).Str("session_id", sessionID).Msg("Failed to read session before deletion.")
+	}
+
 	// Delete from Redis
 	result, err := s.client.Del(ctx, key).Result()
 	if err != nil {
@@ -87,29 +124,124 @@ func (s *RedisSessionStore) Delete(ctx context.Context, sessionID string) error
 
 	if result == 0 {
 		s.logger.Warn().Str("session_id", sessionID).Msg("Session not found for deletion.")
-		return fmt.Errorf("session not found")
+		return ErrSessionNotFound
+	}
+
+	if session.UserID != "" {
+		if err := s.client.SRem(ctx, s.getUserKey(session.UserID), sessionID).Err(); err != nil {
+			s.logger.Warn().Err(err).Str("session_id", sessionID).Str("user_id", session.UserID).Msg("Failed to remove session from user index.")
+		}
 	}
 
 	s.logger.Debug().Str("session_id", sessionID).Msg("Session deleted successfully.")
 	return nil
 }
 
-// DeleteUserSessions removes all sessions for a specific user
-func (s *RedisSessionStore) DeleteUserSessions(ctx context.Context, userID string) error {
-	// Get all session keys
-	pattern := s.prefix + "*"
-	keys, err := s.client.Keys(ctx, pattern).Result()
+// DeleteUserSessions removes all sessions for a specific user and returns how many were removed
+func (s *RedisSessionStore) DeleteUserSessions(ctx context.Context, userID string) (int64, error) {
+	userKey := s.getUserKey(userID)
+
+	// Get the user's session IDs
+	sessionIDs, err := s.client.SMembers(ctx, userKey).Result()
 	if err != nil {
-		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get session keys.")
-		return fmt.Errorf("failed to get session keys: %w", err)
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get user session IDs.")
+		return 0, fmt.Errorf("failed to get user sessions: %w", err)
 	}
 
-	// Check each session to see if it belongs to the user
-	var userSessionKeys []string
-	for _, key := range keys {
-		data, err := s.client.Get(ctx, key).Result()
+	// Delete user sessions
+	var deleted int64
+	if len(sessionIDs) > 0 {
+		keys := make([]string, len(sessionIDs))
+		for i, sessionID := range sessionIDs {
+			keys[i] = s.getKey(sessionID)
+		}
+
+		deleted, err = s.client.Del(ctx, keys...).Result()
 		if err != nil {
-			continue // Skip if we can't get the session
+			s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to delete user sessions.")
+			return 0, fmt.Errorf("failed to delete user sessions: %w", err)
+		}
+	}
+
+	if err := s.client.Del(ctx, userKey).Err(); err != nil {
+		s.logger.Warn().Err(err).Str("user_id", userID).Msg("Failed to delete user session index.")
+	}
+
+	s.logger.Info().Str("user_id", userID).Int64("deleted_count", deleted).Msg("User sessions deleted successfully.")
+	return deleted, nil
+}
+
+// DeleteOtherUserSessions removes every session of a user except keepSessionID and returns
+// how many were removed. The kept session is never touched, so it stays valid throughout.
+func (s *RedisSessionStore) DeleteOtherUserSessions(ctx context.Context, userID, keepSessionID string) (int64, error) {
+	userKey := s.getUserKey(userID)
+
+	sessionIDs, err := s.client.SMembers(ctx, userKey).Result()
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get user session IDs.")
+		return 0, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+
+	var keys []string
+	var members []interface{}
+	for _, sessionID := range sessionIDs {
+		if sessionID == keepSessionID {
+			continue
+		}
+		keys = append(keys, s.getKey(sessionID))
+		members = append(members, sessionID)
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	deleted, err := s.client.Del(ctx, keys...).Result()
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to delete user sessions.")
+		return 0, fmt.Errorf("failed to delete user sessions: %w", err)
+	}
+
+	if err := s.client.SRem(ctx, userKey, members...).Err(); err != nil {
+		s.logger.Warn().Err(err).Str("user_id", userID).Msg("Failed to remove sessions from user index.")
+	}
+
+	s.logger.Info().Str("user_id", userID).Int64("deleted_count", deleted).Msg("Other user sessions deleted successfully.")
+	return deleted, nil
+}
+
+// GetUserSessions returns all sessions belonging to a specific user
+func (s *RedisSessionStore) GetUserSessions(ctx context.Context, userID string) ([]*models.Session, error) {
+	userKey := s.getUserKey(userID)
+
+	// Get the user's session IDs
+	sessionIDs, err := s.client.SMembers(ctx, userKey).Result()
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get user session IDs.")
+		return nil, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+
+	if len(sessionIDs) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(sessionIDs))
+	for i, sessionID := range sessionIDs {
+		keys[i] = s.getKey(sessionID)
+	}
+
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get user sessions.")
+		return nil, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+
+	var sessions []*models.Session
+	var expired []interface{}
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			expired = append(expired, sessionIDs[i]) // Session key has expired
+			continue
 		}
 
 		var session models.Session
@@ -117,23 +249,17 @@ func (s *RedisSessionStore) DeleteUserSessions(ctx context.Context, userID strin
 			continue // Skip if we can't unmarshal the session
 		}
 
-		if session.UserID == userID {
-			userSessionKeys = append(userSessionKeys, key)
-		}
+		sessions = append(sessions, &session)
 	}
 
-	// Delete user sessions
-	if len(userSessionKeys) > 0 {
-		deleted, err := s.client.Del(ctx, userSessionKeys...).Result()
-		if err != nil {
-			s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to delete user sessions.")
-			return fmt.Errorf("failed to delete user sessions: %w", err)
+	// Prune IDs of expired sessions from the index
+	if len(expired) > 0 {
+		if err := s.client.SRem(ctx, userKey, expired...).Err(); err != nil {
+			s.logger.Warn().Err(err).Str("user_id", userID).Msg("Failed to prune user session index.")
 		}
-
-		s.logger.Info().Str("user_id", userID).Int64("deleted_count", deleted).Msg("User sessions deleted successfully.")
 	}
 
-	return nil
+	return sessions, nil
 }
 
 // Exists checks if a session exists in Redis
@@ -161,7 +287,7 @@ func (s *RedisSessionStore) Extend(ctx context.Context, sessionID string, expira
 	}
 
 	if exists == 0 {
-		return fmt.Errorf("session not found")
+		return ErrSessionNotFound
 	}
 
 	// Extend expiration
@@ -189,43 +315,31 @@ func (s *RedisSessionStore) GetTTL(ctx context.Context, sessionID string) (time.
 
 // Count returns the total number of active sessions
 func (s *RedisSessionStore) Count(ctx context.Context) (int64, error) {
-	pattern := s.prefix + "*"
-	keys, err := s.client.Keys(ctx, pattern).Result()
-	if err != nil {
-		s.logger.Error().Err(err).Msg("Failed to count sessions.")
-		return 0, fmt.Errorf("failed to count sessions: %w", err)
-	}
-
-	return int64(len(keys)), nil
-}
-
-// CountUserSessions returns the number of active sessions for a specific user
-func (s *RedisSessionStore) CountUserSessions(ctx context.Context, userID string) (int64, error) {
-	pattern := s.prefix + "*"
-	keys, err := s.client.Keys(ctx, pattern).Result()
-	if err != nil {
-		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to get session keys.")
-		return 0, fmt.Errorf("failed to get session keys: %w", err)
-	}
-
 	var count int64
-	for _, key := range keys {
-		data, err := s.client.Get(ctx, key).Result()
+	var cursor uint64
+	for {
+		keys, next, err := s.client.Scan(ctx, cursor, s.prefix+"*", scanBatchSize).Result()
 		if err != nil {
-			continue // Skip if we can't get the session
+			s.logger.Error().Err(err).Msg("Failed to count sessions.")
+			return 0, fmt.Errorf("failed to count sessions: %w", err)
 		}
 
-		var session models.Session
-		if err := json.Unmarshal([]byte(data), &session); err != nil {
-			continue // Skip if we can't unmarshal the session
+		count += int64(len(keys))
+		if next == 0 {
+			return count, nil
 		}
+		cursor = next
+	}
+}
 
-		if session.UserID == userID {
-			count++
-		}
+// CountUserSessions returns the number of active sessions for a specific user
+func (s *RedisSessionStore) CountUserSessions(ctx context.Context, userID string) (int64, error) {
+	sessions, err := s.GetUserSessions(ctx, userID)
+	if err != nil {
+		return 0, err
 	}
 
-	return count, nil
+	return int64(len(sessions)), nil
 }
 
 // Cleanup removes expired sessions (Redis handles this automatically, but this can be used for manual cleanup)
@@ -240,6 +354,11 @@ func (s *RedisSessionStore) getKey(sessionID string) string {
 	return s.prefix + sessionID
 }
 
+// getUserKey generates the Redis key for the set of a user's session IDs
+func (s *RedisSessionStore) getUserKey(userID string) string {
+	return s.userPrefix + userID
+}
+
 // SetPrefix sets the key prefix for sessions
 func (s *RedisSessionStore) SetPrefix(prefix string) {
 	s.prefix = prefix
@@ -249,3 +368,134 @@ func (s *RedisSessionStore) SetPrefix(prefix string) {
 func (s *RedisSessionStore) GetPrefix() string {
 	return s.prefix
 }
+
+// RedisResetTokenStore implements ResetTokenStore using Redis. Each token is a random ULID
+// whose key holds the ID of the user it resets, and expires with the token.
+type RedisResetTokenStore struct {
+	client redis.Cmdable
+	logger zerolog.Logger
+	prefix string
+}
+
+// NewRedisResetTokenStore creates a new Redis password reset token store
+func NewRedisResetTokenStore(client redis.Cmdable, logger zerolog.Logger) *RedisResetTokenStore {
+	return &RedisResetTokenStore{
+		client: client,
+		logger: logger,
+		prefix: "password_reset:",
+	}
+}
+
+// Create stores a new reset token for the user and returns it
+func (s *RedisResetTokenStore) Create(ctx context.Context, userID string, expiration time.Duration) (string, error) {
+	token, err := ulid.New(ulid.Timestamp(time.Now()), rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	if err := s.client.Set(ctx, s.prefix+token.String(), userID, expiration).Err(); err != nil {
+		s.logger.Error().Err(err).Str("user_id", userID).Msg("Failed to store password reset token in Redis.")
+		return "", fmt.Errorf("failed to store reset token: %w", err)
+	}
+
+	return token.String(), nil
+}
+
+// Get returns the ID of the user a token resets without using it up
+func (s *RedisResetTokenStore) Get(ctx context.Context, token string) (string, error) {
+	userID, err := s.client.Get(ctx, s.prefix+token).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return "", ErrInvalidResetToken
+		}
+		s.logger.Error().Err(err).Msg("Failed to get password reset token from Redis.")
+		return "", fmt.Errorf("failed to get reset token: %w", err)
+	}
+
+	return userID, nil
+}
+
+// Consume deletes a token and returns the ID of the user it reset. The read and delete are a
+// single command, so concurrent requests cannot both use the same token.
+func (s *RedisResetTokenStore) Consume(ctx context.Context, token string) (string, error) {
+	userID, err := s.client.GetDel(ctx, s.prefix+token).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return "", ErrInvalidResetToken
+		}
+		s.logger.Error().Err(err).Msg("Failed to consume password reset token in Redis.")
+		return "", fmt.Errorf("failed to consume reset token: %w", err)
+	}
+
+	return userID, nil
+}
+
+// RedisLoginAttemptStore implements LoginAttemptStore using Redis. Failures are a counter that
+// expires a window after the last one; a lock is a key that expires when the lockout ends.
+type RedisLoginAttemptStore struct {
+	client     redis.Cmdable
+	logger     zerolog.Logger
+	prefix     string
+	lockPrefix string
+}
+
+// NewRedisLoginAttemptStore creates a new Redis failed login store
+func NewRedisLoginAttemptStore(client redis.Cmdable, logger zerolog.Logger) *RedisLoginAttemptStore {
+	return &RedisLoginAttemptStore{
+		client:     client,
+		logger:     logger,
+		prefix:     "login_failures:",
+		lockPrefix: "login_lock:",
+	}
+}
+
+// RecordFailure counts a failed login and returns the failures within the window
+func (s *RedisLoginAttemptStore) RecordFailure(ctx context.Context, key string, window time.Duration) (int64, error) {
+	failures, err := s.client.Incr(ctx, s.prefix+key).Result()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to count failed login in Redis.")
+		return 0, fmt.Errorf("failed to record failed login: %w", err)
+	}
+
+	if err := s.client.Expire(ctx, s.prefix+key, window).Err(); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to set failed login expiration in Redis.")
+		return 0, fmt.Errorf("failed to record failed login: %w", err)
+	}
+
+	return failures, nil
+}
+
+// Lock locks the account for duration and starts its failure count over
+func (s *RedisLoginAttemptStore) Lock(ctx context.Context, key string, duration time.Duration) error {
+	if err := s.client.Set(ctx, s.lockPrefix+key, 1, duration).Err(); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to store account lock in Redis.")
+		return fmt.Errorf("failed to lock account: %w", err)
+	}
+
+	return s.Reset(ctx, key)
+}
+
+// LockedFor returns how long the account stays locked, or 0 when it is not locked
+func (s *RedisLoginAttemptStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(ctx, s.lockPrefix+key).Result()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to get account lock from Redis.")
+		return 0, fmt.Errorf("failed to get account lock: %w", err)
+	}
+
+	// Missing keys have a negative TTL
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// Reset clears the failure count of the account
+func (s *RedisLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, s.prefix+key).Err(); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to reset failed logins in Redis.")
+		return fmt.Errorf("failed to reset failed logins: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,346 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go-fiber/internal/models"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis is an in-memory stand-in for the Redis commands used by RedisSessionStore
+type fakeRedis struct {
+	redis.Cmdable
+	strings map[string]string
+	sets    map[string]map[string]struct{}
+	ttls    map[string]time.Duration
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{
+		strings: make(map[string]string),
+		sets:    make(map[string]map[string]struct{}),
+		ttls:    make(map[string]time.Duration),
+	}
+}
+
+func (f *fakeRedis) Set(_ context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	switch v := value.(type) {
+	case []byte:
+		f.strings[key] = string(v)
+	default:
+		f.strings[key] = fmt.Sprint(v)
+	}
+	f.ttls[key] = expiration
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (f *fakeRedis) Incr(_ context.Context, key string) *redis.IntCmd {
+	value, _ := strconv.ParseInt(f.strings[key], 10, 64)
+	value++
+	f.strings[key] = strconv.FormatInt(value, 10)
+	return redis.NewIntResult(value, nil)
+}
+
+// PTTL reports the expiration the key was set with, or -2 for keys that do not exist
+func (f *fakeRedis) PTTL(_ context.Context, key string) *redis.DurationCmd {
+	if _, ok := f.strings[key]; !ok {
+		return redis.NewDurationResult(-2, nil)
+	}
+	return redis.NewDurationResult(f.ttls[key], nil)
+}
+
+func (f *fakeRedis) GetDel(_ context.Context, key string) *redis.StringCmd {
+	value, ok := f.strings[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	delete(f.strings, key)
+	return redis.NewStringResult(value, nil)
+}
+
+func (f *fakeRedis) Get(_ context.Context, key string) *redis.StringCmd {
+	value, ok := f.strings[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(value, nil)
+}
+
+func (f *fakeRedis) MGet(_ context.Context, keys ...string) *redis.SliceCmd {
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		if value, ok := f.strings[key]; ok {
+			values[i] = value
+		}
+	}
+	return redis.NewSliceResult(values, nil)
+}
+
+func (f *fakeRedis) Del(_ context.Context, keys ...string) *redis.IntCmd {
+	var deleted int64
+	for _, key := range keys {
+		if _, ok := f.strings[key]; ok {
+			delete(f.strings, key)
+			deleted++
+		}
+		if _, ok := f.sets[key]; ok {
+			delete(f.sets, key)
+			deleted++
+		}
+	}
+	return redis.NewIntResult(deleted, nil)
+}
+
+func (f *fakeRedis) SAdd(_ context.Context, key string, members ...interface{}) *redis.IntCmd {
+	if f.sets[key] == nil {
+		f.sets[key] = make(map[string]struct{})
+	}
+	for _, member := range members {
+		f.sets[key][fmt.Sprint(member)] = struct{}{}
+	}
+	return redis.NewIntResult(int64(len(members)), nil)
+}
+
+func (f *fakeRedis) SRem(_ context.Context, key string, members ...interface{}) *redis.IntCmd {
+	for _, member := range members {
+		delete(f.sets[key], fmt.Sprint(member))
+	}
+	return redis.NewIntResult(int64(len(members)), nil)
+}
+
+func (f *fakeRedis) SMembers(_ context.Context, key string) *redis.StringSliceCmd {
+	var members []string
+	for member := range f.sets[key] {
+		members = append(members, member)
+	}
+	return redis.NewStringSliceResult(members, nil)
+}
+
+func (f *fakeRedis) TTL(_ context.Context, _ string) *redis.DurationCmd {
+	return redis.NewDurationResult(-1, nil)
+}
+
+func (f *fakeRedis) Expire(_ context.Context, _ string, _ time.Duration) *redis.BoolCmd {
+	return redis.NewBoolResult(true, nil)
+}
+
+// Scan pages through the sorted string keys, using the cursor as an offset
+func (f *fakeRedis) Scan(_ context.Context, cursor uint64, match string, count int64) *redis.ScanCmd {
+	prefix := strings.TrimSuffix(match, "*")
+	var keys []string
+	for key := range f.strings {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	end := cursor + uint64(count)
+	if end >= uint64(len(keys)) {
+		return redis.NewScanCmdResult(keys[cursor:], 0, nil)
+	}
+	return redis.NewScanCmdResult(keys[cursor:end], end, nil)
+}
+
+func TestRedisSessionStore_UserSessions(t *testing.T) {
+	ctx := context.Background()
+
+	// Arrange - 4 users with 200 sessions each
+	client := newFakeRedis()
+	store := NewRedisSessionStore(client, zerolog.Nop())
+	for u := 0; u < 4; u++ {
+		userID := fmt.Sprintf("user-%d", u)
+		for i := 0; i < 200; i++ {
+			sessionID := fmt.Sprintf("%s-session-%d", userID, i)
+			session := &models.Session{ID: sessionID, UserID: userID, ExpiresAt: time.Now().Add(time.Hour), IsActive: true}
+			require.NoError(t, store.Set(ctx, sessionID, session, time.Hour))
+		}
+	}
+
+	t.Run("counts all and per-user sessions", func(t *testing.T) {
+		// Act
+		total, err := store.Count(ctx)
+		require.NoError(t, err)
+		perUser, err := store.CountUserSessions(ctx, "user-1")
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, int64(800), total)
+		assert.Equal(t, int64(200), perUser)
+	})
+
+	t.Run("deletes only the sessions of one user", func(t *testing.T) {
+		// Act
+		deleted, err := store.DeleteUserSessions(ctx, "user-0")
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, int64(200), deleted)
+		total, _ := store.Count(ctx)
+		assert.Equal(t, int64(600), total)
+		remaining, _ := store.CountUserSessions(ctx, "user-0")
+		assert.Equal(t, int64(0), remaining)
+		untouched, _ := store.CountUserSessions(ctx, "user-1")
+		assert.Equal(t, int64(200), untouched)
+	})
+
+	t.Run("deletes the other sessions of one user but keeps the current one", func(t *testing.T) {
+		// Act
+		deleted, err := store.DeleteOtherUserSessions(ctx, "user-3", "user-3-session-7")
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, int64(199), deleted)
+		remaining, _ := store.CountUserSessions(ctx, "user-3")
+		assert.Equal(t, int64(1), remaining)
+		kept, err := store.Get(ctx, "user-3-session-7")
+		require.NoError(t, err)
+		assert.Equal(t, "user-3", kept.UserID)
+		untouched, _ := store.CountUserSessions(ctx, "user-1")
+		assert.Equal(t, int64(200), untouched)
+	})
+
+	t.Run("delete removes the session from the user index", func(t *testing.T) {
+		// Act
+		require.NoError(t, store.Delete(ctx, "user-1-session-0"))
+
+		// Assert
+		count, _ := store.CountUserSessions(ctx, "user-1")
+		assert.Equal(t, int64(199), count)
+		assert.NotContains(t, client.sets["user_sessions:user-1"], "user-1-session-0")
+	})
+
+	t.Run("expired sessions are pruned from the user index", func(t *testing.T) {
+		// Arrange - simulate Redis expiring the session key
+		delete(client.strings, "session:user-2-session-0")
+
+		// Act
+		sessions, err := store.GetUserSessions(ctx, "user-2")
+		require.NoError(t, err)
+
+		// Assert
+		assert.Len(t, sessions, 199)
+		for _, session := range sessions {
+			assert.Equal(t, "user-2", session.UserID)
+		}
+		assert.Len(t, client.sets["user_sessions:user-2"], 199)
+	})
+}
+
+func TestRedisSessionStore_DeviceMetadata(t *testing.T) {
+	ctx := context.Background()
+
+	// Arrange
+	client := newFakeRedis()
+	store := NewRedisSessionStore(client, zerolog.Nop())
+	session := &models.Session{ID: "session-1", UserID: "user-1", ExpiresAt: time.Now().Add(time.Hour), IsActive: true, UserAgent: "Mozilla/5.0", IP: "203.0.113.7"}
+
+	// Act
+	require.NoError(t, store.Set(ctx, "session-1", session, time.Hour))
+	stored, err := store.Get(ctx, "session-1")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "Mozilla/5.0", stored.UserAgent)
+	assert.Equal(t, "203.0.113.7", stored.IP)
+	assert.Contains(t, client.strings["session:session-1"], `"userAgent":"Mozilla/5.0","ip":"203.0.113.7"`)
+}
+
+func TestRedisResetTokenStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("token can only be consumed once", func(t *testing.T) {
+		// Arrange
+		store := NewRedisResetTokenStore(newFakeRedis(), zerolog.Nop())
+		token, err := store.Create(ctx, "user-1", 15*time.Minute)
+		require.NoError(t, err)
+
+		// Act
+		userID, getErr := store.Get(ctx, token)
+		consumedBy, consumeErr := store.Consume(ctx, token)
+		_, secondErr := store.Consume(ctx, token)
+
+		// Assert
+		assert.NoError(t, getErr)
+		assert.Equal(t, "user-1", userID)
+		assert.NoError(t, consumeErr)
+		assert.Equal(t, "user-1", consumedBy)
+		assert.ErrorIs(t, secondErr, ErrInvalidResetToken)
+	})
+
+	t.Run("expired or unknown token", func(t *testing.T) {
+		// Arrange - simulate Redis expiring the token key
+		client := newFakeRedis()
+		store := NewRedisResetTokenStore(client, zerolog.Nop())
+		token, err := store.Create(ctx, "user-1", 15*time.Minute)
+		require.NoError(t, err)
+		delete(client.strings, "password_reset:"+token)
+
+		// Act
+		_, getErr := store.Get(ctx, token)
+		_, consumeErr := store.Consume(ctx, "unknown")
+
+		// Assert
+		assert.ErrorIs(t, getErr, ErrInvalidResetToken)
+		assert.ErrorIs(t, consumeErr, ErrInvalidResetToken)
+	})
+
+	t.Run("tokens are unique", func(t *testing.T) {
+		store := NewRedisResetTokenStore(newFakeRedis(), zerolog.Nop())
+		first, _ := store.Create(ctx, "user-1", time.Minute)
+		second, _ := store.Create(ctx, "user-1", time.Minute)
+		assert.NotEqual(t, first, second)
+	})
+}
+
+func TestRedisLoginAttemptStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("counts failures until reset", func(t *testing.T) {
+		// Arrange
+		store := NewRedisLoginAttemptStore(newFakeRedis(), zerolog.Nop())
+
+		// Act
+		first, _ := store.RecordFailure(ctx, "user:user-1", 15*time.Minute)
+		second, _ := store.RecordFailure(ctx, "user:user-1", 15*time.Minute)
+		other, _ := store.RecordFailure(ctx, "user:user-2", 15*time.Minute)
+		require.NoError(t, store.Reset(ctx, "user:user-1"))
+		afterReset, _ := store.RecordFailure(ctx, "user:user-1", 15*time.Minute)
+
+		// Assert
+		assert.Equal(t, int64(1), first)
+		assert.Equal(t, int64(2), second)
+		assert.Equal(t, int64(1), other)
+		assert.Equal(t, int64(1), afterReset)
+	})
+
+	t.Run("lock lasts until its key expires", func(t *testing.T) {
+		// Arrange
+		client := newFakeRedis()
+		store := NewRedisLoginAttemptStore(client, zerolog.Nop())
+		_, _ = store.RecordFailure(ctx, "user:user-1", 15*time.Minute)
+
+		// Act
+		require.NoError(t, store.Lock(ctx, "user:user-1", 10*time.Minute))
+		locked, lockedErr := store.LockedFor(ctx, "user:user-1")
+		delete(client.strings, "login_lock:user:user-1")
+		expired, expiredErr := store.LockedFor(ctx, "user:user-1")
+
+		// Assert
+		assert.NoError(t, lockedErr)
+		assert.Equal(t, 10*time.Minute, locked)
+		assert.NotContains(t, client.strings, "login_failures:user:user-1")
+		assert.NoError(t, expiredErr)
+		assert.Zero(t, expired)
+	})
+}
@@ -0,0 +1,439 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"go-fiber/internal/config"
+	"go-fiber/internal/models"
+	"go-fiber/internal/repository/interfaces"
+
+	"github.com/rs/zerolog"
+)
+
+// TrashPurgeInterval is how often deleted todos past the trash retention period are purged
+const TrashPurgeInterval = time.Hour
+
+// TodoService handles todo operations that span more than one repository call
+type TodoService struct {
+	todoRepo interfaces.TodoRepository
+	config   *config.TodoConfig
+	logger   zerolog.Logger
+
+	insightsMu sync.Mutex
+	insights   map[insightsKey]cachedInsights
+}
+
+// insightsKey identifies a cached insights result
+type insightsKey struct {
+	userID   string
+	year     int
+	timezone string
+}
+
+// cachedInsights is an insights result and when it stops being reused
+type cachedInsights struct {
+	insights  *models.UserInsights
+	expiresAt time.Time
+}
+
+// NewTodoService creates a new todo service
+func NewTodoService(todoRepo interfaces.TodoRepository, config *config.TodoConfig, logger zerolog.Logger) *TodoService {
+	return &TodoService{
+		todoRepo: todoRepo,
+		config:   config,
+		logger:   logger,
+	}
+}
+
+// Update applies the non-empty fields of req to a todo owned by userID. With an auto start
+// status configured, a todo still in the default status moves to it when the update changes
+// another field without setting a status. An all-day todo keeps only the day of its due date.
+func (s *TodoService) Update(ctx context.Context, userID, todoID string, req *models.UpdateTodoRequest) (*models.Todo, error) {
+	todo, err := s.getOwnedTodo(ctx, userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := false
+	if req.Title != "" && req.Title != todo.Title {
+		todo.Title, changed = req.Title, true
+	}
+	if req.Description != "" && req.Description != todo.Description {
+		todo.Description, changed = req.Description, true
+	}
+	if req.Priority != "" && req.Priority != todo.Priority {
+		todo.Priority, changed = req.Priority, true
+	}
+	if req.AllDay != nil && *req.AllDay != todo.AllDay {
+		todo.AllDay, changed = *req.AllDay, true
+	}
+	dueDate := req.DueDate
+	if dueDate == nil {
+		dueDate = todo.DueDate
+	}
+	if dueDate != nil && todo.AllDay {
+		day := models.AllDayDate(*dueDate)
+		dueDate = &day
+	}
+	if dueDate != nil && (todo.DueDate == nil || !dueDate.Equal(*todo.DueDate)) {
+		todo.DueDate, changed = dueDate, true
+	}
+	if req.Tags != nil {
+		if tags := models.NormalizeTags(req.Tags); !slices.Equal(tags, todo.Tags) {
+			todo.Tags, changed = tags, true
+		}
+	}
+
+	switch {
+	case req.Status != "":
+		todo.Status = req.Status
+	case changed && s.config.AutoStartStatus != "" && todo.Status == models.DefaultStatus():
+		s.logger.Debug().Str("todo_id", todo.ID).Str("status", s.config.AutoStartStatus).Msg("Todo started automatically on first edit.")
+		todo.Status = s.config.AutoStartStatus
+	}
+
+	return s.todoRepo.Update(ctx, todo)
+}
+
+// Patch applies only the fields present in req to a todo owned by userID. Unlike Update, an
+// empty description or a null due date clears the field. Auto start and all-day due dates
+// behave as in Update.
+func (s *TodoService) Patch(ctx context.Context, userID, todoID string, req *models.PatchTodoRequest) (*models.Todo, error) {
+	todo, err := s.getOwnedTodo(ctx, userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := *req
+	allDay := todo.AllDay
+	if patch.AllDay != nil {
+		allDay = *patch.AllDay
+	}
+	switch {
+	case patch.DueDate.Value != nil && allDay:
+		day := models.AllDayDate(*patch.DueDate.Value)
+		patch.DueDate.Value = &day
+	case !patch.DueDate.Set && allDay && !todo.AllDay && todo.DueDate != nil:
+		// Switching to all-day keeps only the day of the current due date
+		day := models.AllDayDate(*todo.DueDate)
+		patch.DueDate = models.NullableTime{Set: true, Value: &day}
+	}
+	if patch.Tags != nil {
+		patch.Tags = models.NormalizeTags(patch.Tags)
+	}
+
+	if patch.Status == nil && s.config.AutoStartStatus != "" && todo.Status == models.DefaultStatus() && patchChanges(todo, &patch) {
+		s.logger.Debug().Str("todo_id", todo.ID).Str("status", s.config.AutoStartStatus).Msg("Todo started automatically on first edit.")
+		status := s.config.AutoStartStatus
+		patch.Status = &status
+	}
+
+	return s.todoRepo.PartialUpdate(ctx, userID, todoID, &patch)
+}
+
+// patchChanges reports whether patch changes any field of todo other than its status
+func patchChanges(todo *models.Todo, patch *models.PatchTodoRequest) bool {
+	switch {
+	case patch.Title != nil && *patch.Title != todo.Title,
+		patch.Description != nil && *patch.Description != todo.Description,
+		patch.Priority != nil && *patch.Priority != todo.Priority,
+		patch.AllDay != nil && *patch.AllDay != todo.AllDay,
+		patch.Tags != nil && !slices.Equal(patch.Tags, todo.Tags):
+		return true
+	case !patch.DueDate.Set:
+		return false
+	case patch.DueDate.Value == nil || todo.DueDate == nil:
+		return patch.DueDate.Value != todo.DueDate
+	default:
+		return !patch.DueDate.Value.Equal(*todo.DueDate)
+	}
+}
+
+// ErrSubtaskNotFound is returned when a subtask index is outside the todo's checklist
+var ErrSubtaskNotFound = errors.New("subtask not found")
+
+// ErrTooManySubtasks is returned when a todo's checklist is already full
+var ErrTooManySubtasks = fmt.Errorf("a todo can have at most %d subtasks", models.MaxSubtasks)
+
+// AddSubtask appends a subtask to a todo owned by userID
+func (s *TodoService) AddSubtask(ctx context.Context, userID, todoID, title string) (*models.Todo, error) {
+	todo, err := s.getOwnedTodo(ctx, userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+	if len(todo.Subtasks) >= models.MaxSubtasks {
+		return nil, ErrTooManySubtasks
+	}
+
+	todo.Subtasks = append(todo.Subtasks, models.Subtask{Title: title})
+	return s.todoRepo.Update(ctx, todo)
+}
+
+// UpdateSubtask applies the present fields of req to the subtask at index of a todo owned by userID
+func (s *TodoService) UpdateSubtask(ctx context.Context, userID, todoID string, index int, req *models.UpdateSubtaskRequest) (*models.Todo, error) {
+	todo, err := s.getOwnedTodo(ctx, userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(todo.Subtasks) {
+		return nil, ErrSubtaskNotFound
+	}
+
+	if req.Title != "" {
+		todo.Subtasks[index].Title = req.Title
+	}
+	if req.Done != nil {
+		todo.Subtasks[index].Done = *req.Done
+	}
+	return s.todoRepo.Update(ctx, todo)
+}
+
+// DeleteSubtask removes the subtask at index of a todo owned by userID; later subtasks move up
+func (s *TodoService) DeleteSubtask(ctx context.Context, userID, todoID string, index int) (*models.Todo, error) {
+	todo, err := s.getOwnedTodo(ctx, userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(todo.Subtasks) {
+		return nil, ErrSubtaskNotFound
+	}
+
+	todo.Subtasks = slices.Delete(todo.Subtasks, index, index+1)
+	return s.todoRepo.Update(ctx, todo)
+}
+
+// ErrMergeIntoItself is returned when a todo is merged into itself
+var ErrMergeIntoItself = errors.New("cannot merge a todo into itself")
+
+// Merge folds the source todo into the target: the source's description is appended,
+// the due date is chosen by the configured strategy, and the source is soft deleted.
+// Both todos must belong to userID.
+func (s *TodoService) Merge(ctx context.Context, userID, sourceID, targetID string) (*models.Todo, error) {
+	if sourceID == targetID {
+		return nil, ErrMergeIntoItself
+	}
+
+	source, err := s.getOwnedTodo(ctx, userID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.getOwnedTodo(ctx, userID, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := *target
+	merged.Description = mergeDescriptions(target.Description, source.Description)
+	merged.DueDate, merged.AllDay = s.mergeDueDates(target, source)
+	merged.Tags = models.NormalizeTags(append(slices.Clone(target.Tags), source.Tags...))
+
+	result, err := s.todoRepo.Merge(ctx, &merged, source.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info().Str("todo_id", result.ID).Str("source_id", source.ID).Str("user_id", userID).Msg("Todos merged.")
+	return result, nil
+}
+
+// Agenda builds the user's agenda for day's calendar day in day's location. Todos overdue
+// when the day starts are listed apart from those due during it, so none appear twice.
+func (s *TodoService) Agenda(ctx context.Context, userID string, day time.Time) (*models.Agenda, error) {
+	dayRange := models.NewDayRange(day)
+
+	overdue, overdueTotal, err := s.todoRepo.GetOverdue(ctx, userID, models.NewOverdueCutoff(dayRange.Start), models.MaxAgendaOverdue, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	due, err := s.todoRepo.GetDueOn(ctx, userID, dayRange)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := day.Location()
+	sortAgendaTodos(overdue, loc)
+	sortAgendaTodos(due, loc)
+
+	return &models.Agenda{
+		Date:         dayRange.Start.Format(time.DateOnly),
+		Overdue:      overdue,
+		OverdueTotal: overdueTotal,
+		Due:          due,
+	}, nil
+}
+
+// Insights builds the user's year in review over the todos they created during year in loc,
+// composed from separate aggregations. Results are reused for the configured insights cache
+// TTL, per user, year and timezone.
+func (s *TodoService) Insights(ctx context.Context, userID string, year int, loc *time.Location) (*models.UserInsights, error) {
+	key := insightsKey{userID: userID, year: year, timezone: loc.String()}
+	if insights, ok := s.cachedInsights(key); ok {
+		return insights, nil
+	}
+
+	window := models.NewCompletionWindow(
+		time.Date(year, time.January, 1, 0, 0, 0, 0, loc),
+		time.Date(year, time.December, 31, 0, 0, 0, 0, loc),
+		models.CompletionByCreated,
+	)
+
+	completed, created, err := s.todoRepo.CountCompletion(ctx, userID, window)
+	if err != nil {
+		return nil, err
+	}
+
+	busiestDay, err := s.todoRepo.GetBusiestDay(ctx, userID, window)
+	if err != nil {
+		return nil, err
+	}
+
+	topTags, err := s.todoRepo.CountTopTags(ctx, userID, window, models.MaxInsightTags)
+	if err != nil {
+		return nil, err
+	}
+
+	insights := &models.UserInsights{
+		Year:       year,
+		Timezone:   key.timezone,
+		Created:    created,
+		Completed:  completed,
+		BusiestDay: busiestDay,
+		TopTags:    topTags,
+	}
+	if created > 0 {
+		insights.CompletionRate = float64(completed) / float64(created)
+	}
+	if completed > 0 {
+		average, err := s.todoRepo.AverageCompletionTime(ctx, userID, window)
+		if err != nil {
+			return nil, err
+		}
+		hours := math.Round(average.Hours()*100) / 100
+		insights.AverageCompletionHours = &hours
+	}
+
+	s.cacheInsights(key, insights)
+	return insights, nil
+}
+
+// cachedInsights returns the insights cached under key while they are fresh
+func (s *TodoService) cachedInsights(key insightsKey) (*models.UserInsights, bool) {
+	s.insightsMu.Lock()
+	defer s.insightsMu.Unlock()
+
+	cached, ok := s.insights[key]
+	if !ok || !time.Now().Before(cached.expiresAt) {
+		return nil, false
+	}
+	return cached.insights, true
+}
+
+// cacheInsights stores insights under key for the configured TTL, dropping expired entries
+// so the cache only holds recently requested results
+func (s *TodoService) cacheInsights(key insightsKey, insights *models.UserInsights) {
+	if s.config.InsightsCacheTTL <= 0 {
+		return
+	}
+
+	s.insightsMu.Lock()
+	defer s.insightsMu.Unlock()
+
+	now := time.Now()
+	if s.insights == nil {
+		s.insights = make(map[insightsKey]cachedInsights)
+	}
+	for k, cached := range s.insights {
+		if !now.Before(cached.expiresAt) {
+			delete(s.insights, k)
+		}
+	}
+	s.insights[key] = cachedInsights{insights: insights, expiresAt: now.Add(s.config.InsightsCacheTTL)}
+}
+
+// PurgeTrash permanently removes todos deleted more than the configured retention period
+// ago. It is run periodically by the scheduler and stops when ctx is cancelled.
+func (s *TodoService) PurgeTrash(ctx context.Context) error {
+	if s.config.TrashRetentionDays <= 0 {
+		return nil
+	}
+
+	olderThan := time.Now().AddDate(0, 0, -s.config.TrashRetentionDays)
+	purged, err := s.todoRepo.PurgeDeleted(ctx, olderThan)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to purge trash.")
+		return fmt.Errorf("failed to purge trash: %w", err)
+	}
+
+	if purged > 0 {
+		s.logger.Info().Int64("purged", purged).Int("retention_days", s.config.TrashRetentionDays).Msg("Trash purged.")
+	}
+	return nil
+}
+
+// sortAgendaTodos orders todos by priority, highest first, then by due time in loc. An
+// all-day todo counts as due at the start of its day, ahead of timed todos that day.
+func sortAgendaTodos(todos []*models.Todo, loc *time.Location) {
+	slices.SortStableFunc(todos, func(a, b *models.Todo) int {
+		if rankA, rankB := models.PriorityRank(a.Priority), models.PriorityRank(b.Priority); rankA != rankB {
+			return rankB - rankA
+		}
+		return agendaDueTime(a, loc).Compare(agendaDueTime(b, loc))
+	})
+}
+
+// agendaDueTime returns the instant a todo is due in loc, taking an all-day todo's local midnight
+func agendaDueTime(todo *models.Todo, loc *time.Location) time.Time {
+	if todo.DueDate == nil {
+		return time.Time{}
+	}
+	if todo.AllDay {
+		year, month, day := todo.DueDate.Date()
+		return time.Date(year, month, day, 0, 0, 0, 0, loc)
+	}
+	return *todo.DueDate
+}
+
+// getOwnedTodo loads a todo, reporting todos owned by other users as not found
+func (s *TodoService) getOwnedTodo(ctx context.Context, userID, todoID string) (*models.Todo, error) {
+	return s.todoRepo.GetByIDForUser(ctx, todoID, userID)
+}
+
+// mergeDescriptions appends the source description to the target's as a new paragraph
+func mergeDescriptions(target, source string) string {
+	switch {
+	case source == "":
+		return target
+	case target == "":
+		return source
+	default:
+		return target + "\n\n" + source
+	}
+}
+
+// mergeDueDates picks the merged due date, and whether it is all-day, according to the
+// configured strategy
+func (s *TodoService) mergeDueDates(target, source *models.Todo) (*time.Time, bool) {
+	if s.config.MergeDueDateStrategy == "target" || source.DueDate == nil {
+		return target.DueDate, target.AllDay
+	}
+	if target.DueDate == nil {
+		return source.DueDate, source.AllDay
+	}
+
+	sourceFirst := source.DueDate.Before(*target.DueDate)
+	if s.config.MergeDueDateStrategy == "latest" {
+		sourceFirst = !sourceFirst
+	}
+	if sourceFirst {
+		return source.DueDate, source.AllDay
+	}
+	return target.DueDate, target.AllDay
+}
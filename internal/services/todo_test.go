@@ -0,0 +1,498 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"go-fiber/internal/config"
+	"go-fiber/internal/mocks"
+	"go-fiber/internal/models"
+	"go-fiber/internal/repository/interfaces"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTodoService_Merge(t *testing.T) {
+	ctx := context.Background()
+	earlier := time.Date(2025, 10, 20, 9, 0, 0, 0, time.UTC)
+	later := time.Date(2025, 10, 27, 9, 0, 0, 0, time.UTC)
+
+	newTodos := func() (*models.Todo, *models.Todo) {
+		source := &models.Todo{ID: "source-id", UserID: "user-id", Title: "Buy milk", Description: "Semi-skimmed", DueDate: &earlier}
+		target := &models.Todo{ID: "target-id", UserID: "user-id", Title: "Buy milk", Description: "From the corner shop", DueDate: &later}
+		return source, target
+	}
+
+	t.Run("merges description and earliest due date and deletes the source", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{MergeDueDateStrategy: "earliest"}, zerolog.Nop())
+		source, target := newTodos()
+
+		mockRepo.On("GetByIDForUser", ctx, "source-id", "user-id").Return(source, nil)
+		mockRepo.On("GetByIDForUser", ctx, "target-id", "user-id").Return(target, nil)
+		mockRepo.On("Merge", ctx, mock.MatchedBy(func(merged *models.Todo) bool {
+			return merged.ID == "target-id" &&
+				merged.Description == "From the corner shop\n\nSemi-skimmed" &&
+				merged.DueDate.Equal(earlier)
+		}), "source-id").Return(&models.Todo{ID: "target-id", Description: "From the corner shop\n\nSemi-skimmed", DueDate: &earlier}, nil)
+
+		// Act
+		result, err := service.Merge(ctx, "user-id", "source-id", "target-id")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "target-id", result.ID)
+		assert.Equal(t, earlier, *result.DueDate)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("tags of both todos are combined", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{MergeDueDateStrategy: "earliest"}, zerolog.Nop())
+		source, target := newTodos()
+		source.Tags = []string{"shopping", "Errands"}
+		target.Tags = []string{"errands", "home"}
+
+		mockRepo.On("GetByIDForUser", ctx, "source-id", "user-id").Return(source, nil)
+		mockRepo.On("GetByIDForUser", ctx, "target-id", "user-id").Return(target, nil)
+		mockRepo.On("Merge", ctx, mock.MatchedBy(func(merged *models.Todo) bool {
+			return slices.Equal(merged.Tags, []string{"errands", "home", "shopping"})
+		}), "source-id").Return(target, nil)
+
+		// Act
+		_, err := service.Merge(ctx, "user-id", "source-id", "target-id")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"errands", "home"}, target.Tags)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("latest and target strategies", func(t *testing.T) {
+		for strategy, expected := range map[string]time.Time{"latest": later, "target": later} {
+			mockRepo := new(mocks.MockTodoRepository)
+			service := NewTodoService(mockRepo, &config.TodoConfig{MergeDueDateStrategy: strategy}, zerolog.Nop())
+			source, target := newTodos()
+
+			mockRepo.On("GetByIDForUser", ctx, "source-id", "user-id").Return(source, nil)
+			mockRepo.On("GetByIDForUser", ctx, "target-id", "user-id").Return(target, nil)
+			mockRepo.On("Merge", ctx, mock.MatchedBy(func(merged *models.Todo) bool {
+				return merged.DueDate.Equal(expected)
+			}), "source-id").Return(target, nil)
+
+			_, err := service.Merge(ctx, "user-id", "source-id", "target-id")
+
+			assert.NoError(t, err, strategy)
+			mockRepo.AssertExpectations(t)
+		}
+	})
+
+	t.Run("target keeps its description when the source has none", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{MergeDueDateStrategy: "earliest"}, zerolog.Nop())
+		source, target := newTodos()
+		source.Description = ""
+		source.DueDate = nil
+
+		mockRepo.On("GetByIDForUser", ctx, "source-id", "user-id").Return(source, nil)
+		mockRepo.On("GetByIDForUser", ctx, "target-id", "user-id").Return(target, nil)
+		mockRepo.On("Merge", ctx, mock.MatchedBy(func(merged *models.Todo) bool {
+			return merged.Description == "From the corner shop" && merged.DueDate.Equal(later)
+		}), "source-id").Return(target, nil)
+
+		// Act
+		_, err := service.Merge(ctx, "user-id", "source-id", "target-id")
+
+		// Assert
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("source owned by another user", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{MergeDueDateStrategy: "earliest"}, zerolog.Nop())
+
+		// The repository only finds todos owned by the given user
+		mockRepo.On("GetByIDForUser", ctx, "source-id", "user-id").Return(nil, interfaces.ErrTodoNotFound)
+
+		// Act
+		result, err := service.Merge(ctx, "user-id", "source-id", "target-id")
+
+		// Assert
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, interfaces.ErrTodoNotFound)
+		mockRepo.AssertNotCalled(t, "Merge", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("same source and target", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{MergeDueDateStrategy: "earliest"}, zerolog.Nop())
+
+		// Act
+		_, err := service.Merge(ctx, "user-id", "target-id", "target-id")
+
+		// Assert
+		assert.ErrorIs(t, err, ErrMergeIntoItself)
+		mockRepo.AssertNotCalled(t, "GetByIDForUser", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestTodoService_Update(t *testing.T) {
+	ctx := context.Background()
+
+	newTodo := func() *models.Todo {
+		return &models.Todo{ID: "todo-id", UserID: "user-id", Title: "Buy milk", Status: models.TodoStatusPending, Priority: models.TodoPriorityMedium}
+	}
+
+	t.Run("auto start moves a pending todo on its first edit", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{AutoStartStatus: models.TodoStatusInProgress}, zerolog.Nop())
+
+		mockRepo.On("GetByIDForUser", ctx, "todo-id", "user-id").Return(newTodo(), nil)
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(todo *models.Todo) bool {
+			return todo.Title == "Buy oat milk" && todo.Status == models.TodoStatusInProgress
+		})).Return(&models.Todo{ID: "todo-id", Status: models.TodoStatusInProgress}, nil)
+
+		// Act
+		result, err := service.Update(ctx, "user-id", "todo-id", &models.UpdateTodoRequest{Title: "Buy oat milk"})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, models.TodoStatusInProgress, result.Status)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("explicit status overrides auto start", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{AutoStartStatus: models.TodoStatusInProgress}, zerolog.Nop())
+
+		mockRepo.On("GetByIDForUser", ctx, "todo-id", "user-id").Return(newTodo(), nil)
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(todo *models.Todo) bool {
+			return todo.Title == "Buy oat milk" && todo.Status == models.TodoStatusCompleted
+		})).Return(newTodo(), nil)
+
+		// Act
+		_, err := service.Update(ctx, "user-id", "todo-id", &models.UpdateTodoRequest{Title: "Buy oat milk", Status: models.TodoStatusCompleted})
+
+		// Assert
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("status is kept when auto start is disabled", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{}, zerolog.Nop())
+
+		mockRepo.On("GetByIDForUser", ctx, "todo-id", "user-id").Return(newTodo(), nil)
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(todo *models.Todo) bool {
+			return todo.Priority == models.TodoPriorityHigh && todo.Status == models.TodoStatusPending
+		})).Return(newTodo(), nil)
+
+		// Act
+		_, err := service.Update(ctx, "user-id", "todo-id", &models.UpdateTodoRequest{Priority: models.TodoPriorityHigh})
+
+		// Assert
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("unchanged fields do not start the todo", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{AutoStartStatus: models.TodoStatusInProgress}, zerolog.Nop())
+
+		mockRepo.On("GetByIDForUser", ctx, "todo-id", "user-id").Return(newTodo(), nil)
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(todo *models.Todo) bool {
+			return todo.Status == models.TodoStatusPending
+		})).Return(newTodo(), nil)
+
+		// Act
+		_, err := service.Update(ctx, "user-id", "todo-id", &models.UpdateTodoRequest{Title: "Buy milk"})
+
+		// Assert
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("tags are normalized and replaced", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{}, zerolog.Nop())
+		todo := newTodo()
+		todo.Tags = []string{"home"}
+
+		mockRepo.On("GetByIDForUser", ctx, "todo-id", "user-id").Return(todo, nil)
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(todo *models.Todo) bool {
+			return assert.ObjectsAreEqual([]string{"work", "urgent"}, todo.Tags)
+		})).Return(todo, nil)
+
+		// Act
+		_, err := service.Update(ctx, "user-id", "todo-id", &models.UpdateTodoRequest{Tags: []string{"Work", "urgent", "work"}})
+
+		// Assert
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("tags are kept when not sent", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{}, zerolog.Nop())
+		todo := newTodo()
+		todo.Tags = []string{"home"}
+
+		mockRepo.On("GetByIDForUser", ctx, "todo-id", "user-id").Return(todo, nil)
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(todo *models.Todo) bool {
+			return assert.ObjectsAreEqual([]string{"home"}, todo.Tags)
+		})).Return(todo, nil)
+
+		// Act
+		_, err := service.Update(ctx, "user-id", "todo-id", &models.UpdateTodoRequest{Title: "Buy oat milk"})
+
+		// Assert
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("todo owned by another user", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{}, zerolog.Nop())
+
+		mockRepo.On("GetByIDForUser", ctx, "todo-id", "other-user-id").Return(nil, interfaces.ErrTodoNotFound)
+
+		// Act
+		_, err := service.Update(ctx, "other-user-id", "todo-id", &models.UpdateTodoRequest{Title: "Buy oat milk"})
+
+		// Assert
+		assert.ErrorIs(t, err, interfaces.ErrTodoNotFound)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTodoService_Patch(t *testing.T) {
+	ctx := context.Background()
+
+	newTodo := func() *models.Todo {
+		return &models.Todo{ID: "todo-id", UserID: "user-id", Title: "Buy milk", Description: "Semi-skimmed", Status: models.TodoStatusPending, Priority: models.TodoPriorityMedium}
+	}
+
+	t.Run("clearing the description starts the todo", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{AutoStartStatus: models.TodoStatusInProgress}, zerolog.Nop())
+		description := ""
+
+		mockRepo.On("GetByIDForUser", ctx, "todo-id", "user-id").Return(newTodo(), nil)
+		mockRepo.On("PartialUpdate", ctx, "user-id", "todo-id", mock.MatchedBy(func(patch *models.PatchTodoRequest) bool {
+			return *patch.Description == "" && patch.Status != nil && *patch.Status == models.TodoStatusInProgress
+		})).Return(newTodo(), nil)
+
+		// Act
+		_, err := service.Patch(ctx, "user-id", "todo-id", &models.PatchTodoRequest{Description: &description})
+
+		// Assert
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("all-day due date keeps only its day", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{}, zerolog.Nop())
+		todo := newTodo()
+		todo.AllDay = true
+		dueDate := time.Date(2025, 10, 20, 15, 30, 0, 0, time.UTC)
+
+		mockRepo.On("GetByIDForUser", ctx, "todo-id", "user-id").Return(todo, nil)
+		mockRepo.On("PartialUpdate", ctx, "user-id", "todo-id", mock.MatchedBy(func(patch *models.PatchTodoRequest) bool {
+			return patch.DueDate.Set && patch.DueDate.Value.Equal(time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC)) && patch.Status == nil
+		})).Return(todo, nil)
+
+		// Act
+		_, err := service.Patch(ctx, "user-id", "todo-id", &models.PatchTodoRequest{DueDate: models.NullableTime{Set: true, Value: &dueDate}})
+
+		// Assert
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestTodoService_Insights(t *testing.T) {
+	ctx := context.Background()
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	window := models.NewCompletionWindow(
+		time.Date(2025, time.January, 1, 0, 0, 0, 0, berlin),
+		time.Date(2025, time.December, 31, 0, 0, 0, 0, berlin),
+		models.CompletionByCreated,
+	)
+
+	t.Run("composes the year's aggregations", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{}, zerolog.Nop())
+		busiest := &models.DayCount{Date: "2025-03-14", Count: 9}
+		tags := []models.TagCount{{Tag: "work", Count: 42}, {Tag: "home", Count: 17}}
+
+		mockRepo.On("CountCompletion", ctx, "user-id", window).Return(int64(180), int64(240), nil)
+		mockRepo.On("GetBusiestDay", ctx, "user-id", window).Return(busiest, nil)
+		mockRepo.On("CountTopTags", ctx, "user-id", window, models.MaxInsightTags).Return(tags, nil)
+		mockRepo.On("AverageCompletionTime", ctx, "user-id", window).Return(30*time.Hour+20*time.Minute, nil)
+
+		// Act
+		insights, err := service.Insights(ctx, "user-id", 2025, berlin)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 2025, insights.Year)
+		assert.Equal(t, "Europe/Berlin", insights.Timezone)
+		assert.Equal(t, int64(240), insights.Created)
+		assert.Equal(t, int64(180), insights.Completed)
+		assert.Equal(t, 0.75, insights.CompletionRate)
+		assert.Equal(t, busiest, insights.BusiestDay)
+		assert.Equal(t, tags, insights.TopTags)
+		assert.Equal(t, 30.33, *insights.AverageCompletionHours)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("no todos leaves the rate zero and the day and average empty", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{}, zerolog.Nop())
+
+		mockRepo.On("CountCompletion", ctx, "user-id", window).Return(int64(0), int64(0), nil)
+		mockRepo.On("GetBusiestDay", ctx, "user-id", window).Return(nil, nil)
+		mockRepo.On("CountTopTags", ctx, "user-id", window, models.MaxInsightTags).Return([]models.TagCount{}, nil)
+
+		// Act
+		insights, err := service.Insights(ctx, "user-id", 2025, berlin)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Zero(t, insights.CompletionRate)
+		assert.Nil(t, insights.BusiestDay)
+		assert.Empty(t, insights.TopTags)
+		assert.Nil(t, insights.AverageCompletionHours)
+		mockRepo.AssertNotCalled(t, "AverageCompletionTime", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("repeated requests are served from the cache", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{InsightsCacheTTL: time.Minute}, zerolog.Nop())
+
+		mockRepo.On("CountCompletion", ctx, mock.Anything, mock.Anything).Return(int64(0), int64(3), nil)
+		mockRepo.On("GetBusiestDay", ctx, mock.Anything, mock.Anything).Return(&models.DayCount{Date: "2025-01-02", Count: 3}, nil)
+		mockRepo.On("CountTopTags", ctx, mock.Anything, mock.Anything, models.MaxInsightTags).Return([]models.TagCount{}, nil)
+
+		// Act
+		first, err := service.Insights(ctx, "user-id", 2025, berlin)
+		assert.NoError(t, err)
+		second, err := service.Insights(ctx, "user-id", 2025, berlin)
+		assert.NoError(t, err)
+		_, err = service.Insights(ctx, "user-id", 2025, time.UTC)
+		assert.NoError(t, err)
+
+		// Assert
+		assert.Same(t, first, second)
+		mockRepo.AssertNumberOfCalls(t, "CountCompletion", 2)
+	})
+
+	t.Run("a zero TTL disables the cache", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{}, zerolog.Nop())
+
+		mockRepo.On("CountCompletion", ctx, "user-id", window).Return(int64(0), int64(0), nil)
+		mockRepo.On("GetBusiestDay", ctx, "user-id", window).Return(nil, nil)
+		mockRepo.On("CountTopTags", ctx, "user-id", window, models.MaxInsightTags).Return([]models.TagCount{}, nil)
+
+		// Act
+		_, err := service.Insights(ctx, "user-id", 2025, berlin)
+		assert.NoError(t, err)
+		_, err = service.Insights(ctx, "user-id", 2025, berlin)
+		assert.NoError(t, err)
+
+		// Assert
+		mockRepo.AssertNumberOfCalls(t, "CountCompletion", 2)
+	})
+
+	t.Run("repository errors are not cached", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{InsightsCacheTTL: time.Minute}, zerolog.Nop())
+
+		mockRepo.On("CountCompletion", ctx, "user-id", window).Return(int64(0), int64(0), assert.AnError)
+
+		// Act
+		_, err := service.Insights(ctx, "user-id", 2025, berlin)
+		_, err2 := service.Insights(ctx, "user-id", 2025, berlin)
+
+		// Assert
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.ErrorIs(t, err2, assert.AnError)
+		mockRepo.AssertNumberOfCalls(t, "CountCompletion", 2)
+	})
+}
+
+func TestTodoService_PurgeTrash(t *testing.T) {
+	t.Run("purges todos deleted before the retention period", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{TrashRetentionDays: 30}, zerolog.Nop())
+		expected := time.Now().AddDate(0, 0, -30)
+
+		mockRepo.On("PurgeDeleted", ctx, mock.MatchedBy(func(olderThan time.Time) bool {
+			return olderThan.Sub(expected).Abs() < time.Minute
+		})).Return(int64(4), nil)
+
+		// Act
+		err := service.PurgeTrash(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("zero retention keeps the trash", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{}, zerolog.Nop())
+
+		// Act
+		err := service.PurgeTrash(context.Background())
+
+		// Assert
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "PurgeDeleted", mock.Anything, mock.Anything)
+	})
+
+	t.Run("repository error is returned", func(t *testing.T) {
+		// Arrange
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		mockRepo := new(mocks.MockTodoRepository)
+		service := NewTodoService(mockRepo, &config.TodoConfig{TrashRetentionDays: 7}, zerolog.Nop())
+
+		mockRepo.On("PurgeDeleted", ctx, mock.Anything).Return(int64(0), context.Canceled)
+
+		// Act
+		err := service.PurgeTrash(ctx)
+
+		// Assert
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
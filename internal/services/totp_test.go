@@ -0,0 +1,102 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6238Secret is the SHA1 test secret of RFC 6238 appendix B, "12345678901234567890"
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// The RFC lists 8-digit codes; 6-digit codes are their last six digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := totp.GenerateCodeCustom(rfc6238Secret, time.Unix(tt.unix, 0), totpOpts)
+
+		require.NoError(t, err)
+		assert.Equal(t, tt.code, code, tt.unix)
+	}
+}
+
+func TestMatchTOTPCode(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	t.Run("accepts the previous, current and next code", func(t *testing.T) {
+		for _, offset := range []time.Duration{-totpPeriod, 0, totpPeriod} {
+			code, _ := totp.GenerateCodeCustom(rfc6238Secret, now.Add(offset), totpOpts)
+
+			step, ok := matchTOTPCode(rfc6238Secret, code, now)
+
+			assert.True(t, ok, offset)
+			assert.Equal(t, totpStep(now.Add(offset)), step, offset)
+		}
+	})
+
+	t.Run("rejects older codes and malformed input", func(t *testing.T) {
+		old, _ := totp.GenerateCodeCustom(rfc6238Secret, now.Add(-2*totpPeriod), totpOpts)
+
+		for _, code := range []string{old, "12345", "1234567", ""} {
+			_, ok := matchTOTPCode(rfc6238Secret, code, now)
+			assert.False(t, ok, code)
+		}
+	})
+}
+
+func TestNewTOTPKey(t *testing.T) {
+	key, err := newTOTPKey("Go Fiber Todo", "jane")
+
+	require.NoError(t, err)
+	assert.Len(t, key.Secret(), 32)
+	assert.True(t, strings.HasPrefix(key.URL(), "otpauth://totp/Go%20Fiber%20Todo:jane?"))
+	assert.Contains(t, key.URL(), "secret="+key.Secret())
+	assert.Contains(t, key.URL(), "issuer=Go%20Fiber%20Todo")
+	assert.Contains(t, key.URL(), "digits=6")
+	assert.Contains(t, key.URL(), "period=30")
+}
+
+func TestTOTPCipher(t *testing.T) {
+	cipher, err := newTOTPCipher(make([]byte, 32))
+	require.NoError(t, err)
+
+	t.Run("round trip", func(t *testing.T) {
+		encrypted, err := cipher.encrypt(rfc6238Secret)
+		require.NoError(t, err)
+
+		decrypted, err := cipher.decrypt(encrypted)
+
+		assert.NoError(t, err)
+		assert.Equal(t, rfc6238Secret, decrypted)
+		assert.NotContains(t, encrypted, rfc6238Secret)
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		encrypted, _ := cipher.encrypt(rfc6238Secret)
+		key := make([]byte, 32)
+		key[0] = 1
+		other, _ := newTOTPCipher(key)
+
+		_, err := other.decrypt(encrypted)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("short key", func(t *testing.T) {
+		_, err := newTOTPCipher(make([]byte, 10))
+		assert.Error(t, err)
+	})
+}
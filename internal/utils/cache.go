@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NoCache marks a response as requiring revalidation on every use
+func NoCache(c *fiber.Ctx) {
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+}
+
+// CacheUntilModified marks an authenticated response as cacheable by the client for maxAge
+// and sets Last-Modified from the resource's last update. It reports whether the request's
+// If-Modified-Since shows the client's copy is still current, in which case the caller
+// should answer 304 Not Modified instead of sending the body.
+func CacheUntilModified(c *fiber.Ctx, lastModified time.Time, maxAge time.Duration) bool {
+	// HTTP dates have second precision
+	lastModified = lastModified.UTC().Truncate(time.Second)
+
+	// Responses are per user, so shared caches must not store them
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("private, max-age=%d", int(maxAge.Seconds())))
+	c.Set(fiber.HeaderLastModified, lastModified.Format(http.TimeFormat))
+	c.Vary(fiber.HeaderAuthorization)
+
+	since, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince))
+	if err != nil {
+		return false
+	}
+	return !lastModified.After(since)
+}
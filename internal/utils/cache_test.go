@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheUntilModified(t *testing.T) {
+	lastModified := time.Date(2025, 10, 1, 9, 30, 0, 500, time.UTC)
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		if CacheUntilModified(c, lastModified, 10*time.Minute) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+		return c.SendString("body")
+	})
+
+	tests := []struct {
+		name            string
+		ifModifiedSince string
+		expectedStatus  int
+	}{
+		{"no conditional header", "", 200},
+		{"client copy is current", "Wed, 01 Oct 2025 09:30:00 GMT", 304},
+		{"client copy is newer", "Wed, 01 Oct 2025 10:00:00 GMT", 304},
+		{"client copy is stale", "Wed, 01 Oct 2025 09:29:59 GMT", 200},
+		{"malformed date", "yesterday", 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.ifModifiedSince != "" {
+				req.Header.Set("If-Modified-Since", tt.ifModifiedSince)
+			}
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			assert.Equal(t, "private, max-age=600", resp.Header.Get("Cache-Control"))
+			assert.Equal(t, "Wed, 01 Oct 2025 09:30:00 GMT", resp.Header.Get("Last-Modified"))
+			assert.Equal(t, "Authorization", resp.Header.Get("Vary"))
+		})
+	}
+}
+
+func TestNoCache(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		NoCache(c)
+		return c.SendString("body")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+}
@@ -0,0 +1,199 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	// Embed the timezone database so X-Timezone works on hosts without one
+	_ "time/tzdata"
+)
+
+// ErrUnparseableDate is returned when a natural-language date cannot be understood
+var ErrUnparseableDate = errors.New("unrecognised date expression")
+
+// weekdays maps weekday names and common abbreviations to time.Weekday
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// LoadTimezone resolves an IANA timezone name, defaulting to UTC when empty
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// IsDateOnly reports whether t falls at exactly midnight in its own location, the way
+// clients that only track dates send due dates
+func IsDateOnly(t time.Time) bool {
+	hour, minute, second := t.Clock()
+	return hour == 0 && minute == 0 && second == 0 && t.Nanosecond() == 0
+}
+
+// ParseNaturalDate resolves phrases such as "tomorrow 5pm", "next friday", "in 3 days"
+// or "17:30" relative to now, in now's location.
+//
+// A weekday on its own means its next occurrence including today, while "next <weekday>"
+// always lies after today. Phrases without a time of day resolve to the end of that day,
+// and a bare time that has already passed today resolves to tomorrow.
+func ParseNaturalDate(text string, now time.Time) (time.Time, error) {
+	tokens := make([]string, 0)
+	for _, token := range strings.Fields(strings.ToLower(strings.ReplaceAll(text, ",", " "))) {
+		if token != "at" && token != "on" && token != "by" {
+			tokens = append(tokens, token)
+		}
+	}
+	if len(tokens) == 0 {
+		return time.Time{}, ErrUnparseableDate
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var day time.Time
+	hasDay := false
+	rest := tokens
+
+	switch first := tokens[0]; {
+	case first == "in":
+		if len(tokens) < 3 {
+			return time.Time{}, ErrUnparseableDate
+		}
+		n, err := strconv.Atoi(tokens[1])
+		if err != nil || n <= 0 {
+			return time.Time{}, ErrUnparseableDate
+		}
+
+		switch strings.TrimSuffix(tokens[2], "s") {
+		case "minute", "min":
+			if len(tokens) > 3 {
+				return time.Time{}, ErrUnparseableDate
+			}
+			return now.Add(time.Duration(n) * time.Minute), nil
+		case "hour":
+			if len(tokens) > 3 {
+				return time.Time{}, ErrUnparseableDate
+			}
+			return now.Add(time.Duration(n) * time.Hour), nil
+		case "day":
+			day = today.AddDate(0, 0, n)
+		case "week":
+			day = today.AddDate(0, 0, 7*n)
+		default:
+			return time.Time{}, ErrUnparseableDate
+		}
+		hasDay, rest = true, tokens[3:]
+	case first == "today" || first == "tonight":
+		day, hasDay, rest = today, true, tokens[1:]
+	case first == "tomorrow":
+		day, hasDay, rest = today.AddDate(0, 0, 1), true, tokens[1:]
+	case first == "next" || first == "this":
+		if len(tokens) < 2 {
+			return time.Time{}, ErrUnparseableDate
+		}
+		if first == "next" && tokens[1] == "week" {
+			day, hasDay, rest = today.AddDate(0, 0, 7), true, tokens[2:]
+			break
+		}
+		weekday, ok := weekdays[tokens[1]]
+		if !ok {
+			return time.Time{}, ErrUnparseableDate
+		}
+		day, hasDay, rest = nextWeekday(today, weekday, first == "next"), true, tokens[2:]
+	default:
+		if weekday, ok := weekdays[first]; ok {
+			day, hasDay, rest = nextWeekday(today, weekday, false), true, tokens[1:]
+		}
+	}
+
+	// Anything left must be a time of day ("5 pm" is joined into "5pm")
+	if len(rest) == 0 {
+		if !hasDay {
+			return time.Time{}, ErrUnparseableDate
+		}
+		return time.Date(day.Year(), day.Month(), day.Day(), 23, 59, 59, 0, now.Location()), nil
+	}
+
+	hour, minute, ok := parseClock(strings.Join(rest, ""))
+	if !ok {
+		return time.Time{}, ErrUnparseableDate
+	}
+
+	if !hasDay {
+		result := time.Date(today.Year(), today.Month(), today.Day(), hour, minute, 0, 0, now.Location())
+		if result.Before(now) {
+			result = result.AddDate(0, 0, 1)
+		}
+		return result, nil
+	}
+
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, now.Location()), nil
+}
+
+// nextWeekday returns the next date falling on weekday, counting today unless strictlyAfter is set
+func nextWeekday(today time.Time, weekday time.Weekday, strictlyAfter bool) time.Time {
+	days := (int(weekday) - int(today.Weekday()) + 7) % 7
+	if days == 0 && strictlyAfter {
+		days = 7
+	}
+	return today.AddDate(0, 0, days)
+}
+
+// parseClock parses "5pm", "5:30am", "17:00", "noon" and "midnight" into hour and minute
+func parseClock(s string) (int, int, bool) {
+	switch s {
+	case "noon":
+		return 12, 0, true
+	case "midnight":
+		return 0, 0, true
+	}
+
+	meridiem := ""
+	if strings.HasSuffix(s, "am") || strings.HasSuffix(s, "pm") {
+		meridiem, s = s[len(s)-2:], s[:len(s)-2]
+	}
+
+	hourText, minuteText, hasMinutes := strings.Cut(s, ":")
+	// A bare number like "5" is ambiguous without am/pm
+	if !hasMinutes && meridiem == "" {
+		return 0, 0, false
+	}
+
+	hour, err := strconv.Atoi(hourText)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	minute := 0
+	if hasMinutes {
+		if len(minuteText) != 2 {
+			return 0, 0, false
+		}
+		if minute, err = strconv.Atoi(minuteText); err != nil || minute < 0 || minute > 59 {
+			return 0, 0, false
+		}
+	}
+
+	if meridiem == "" {
+		return hour, minute, hour >= 0 && hour <= 23
+	}
+	if hour < 1 || hour > 12 {
+		return 0, 0, false
+	}
+	if hour == 12 {
+		hour = 0
+	}
+	if meridiem == "pm" {
+		hour += 12
+	}
+
+	return hour, minute, true
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNaturalDate(t *testing.T) {
+	// Wednesday, 15 October 2025, 10:00 UTC
+	now := time.Date(2025, 10, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		text     string
+		expected time.Time
+	}{
+		{"tomorrow with time", "tomorrow 5pm", time.Date(2025, 10, 16, 17, 0, 0, 0, time.UTC)},
+		{"tomorrow with spaced meridiem", "Tomorrow at 5 pm", time.Date(2025, 10, 16, 17, 0, 0, 0, time.UTC)},
+		{"next weekday", "next friday", time.Date(2025, 10, 17, 23, 59, 59, 0, time.UTC)},
+		{"weekday with minutes", "fri at 9:30am", time.Date(2025, 10, 17, 9, 30, 0, 0, time.UTC)},
+		{"plain weekday is today", "wednesday", time.Date(2025, 10, 15, 23, 59, 59, 0, time.UTC)},
+		{"next same weekday", "next wednesday", time.Date(2025, 10, 22, 23, 59, 59, 0, time.UTC)},
+		{"next week", "next week", time.Date(2025, 10, 22, 23, 59, 59, 0, time.UTC)},
+		{"in days", "in 3 days", time.Date(2025, 10, 18, 23, 59, 59, 0, time.UTC)},
+		{"in hours", "in 2 hours", time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC)},
+		{"today 24-hour time", "today 17:30", time.Date(2025, 10, 15, 17, 30, 0, 0, time.UTC)},
+		{"bare time later today", "noon", time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC)},
+		{"bare time already passed", "9am", time.Date(2025, 10, 16, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseNaturalDate(tt.text, now)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	t.Run("unparseable phrases", func(t *testing.T) {
+		for _, text := range []string{"", "someday", "tomorrow 25:00", "in five days", "5", "next month", "in 2 hours 5pm"} {
+			_, err := ParseNaturalDate(text, now)
+
+			assert.ErrorIs(t, err, ErrUnparseableDate, text)
+		}
+	})
+
+	t.Run("resolved in the given timezone", func(t *testing.T) {
+		loc, err := LoadTimezone("America/New_York")
+		require.NoError(t, err)
+
+		result, err := ParseNaturalDate("tomorrow 5pm", now.In(loc))
+
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 10, 16, 21, 0, 0, 0, time.UTC), result.UTC())
+	})
+}
+
+func TestIsDateOnly(t *testing.T) {
+	loc, err := LoadTimezone("America/New_York")
+	require.NoError(t, err)
+
+	assert.True(t, IsDateOnly(time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, IsDateOnly(time.Date(2025, 10, 15, 0, 0, 0, 0, loc)))
+	assert.False(t, IsDateOnly(time.Date(2025, 10, 15, 0, 0, 1, 0, time.UTC)))
+	assert.False(t, IsDateOnly(time.Date(2025, 10, 15, 9, 0, 0, 0, time.UTC)))
+}
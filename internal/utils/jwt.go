@@ -23,12 +23,21 @@ type JWTClaims struct {
 // JWTService handles JWT operations
 type JWTService struct {
 	config config.JWTConfig
+
+	// keys sign and verify tokens with the configured algorithm; keysErr is why they could
+	// not be loaded, failing every token operation
+	keys    *config.JWTKeys
+	keysErr error
 }
 
-// NewJWTService creates a new JWT service
+// NewJWTService creates a new JWT service. With RS256 and only a public key configured it
+// can validate tokens but not generate them.
 func NewJWTService(config config.JWTConfig) *JWTService {
+	keys, err := config.Keys()
 	return &JWTService{
-		config: config,
+		config:  config,
+		keys:    keys,
+		keysErr: err,
 	}
 }
 
@@ -51,8 +60,7 @@ func (j *JWTService) GenerateAccessToken(user *models.User, sessionID string) (s
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.config.Secret))
+	return j.sign(claims)
 }
 
 // GenerateRefreshToken generates a refresh token for the user
@@ -74,17 +82,33 @@ func (j *JWTService) GenerateRefreshToken(user *models.User, sessionID string) (
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.config.Secret))
+	return j.sign(claims)
+}
+
+// sign signs claims with the configured algorithm
+func (j *JWTService) sign(claims *JWTClaims) (string, error) {
+	if j.keysErr != nil {
+		return "", j.keysErr
+	}
+	if j.keys.SignKey == nil {
+		return "", fmt.Errorf("no jwt private key to sign tokens with")
+	}
+
+	return jwt.NewWithClaims(j.keys.Method, claims).SignedString(j.keys.SignKey)
 }
 
-// ValidateToken validates a JWT token and returns the claims
+// ValidateToken validates a JWT token and returns the claims. Tokens signed with any other
+// algorithm than the configured one are rejected.
 func (j *JWTService) ValidateToken(tokenString string) (*JWTClaims, error) {
+	if j.keysErr != nil {
+		return nil, j.keysErr
+	}
+
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		if token.Method.Alg() != j.keys.Method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(j.config.Secret), nil
+		return j.keys.VerifyKey, nil
 	})
 
 	if err != nil {
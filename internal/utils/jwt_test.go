@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"go-fiber/internal/config"
+	"go-fiber/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTService_RS256(t *testing.T) {
+	privatePEM, publicPEM := config.NewTestRSAKeyPEM()
+	user := &models.User{ID: "user-id", Username: "testuser"}
+
+	issuer := NewJWTService(config.JWTConfig{
+		Algorithm:    config.JWTAlgorithmRS256,
+		PrivateKey:   privatePEM,
+		AccessExpiry: time.Hour,
+	})
+	verifier := NewJWTService(config.JWTConfig{
+		Algorithm: config.JWTAlgorithmRS256,
+		PublicKey: publicPEM,
+	})
+
+	t.Run("public key alone validates tokens", func(t *testing.T) {
+		// Arrange
+		token, err := issuer.GenerateAccessToken(user, "session-id")
+		require.NoError(t, err)
+
+		// Act
+		claims, err := verifier.ValidateAccessToken(token)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "user-id", claims.UserID)
+	})
+
+	t.Run("public key alone cannot generate tokens", func(t *testing.T) {
+		// Act
+		_, err := verifier.GenerateAccessToken(user, "session-id")
+
+		// Assert
+		assert.Error(t, err)
+	})
+
+	t.Run("HS256 tokens are rejected", func(t *testing.T) {
+		// Arrange
+		hs256 := NewJWTService(config.JWTConfig{Secret: "test-secret", AccessExpiry: time.Hour})
+		token, err := hs256.GenerateAccessToken(user, "session-id")
+		require.NoError(t, err)
+
+		// Act
+		_, err = verifier.ValidateAccessToken(token)
+
+		// Assert
+		assert.ErrorContains(t, err, "unexpected signing method")
+	})
+}
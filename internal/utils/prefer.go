@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PreferReturn returns the "return" preference from the request's Prefer headers
+// (RFC 7240): "minimal", "representation", or "" when none was given
+func PreferReturn(c *fiber.Ctx) string {
+	for _, header := range c.Request().Header.PeekAll("Prefer") {
+		for _, preference := range strings.Split(string(header), ",") {
+			// Preference parameters after ";" do not apply to "return"
+			token, _, _ := strings.Cut(preference, ";")
+			name, value, found := strings.Cut(strings.TrimSpace(token), "=")
+			if found && strings.EqualFold(strings.TrimSpace(name), "return") {
+				return strings.ToLower(strings.Trim(strings.TrimSpace(value), `"`))
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreferReturn(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(PreferReturn(c))
+	})
+
+	tests := []struct {
+		name     string
+		headers  []string
+		expected string
+	}{
+		{"no header", nil, ""},
+		{"minimal", []string{"return=minimal"}, "minimal"},
+		{"representation", []string{"return=representation"}, "representation"},
+		{"among other preferences", []string{"respond-async, wait=10, return=minimal"}, "minimal"},
+		{"case and quotes", []string{`Return="Minimal"`}, "minimal"},
+		{"with parameters", []string{"return=minimal; foo=bar"}, "minimal"},
+		{"second header", []string{"wait=10", "return=minimal"}, "minimal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			for _, header := range tt.headers {
+				req.Header.Add("Prefer", header)
+			}
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, tt.expected, string(body))
+		})
+	}
+}
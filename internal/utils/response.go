@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"go-fiber/internal/models"
+
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -17,24 +19,51 @@ type SuccessResponse struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
-// PaginatedResponse represents a paginated response
-type PaginatedResponse struct {
-	Data       interface{} `json:"data"`
-	Total      int64       `json:"total"`
-	Limit      int         `json:"limit"`
-	Offset     int         `json:"offset"`
-	TotalPages int         `json:"total_pages"`
-	Page       int         `json:"page"`
+// PaginatedResponse represents a page of data
+type PaginatedResponse[T any] struct {
+	Data       T     `json:"data"`
+	Total      int64 `json:"total"`
+	Limit      int   `json:"limit"`
+	Offset     int   `json:"offset"`
+	TotalPages int   `json:"total_pages"`
+	Page       int   `json:"page"`
+}
+
+// exposeErrorDetails sends raw error details to clients; production deployments turn it off
+// so internal errors don't leak
+var exposeErrorDetails = true
+
+// SetExposeErrorDetails configures whether error responses carry raw error details
+func SetExposeErrorDetails(enabled bool) {
+	exposeErrorDetails = enabled
+}
+
+// ErrorDetail returns the error's message for a response, or an empty string when error
+// details are hidden
+func ErrorDetail(err error) string {
+	if !exposeErrorDetails || err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// WithErrorDetails adds the error's message to an error response body as details, leaving
+// the body as is when error details are hidden
+func WithErrorDetails(body fiber.Map, err error) fiber.Map {
+	if detail := ErrorDetail(err); detail != "" {
+		body["details"] = detail
+	}
+	return body
 }
 
-// SendError sends an error response
+// SendError sends an error response. Details are dropped when error details are hidden.
 func SendError(c *fiber.Ctx, statusCode int, message string, details ...interface{}) error {
 	response := ErrorResponse{
 		Error:   fiber.ErrBadRequest.Message,
 		Message: message,
 	}
 
-	if len(details) > 0 {
+	if len(details) > 0 && exposeErrorDetails {
 		response.Details = details[0]
 	}
 
@@ -80,11 +109,10 @@ func SendData(c *fiber.Ctx, data interface{}) error {
 }
 
 // SendPaginated sends a paginated response
-func SendPaginated(c *fiber.Ctx, data interface{}, total int64, limit, offset int) error {
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
-	page := (offset / limit) + 1
+func SendPaginated[T any](c *fiber.Ctx, data T, total int64, limit, offset int) error {
+	totalPages, page := models.PageCount(total, limit, offset)
 
-	response := PaginatedResponse{
+	response := PaginatedResponse[T]{
 		Data:       data,
 		Total:      total,
 		Limit:      limit,
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorDetails(t *testing.T) {
+	t.Cleanup(func() { SetExposeErrorDetails(true) })
+
+	err := errors.New("pq: relation \"todos\" does not exist")
+
+	app := fiber.New()
+	app.Get("/send", func(c *fiber.Ctx) error {
+		return SendError(c, fiber.StatusBadRequest, "Invalid input", err.Error())
+	})
+	app.Get("/map", func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusBadRequest).JSON(WithErrorDetails(fiber.Map{
+			"error":   "Bad Request",
+			"message": "Invalid input",
+		}, err))
+	})
+
+	// get returns the decoded error response of path
+	get := func(path string) map[string]any {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		require.NoError(t, err)
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body
+	}
+
+	t.Run("exposed", func(t *testing.T) {
+		SetExposeErrorDetails(true)
+
+		for _, path := range []string{"/send", "/map"} {
+			body := get(path)
+
+			assert.Equal(t, err.Error(), body["details"], path)
+			assert.Equal(t, "Invalid input", body["message"], path)
+		}
+		assert.Equal(t, err.Error(), ErrorDetail(err))
+	})
+
+	t.Run("hidden", func(t *testing.T) {
+		SetExposeErrorDetails(false)
+
+		for _, path := range []string{"/send", "/map"} {
+			body := get(path)
+
+			assert.NotContains(t, body, "details", path)
+			assert.Equal(t, "Bad Request", body["error"], path)
+			assert.Equal(t, "Invalid input", body["message"], path)
+		}
+		assert.Empty(t, ErrorDetail(err))
+	})
+}
+
+func TestSendPaginated(t *testing.T) {
+	app := fiber.New()
+	app.Get("/items", func(c *fiber.Ctx) error {
+		return SendPaginated(c, []string{"a", "b"}, 5, c.QueryInt("limit"), c.QueryInt("offset"))
+	})
+
+	// get returns the decoded paginated response of path
+	get := func(path string) PaginatedResponse[[]string] {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		require.NoError(t, err)
+
+		var body PaginatedResponse[[]string]
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body
+	}
+
+	t.Run("pages", func(t *testing.T) {
+		body := get("/items?limit=2&offset=2")
+
+		assert.Equal(t, []string{"a", "b"}, body.Data)
+		assert.Equal(t, 3, body.TotalPages)
+		assert.Equal(t, 2, body.Page)
+	})
+
+	t.Run("zero limit", func(t *testing.T) {
+		body := get("/items?limit=0")
+
+		assert.Equal(t, 1, body.TotalPages)
+		assert.Equal(t, 1, body.Page)
+	})
+}
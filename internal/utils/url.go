@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AbsoluteURL builds an absolute URL for the given path.
+// The configured public URL takes precedence; otherwise the base is derived from the request,
+// which only honours forwarded headers from trusted proxies.
+func AbsoluteURL(c *fiber.Ctx, path string) string {
+	base, _ := c.Locals("publicURL").(string)
+	if base == "" {
+		base = c.Protocol() + "://" + c.Hostname()
+	}
+
+	base = strings.TrimRight(base, "/")
+	if path == "" {
+		return base
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return base + path
+}
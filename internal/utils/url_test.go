@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"go-fiber/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupURLApp creates an app that echoes the absolute URL built for /todos
+func setupURLApp(publicURL string, trustedProxies []string) *fiber.App {
+	app := fiber.New(fiber.Config{
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          trustedProxies,
+	})
+	app.Use(middleware.PublicURL(publicURL))
+	app.Get("/link", func(c *fiber.Ctx) error {
+		return c.SendString(AbsoluteURL(c, "/todos"))
+	})
+	return app
+}
+
+func TestAbsoluteURL(t *testing.T) {
+	t.Run("configured public url overrides request host", func(t *testing.T) {
+		// Arrange
+		app := setupURLApp("https://todo.example.com/", nil)
+		req := httptest.NewRequest("GET", "http://internal:9000/link", nil)
+		req.Header.Set("X-Forwarded-Host", "evil.example.com")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "https://todo.example.com/todos", string(body))
+	})
+
+	t.Run("derived from request host when unset", func(t *testing.T) {
+		// Arrange
+		app := setupURLApp("", nil)
+		req := httptest.NewRequest("GET", "http://api.example.com/link", nil)
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "http://api.example.com/todos", string(body))
+	})
+
+	t.Run("forwarded host ignored from untrusted client", func(t *testing.T) {
+		// Arrange
+		app := setupURLApp("", nil)
+		req := httptest.NewRequest("GET", "http://api.example.com/link", nil)
+		req.Header.Set("X-Forwarded-Host", "evil.example.com")
+		req.Header.Set("X-Forwarded-Proto", "https")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "http://api.example.com/todos", string(body))
+	})
+
+	t.Run("forwarded host honoured from trusted proxy", func(t *testing.T) {
+		// Arrange
+		app := setupURLApp("", []string{"0.0.0.0"})
+		req := httptest.NewRequest("GET", "http://internal:9000/link", nil)
+		req.Header.Set("X-Forwarded-Host", "todo.example.com")
+		req.Header.Set("X-Forwarded-Proto", "https")
+
+		// Act
+		resp, err := app.Test(req)
+
+		// Assert
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "https://todo.example.com/todos", string(body))
+	})
+}
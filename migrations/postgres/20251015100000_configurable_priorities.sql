@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Priority levels are configured per deployment (TODO_PRIORITIES) and validated by the application,
+-- so the hardcoded check constraint is dropped. Existing 'low', 'medium' and 'high' rows stay valid
+-- as long as those levels remain in the configured set.
+ALTER TABLE todos DROP CONSTRAINT IF EXISTS todos_priority_check;
+ALTER TABLE todos ALTER COLUMN priority TYPE VARCHAR(20);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Rows using levels outside the original set must be remapped before rolling back, e.g.:
+-- UPDATE todos SET priority = 'high' WHERE priority = 'urgent';
+ALTER TABLE todos ALTER COLUMN priority TYPE VARCHAR(10);
+ALTER TABLE todos ADD CONSTRAINT todos_priority_check CHECK (priority IN ('low', 'medium', 'high'));
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Statuses are configured per deployment (TODO_STATUSES) and validated by the application,
+-- so the hardcoded check constraint and column default are dropped; new rows get TODO_DEFAULT_STATUS.
+ALTER TABLE todos DROP CONSTRAINT IF EXISTS todos_status_check;
+ALTER TABLE todos ALTER COLUMN status DROP DEFAULT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Rows using statuses outside the original set must be remapped before rolling back, e.g.:
+-- UPDATE todos SET status = 'in_progress' WHERE status IN ('blocked', 'review');
+ALTER TABLE todos ALTER COLUMN status SET DEFAULT 'pending';
+ALTER TABLE todos ADD CONSTRAINT todos_status_check CHECK (status IN ('pending', 'in_progress', 'completed'));
+-- +goose StatementEnd
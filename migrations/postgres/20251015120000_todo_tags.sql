@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Tags are stored normalized (trimmed, lowercase, deduplicated) by the application
+ALTER TABLE todos ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+CREATE INDEX idx_todos_tags ON todos USING gin(tags) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_todos_tags;
+ALTER TABLE todos DROP COLUMN IF EXISTS tags;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- All-day todos store their due day as midnight UTC; the application places the day in
+-- the reader's timezone when deciding whether it is overdue
+ALTER TABLE todos ADD COLUMN all_day BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE todos DROP COLUMN IF EXISTS all_day;
+-- +goose StatementEnd
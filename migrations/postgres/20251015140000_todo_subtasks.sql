@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Subtasks are a JSON array of {"title", "done"} objects, addressed by position
+ALTER TABLE todos ADD COLUMN subtasks JSONB NOT NULL DEFAULT '[]';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE todos DROP COLUMN IF EXISTS subtasks;
+-- +goose StatementEnd
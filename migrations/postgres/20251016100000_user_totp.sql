@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- totp_secret is encrypted by the application; it only counts once totp_enabled is set.
+-- totp_last_step is the time step of the last accepted code, so no code is accepted twice.
+ALTER TABLE users
+    ADD COLUMN totp_secret TEXT,
+    ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN totp_last_step BIGINT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP COLUMN IF EXISTS totp_last_step,
+    DROP COLUMN IF EXISTS totp_enabled,
+    DROP COLUMN IF EXISTS totp_secret;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- API keys for machine clients. Only a SHA-256 hash of each key is stored; prefix keeps the
+-- first characters so users can tell their keys apart. Revoking a key deletes its row.
+CREATE TABLE api_keys (
+    id ULID PRIMARY KEY DEFAULT gen_ulid() NOT NULL,
+    user_id ULID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,
+    key_hash CHAR(64) UNIQUE NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_api_keys_user_id ON api_keys(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS api_keys;
+-- +goose StatementEnd
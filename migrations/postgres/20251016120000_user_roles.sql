@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every existing user becomes a plain user; admins are promoted through AUTH_ADMIN_USERNAMES
+-- or by updating the column directly.
+ALTER TABLE users
+    ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user',
+    ADD CONSTRAINT users_role_check CHECK (role IN ('user', 'admin'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_role_check,
+    DROP COLUMN IF EXISTS role;
+-- +goose StatementEnd